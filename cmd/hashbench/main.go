@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+)
+
+// main is the entry point for the hashbench tool.
+//
+// hashbench compares the placement algorithms and hash functions supported
+// by core/hash on synthetic keys shaped like production keys:
+//   - Lookup latency (ns/op) for GetNode
+//   - Distribution quality (max overload and standard deviation)
+//   - Remap percentage when a node is added or removed
+//   - Max/avg load with and without bounded loads under Zipf-skewed traffic
+//
+// Usage:
+//
+//	hashbench --nodes=10 --keys=200000 --vnodes=150 --pattern=user:%d
+//	hashbench --epsilon=0.25 --requests=1000000 --zipf=1.1
+func main() {
+	nodeCount := flag.Int("nodes", 10, "Number of cache nodes")
+	keyCount := flag.Int("keys", 200000, "Number of sample keys")
+	virtualNodes := flag.Int("vnodes", hash.DefaultVirtualNodes, "Virtual nodes per node (ketama only)")
+	tableSize := flag.Int("table-size", hash.DefaultMaglevTableSize, "Lookup table size (maglev only)")
+	pattern := flag.String("pattern", "namespace:user:%d", "Key pattern; %d is replaced by the key index")
+	algorithms := flag.String("algorithms", "ketama,jump,rendezvous,maglev", "Comma-separated placement algorithms")
+	hashFunctions := flag.String("hash-functions", "crc32,xxhash,murmur3", "Comma-separated hash functions")
+	epsilon := flag.Float64("epsilon", 0.25, "Bounded-load epsilon for the load simulation")
+	requests := flag.Int("requests", 1000000, "Number of requests in the load simulation")
+	zipfS := flag.Float64("zipf", 1.1, "Zipf exponent (> 1) of key popularity in the load simulation")
+	flag.Parse()
+
+	if *nodeCount < 2 || *keyCount <= 0 {
+		fmt.Fprintln(os.Stderr, "hashbench: --nodes must be >= 2 and --keys must be > 0")
+		os.Exit(2)
+	}
+
+	keys := make([]string, *keyCount)
+	for i := range keys {
+		keys[i] = fmt.Sprintf(*pattern, i)
+	}
+
+	nodes := make([]string, *nodeCount+1)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("yao-oracle-node-%d.yao-oracle-node:8080", i)
+	}
+
+	fmt.Printf("nodes=%d keys=%d pattern=%q ideal remap on add=%.2f%%, on remove=%.2f%%\n\n",
+		*nodeCount, *keyCount, *pattern,
+		100/float64(*nodeCount+1), 100/float64(*nodeCount))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "algorithm\thash\tns/op\tmax overload\tstddev\tremap add\tremap remove\t")
+
+	for _, algorithm := range strings.Split(*algorithms, ",") {
+		for _, hashFunction := range strings.Split(*hashFunctions, ",") {
+			cfg := hash.PlacementConfig{
+				Algorithm:    strings.TrimSpace(algorithm),
+				HashFunction: strings.TrimSpace(hashFunction),
+				VirtualNodes: *virtualNodes,
+				TableSize:    *tableSize,
+			}
+
+			result, err := run(cfg, nodes, keys)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "hashbench: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%.0f\t%.4f\t%.4f\t%.2f%%\t%.2f%%\t\n",
+				cfg.Algorithm, cfg.HashFunction, result.nsPerOp,
+				result.stats.MaxOverload, result.stats.StdDev,
+				100*result.remapAdd, 100*result.remapRemove)
+		}
+	}
+
+	w.Flush()
+
+	if *epsilon > 0 && *requests > 0 && *zipfS > 1 {
+		fmt.Printf("\nbounded loads: %d Zipf(%.2f) requests, epsilon=%.2f, bound max/avg <= %.4f\n\n",
+			*requests, *zipfS, *epsilon, 1+*epsilon)
+
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "mode\tmax/avg\tdiverted\t")
+		for _, bound := range []float64{0, *epsilon} {
+			maxAvg, diverted := simulateLoad(nodes[:len(nodes)-1], keys, *virtualNodes, bound, *requests, *zipfS)
+			mode := "unbounded"
+			if bound > 0 {
+				mode = fmt.Sprintf("epsilon=%.2f", bound)
+			}
+			fmt.Fprintf(w, "%s\t%.4f\t%.2f%%\t\n", mode, maxAvg, 100*diverted)
+		}
+		w.Flush()
+	}
+}
+
+// simulateLoad routes Zipf-distributed reads through a ketama ring, as if
+// every key were replicated on all nodes, accounting one unit of load per
+// request. It returns the final max/avg node load and the fraction of
+// requests served by a replica other than the key's owner.
+func simulateLoad(nodes, keys []string, virtualNodes int, epsilon float64, requests int, s float64) (float64, float64) {
+	bounded := hash.NewRingWithHash(virtualNodes, hash.XXHash)
+	for _, node := range nodes {
+		bounded.AddNode(node)
+	}
+	bounded.SetLoadBound(epsilon)
+
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), s, 1, uint64(len(keys)-1))
+	diverted := 0
+	for i := 0; i < requests; i++ {
+		key := keys[zipf.Uint64()]
+		node := bounded.GetNodeBounded(key, len(nodes))
+		if node != bounded.Owner(key) {
+			diverted++
+		}
+		bounded.AddLoad(node, 1)
+	}
+
+	maxLoad := 0.0
+	for _, node := range nodes {
+		maxLoad = max(maxLoad, bounded.Load(node))
+	}
+	avg := float64(requests) / float64(len(nodes))
+
+	return maxLoad / avg, float64(diverted) / float64(requests)
+}
+
+// benchResult holds the measurements for one algorithm/hash combination.
+type benchResult struct {
+	nsPerOp     float64
+	stats       hash.DistributionStats
+	remapAdd    float64
+	remapRemove float64
+}
+
+// run measures one placement configuration.
+//
+// nodes holds one more node than the baseline cluster; it is used to
+// measure the remap percentage of a scale-out. The remove case drops a
+// node from the middle of the baseline cluster.
+func run(cfg hash.PlacementConfig, nodes, keys []string) (benchResult, error) {
+	var result benchResult
+
+	base := nodes[:len(nodes)-1]
+	baseline, err := build(cfg, base)
+	if err != nil {
+		return result, err
+	}
+
+	// Lookup latency over shuffled keys to avoid measuring cache locality
+	order := rand.New(rand.NewSource(1)).Perm(len(keys))
+	start := time.Now()
+	for _, i := range order {
+		baseline.GetNode(keys[i])
+	}
+	result.nsPerOp = float64(time.Since(start).Nanoseconds()) / float64(len(keys))
+
+	result.stats = hash.MeasureDistribution(baseline, keys)
+
+	scaledOut, err := build(cfg, nodes)
+	if err != nil {
+		return result, err
+	}
+	result.remapAdd = hash.RemapFraction(baseline, scaledOut, keys)
+
+	scaledIn, err := build(cfg, base)
+	if err != nil {
+		return result, err
+	}
+	scaledIn.RemoveNode(base[len(base)/2])
+	result.remapRemove = hash.RemapFraction(baseline, scaledIn, keys)
+
+	return result, nil
+}
+
+// build creates a placement containing the given nodes in order.
+func build(cfg hash.PlacementConfig, nodes []string) (hash.Placement, error) {
+	p, err := hash.NewPlacement(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		p.AddNode(node)
+	}
+	return p, nil
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"runtime"
 	"strconv"
+	"strings"
 	"syscall"
 
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/gossip"
 	"github.com/eggybyte-technology/yao-oracle/core/utils"
 	"github.com/eggybyte-technology/yao-oracle/internal/node"
 )
@@ -23,9 +28,20 @@ const (
 	envMaxMemoryMB = "MAX_MEMORY_MB"
 	envMaxKeys     = "MAX_KEYS"
 
+	// Primary-backup replication (optional)
+	envReplicationFollowers = "REPLICATION_FOLLOWERS" // Comma-separated follower addresses
+	envReplicationLogSize   = "REPLICATION_LOG_SIZE"  // Mutations kept for lagging followers
+
 	// Pod metadata (auto-injected by Kubernetes)
 	envPodName      = "POD_NAME"
 	envPodNamespace = "POD_NAMESPACE"
+	envPodIP        = "POD_IP"
+
+	// Gossip membership (enabled when seeds are configured)
+	envGossipSeeds = "GOSSIP_SEEDS" // Comma-separated gossip addresses of other nodes
+	envGossipPort  = "GOSSIP_PORT"  // UDP port for gossip
+	envNodeZone    = "NODE_ZONE"    // Failure domain announced to the cluster
+	envNodeWeight  = "NODE_WEIGHT"  // Relative capacity announced to the cluster
 
 	// Standard port allocation (same across all services)
 	defaultGRPCPort    = 8080 // Business gRPC/HTTP port
@@ -34,6 +50,9 @@ const (
 	defaultLogLevel    = "info"
 	defaultMaxMemoryMB = 512
 	defaultMaxKeys     = 100000
+	defaultGossipPort  = 7946
+
+	defaultGRPCMaxMessageSizeMB = 16
 )
 
 // NodeConfig holds the cache node configuration.
@@ -44,6 +63,16 @@ type NodeConfig struct {
 	LogLevel    string
 	MaxMemoryMB int
 	MaxKeys     int
+
+	GRPCMaxMessageSizeMB int // Max gRPC message size, must match the proxy
+
+	ReplicationFollowers []string // Followers this node streams its mutations to
+	ReplicationLogSize   int      // Mutations kept for lagging followers (0 = default)
+
+	GossipSeeds []string // Gossip addresses to join through (empty = gossip disabled)
+	GossipPort  int      // UDP port for gossip (7946)
+	Zone        string   // Failure domain announced via gossip
+	Weight      int      // Relative capacity announced via gossip (0 = default)
 }
 
 // loadEnvConfig loads infrastructure configuration from environment variables.
@@ -55,6 +84,9 @@ func loadEnvConfig() NodeConfig {
 		LogLevel:    defaultLogLevel,
 		MaxMemoryMB: defaultMaxMemoryMB,
 		MaxKeys:     defaultMaxKeys,
+		GossipPort:  defaultGossipPort,
+
+		GRPCMaxMessageSizeMB: defaultGRPCMaxMessageSizeMB,
 	}
 
 	// Load GRPC port (business port)
@@ -97,6 +129,45 @@ func loadEnvConfig() NodeConfig {
 		}
 	}
 
+	// Load max gRPC message size
+	if sizeStr := os.Getenv(config.EnvGRPCMaxMessageSizeMB); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil && size > 0 {
+			cfg.GRPCMaxMessageSizeMB = size
+		}
+	}
+
+	// Load replication followers
+	for _, addr := range strings.Split(os.Getenv(envReplicationFollowers), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.ReplicationFollowers = append(cfg.ReplicationFollowers, addr)
+		}
+	}
+
+	// Load replication log size
+	if sizeStr := os.Getenv(envReplicationLogSize); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil && size > 0 {
+			cfg.ReplicationLogSize = size
+		}
+	}
+
+	// Load gossip membership configuration
+	for _, addr := range strings.Split(os.Getenv(envGossipSeeds), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.GossipSeeds = append(cfg.GossipSeeds, addr)
+		}
+	}
+	if portStr := os.Getenv(envGossipPort); portStr != "" {
+		if p, err := strconv.Atoi(portStr); err == nil && p > 0 {
+			cfg.GossipPort = p
+		}
+	}
+	cfg.Zone = os.Getenv(envNodeZone)
+	if weightStr := os.Getenv(envNodeWeight); weightStr != "" {
+		if w, err := strconv.Atoi(weightStr); err == nil && w > 0 {
+			cfg.Weight = w
+		}
+	}
+
 	return cfg
 }
 
@@ -127,6 +198,13 @@ func main() {
 	logger.Info("Log level: %s (from %s)", cfg.LogLevel, envOrDefault(envLogLevel, "default"))
 	logger.Info("Max memory: %d MB (from %s)", cfg.MaxMemoryMB, envOrDefault(envMaxMemoryMB, "default"))
 	logger.Info("Max keys: %d (from %s)", cfg.MaxKeys, envOrDefault(envMaxKeys, "default"))
+	logger.Info("Max gRPC message size: %d MB (from %s)", cfg.GRPCMaxMessageSizeMB, envOrDefault(config.EnvGRPCMaxMessageSizeMB, "default"))
+	if len(cfg.ReplicationFollowers) > 0 {
+		logger.Info("Replication followers: %s (from %s)", strings.Join(cfg.ReplicationFollowers, ", "), envReplicationFollowers)
+	}
+	if len(cfg.GossipSeeds) > 0 {
+		logger.Info("Gossip seeds: %s (port %d, from %s)", strings.Join(cfg.GossipSeeds, ", "), cfg.GossipPort, envGossipSeeds)
+	}
 
 	// Step 2: Check runtime environment
 	logger.Step(2, 4, "Checking runtime environment")
@@ -152,11 +230,40 @@ func main() {
 	// Step 3: Create cache node server
 	logger.Step(3, 4, "Creating cache node server")
 	server := node.NewServer()
+	server.SetMaxMessageSize(cfg.GRPCMaxMessageSizeMB * 1024 * 1024)
+	if len(cfg.ReplicationFollowers) > 0 {
+		nodeID := podName
+		if nodeID == "" {
+			nodeID = hostname
+		}
+		server.SetReplication(nodeID, cfg.ReplicationFollowers, cfg.ReplicationLogSize)
+		logger.Info("Streaming mutations to %d followers as %s", len(cfg.ReplicationFollowers), nodeID)
+	}
 	logger.Success("Cache node server instance created")
 
+	// Join the gossip membership so that nodes detect each other's failures
+	var members *gossip.Membership
+	if len(cfg.GossipSeeds) > 0 {
+		nodeID := podName
+		if nodeID == "" {
+			nodeID = hostname
+		}
+		host := os.Getenv(envPodIP)
+		if host == "" {
+			host = hostname
+		}
+
+		var err error
+		members, err = startGossip(cfg, nodeID, host)
+		if err != nil {
+			logger.Fatal("Failed to start gossip membership: %v", err)
+		}
+		logger.Success("Joined gossip membership as %s via %d seeds", nodeID, len(cfg.GossipSeeds))
+	}
+
 	// Step 4: Setup graceful shutdown
 	logger.Step(4, 4, "Setting up graceful shutdown handler")
-	setupGracefulShutdown(logger, server)
+	setupGracefulShutdown(logger, server, members)
 
 	// Start health check server (independent HTTP server for K8s probes)
 	go func() {
@@ -178,8 +285,45 @@ func main() {
 	}
 }
 
+// startGossip joins the gossip membership, announcing this node's gRPC
+// endpoint, zone and weight.
+//
+// Parameters:
+//   - cfg: Node configuration with the gossip settings
+//   - nodeID: Stable member name (pod name or hostname)
+//   - host: Address other members and proxies reach this node at
+func startGossip(cfg NodeConfig, nodeID, host string) (*gossip.Membership, error) {
+	port := strconv.Itoa(cfg.GossipPort)
+	transport, err := gossip.NewUDPTransport(":"+port, net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := gossip.New(gossip.Config{
+		Name:      nodeID,
+		Endpoint:  net.JoinHostPort(host, strconv.Itoa(cfg.GRPCPort)),
+		Zone:      cfg.Zone,
+		Weight:    cfg.Weight,
+		Seeds:     cfg.GossipSeeds,
+		Transport: transport,
+	})
+	if err != nil {
+		transport.Close()
+		return nil, err
+	}
+
+	logger := utils.NewLogger("node-gossip")
+	if err := members.Start(context.Background(), func(endpoints []string) {
+		logger.Info("Cluster membership: %d nodes", len(endpoints))
+	}); err != nil {
+		members.Stop()
+		return nil, err
+	}
+	return members, nil
+}
+
 // setupGracefulShutdown registers signal handlers for graceful termination.
-func setupGracefulShutdown(logger *utils.Logger, server *node.Server) {
+func setupGracefulShutdown(logger *utils.Logger, server *node.Server, members *gossip.Membership) {
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -188,6 +332,12 @@ func setupGracefulShutdown(logger *utils.Logger, server *node.Server) {
 		logger.Warn("Received signal: %v", sig)
 		logger.Info("Initiating graceful shutdown...")
 
+		// Leave the gossip membership before the server stops answering
+		if members != nil {
+			logger.Info("Leaving gossip membership...")
+			members.Stop()
+		}
+
 		// Stop server gracefully
 		if server != nil {
 			logger.Info("Stopping node server...")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"runtime"
@@ -13,6 +14,8 @@ import (
 	"time"
 
 	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/discovery"
+	"github.com/eggybyte-technology/yao-oracle/core/gossip"
 	"github.com/eggybyte-technology/yao-oracle/core/utils"
 	"github.com/eggybyte-technology/yao-oracle/internal/proxy"
 )
@@ -33,8 +36,16 @@ const (
 
 	// Service discovery configuration
 	envNodeHeadlessService = "NODE_HEADLESS_SERVICE"
+	envNodeGRPCPort        = "NODE_GRPC_PORT" // gRPC port of the cache nodes
 	envDiscoveryMode       = "DISCOVERY_MODE"
 	envDiscoveryInterval   = "DISCOVERY_INTERVAL"
+	envDiscoveryFile       = "DISCOVERY_FILE"        // Node list file (file mode)
+	envDiscoverySRVService = "DISCOVERY_SRV_SERVICE" // SRV service name (dns mode)
+	envGossipSeeds         = "GOSSIP_SEEDS"          // Node gossip addresses (gossip mode)
+	envGossipPort          = "GOSSIP_PORT"           // UDP port for gossip (gossip mode)
+
+	// nodeGRPCPortName is the name of the gRPC port of the node Service
+	nodeGRPCPortName = "grpc"
 
 	// Standard port allocation (same across all services)
 	defaultGRPCPort          = 8080 // Business gRPC/HTTP port
@@ -45,6 +56,9 @@ const (
 	defaultSecretName        = "yao-oracle-secret"
 	defaultDiscoveryMode     = "k8s"
 	defaultDiscoveryInterval = 10
+	defaultGossipPort        = 7946
+
+	defaultGRPCMaxMessageSizeMB = 16
 )
 
 // ProxyEnvConfig holds infrastructure configuration loaded from environment variables.
@@ -58,8 +72,15 @@ type ProxyEnvConfig struct {
 	PodName           string
 	PodIP             string
 	NodeService       string
+	NodeGRPCPort      int // gRPC port of the cache nodes (8080)
 	DiscoveryMode     string
 	DiscoveryInterval int
+	DiscoveryFile     string
+	DiscoverySRV      string
+	GossipSeeds       []string
+	GossipPort        int
+
+	GRPCMaxMessageSizeMB int // Max gRPC message size, must match the nodes
 }
 
 // loadEnvConfig loads infrastructure configuration from environment variables.
@@ -73,6 +94,10 @@ func loadEnvConfig() ProxyEnvConfig {
 		SecretName:        defaultSecretName,
 		DiscoveryMode:     defaultDiscoveryMode,
 		DiscoveryInterval: defaultDiscoveryInterval,
+		NodeGRPCPort:      defaultGRPCPort,
+		GossipPort:        defaultGossipPort,
+
+		GRPCMaxMessageSizeMB: defaultGRPCMaxMessageSizeMB,
 	}
 
 	// Load GRPC port (business port)
@@ -113,6 +138,11 @@ func loadEnvConfig() ProxyEnvConfig {
 
 	// Load service discovery configuration
 	cfg.NodeService = os.Getenv(envNodeHeadlessService)
+	if portStr := os.Getenv(envNodeGRPCPort); portStr != "" {
+		if p, err := strconv.Atoi(portStr); err == nil && p > 0 {
+			cfg.NodeGRPCPort = p
+		}
+	}
 	if mode := os.Getenv(envDiscoveryMode); mode != "" {
 		cfg.DiscoveryMode = mode
 	}
@@ -121,6 +151,25 @@ func loadEnvConfig() ProxyEnvConfig {
 			cfg.DiscoveryInterval = interval
 		}
 	}
+	cfg.DiscoveryFile = os.Getenv(envDiscoveryFile)
+	cfg.DiscoverySRV = os.Getenv(envDiscoverySRVService)
+	for _, addr := range strings.Split(os.Getenv(envGossipSeeds), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.GossipSeeds = append(cfg.GossipSeeds, addr)
+		}
+	}
+	if portStr := os.Getenv(envGossipPort); portStr != "" {
+		if p, err := strconv.Atoi(portStr); err == nil && p > 0 {
+			cfg.GossipPort = p
+		}
+	}
+
+	// Load max gRPC message size
+	if sizeStr := os.Getenv(config.EnvGRPCMaxMessageSizeMB); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil && size > 0 {
+			cfg.GRPCMaxMessageSizeMB = size
+		}
+	}
 
 	return cfg
 }
@@ -147,6 +196,7 @@ func main() {
 	logger.Info("Health port: %d (health check, from %s)", envCfg.HealthPort, envOrDefault(envHealthPort, "default"))
 	logger.Info("Metrics port: %d (Prometheus, from %s)", envCfg.MetricsPort, envOrDefault(envMetricsPort, "default"))
 	logger.Info("Log level: %s", envCfg.LogLevel)
+	logger.Info("Max gRPC message size: %d MB (from %s)", envCfg.GRPCMaxMessageSizeMB, envOrDefault(config.EnvGRPCMaxMessageSizeMB, "default"))
 	logger.Info("Kubernetes namespace: %s", envCfg.Namespace)
 	logger.Info("Secret name: %s", envCfg.SecretName)
 	if envCfg.PodName != "" {
@@ -191,6 +241,22 @@ func main() {
 		logger.Fatal("Failed to create Kubernetes Informer: %v", err)
 	}
 
+	// Create the proxy server before starting the informer so that
+	// configuration reloads can be applied to it
+	server := proxy.NewServer(informer)
+	server.SetMaxMessageSize(envCfg.GRPCMaxMessageSizeMB * 1024 * 1024)
+	if err := server.SetPlacement(proxyCfg.Placement); err != nil {
+		logger.Fatal("Invalid placement configuration: %v", err)
+	}
+	server.SetRebalanceConfig(proxyCfg.Rebalance)
+	server.SetFailoverConfig(proxyCfg.Failover)
+	server.SetAntiEntropyConfig(proxyCfg.AntiEntropy)
+	server.SetHintedHandoffConfig(proxyCfg.HintedHandoff)
+	server.SetCircuitBreakerConfig(proxyCfg.CircuitBreaker)
+	server.SetRequestPolicy(proxyCfg.Retry, proxyCfg.Hedging)
+	server.SetHotKeyConfig(proxyCfg.HotKeys)
+	server.SetLegacyKeysConfig(proxyCfg.LegacyKeys)
+
 	// Start informer with reload callback
 	go func() {
 		err := informer.Start(ctx, func(kind string, data map[string][]byte) {
@@ -199,6 +265,17 @@ func main() {
 			newCfg := informer.GetConfig()
 			if newCfg.Proxy != nil {
 				logger.Info("Reloaded: %d namespaces", len(newCfg.Proxy.Namespaces))
+				if err := server.SetPlacement(newCfg.Proxy.Placement); err != nil {
+					logger.Error("Ignoring invalid placement configuration: %v", err)
+				}
+				server.SetRebalanceConfig(newCfg.Proxy.Rebalance)
+				server.SetFailoverConfig(newCfg.Proxy.Failover)
+				server.SetAntiEntropyConfig(newCfg.Proxy.AntiEntropy)
+				server.SetHintedHandoffConfig(newCfg.Proxy.HintedHandoff)
+				server.SetCircuitBreakerConfig(newCfg.Proxy.CircuitBreaker)
+				server.SetRequestPolicy(newCfg.Proxy.Retry, newCfg.Proxy.Hedging)
+				server.SetHotKeyConfig(newCfg.Proxy.HotKeys)
+				server.SetLegacyKeysConfig(newCfg.Proxy.LegacyKeys)
 			}
 		})
 		if err != nil {
@@ -210,12 +287,12 @@ func main() {
 	time.Sleep(time.Second)
 	logger.Success("Kubernetes Informer started, watching for config changes")
 
-	// Step 6: Create proxy server with informer
-	logger.Step(6, 7, "Creating proxy server")
-	server := proxy.NewServer(informer)
+	// Step 6: Configure proxy server
+	logger.Step(6, 7, "Configuring proxy server")
 	logger.Success("Proxy server instance created")
 
-	// Configure cache nodes (for testing)
+	// Configure cache nodes (static list for testing, otherwise discovery)
+	var nodeDiscovery discovery.ServiceDiscovery
 	if *flagNodes != "" {
 		nodeList := strings.Split(*flagNodes, ",")
 		server.SetNodes(nodeList)
@@ -224,14 +301,28 @@ func main() {
 			logger.Info("  Node %d: %s", i+1, node)
 		}
 	} else {
-		logger.Info("Using Kubernetes service discovery for cache nodes")
-		logger.Info("Node service: %s", envCfg.NodeService)
 		logger.Info("Discovery mode: %s", envCfg.DiscoveryMode)
+		switch envCfg.DiscoveryMode {
+		case "file":
+			logger.Info("Node list file: %s", envCfg.DiscoveryFile)
+		case "gossip":
+			logger.Info("Gossip seeds: %s", strings.Join(envCfg.GossipSeeds, ", "))
+		default:
+			logger.Info("Node service: %s", envCfg.NodeService)
+		}
+		nodeDiscovery, err = newNodeDiscovery(envCfg)
+		if err != nil {
+			logger.Fatal("Failed to create node discovery: %v", err)
+		}
+		if err := server.WatchNodes(ctx, nodeDiscovery); err != nil {
+			logger.Fatal("Failed to start node discovery: %v", err)
+		}
+		logger.Success("Discovered %d cache nodes", len(nodeDiscovery.GetEndpoints()))
 	}
 
 	// Step 7: Setup graceful shutdown
 	logger.Step(7, 7, "Setting up graceful shutdown handler")
-	setupGracefulShutdown(logger, informer, server)
+	setupGracefulShutdown(logger, informer, nodeDiscovery, server)
 
 	// Start health check server (independent HTTP server for K8s probes)
 	go func() {
@@ -253,8 +344,104 @@ func main() {
 	}
 }
 
+// newNodeDiscovery creates the cache node discovery selected by DISCOVERY_MODE.
+//
+// Modes:
+//   - k8s: The EndpointSlices of NODE_HEADLESS_SERVICE are watched. The
+//     variable holds the service's DNS name; its first label is the Service
+//     name and its second label, if present, the namespace (default: NAMESPACE).
+//     Nodes are reached on the Service port named "grpc", or on
+//     NODE_GRPC_PORT if the Service has no such port.
+//   - file: The node list in DISCOVERY_FILE is re-read every DISCOVERY_INTERVAL.
+//   - dns: NODE_HEADLESS_SERVICE is resolved every DISCOVERY_INTERVAL. With
+//     DISCOVERY_SRV_SERVICE set, SRV records provide ports and weights;
+//     otherwise A records are used with the port given as "NAME:PORT"
+//     (default: NODE_GRPC_PORT).
+//   - gossip: The proxy joins the nodes' gossip membership through
+//     GOSSIP_SEEDS as an observer; nodes announce their own endpoints.
+func newNodeDiscovery(envCfg ProxyEnvConfig) (discovery.ServiceDiscovery, error) {
+	interval := time.Duration(envCfg.DiscoveryInterval) * time.Second
+
+	switch envCfg.DiscoveryMode {
+	case "k8s":
+		if envCfg.NodeService == "" {
+			return nil, fmt.Errorf("%s is required in k8s discovery mode", envNodeHeadlessService)
+		}
+
+		labels := strings.Split(envCfg.NodeService, ".")
+		namespace := envCfg.Namespace
+		if len(labels) > 1 && labels[1] != "svc" {
+			namespace = labels[1]
+		}
+
+		// The headless Service also exposes health and metrics ports
+		return discovery.NewEndpointSliceDiscovery(discovery.Config{
+			Namespace:   namespace,
+			ServiceName: labels[0],
+			PortName:    nodeGRPCPortName,
+			Port:        envCfg.NodeGRPCPort,
+		})
+	case "file":
+		return discovery.NewFileDiscovery(discovery.FileConfig{
+			Path:     envCfg.DiscoveryFile,
+			Interval: interval,
+		})
+	case "dns":
+		if envCfg.NodeService == "" {
+			return nil, fmt.Errorf("%s is required in dns discovery mode", envNodeHeadlessService)
+		}
+
+		name, port := envCfg.NodeService, envCfg.NodeGRPCPort
+		if host, portStr, err := net.SplitHostPort(envCfg.NodeService); err == nil {
+			p, err := strconv.Atoi(portStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port in %s: %q", envNodeHeadlessService, portStr)
+			}
+			name, port = host, p
+		}
+
+		return discovery.NewDNSDiscovery(discovery.DNSConfig{
+			Name:     name,
+			Port:     port,
+			Service:  envCfg.DiscoverySRV,
+			Interval: interval,
+		})
+	case "gossip":
+		if len(envCfg.GossipSeeds) == 0 {
+			return nil, fmt.Errorf("%s is required in gossip discovery mode", envGossipSeeds)
+		}
+
+		name := envCfg.PodName
+		if name == "" {
+			name, _ = os.Hostname()
+		}
+		host := envCfg.PodIP
+		if host == "" {
+			host, _ = os.Hostname()
+		}
+
+		port := strconv.Itoa(envCfg.GossipPort)
+		transport, err := gossip.NewUDPTransport(":"+port, net.JoinHostPort(host, port))
+		if err != nil {
+			return nil, err
+		}
+		members, err := gossip.New(gossip.Config{
+			Name:      name,
+			Seeds:     envCfg.GossipSeeds,
+			Transport: transport,
+		})
+		if err != nil {
+			transport.Close()
+			return nil, err
+		}
+		return members, nil
+	default:
+		return nil, fmt.Errorf("unsupported discovery mode %q (supported: k8s, file, dns, gossip)", envCfg.DiscoveryMode)
+	}
+}
+
 // setupGracefulShutdown registers signal handlers for graceful termination.
-func setupGracefulShutdown(logger *utils.Logger, informer *config.K8sInformer, server *proxy.Server) {
+func setupGracefulShutdown(logger *utils.Logger, informer *config.K8sInformer, nodeDiscovery discovery.ServiceDiscovery, server *proxy.Server) {
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -269,6 +456,12 @@ func setupGracefulShutdown(logger *utils.Logger, informer *config.K8sInformer, s
 			informer.Stop()
 		}
 
+		// Stop cache node discovery
+		if nodeDiscovery != nil {
+			logger.Info("Stopping node discovery...")
+			nodeDiscovery.Stop()
+		}
+
 		// Stop proxy server
 		if server != nil {
 			logger.Info("Stopping proxy server...")
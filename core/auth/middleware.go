@@ -125,7 +125,7 @@ func UnaryServerInterceptor(auth Authenticator) grpc.UnaryServerInterceptor {
 //	        return nil, status.Error(codes.Unauthenticated, "namespace not found")
 //	    }
 //	    // Use namespace for data isolation
-//	    value := s.cache.Get(namespace + ":" + req.Key)
+//	    value := s.cache.Get(kv.NamespaceKey(namespace, req.Key))
 //	    return &pb.GetResponse{Value: value}, nil
 //	}
 func GetNamespaceFromContext(ctx context.Context) (string, bool) {
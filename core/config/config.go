@@ -27,6 +27,56 @@ type Namespace struct {
 	// RateLimitQPS is the queries-per-second limit for this namespace
 	// Optional: 0 means no rate limiting
 	RateLimitQPS int `json:"rateLimitQPS,omitempty"`
+
+	// MaxKeyBytes is the maximum length of a cache key in bytes
+	// Optional: 0 means only the gRPC message size limit applies
+	MaxKeyBytes int `json:"maxKeyBytes,omitempty"`
+
+	// MaxValueBytes is the maximum size of a cached value in bytes
+	// Optional: 0 means only the gRPC message size limit applies
+	MaxValueBytes int `json:"maxValueBytes,omitempty"`
+
+	// ReplicationFactor is the number of cache nodes that store each key
+	// Optional: 0 or 1 means no replication
+	ReplicationFactor int `json:"replicationFactor,omitempty"`
+
+	// WriteQuorum is the number of replicas that must acknowledge a write
+	// Optional: 0 means a majority of ReplicationFactor
+	WriteQuorum int `json:"writeQuorum,omitempty"`
+
+	// ReadQuorum is the number of replicas consulted on every read
+	// WriteQuorum + ReadQuorum must exceed ReplicationFactor
+	// Optional: 0 means a majority of ReplicationFactor
+	ReadQuorum int `json:"readQuorum,omitempty"`
+}
+
+// Replication returns the effective replication settings of the namespace.
+//
+// Returns:
+//   - n: Number of replicas per key (at least 1)
+//   - w: Number of replicas that must acknowledge a write
+//   - r: Number of replicas consulted on a read
+//
+// Unset quorums default to a majority of n, so that every read overlaps
+// with the latest successful write.
+//
+// Example:
+//
+//	n, w, r := ns.Replication()
+//	// replicationFactor 3, no quorums set: n=3, w=2, r=2
+func (ns *Namespace) Replication() (n, w, r int) {
+	n = max(ns.ReplicationFactor, 1)
+	majority := n/2 + 1
+
+	w = ns.WriteQuorum
+	if w <= 0 {
+		w = majority
+	}
+	r = ns.ReadQuorum
+	if r <= 0 {
+		r = majority
+	}
+	return n, min(n, w), min(n, r)
 }
 
 // ProxyConfig holds the proxy service configuration.
@@ -41,6 +91,335 @@ type ProxyConfig struct {
 	// Port is deprecated and should be configured via environment variables
 	// This field is kept for backward compatibility
 	Port int `json:"port,omitempty"`
+
+	// Placement selects the algorithm that maps keys to cache nodes
+	// Optional: nil means a ketama ring with CRC32 and 150 virtual nodes
+	Placement *PlacementConfig `json:"placement,omitempty"`
+
+	// Rebalance controls key migration after cache node membership changes
+	// Optional: nil means migration is enabled with default throttling
+	Rebalance *RebalanceConfig `json:"rebalance,omitempty"`
+
+	// Failover promotes follower nodes when their primary fails
+	// Optional: nil disables failover
+	Failover *FailoverConfig `json:"failover,omitempty"`
+
+	// AntiEntropy periodically compares and repairs replicas of replicated
+	// namespaces
+	// Optional: nil means anti-entropy is enabled with default settings
+	AntiEntropy *AntiEntropyConfig `json:"antiEntropy,omitempty"`
+
+	// HintedHandoff keeps writes for unavailable nodes on another node
+	// until the owner is back
+	// Optional: nil means hinted handoff is enabled with default limits
+	HintedHandoff *HintedHandoffConfig `json:"hintedHandoff,omitempty"`
+
+	// CircuitBreaker ejects failing or slow cache nodes from routing
+	// Optional: nil means circuit breaking is enabled with default limits
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+
+	// Retry retries failed node reads and versioned writes
+	// Optional: nil means one retry of UNAVAILABLE errors within the budget
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// Hedging sends a second read to another replica when the first one is
+	// slow
+	// Optional: nil disables hedging
+	Hedging *HedgingConfig `json:"hedging,omitempty"`
+
+	// HotKeys detects the most requested keys of each namespace and can
+	// serve them from a near cache in the proxy
+	// Optional: nil means hot keys are reported but not cached
+	HotKeys *HotKeyConfig `json:"hotKeys,omitempty"`
+
+	// LegacyKeys rewrites keys stored in the legacy "namespace:key" format
+	// into the current key encoding
+	// Optional: nil means legacy keys are migrated with default limits
+	LegacyKeys *LegacyKeysConfig `json:"legacyKeys,omitempty"`
+}
+
+// LegacyKeysConfig controls the migration of keys written by proxies that
+// used the "namespace:key" encoding.
+//
+// One proxy at a time scans every node for such keys, stores them under
+// their current key and deletes the old copy, repeating the scan until it
+// finds none. It then records on the nodes that the migration is done, which
+// stops it on every proxy. Until then, Gets that miss also look up the
+// legacy key, and Deletes remove it.
+type LegacyKeysConfig struct {
+	// Disabled turns off the migration; legacy keys become cache misses
+	Disabled bool `json:"disabled,omitempty"`
+
+	// MaxKeysPerSecond throttles migration to protect node latency
+	// Optional: 0 means 1000 keys per second
+	MaxKeysPerSecond int `json:"maxKeysPerSecond,omitempty"`
+
+	// BatchSize is the number of keys scanned and rewritten per batch
+	// Optional: 0 means 100
+	BatchSize int `json:"batchSize,omitempty"`
+}
+
+// HotKeyConfig controls hot key detection and the proxy near cache.
+//
+// The proxy counts Gets per key with a Count-Min sketch and keeps the TopK
+// most requested keys of each namespace. Keys requested at least
+// MinRequestsPerSecond are hot. With NearCache enabled, the proxy serves hot
+// keys from local memory: entries are dropped when the cache nodes report a
+// change to the key, and are never older than MaxStalenessMs.
+type HotKeyConfig struct {
+	// TopK is the number of most requested keys tracked per namespace
+	// Optional: 0 means 10
+	TopK int `json:"topK,omitempty"`
+
+	// MinRequestsPerSecond is the request rate from which a key is hot
+	// Optional: 0 means 100
+	MinRequestsPerSecond float64 `json:"minRequestsPerSecond,omitempty"`
+
+	// NearCache turns on serving hot keys from the proxy
+	NearCache bool `json:"nearCache,omitempty"`
+
+	// MaxStalenessMs is the longest time a near cache entry is served
+	// Optional: 0 means 1000 milliseconds
+	MaxStalenessMs int `json:"maxStalenessMs,omitempty"`
+
+	// MaxValueBytes is the largest value kept in the near cache
+	// Optional: 0 means 65536 bytes
+	MaxValueBytes int `json:"maxValueBytes,omitempty"`
+}
+
+// RetryConfig controls retries of node requests that are safe to repeat:
+// reads, and writes that carry a timestamp. Deletes are never retried.
+//
+// Retries and hedged requests share a budget: together they may add at most
+// BudgetPercent to the request rate, plus MinRetriesPerSecond, so that
+// retries cannot multiply the load on a cluster that is already failing.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts per request, including
+	// the first one
+	// Optional: 0 means 2; 1 disables retries
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+
+	// RetryableCodes lists the gRPC status codes that are retried
+	// Example: ["UNAVAILABLE", "RESOURCE_EXHAUSTED"]
+	// Optional: empty means ["UNAVAILABLE"]
+	RetryableCodes []string `json:"retryableCodes,omitempty"`
+
+	// InitialBackoffMs is the delay before the first retry; every further
+	// retry doubles it, with random jitter
+	// Optional: 0 means 10 milliseconds
+	InitialBackoffMs int `json:"initialBackoffMs,omitempty"`
+
+	// MaxBackoffMs caps the delay between retries
+	// Optional: 0 means 200 milliseconds
+	MaxBackoffMs int `json:"maxBackoffMs,omitempty"`
+
+	// BudgetPercent is the share of requests that may be retried or hedged
+	// Optional: 0 means 10
+	BudgetPercent int `json:"budgetPercent,omitempty"`
+
+	// MinRetriesPerSecond is a retry allowance granted even at low traffic
+	// Optional: 0 means 10
+	MinRetriesPerSecond int `json:"minRetriesPerSecond,omitempty"`
+}
+
+// HedgingConfig controls hedged reads in replicated namespaces.
+//
+// When a replica has not answered a read after the observed latency
+// percentile, the proxy sends the read to one more replica and uses
+// whichever answers first. Hedged reads consume the retry budget.
+type HedgingConfig struct {
+	// Enabled turns on hedged reads
+	Enabled bool `json:"enabled,omitempty"`
+
+	// LatencyPercentile of recent replica reads after which a read is hedged
+	// Optional: 0 means 0.95
+	LatencyPercentile float64 `json:"latencyPercentile,omitempty"`
+
+	// MinDelayMs is the minimum delay before a read is hedged
+	// Optional: 0 means 1 millisecond
+	MinDelayMs int `json:"minDelayMs,omitempty"`
+}
+
+// CircuitBreakerConfig controls the per-node circuit breakers of the proxy.
+//
+// A node is ejected after too many consecutive errors or when its request
+// latency percentile exceeds a threshold. Requests to an ejected node fail
+// fast instead of waiting for the client deadline. After the ejection time
+// the breaker lets probe requests through (half-open) and re-admits the
+// node once enough of them succeed; a failed probe ejects it again for
+// twice as long.
+type CircuitBreakerConfig struct {
+	// Disabled turns off circuit breaking
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Mode selects how reads of keys owned by an ejected node are handled
+	// One of "skip" (default: read from the next node in the ring) or
+	// "failfast" (fail immediately). Writes to an ejected node always fail
+	// fast, which hands them off as hints if hinted handoff is enabled.
+	Mode string `json:"mode,omitempty"`
+
+	// ConsecutiveErrors is the number of consecutive failed requests that
+	// ejects a node
+	// Optional: 0 means 5
+	ConsecutiveErrors int `json:"consecutiveErrors,omitempty"`
+
+	// LatencyThresholdMs ejects a node whose latency percentile exceeds it
+	// Optional: 0 disables latency-based ejection
+	LatencyThresholdMs int `json:"latencyThresholdMs,omitempty"`
+
+	// LatencyPercentile is the percentile compared against the threshold
+	// Optional: 0 means 0.99
+	LatencyPercentile float64 `json:"latencyPercentile,omitempty"`
+
+	// BaseEjectionSeconds is how long a node is ejected the first time;
+	// every further ejection without recovery doubles it
+	// Optional: 0 means 10 seconds
+	BaseEjectionSeconds int `json:"baseEjectionSeconds,omitempty"`
+
+	// MaxEjectionSeconds caps the ejection time
+	// Optional: 0 means 300 seconds
+	MaxEjectionSeconds int `json:"maxEjectionSeconds,omitempty"`
+
+	// HalfOpenSuccesses is the number of successful probes that re-admits
+	// an ejected node
+	// Optional: 0 means 3
+	HalfOpenSuccesses int `json:"halfOpenSuccesses,omitempty"`
+
+	// MaxEjectionPercent is the largest share of nodes that may be ejected
+	// at the same time, so that an overloaded cluster is not emptied
+	// Optional: 0 means 50; at least one node can always be ejected
+	MaxEjectionPercent int `json:"maxEjectionPercent,omitempty"`
+}
+
+// HintedHandoffConfig controls how writes to an unavailable cache node are
+// handled in namespaces without replication.
+//
+// Instead of failing, the proxy stores the write on the next healthy node in
+// the ring and remembers a hint. Once the owner passes health checks again,
+// the hinted writes are replayed to it and removed from the stand-in node.
+// Hints are kept in the memory of the proxy that stored them and are lost
+// when it restarts; each proxy replica replays its own hints.
+type HintedHandoffConfig struct {
+	// Disabled turns off hinted handoff; writes to unavailable nodes fail
+	Disabled bool `json:"disabled,omitempty"`
+
+	// MaxHints is the maximum number of pending hints across all nodes;
+	// writes beyond the limit fail as if hinted handoff was disabled
+	// Optional: 0 means 10000
+	MaxHints int `json:"maxHints,omitempty"`
+
+	// HintTTLSeconds is how long a hint is kept for an owner that does not
+	// come back; expired hints are discarded. Stand-in copies expire after
+	// the same time
+	// Optional: 0 means 3600 seconds
+	HintTTLSeconds int `json:"hintTTLSeconds,omitempty"`
+
+	// ReplayIntervalSeconds is the interval between health checks of nodes
+	// with pending hints
+	// Optional: 0 means 5 seconds
+	ReplayIntervalSeconds int `json:"replayIntervalSeconds,omitempty"`
+}
+
+// AntiEntropyConfig controls the background repair of replicas that drifted
+// apart, e.g. after dropped writes or node restarts.
+//
+// Replica pairs compare Merkle trees over the hash ranges they share and
+// exchange only the keys of differing leaves; the newest write wins.
+// Anti-entropy requires the "ketama" placement algorithm and only runs
+// while a namespace has a replicationFactor above 1.
+type AntiEntropyConfig struct {
+	// Disabled turns off anti-entropy; replicas then converge only through
+	// read repair
+	Disabled bool `json:"disabled,omitempty"`
+
+	// IntervalSeconds is the pause between anti-entropy rounds
+	// Optional: 0 means 60 seconds
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+
+	// MaxKeysPerSecond throttles the number of keys compared and repaired
+	// Optional: 0 means 500 keys per second
+	MaxKeysPerSecond int `json:"maxKeysPerSecond,omitempty"`
+
+	// TreeDepth is the Merkle tree depth; trees have 2^treeDepth leaves
+	// Optional: 0 means 10, at most 16
+	TreeDepth int `json:"treeDepth,omitempty"`
+}
+
+// FailoverConfig configures promotion of follower nodes that replicate a
+// primary cache node through the node Replicate stream.
+//
+// When a primary fails its health checks, the proxy promotes the follower
+// with the most replicated mutations and routes the primary's keys to it.
+type FailoverConfig struct {
+	// Followers maps a primary node address to the addresses of its followers
+	// Example: {"cache-node-0:8080": ["cache-backup-0:8080"]}
+	Followers map[string][]string `json:"followers"`
+
+	// CheckIntervalSeconds is the interval between primary health checks
+	// Optional: 0 means 2 seconds
+	CheckIntervalSeconds int `json:"checkIntervalSeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed health checks
+	// after which a primary is failed over
+	// Optional: 0 means 3
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+}
+
+// RebalanceConfig controls how keys are migrated to their new owner when
+// cache nodes join or leave the ring.
+//
+// Migration requires the "ketama" placement algorithm.
+type RebalanceConfig struct {
+	// Disabled turns off key migration; moved keys become cache misses
+	Disabled bool `json:"disabled,omitempty"`
+
+	// MaxKeysPerSecond throttles migration to protect node latency
+	// Optional: 0 means 1000 keys per second
+	MaxKeysPerSecond int `json:"maxKeysPerSecond,omitempty"`
+
+	// BatchSize is the number of keys scanned and imported per batch
+	// Optional: 0 means 100
+	BatchSize int `json:"batchSize,omitempty"`
+
+	// MaxAttempts is how often a failing old owner is retried before its
+	// remaining keys are abandoned
+	// Optional: 0 means 10
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+}
+
+// PlacementConfig selects the key placement algorithm and hash function.
+//
+// All proxy instances must use the same placement settings, and changing
+// them remaps most keys to different nodes (a cold cache for those keys).
+type PlacementConfig struct {
+	// Algorithm is the placement algorithm
+	// One of "ketama" (default), "jump", "rendezvous", "maglev"
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// HashFunction is the hash function used to position keys
+	// One of "crc32" (default), "xxhash", "murmur3"
+	HashFunction string `json:"hashFunction,omitempty"`
+
+	// VirtualNodes is the number of virtual nodes per unit of node weight
+	// Optional: only used by "ketama", 0 means 150
+	VirtualNodes int `json:"virtualNodes,omitempty"`
+
+	// TableSize is the lookup table size, rounded up to a prime
+	// Optional: only used by "maglev", 0 means 65537
+	TableSize int `json:"tableSize,omitempty"`
+
+	// BoundedLoadEpsilon enables consistent hashing with bounded loads
+	// Gets skip a node above (1+epsilon) times its share of requests: reads
+	// in replicated namespaces go to the next replica, other keys are read
+	// from a short-lived overflow copy on the next node with room. Key
+	// ownership and writes are unaffected
+	// Optional: only supported by "ketama", 0 disables bounded loads
+	BoundedLoadEpsilon float64 `json:"boundedLoadEpsilon,omitempty"`
+
+	// BoundedLoadCopyTTLSeconds is the lifetime of overflow copies, which
+	// bounds how stale a diverted read can be
+	// Optional: only used with boundedLoadEpsilon, 0 means 1 second
+	BoundedLoadCopyTTLSeconds int `json:"boundedLoadCopyTTLSeconds,omitempty"`
 }
 
 // DashboardConfig holds the dashboard service configuration.
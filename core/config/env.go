@@ -20,6 +20,9 @@ const (
 	EnvLogLevel    = "LOG_LEVEL"
 	EnvMetricsPort = "METRICS_PORT"
 
+	// EnvGRPCMaxMessageSizeMB bounds gRPC message size; proxy and node must match
+	EnvGRPCMaxMessageSizeMB = "GRPC_MAX_MESSAGE_SIZE_MB"
+
 	// Kubernetes Resource Names (for direct API access)
 	EnvNamespace     = "NAMESPACE"      // Kubernetes namespace
 	EnvSecretName    = "SECRET_NAME"    // Name of Secret to read config from
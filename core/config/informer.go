@@ -179,6 +179,24 @@ func NewK8sInformer(cfg K8sInformerConfig) (*K8sInformer, error) {
 	}, nil
 }
 
+// NewStaticInformer creates an informer that serves a fixed configuration
+// without watching Kubernetes, for tests and local runs.
+//
+// Start fails on a static informer; GetConfig and GetNamespaceByAPIKey
+// return cfg until the process exits.
+//
+// Parameters:
+//   - cfg: Configuration to serve
+//
+// Returns:
+//   - *K8sInformer: An informer serving cfg
+func NewStaticInformer(cfg Config) *K8sInformer {
+	return &K8sInformer{
+		config: cfg,
+		logger: utils.NewLogger("k8s-informer"),
+	}
+}
+
 // Start begins watching the Secret for changes.
 //
 // This method creates a SharedInformerFactory and starts watching the Secret.
@@ -195,6 +213,9 @@ func NewK8sInformer(cfg K8sInformerConfig) (*K8sInformer, error) {
 //   - Starts background goroutines
 //   - Calls onChange immediately with initial config
 func (i *K8sInformer) Start(ctx context.Context, onChange func(kind string, data map[string][]byte)) error {
+	if i.clientset == nil {
+		return fmt.Errorf("static informer cannot watch a Secret")
+	}
 	i.onChange = onChange
 
 	// Load initial configuration
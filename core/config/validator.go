@@ -2,6 +2,10 @@ package config
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
 )
 
 // ValidateConfig validates the complete configuration structure and business rules.
@@ -59,8 +63,21 @@ func ValidateConfig(cfg *Config) error {
 // Validation rules:
 //   - At least one namespace must be defined
 //   - Namespace names must be unique and non-empty
+//   - Namespace names must not contain control characters
 //   - API keys must be non-empty for each namespace
 //   - Resource limits must be non-negative if specified
+//   - Key and value size limits must be non-negative if specified
+//   - Write and read quorums must not exceed the replication factor, and
+//     their sum (after defaults) must exceed it
+//   - Placement settings must be valid if specified
+//   - Rebalance limits must be non-negative if specified
+//   - Failover settings must be valid if specified
+//   - Anti-entropy limits must be non-negative, tree depth at most 16
+//   - Hinted handoff limits must be non-negative
+//   - Circuit breaker settings must be valid if specified
+//   - Retry and hedging settings must be valid if specified
+//   - Hot key and near cache limits must be non-negative
+//   - Legacy key migration limits must be non-negative
 //
 // Parameters:
 //   - cfg: The proxy configuration to validate
@@ -86,6 +103,9 @@ func ValidateProxyConfig(cfg *ProxyConfig) error {
 		if ns.Name == "" {
 			return fmt.Errorf("namespace[%d]: name cannot be empty", i)
 		}
+		if strings.ContainsFunc(ns.Name, unicode.IsControl) {
+			return fmt.Errorf("namespace[%d]: name %q cannot contain control characters", i, ns.Name)
+		}
 
 		// Check for duplicate namespace names
 		if namespaceNames[ns.Name] {
@@ -118,6 +138,288 @@ func ValidateProxyConfig(cfg *ProxyConfig) error {
 		if ns.RateLimitQPS < 0 {
 			return fmt.Errorf("namespace[%d] (%s): rateLimitQPS cannot be negative, got %d", i, ns.Name, ns.RateLimitQPS)
 		}
+
+		if ns.MaxKeyBytes < 0 {
+			return fmt.Errorf("namespace[%d] (%s): maxKeyBytes cannot be negative, got %d", i, ns.Name, ns.MaxKeyBytes)
+		}
+
+		if ns.MaxValueBytes < 0 {
+			return fmt.Errorf("namespace[%d] (%s): maxValueBytes cannot be negative, got %d", i, ns.Name, ns.MaxValueBytes)
+		}
+
+		if err := validateReplication(&ns); err != nil {
+			return fmt.Errorf("namespace[%d] (%s): %w", i, ns.Name, err)
+		}
+	}
+
+	if cfg.Placement != nil {
+		if err := ValidatePlacementConfig(cfg.Placement); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Rebalance != nil {
+		if cfg.Rebalance.MaxKeysPerSecond < 0 {
+			return fmt.Errorf("rebalance: maxKeysPerSecond cannot be negative, got %d", cfg.Rebalance.MaxKeysPerSecond)
+		}
+		if cfg.Rebalance.BatchSize < 0 {
+			return fmt.Errorf("rebalance: batchSize cannot be negative, got %d", cfg.Rebalance.BatchSize)
+		}
+		if cfg.Rebalance.MaxAttempts < 0 {
+			return fmt.Errorf("rebalance: maxAttempts cannot be negative, got %d", cfg.Rebalance.MaxAttempts)
+		}
+	}
+
+	if cfg.Failover != nil {
+		if err := ValidateFailoverConfig(cfg.Failover); err != nil {
+			return err
+		}
+	}
+
+	if cfg.AntiEntropy != nil {
+		if cfg.AntiEntropy.IntervalSeconds < 0 {
+			return fmt.Errorf("antiEntropy: intervalSeconds cannot be negative, got %d", cfg.AntiEntropy.IntervalSeconds)
+		}
+		if cfg.AntiEntropy.MaxKeysPerSecond < 0 {
+			return fmt.Errorf("antiEntropy: maxKeysPerSecond cannot be negative, got %d", cfg.AntiEntropy.MaxKeysPerSecond)
+		}
+		if cfg.AntiEntropy.TreeDepth < 0 || cfg.AntiEntropy.TreeDepth > 16 {
+			return fmt.Errorf("antiEntropy: treeDepth must be between 0 and 16, got %d", cfg.AntiEntropy.TreeDepth)
+		}
+	}
+
+	if cfg.HintedHandoff != nil {
+		if cfg.HintedHandoff.MaxHints < 0 {
+			return fmt.Errorf("hintedHandoff: maxHints cannot be negative, got %d", cfg.HintedHandoff.MaxHints)
+		}
+		if cfg.HintedHandoff.HintTTLSeconds < 0 {
+			return fmt.Errorf("hintedHandoff: hintTTLSeconds cannot be negative, got %d", cfg.HintedHandoff.HintTTLSeconds)
+		}
+		if cfg.HintedHandoff.ReplayIntervalSeconds < 0 {
+			return fmt.Errorf("hintedHandoff: replayIntervalSeconds cannot be negative, got %d", cfg.HintedHandoff.ReplayIntervalSeconds)
+		}
+	}
+
+	if cfg.CircuitBreaker != nil {
+		if err := ValidateCircuitBreakerConfig(cfg.CircuitBreaker); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Retry != nil {
+		if err := ValidateRetryConfig(cfg.Retry); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Hedging != nil {
+		if cfg.Hedging.LatencyPercentile < 0 || cfg.Hedging.LatencyPercentile >= 1 {
+			return fmt.Errorf("hedging: latencyPercentile must be in [0, 1), got %g", cfg.Hedging.LatencyPercentile)
+		}
+		if cfg.Hedging.MinDelayMs < 0 {
+			return fmt.Errorf("hedging: minDelayMs cannot be negative, got %d", cfg.Hedging.MinDelayMs)
+		}
+	}
+
+	if cfg.HotKeys != nil {
+		if cfg.HotKeys.TopK < 0 {
+			return fmt.Errorf("hotKeys: topK cannot be negative, got %d", cfg.HotKeys.TopK)
+		}
+		if cfg.HotKeys.MinRequestsPerSecond < 0 {
+			return fmt.Errorf("hotKeys: minRequestsPerSecond cannot be negative, got %g", cfg.HotKeys.MinRequestsPerSecond)
+		}
+		if cfg.HotKeys.MaxStalenessMs < 0 {
+			return fmt.Errorf("hotKeys: maxStalenessMs cannot be negative, got %d", cfg.HotKeys.MaxStalenessMs)
+		}
+		if cfg.HotKeys.MaxValueBytes < 0 {
+			return fmt.Errorf("hotKeys: maxValueBytes cannot be negative, got %d", cfg.HotKeys.MaxValueBytes)
+		}
+	}
+
+	if cfg.LegacyKeys != nil {
+		if cfg.LegacyKeys.MaxKeysPerSecond < 0 {
+			return fmt.Errorf("legacyKeys: maxKeysPerSecond cannot be negative, got %d", cfg.LegacyKeys.MaxKeysPerSecond)
+		}
+		if cfg.LegacyKeys.BatchSize < 0 {
+			return fmt.Errorf("legacyKeys: batchSize cannot be negative, got %d", cfg.LegacyKeys.BatchSize)
+		}
+	}
+
+	return nil
+}
+
+// ValidateFailoverConfig validates the follower failover settings.
+//
+// Validation rules:
+//   - Every primary must have at least one follower
+//   - A node cannot follow itself
+//   - Check interval and failure threshold must be non-negative
+//
+// Parameters:
+//   - cfg: Failover configuration to validate
+//
+// Returns:
+//   - error: Validation error if any rule is violated, nil if valid
+func ValidateFailoverConfig(cfg *FailoverConfig) error {
+	for primary, followers := range cfg.Followers {
+		if primary == "" {
+			return fmt.Errorf("failover: primary address cannot be empty")
+		}
+		if len(followers) == 0 {
+			return fmt.Errorf("failover: primary '%s' has no followers", primary)
+		}
+		for _, follower := range followers {
+			if follower == "" || follower == primary {
+				return fmt.Errorf("failover: invalid follower '%s' for primary '%s'", follower, primary)
+			}
+		}
+	}
+
+	if cfg.CheckIntervalSeconds < 0 {
+		return fmt.Errorf("failover: checkIntervalSeconds cannot be negative, got %d", cfg.CheckIntervalSeconds)
+	}
+	if cfg.FailureThreshold < 0 {
+		return fmt.Errorf("failover: failureThreshold cannot be negative, got %d", cfg.FailureThreshold)
+	}
+
+	return nil
+}
+
+// retryableCodeNames are the gRPC status codes that may be retried.
+var retryableCodeNames = map[string]bool{
+	"UNKNOWN":            true,
+	"DEADLINE_EXCEEDED":  true,
+	"RESOURCE_EXHAUSTED": true,
+	"ABORTED":            true,
+	"INTERNAL":           true,
+	"UNAVAILABLE":        true,
+}
+
+// ValidateRetryConfig validates the retry policy.
+//
+// Validation rules:
+//   - Attempts, backoffs and budget must be non-negative
+//   - Max backoff must not be below the initial backoff
+//   - Budget percent must be at most 100
+//   - Retryable codes must be transient gRPC status codes, e.g. "UNAVAILABLE"
+//
+// Parameters:
+//   - cfg: Retry configuration to validate
+//
+// Returns:
+//   - error: Validation error if any rule is violated, nil if valid
+func ValidateRetryConfig(cfg *RetryConfig) error {
+	if cfg.MaxAttempts < 0 {
+		return fmt.Errorf("retry: maxAttempts cannot be negative, got %d", cfg.MaxAttempts)
+	}
+	if cfg.InitialBackoffMs < 0 || cfg.MaxBackoffMs < 0 {
+		return fmt.Errorf("retry: backoffs cannot be negative")
+	}
+	if cfg.MaxBackoffMs > 0 && cfg.MaxBackoffMs < cfg.InitialBackoffMs {
+		return fmt.Errorf("retry: maxBackoffMs (%d) cannot be below initialBackoffMs (%d)", cfg.MaxBackoffMs, cfg.InitialBackoffMs)
+	}
+	if cfg.BudgetPercent < 0 || cfg.BudgetPercent > 100 {
+		return fmt.Errorf("retry: budgetPercent must be between 0 and 100, got %d", cfg.BudgetPercent)
+	}
+	if cfg.MinRetriesPerSecond < 0 {
+		return fmt.Errorf("retry: minRetriesPerSecond cannot be negative, got %d", cfg.MinRetriesPerSecond)
+	}
+	for _, code := range cfg.RetryableCodes {
+		if !retryableCodeNames[code] {
+			return fmt.Errorf("retry: status code '%s' cannot be retried", code)
+		}
+	}
+
+	return nil
+}
+
+// ValidateCircuitBreakerConfig validates the per-node circuit breaker settings.
+//
+// Validation rules:
+//   - Mode must be empty, "skip" or "failfast"
+//   - Thresholds and durations must be non-negative
+//   - Latency percentile must be in [0, 1)
+//   - Max ejection percent must be between 0 and 100
+//
+// Parameters:
+//   - cfg: Circuit breaker configuration to validate
+//
+// Returns:
+//   - error: Validation error if any rule is violated, nil if valid
+func ValidateCircuitBreakerConfig(cfg *CircuitBreakerConfig) error {
+	switch cfg.Mode {
+	case "", "skip", "failfast":
+	default:
+		return fmt.Errorf("circuitBreaker: unknown mode '%s' (must be 'skip' or 'failfast')", cfg.Mode)
+	}
+
+	if cfg.ConsecutiveErrors < 0 {
+		return fmt.Errorf("circuitBreaker: consecutiveErrors cannot be negative, got %d", cfg.ConsecutiveErrors)
+	}
+	if cfg.LatencyThresholdMs < 0 {
+		return fmt.Errorf("circuitBreaker: latencyThresholdMs cannot be negative, got %d", cfg.LatencyThresholdMs)
+	}
+	if cfg.LatencyPercentile < 0 || cfg.LatencyPercentile >= 1 {
+		return fmt.Errorf("circuitBreaker: latencyPercentile must be in [0, 1), got %g", cfg.LatencyPercentile)
+	}
+	if cfg.BaseEjectionSeconds < 0 {
+		return fmt.Errorf("circuitBreaker: baseEjectionSeconds cannot be negative, got %d", cfg.BaseEjectionSeconds)
+	}
+	if cfg.MaxEjectionSeconds < 0 {
+		return fmt.Errorf("circuitBreaker: maxEjectionSeconds cannot be negative, got %d", cfg.MaxEjectionSeconds)
+	}
+	if cfg.HalfOpenSuccesses < 0 {
+		return fmt.Errorf("circuitBreaker: halfOpenSuccesses cannot be negative, got %d", cfg.HalfOpenSuccesses)
+	}
+	if cfg.MaxEjectionPercent < 0 || cfg.MaxEjectionPercent > 100 {
+		return fmt.Errorf("circuitBreaker: maxEjectionPercent must be between 0 and 100, got %d", cfg.MaxEjectionPercent)
+	}
+
+	return nil
+}
+
+// ValidatePlacementConfig validates the key placement settings.
+//
+// Validation rules:
+//   - Algorithm must be empty or a supported placement algorithm
+//   - Hash function must be empty or a supported hash function
+//   - Virtual nodes and table size must be non-negative if specified
+//   - Bounded-load epsilon must be non-negative and requires "ketama"
+//
+// Parameters:
+//   - cfg: The placement configuration to validate
+//
+// Returns:
+//   - error: nil if valid, error describing the validation failure otherwise
+func ValidatePlacementConfig(cfg *PlacementConfig) error {
+	switch cfg.Algorithm {
+	case "", hash.AlgorithmKetama, hash.AlgorithmJump, hash.AlgorithmRendezvous, hash.AlgorithmMaglev:
+	default:
+		return fmt.Errorf("placement: unsupported algorithm '%s'", cfg.Algorithm)
+	}
+
+	if _, err := hash.HashFuncByName(cfg.HashFunction); err != nil {
+		return fmt.Errorf("placement: %w", err)
+	}
+
+	if cfg.VirtualNodes < 0 {
+		return fmt.Errorf("placement: virtualNodes cannot be negative, got %d", cfg.VirtualNodes)
+	}
+
+	if cfg.TableSize < 0 {
+		return fmt.Errorf("placement: tableSize cannot be negative, got %d", cfg.TableSize)
+	}
+
+	if cfg.BoundedLoadEpsilon < 0 {
+		return fmt.Errorf("placement: boundedLoadEpsilon cannot be negative, got %g", cfg.BoundedLoadEpsilon)
+	}
+
+	if cfg.BoundedLoadEpsilon > 0 && cfg.Algorithm != "" && cfg.Algorithm != hash.AlgorithmKetama {
+		return fmt.Errorf("placement: boundedLoadEpsilon is only supported by the '%s' algorithm", hash.AlgorithmKetama)
+	}
+
+	if cfg.BoundedLoadCopyTTLSeconds < 0 {
+		return fmt.Errorf("placement: boundedLoadCopyTTLSeconds cannot be negative, got %d", cfg.BoundedLoadCopyTTLSeconds)
 	}
 
 	return nil
@@ -180,6 +482,10 @@ func ValidateNamespace(ns *Namespace) error {
 		return fmt.Errorf("namespace name cannot be empty")
 	}
 
+	if strings.ContainsFunc(ns.Name, unicode.IsControl) {
+		return fmt.Errorf("namespace name %q cannot contain control characters", ns.Name)
+	}
+
 	if ns.APIKey == "" {
 		return fmt.Errorf("namespace '%s': API key cannot be empty", ns.Name)
 	}
@@ -196,5 +502,45 @@ func ValidateNamespace(ns *Namespace) error {
 		return fmt.Errorf("namespace '%s': rateLimitQPS cannot be negative, got %d", ns.Name, ns.RateLimitQPS)
 	}
 
+	if ns.MaxKeyBytes < 0 {
+		return fmt.Errorf("namespace '%s': maxKeyBytes cannot be negative, got %d", ns.Name, ns.MaxKeyBytes)
+	}
+
+	if ns.MaxValueBytes < 0 {
+		return fmt.Errorf("namespace '%s': maxValueBytes cannot be negative, got %d", ns.Name, ns.MaxValueBytes)
+	}
+
+	if err := validateReplication(ns); err != nil {
+		return fmt.Errorf("namespace '%s': %w", ns.Name, err)
+	}
+
+	return nil
+}
+
+// validateReplication checks the replication factor and quorum settings
+// of a namespace.
+func validateReplication(ns *Namespace) error {
+	if ns.ReplicationFactor < 0 {
+		return fmt.Errorf("replicationFactor cannot be negative, got %d", ns.ReplicationFactor)
+	}
+	if ns.WriteQuorum < 0 {
+		return fmt.Errorf("writeQuorum cannot be negative, got %d", ns.WriteQuorum)
+	}
+	if ns.ReadQuorum < 0 {
+		return fmt.Errorf("readQuorum cannot be negative, got %d", ns.ReadQuorum)
+	}
+
+	n := max(ns.ReplicationFactor, 1)
+	if ns.WriteQuorum > n {
+		return fmt.Errorf("writeQuorum (%d) cannot exceed replicationFactor (%d)", ns.WriteQuorum, n)
+	}
+	if ns.ReadQuorum > n {
+		return fmt.Errorf("readQuorum (%d) cannot exceed replicationFactor (%d)", ns.ReadQuorum, n)
+	}
+
+	// Reads must overlap with the latest successful write
+	if n, w, r := ns.Replication(); n > 1 && w+r <= n {
+		return fmt.Errorf("writeQuorum (%d) + readQuorum (%d) must exceed replicationFactor (%d)", w, r, n)
+	}
 	return nil
 }
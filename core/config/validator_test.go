@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateReplication(t *testing.T) {
+	tests := []struct {
+		name    string
+		ns      Namespace
+		wantErr string
+	}{
+		{name: "not replicated", ns: Namespace{}},
+		{name: "majority defaults", ns: Namespace{ReplicationFactor: 3}},
+		{name: "write all, read one", ns: Namespace{ReplicationFactor: 3, WriteQuorum: 3, ReadQuorum: 1}},
+		{name: "even factor defaults", ns: Namespace{ReplicationFactor: 4}},
+		{
+			name:    "quorums do not overlap",
+			ns:      Namespace{ReplicationFactor: 3, WriteQuorum: 1, ReadQuorum: 1},
+			wantErr: "must exceed replicationFactor",
+		},
+		{
+			name:    "explicit write quorum with default read quorum",
+			ns:      Namespace{ReplicationFactor: 3, WriteQuorum: 1},
+			wantErr: "must exceed replicationFactor",
+		},
+		{
+			name:    "read quorum above factor",
+			ns:      Namespace{ReplicationFactor: 2, ReadQuorum: 3},
+			wantErr: "cannot exceed replicationFactor",
+		},
+		{
+			name:    "negative write quorum",
+			ns:      Namespace{ReplicationFactor: 3, WriteQuorum: -1},
+			wantErr: "cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReplication(&tt.ns)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateReplication(%+v) = %v, want nil", tt.ns, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateReplication(%+v) = %v, want error containing %q", tt.ns, err, tt.wantErr)
+			}
+		})
+	}
+}
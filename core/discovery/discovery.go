@@ -1,21 +1,25 @@
 // Package discovery implements Kubernetes-native service discovery using
-// Endpoints API for real-time cluster node detection.
+// the EndpointSlice API for real-time cluster node detection.
 //
 // This package provides efficient service discovery for Yao-Oracle cluster
-// nodes without relying on DNS lookups. It uses Kubernetes Endpoints API
-// to discover service instances in real-time.
+// nodes without relying on DNS lookups. It uses the Kubernetes EndpointSlice
+// API (or the legacy Endpoints API) to discover service instances in real-time.
 //
 // Key features:
 //   - Direct Kubernetes API access (no DNS caching issues)
 //   - Real-time endpoint updates via Informer
 //   - Support for headless services
 //   - Automatic handling of pod additions/removals
+//   - Draining of terminating pods (EndpointSliceDiscovery)
+//   - Pod names as stable node identities
+//   - Per-node weights from a pod annotation or label
 //
 // Example usage:
 //
-//	disco, err := discovery.NewK8sServiceDiscovery(discovery.Config{
+//	disco, err := discovery.NewEndpointSliceDiscovery(discovery.Config{
 //	    Namespace:   "yao-system",
 //	    ServiceName: "yao-oracle-node",
+//	    PortName:    "grpc",
 //	})
 //	if err != nil {
 //	    log.Fatal(err)
@@ -31,13 +35,16 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
@@ -70,8 +77,64 @@ type ServiceDiscovery interface {
 	GetEndpoints() []string
 }
 
+// DefaultWeight is the weight reported for endpoints without a weight
+// annotation or label.
+const DefaultWeight = 1
+
+// DefaultWeightKey is the pod annotation (or label) holding a node's weight.
+//
+// Example:
+//
+//	metadata:
+//	  annotations:
+//	    yao-oracle.io/weight: "4"
+const DefaultWeightKey = "yao-oracle.io/weight"
+
+// Endpoint describes a discovered service instance with its metadata.
+type Endpoint struct {
+	// Address is the endpoint in "IP:PORT" format
+	Address string
+
+	// Weight is the relative capacity of the instance (DefaultWeight if unset)
+	Weight int
+
+	// Name is the stable identity of the instance, such as the pod name of a
+	// StatefulSet member. It survives restarts that change Address; empty if
+	// the backend cannot tell.
+	Name string
+
+	// Zone is the failure domain of the instance; empty if unknown
+	Zone string
+
+	// Draining marks an instance that is shutting down but still serving.
+	// It should receive no new traffic, but existing connections stay usable
+	// so that its data can be moved elsewhere. GetEndpoints omits draining
+	// instances.
+	Draining bool
+}
+
+// DetailedServiceDiscovery is implemented by discovery backends that can
+// report endpoint metadata in addition to plain addresses.
+//
+// Consumers should type-assert a ServiceDiscovery to this interface and
+// fall back to GetEndpoints with DefaultWeight when it is not implemented.
+type DetailedServiceDiscovery interface {
+	ServiceDiscovery
+
+	// GetEndpointDetails returns the current endpoints with their metadata
+	//
+	// Returns:
+	//   - []Endpoint: Endpoints in the same order as GetEndpoints, followed by
+	//     draining endpoints if the backend reports them
+	GetEndpointDetails() []Endpoint
+}
+
 // K8sServiceDiscovery implements service discovery using Kubernetes Endpoints API.
 //
+// The Endpoints API is deprecated in favor of EndpointSlices; new code should
+// use EndpointSliceDiscovery, which also selects ports by name and drains
+// terminating pods.
+//
 // This implementation uses Kubernetes SharedInformer to watch Endpoints resources.
 // It maintains a cache of current endpoints and notifies listeners when changes occur.
 //
@@ -89,8 +152,15 @@ type K8sServiceDiscovery struct {
 	// endpoints holds the current list of service endpoints
 	endpoints []string
 
+	// details holds the current endpoints with their metadata
+	details []Endpoint
+
+	// lastEndpoints is the most recent Endpoints object, kept so that pod
+	// weight changes can be re-applied without waiting for an Endpoints event
+	lastEndpoints *corev1.Endpoints
+
 	// clientset is the Kubernetes client
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 
 	// namespace is the Kubernetes namespace
 	namespace string
@@ -98,6 +168,15 @@ type K8sServiceDiscovery struct {
 	// serviceName is the name of the Service to discover
 	serviceName string
 
+	// port overrides the endpoint port when > 0
+	port int
+
+	// weightKey is the pod annotation or label holding the node weight
+	weightKey string
+
+	// podLister reads pod metadata from the informer cache once started
+	podLister corelisters.PodLister
+
 	// factory is the SharedInformerFactory
 	factory informers.SharedInformerFactory
 
@@ -117,13 +196,23 @@ type Config struct {
 	// This should typically be a headless service for StatefulSets
 	ServiceName string
 
-	// Port is the service port number (optional)
+	// Port is the port cache nodes listen on (optional)
 	// If not specified, the first port from endpoints will be used
 	Port int
 
+	// PortName selects the endpoint port by name (EndpointSliceDiscovery only)
+	// It takes precedence over Port; endpoints without the named port use
+	// Port, or are skipped if Port is not set
+	PortName string
+
 	// KubeconfigPath is the path to kubeconfig file (for out-of-cluster use)
 	// Leave empty to use in-cluster config
 	KubeconfigPath string
+
+	// WeightKey is the pod annotation or label holding the node weight
+	// The annotation takes precedence over a label with the same key
+	// Default: DefaultWeightKey
+	WeightKey string
 }
 
 // NewK8sServiceDiscovery creates a new Kubernetes service discovery instance.
@@ -135,6 +224,7 @@ type Config struct {
 //   - Service must have appropriate RBAC permissions to list/watch Endpoints
 //   - ServiceAccount must be attached to the Pod
 //   - Role/RoleBinding must grant "get", "list", "watch" permissions on Endpoints
+//     and Pods (Pods are read for weight annotations and labels)
 //
 // Parameters:
 //   - cfg: Service discovery configuration
@@ -154,36 +244,49 @@ type Config struct {
 //	    log.Fatal("Failed to create discovery:", err)
 //	}
 func NewK8sServiceDiscovery(cfg Config) (*K8sServiceDiscovery, error) {
-	// Create Kubernetes client
-	var config *rest.Config
-	var err error
-
-	if cfg.KubeconfigPath != "" {
-		// Use kubeconfig file (for local development)
-		config, err = clientcmd.BuildConfigFromFlags("", cfg.KubeconfigPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load kubeconfig from %s: %w", cfg.KubeconfigPath, err)
-		}
-	} else {
-		// Use in-cluster config (for production)
-		config, err = rest.InClusterConfig()
-		if err != nil {
-			return nil, fmt.Errorf("failed to load in-cluster config: %w", err)
-		}
+	clientset, err := newClientset(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+	return NewK8sServiceDiscoveryWithClient(clientset, cfg), nil
+}
+
+// NewK8sServiceDiscoveryWithClient creates a Kubernetes service discovery
+// instance that uses an existing client. cfg.KubeconfigPath is ignored.
+//
+// This is mainly useful for tests, which pass the fake clientset from
+// k8s.io/client-go/kubernetes/fake.
+//
+// Parameters:
+//   - clientset: Kubernetes client used to read Endpoints and Pods
+//   - cfg: Service discovery configuration
+//
+// Returns:
+//   - *K8sServiceDiscovery: A new discovery instance ready to start
+//
+// Example:
+//
+//	clientset := fake.NewSimpleClientset(endpoints)
+//	disco := discovery.NewK8sServiceDiscoveryWithClient(clientset, discovery.Config{
+//	    Namespace:   "yao-system",
+//	    ServiceName: "yao-oracle-node",
+//	})
+func NewK8sServiceDiscoveryWithClient(clientset kubernetes.Interface, cfg Config) *K8sServiceDiscovery {
+	weightKey := cfg.WeightKey
+	if weightKey == "" {
+		weightKey = DefaultWeightKey
 	}
 
 	return &K8sServiceDiscovery{
 		clientset:   clientset,
 		namespace:   cfg.Namespace,
 		serviceName: cfg.ServiceName,
+		port:        cfg.Port,
+		weightKey:   weightKey,
 		stopCh:      make(chan struct{}),
 		endpoints:   []string{},
-	}, nil
+	}
 }
 
 // Start begins watching for service endpoint changes.
@@ -230,6 +333,8 @@ func (d *K8sServiceDiscovery) Start(ctx context.Context, onChange func(endpoints
 			if ep.Name == d.serviceName {
 				d.mu.Lock()
 				d.endpoints = []string{}
+				d.details = nil
+				d.lastEndpoints = nil
 				d.mu.Unlock()
 
 				if d.onChange != nil {
@@ -239,6 +344,26 @@ func (d *K8sServiceDiscovery) Start(ctx context.Context, onChange func(endpoints
 		},
 	})
 
+	// Watch Pods so that weight changes are applied without a restart
+	podInformer := d.factory.Core().V1().Pods()
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod := oldObj.(*corev1.Pod)
+			newPod := newObj.(*corev1.Pod)
+			if podWeight(oldPod, d.weightKey) == podWeight(newPod, d.weightKey) {
+				return
+			}
+
+			d.mu.RLock()
+			ep := d.lastEndpoints
+			d.mu.RUnlock()
+
+			if ep != nil {
+				d.handleEndpointsUpdate(ep)
+			}
+		},
+	})
+
 	// Start informers
 	d.factory.Start(d.stopCh)
 
@@ -250,6 +375,10 @@ func (d *K8sServiceDiscovery) Start(ctx context.Context, onChange func(endpoints
 		}
 	}
 
+	d.mu.Lock()
+	d.podLister = podInformer.Lister()
+	d.mu.Unlock()
+
 	return nil
 }
 
@@ -274,9 +403,27 @@ func (d *K8sServiceDiscovery) GetEndpoints() []string {
 	return result
 }
 
+// GetEndpointDetails returns the current endpoints with their weights.
+//
+// Thread-safe: Safe for concurrent calls.
+func (d *K8sServiceDiscovery) GetEndpointDetails() []Endpoint {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]Endpoint, len(d.details))
+	copy(result, d.details)
+	return result
+}
+
 // loadInitialEndpoints loads the initial list of endpoints.
+//
+// A missing Endpoints object is not an error: the Service may not have been
+// created yet, and the informer reports it once it is.
 func (d *K8sServiceDiscovery) loadInitialEndpoints(ctx context.Context) error {
 	ep, err := d.clientset.CoreV1().Endpoints(d.namespace).Get(ctx, d.serviceName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get endpoints: %w", err)
 	}
@@ -289,27 +436,39 @@ func (d *K8sServiceDiscovery) loadInitialEndpoints(ctx context.Context) error {
 func (d *K8sServiceDiscovery) handleEndpointsUpdate(ep *corev1.Endpoints) {
 	// Extract IP addresses from endpoints
 	var newEndpoints []string
+	var newDetails []Endpoint
 
 	for _, subset := range ep.Subsets {
 		// Get port
-		port := 0
-		if len(subset.Ports) > 0 {
+		port := d.port
+		if port == 0 && len(subset.Ports) > 0 {
 			port = int(subset.Ports[0].Port)
 		}
 
 		// Get addresses
 		for _, addr := range subset.Addresses {
+			address := addr.IP
 			if port > 0 {
-				newEndpoints = append(newEndpoints, fmt.Sprintf("%s:%d", addr.IP, port))
-			} else {
-				newEndpoints = append(newEndpoints, addr.IP)
+				address = fmt.Sprintf("%s:%d", addr.IP, port)
 			}
+
+			newEndpoints = append(newEndpoints, address)
+			endpoint := Endpoint{
+				Address: address,
+				Weight:  d.targetWeight(addr.TargetRef),
+			}
+			if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" {
+				endpoint.Name = addr.TargetRef.Name
+			}
+			newDetails = append(newDetails, endpoint)
 		}
 	}
 
 	// Update endpoints atomically
 	d.mu.Lock()
 	d.endpoints = newEndpoints
+	d.details = newDetails
+	d.lastEndpoints = ep
 	d.mu.Unlock()
 
 	// Call onChange callback
@@ -317,3 +476,92 @@ func (d *K8sServiceDiscovery) handleEndpointsUpdate(ep *corev1.Endpoints) {
 		d.onChange(newEndpoints)
 	}
 }
+
+// targetWeight returns the weight of the pod backing an endpoint address.
+func (d *K8sServiceDiscovery) targetWeight(ref *corev1.ObjectReference) int {
+	d.mu.RLock()
+	lister := d.podLister
+	d.mu.RUnlock()
+
+	return targetWeight(d.clientset, lister, d.namespace, d.weightKey, ref)
+}
+
+// targetWeight returns the weight of the pod an endpoint refers to.
+//
+// Pods are read from the informer cache once it is synced (lister is set);
+// before that (during the initial load) they are fetched from the API server
+// directly. Endpoints that are not backed by a pod, or whose pod cannot be
+// read, get DefaultWeight.
+func targetWeight(clientset kubernetes.Interface, lister corelisters.PodLister, defaultNamespace, weightKey string, ref *corev1.ObjectReference) int {
+	if ref == nil || ref.Kind != "Pod" {
+		return DefaultWeight
+	}
+
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	var pod *corev1.Pod
+	var err error
+	if lister != nil {
+		pod, err = lister.Pods(namespace).Get(ref.Name)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		pod, err = clientset.CoreV1().Pods(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		cancel()
+	}
+	if err != nil {
+		return DefaultWeight
+	}
+
+	return podWeight(pod, weightKey)
+}
+
+// podWeight parses the weight from a pod's annotation or label.
+//
+// Returns DefaultWeight if neither is set or the value is not a positive integer.
+func podWeight(pod *corev1.Pod, weightKey string) int {
+	value, ok := pod.Annotations[weightKey]
+	if !ok {
+		value, ok = pod.Labels[weightKey]
+	}
+	if !ok {
+		return DefaultWeight
+	}
+
+	weight, err := strconv.Atoi(value)
+	if err != nil || weight <= 0 {
+		return DefaultWeight
+	}
+	return weight
+}
+
+// newClientset creates a Kubernetes client from cfg.KubeconfigPath, or from
+// the in-cluster config when it is empty.
+func newClientset(cfg Config) (kubernetes.Interface, error) {
+	// Create Kubernetes client
+	var config *rest.Config
+	var err error
+
+	if cfg.KubeconfigPath != "" {
+		// Use kubeconfig file (for local development)
+		config, err = clientcmd.BuildConfigFromFlags("", cfg.KubeconfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig from %s: %w", cfg.KubeconfigPath, err)
+		}
+	} else {
+		// Use in-cluster config (for production)
+		config, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load in-cluster config: %w", err)
+		}
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+	}
+
+	return clientset, nil
+}
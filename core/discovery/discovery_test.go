@@ -0,0 +1,174 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testNamespace = "yao-system"
+	testService   = "yao-oracle-node"
+)
+
+// testEndpoints returns the Endpoints of the test Service with one address
+// per pod name, numbered from 10.0.0.1.
+func testEndpoints(port int32, pods ...string) *corev1.Endpoints {
+	subset := corev1.EndpointSubset{
+		Ports: []corev1.EndpointPort{{Name: "grpc", Port: port}},
+	}
+	for i, pod := range pods {
+		subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{
+			IP:        "10.0.0." + string(rune('1'+i)),
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: pod, Namespace: testNamespace},
+		})
+	}
+	return &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: testService, Namespace: testNamespace},
+		Subsets:    []corev1.EndpointSubset{subset},
+	}
+}
+
+func testPod(name string, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Annotations: annotations},
+	}
+}
+
+// recorder collects the endpoint lists passed to onChange.
+type recorder chan []string
+
+func (r recorder) onChange(endpoints []string) {
+	r <- endpoints
+}
+
+// next returns the next reported endpoint list.
+func (r recorder) next(t *testing.T) []string {
+	t.Helper()
+
+	select {
+	case endpoints := <-r:
+		return endpoints
+	case <-time.After(5 * time.Second):
+		t.Fatal("no endpoint change reported")
+		return nil
+	}
+}
+
+// until returns the first reported endpoint list with n entries.
+func (r recorder) until(t *testing.T, n int) []string {
+	t.Helper()
+
+	for {
+		if endpoints := r.next(t); len(endpoints) == n {
+			return endpoints
+		}
+	}
+}
+
+func TestK8sServiceDiscoveryInitialEndpoints(t *testing.T) {
+	clientset := fake.NewClientset(
+		testEndpoints(7070, "node-0", "node-1"),
+		testPod("node-0", map[string]string{DefaultWeightKey: "3"}),
+		testPod("node-1", nil),
+	)
+	d := NewK8sServiceDiscoveryWithClient(clientset, Config{Namespace: testNamespace, ServiceName: testService})
+	defer d.Stop()
+
+	changes := make(recorder, 64)
+	if err := d.Start(context.Background(), changes.onChange); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	got := changes.next(t)
+	if len(got) != 2 || got[0] != "10.0.0.1:7070" || got[1] != "10.0.0.2:7070" {
+		t.Fatalf("initial endpoints = %v, want 10.0.0.1:7070 and 10.0.0.2:7070", got)
+	}
+
+	details := d.GetEndpointDetails()
+	if len(details) != 2 {
+		t.Fatalf("GetEndpointDetails returned %d endpoints, want 2", len(details))
+	}
+	if details[0].Name != "node-0" || details[0].Weight != 3 {
+		t.Errorf("first endpoint = %+v, want node-0 with weight 3", details[0])
+	}
+	if details[1].Name != "node-1" || details[1].Weight != DefaultWeight {
+		t.Errorf("second endpoint = %+v, want node-1 with the default weight", details[1])
+	}
+}
+
+func TestK8sServiceDiscoveryPortOverride(t *testing.T) {
+	clientset := fake.NewClientset(testEndpoints(7070, "node-0"))
+	d := NewK8sServiceDiscoveryWithClient(clientset, Config{Namespace: testNamespace, ServiceName: testService, Port: 8080})
+	defer d.Stop()
+
+	if err := d.Start(context.Background(), nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if got := d.GetEndpoints(); len(got) != 1 || got[0] != "10.0.0.1:8080" {
+		t.Errorf("endpoints = %v, want [10.0.0.1:8080]", got)
+	}
+}
+
+func TestK8sServiceDiscoveryFollowsChanges(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewClientset()
+	d := NewK8sServiceDiscoveryWithClient(clientset, Config{Namespace: testNamespace, ServiceName: testService})
+	defer d.Stop()
+
+	changes := make(recorder, 64)
+	if err := d.Start(ctx, changes.onChange); err != nil {
+		t.Fatalf("Start without Endpoints: %v", err)
+	}
+	if got := changes.next(t); len(got) != 0 {
+		t.Fatalf("initial endpoints = %v, want none before the Service exists", got)
+	}
+
+	endpoints := clientset.CoreV1().Endpoints(testNamespace)
+	if _, err := endpoints.Create(ctx, testEndpoints(7070, "node-0", "node-1"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create endpoints: %v", err)
+	}
+	changes.until(t, 2)
+
+	if _, err := endpoints.Update(ctx, testEndpoints(7070, "node-0"), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update endpoints: %v", err)
+	}
+	if got := changes.until(t, 1); got[0] != "10.0.0.1:7070" {
+		t.Errorf("endpoints after scale-down = %v, want [10.0.0.1:7070]", got)
+	}
+
+	if err := endpoints.Delete(ctx, testService, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete endpoints: %v", err)
+	}
+	changes.until(t, 0)
+}
+
+func TestK8sServiceDiscoveryReweightsOnPodChange(t *testing.T) {
+	ctx := context.Background()
+	pod := testPod("node-0", nil)
+	clientset := fake.NewClientset(testEndpoints(7070, "node-0"), pod)
+	d := NewK8sServiceDiscoveryWithClient(clientset, Config{Namespace: testNamespace, ServiceName: testService})
+	defer d.Stop()
+
+	changes := make(recorder, 64)
+	if err := d.Start(ctx, changes.onChange); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	updated := pod.DeepCopy()
+	updated.Annotations = map[string]string{DefaultWeightKey: "5"}
+	if _, err := clientset.CoreV1().Pods(testNamespace).Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update pod: %v", err)
+	}
+
+	for {
+		changes.next(t)
+		if details := d.GetEndpointDetails(); len(details) == 1 && details[0].Weight == 5 {
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dnsLookupTimeout bounds a single DNS lookup.
+const dnsLookupTimeout = 5 * time.Second
+
+// Resolver performs the DNS lookups of DNSDiscovery.
+//
+// *net.Resolver implements it; tests can substitute a fake.
+type Resolver interface {
+	// LookupHost returns the addresses of a host
+	LookupHost(ctx context.Context, host string) ([]string, error)
+
+	// LookupSRV returns the SRV records of _service._proto.name
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// DNSConfig holds configuration for DNS discovery.
+type DNSConfig struct {
+	// Name is the DNS name to resolve, e.g. a headless Service
+	// ("yao-oracle-node.yao-system.svc.cluster.local")
+	Name string
+
+	// Port is the node port used with A/AAAA records
+	// Required unless Service is set
+	Port int
+
+	// Service selects SRV lookups of _Service._Protocol.Name; the records
+	// provide ports and weights. Leave empty for A/AAAA lookups
+	Service string
+
+	// Protocol is the SRV protocol
+	// Default: "tcp"
+	Protocol string
+
+	// Interval is the time between lookups
+	// Default: DefaultPollInterval
+	Interval time.Duration
+
+	// Resolver performs the lookups
+	// Default: net.DefaultResolver
+	Resolver Resolver
+}
+
+// DNSDiscovery implements service discovery by polling DNS.
+//
+// With A/AAAA lookups every address of Name becomes a node on Port, with
+// DefaultWeight. With SRV lookups every record becomes a node at its target
+// and port; the record weight is used as node weight (DefaultWeight when 0)
+// and the target host name as node name. Record priorities are ignored.
+//
+// A name that does not exist yields no endpoints rather than an error, as
+// for a headless Service without ready pods.
+//
+// Thread-safety: All methods are safe for concurrent use.
+type DNSDiscovery struct {
+	*poller
+
+	cfg DNSConfig
+}
+
+// NewDNSDiscovery creates a DNS discovery instance.
+//
+// Parameters:
+//   - cfg: DNS discovery configuration
+//
+// Returns:
+//   - *DNSDiscovery: A new discovery instance ready to start
+//   - error: Error if the configuration is incomplete
+//
+// Example:
+//
+//	disco, err := discovery.NewDNSDiscovery(discovery.DNSConfig{
+//	    Name:     "cache.staging.internal",
+//	    Service:  "grpc",
+//	    Interval: 10 * time.Second,
+//	})
+//	if err != nil {
+//	    log.Fatal("Failed to create discovery:", err)
+//	}
+func NewDNSDiscovery(cfg DNSConfig) (*DNSDiscovery, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("DNS name is required")
+	}
+	if cfg.Service == "" && (cfg.Port <= 0 || cfg.Port > 65535) {
+		return nil, fmt.Errorf("port must be between 1 and 65535 for A record lookups, got %d", cfg.Port)
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = "tcp"
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = net.DefaultResolver
+	}
+
+	d := &DNSDiscovery{cfg: cfg}
+	d.poller = newPoller("dns-discovery", cfg.Interval, d.load)
+	return d, nil
+}
+
+// load resolves the configured name.
+func (d *DNSDiscovery) load(ctx context.Context) ([]Endpoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+
+	var endpoints []Endpoint
+	if d.cfg.Service != "" {
+		_, records, err := d.cfg.Resolver.LookupSRV(ctx, d.cfg.Service, d.cfg.Protocol, d.cfg.Name)
+		if err != nil {
+			return notFoundAsEmpty(fmt.Errorf("SRV lookup of _%s._%s.%s failed: %w", d.cfg.Service, d.cfg.Protocol, d.cfg.Name, err))
+		}
+
+		for _, srv := range records {
+			target := strings.TrimSuffix(srv.Target, ".")
+			weight := int(srv.Weight)
+			if weight == 0 {
+				weight = DefaultWeight
+			}
+			endpoints = append(endpoints, Endpoint{
+				Address: net.JoinHostPort(target, strconv.Itoa(int(srv.Port))),
+				Weight:  weight,
+				Name:    target,
+			})
+		}
+	} else {
+		addrs, err := d.cfg.Resolver.LookupHost(ctx, d.cfg.Name)
+		if err != nil {
+			return notFoundAsEmpty(fmt.Errorf("lookup of %s failed: %w", d.cfg.Name, err))
+		}
+
+		for _, addr := range addrs {
+			endpoints = append(endpoints, Endpoint{
+				Address: net.JoinHostPort(addr, strconv.Itoa(d.cfg.Port)),
+				Weight:  DefaultWeight,
+			})
+		}
+	}
+
+	// Resolvers rotate record order; sort so that only real changes notify
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Address < endpoints[j].Address })
+	return endpoints, nil
+}
+
+// notFoundAsEmpty turns a lookup error for a nonexistent name into an
+// empty result.
+func notFoundAsEmpty(err error) ([]Endpoint, error) {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return []Endpoint{}, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,158 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResolver serves lookups from in-memory records.
+type fakeResolver struct {
+	mu    sync.Mutex
+	hosts map[string][]string
+	srv   map[string][]*net.SRV
+	err   error
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	cname := "_" + service + "._" + proto + "." + name
+	records, ok := r.srv[cname]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: cname, IsNotFound: true}
+	}
+	return cname, records, nil
+}
+
+func (r *fakeResolver) setHosts(host string, addrs ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hosts[host] = addrs
+}
+
+func (r *fakeResolver) setErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func TestDNSDiscoveryARecords(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string][]string{
+		"cache.staging.internal": {"10.0.0.2", "10.0.0.1"},
+	}}
+	d, err := NewDNSDiscovery(DNSConfig{Name: "cache.staging.internal", Port: 8080, Resolver: resolver})
+	if err != nil {
+		t.Fatalf("NewDNSDiscovery: %v", err)
+	}
+	defer d.Stop()
+
+	if err := d.Start(context.Background(), nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	want := []Endpoint{
+		{Address: "10.0.0.1:8080", Weight: DefaultWeight},
+		{Address: "10.0.0.2:8080", Weight: DefaultWeight},
+	}
+	if got := d.GetEndpointDetails(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEndpointDetails = %+v, want %+v", got, want)
+	}
+}
+
+func TestDNSDiscoverySRVRecords(t *testing.T) {
+	resolver := &fakeResolver{srv: map[string][]*net.SRV{
+		"_grpc._tcp.cache.staging.internal": {
+			{Target: "cache-1.staging.internal.", Port: 7070, Weight: 0},
+			{Target: "cache-0.staging.internal.", Port: 7070, Weight: 3},
+		},
+	}}
+	d, err := NewDNSDiscovery(DNSConfig{Name: "cache.staging.internal", Service: "grpc", Resolver: resolver})
+	if err != nil {
+		t.Fatalf("NewDNSDiscovery: %v", err)
+	}
+	defer d.Stop()
+
+	if err := d.Start(context.Background(), nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	want := []Endpoint{
+		{Address: "cache-0.staging.internal:7070", Weight: 3, Name: "cache-0.staging.internal"},
+		{Address: "cache-1.staging.internal:7070", Weight: DefaultWeight, Name: "cache-1.staging.internal"},
+	}
+	if got := d.GetEndpointDetails(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEndpointDetails = %+v, want %+v", got, want)
+	}
+}
+
+func TestDNSDiscoveryPolls(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string][]string{}}
+	d, err := NewDNSDiscovery(DNSConfig{
+		Name:     "cache.staging.internal",
+		Port:     8080,
+		Interval: 10 * time.Millisecond,
+		Resolver: resolver,
+	})
+	if err != nil {
+		t.Fatalf("NewDNSDiscovery: %v", err)
+	}
+	defer d.Stop()
+
+	// A name that does not exist yet has no endpoints
+	changes := make(recorder, 64)
+	if err := d.Start(context.Background(), changes.onChange); err != nil {
+		t.Fatalf("Start with unknown name: %v", err)
+	}
+	if got := changes.next(t); len(got) != 0 {
+		t.Fatalf("initial endpoints = %v, want none", got)
+	}
+
+	resolver.setHosts("cache.staging.internal", "10.0.0.1", "10.0.0.2")
+	changes.until(t, 2)
+
+	// Lookup failures keep the previous endpoints
+	resolver.setErr(errors.New("server misbehaving"))
+	time.Sleep(50 * time.Millisecond)
+	if got := d.GetEndpoints(); len(got) != 2 {
+		t.Errorf("endpoints during lookup failures = %v, want the previous two", got)
+	}
+
+	resolver.setErr(nil)
+	resolver.setHosts("cache.staging.internal", "10.0.0.2")
+	if got := changes.until(t, 1); got[0] != "10.0.0.2:8080" {
+		t.Errorf("endpoints after scale-down = %v, want [10.0.0.2:8080]", got)
+	}
+}
+
+func TestNewDNSDiscoveryValidates(t *testing.T) {
+	if _, err := NewDNSDiscovery(DNSConfig{Port: 8080}); err == nil {
+		t.Error("NewDNSDiscovery accepted an empty name")
+	}
+	if _, err := NewDNSDiscovery(DNSConfig{Name: "cache.staging.internal"}); err == nil {
+		t.Error("NewDNSDiscovery accepted A lookups without a port")
+	}
+	if _, err := NewDNSDiscovery(DNSConfig{Name: "cache.staging.internal", Service: "grpc"}); err != nil {
+		t.Errorf("NewDNSDiscovery rejected SRV lookups without a port: %v", err)
+	}
+}
@@ -0,0 +1,428 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EndpointSliceDiscovery implements service discovery using the Kubernetes
+// EndpointSlice API.
+//
+// A Service's endpoints may be split across several EndpointSlices; all
+// slices labeled with the Service name are merged. Each endpoint is
+// classified by its conditions:
+//   - serving and not terminating: the instance receives traffic
+//   - serving and terminating: the instance is draining; it is reported
+//     with Draining set and omitted from GetEndpoints
+//   - not serving (not yet ready, or terminated): the instance is left out
+//
+// Serving rather than ready is used because ready is always true for
+// Services that publish not-ready addresses.
+//
+// Endpoints carry the name of their pod as a stable identity. The onChange
+// callback runs only when the endpoints or their metadata actually change.
+//
+// Thread-safety: All methods are safe for concurrent use.
+type EndpointSliceDiscovery struct {
+	// mu protects slices, endpoints, details and podLister
+	mu sync.RWMutex
+
+	// updateMu serializes rebuilds so that onChange sees updates in order
+	updateMu sync.Mutex
+
+	// slices holds the Service's EndpointSlices by name
+	slices map[string]*discoveryv1.EndpointSlice
+
+	// endpoints holds the addresses of the serving endpoints
+	endpoints []string
+
+	// details holds the serving endpoints followed by the draining ones
+	details []Endpoint
+
+	// clientset is the Kubernetes client
+	clientset kubernetes.Interface
+
+	// namespace is the Kubernetes namespace
+	namespace string
+
+	// serviceName is the name of the Service to discover
+	serviceName string
+
+	// portName selects the endpoint port by name when set
+	portName string
+
+	// port overrides the endpoint port when > 0 and portName is empty, and
+	// is used for slices without the named port
+	port int
+
+	// weightKey is the pod annotation or label holding the node weight
+	weightKey string
+
+	// podLister reads pod metadata from the informer cache once started
+	podLister corelisters.PodLister
+
+	// stopCh signals the informers to stop
+	stopCh chan struct{}
+
+	// onChange callback function
+	onChange func(endpoints []string)
+}
+
+// NewEndpointSliceDiscovery creates an EndpointSlice-based discovery
+// instance.
+//
+// Requirements:
+//   - Role/RoleBinding must grant "get", "list", "watch" permissions on
+//     endpointslices (API group discovery.k8s.io) and pods
+//
+// Parameters:
+//   - cfg: Service discovery configuration; PortName should name the port
+//     cache nodes serve gRPC on
+//
+// Returns:
+//   - *EndpointSliceDiscovery: A new discovery instance ready to start
+//   - error: Error if Kubernetes client cannot be created
+//
+// Example:
+//
+//	disco, err := discovery.NewEndpointSliceDiscovery(discovery.Config{
+//	    Namespace:   "yao-system",
+//	    ServiceName: "yao-oracle-node",
+//	    PortName:    "grpc",
+//	})
+//	if err != nil {
+//	    log.Fatal("Failed to create discovery:", err)
+//	}
+func NewEndpointSliceDiscovery(cfg Config) (*EndpointSliceDiscovery, error) {
+	clientset, err := newClientset(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEndpointSliceDiscoveryWithClient(clientset, cfg), nil
+}
+
+// NewEndpointSliceDiscoveryWithClient creates an EndpointSlice-based
+// discovery instance that uses an existing client, such as the fake
+// clientset from k8s.io/client-go/kubernetes/fake. cfg.KubeconfigPath is
+// ignored.
+//
+// Parameters:
+//   - clientset: Kubernetes client used to read EndpointSlices and Pods
+//   - cfg: Service discovery configuration
+//
+// Returns:
+//   - *EndpointSliceDiscovery: A new discovery instance ready to start
+func NewEndpointSliceDiscoveryWithClient(clientset kubernetes.Interface, cfg Config) *EndpointSliceDiscovery {
+	weightKey := cfg.WeightKey
+	if weightKey == "" {
+		weightKey = DefaultWeightKey
+	}
+
+	return &EndpointSliceDiscovery{
+		slices:      make(map[string]*discoveryv1.EndpointSlice),
+		endpoints:   []string{},
+		clientset:   clientset,
+		namespace:   cfg.Namespace,
+		serviceName: cfg.ServiceName,
+		portName:    cfg.PortName,
+		port:        cfg.Port,
+		weightKey:   weightKey,
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// Start begins watching the Service's EndpointSlices.
+//
+// The current endpoints are loaded and reported to onChange before Start
+// returns; later changes are reported as they happen.
+func (d *EndpointSliceDiscovery) Start(ctx context.Context, onChange func(endpoints []string)) error {
+	d.onChange = onChange
+	selector := discoveryv1.LabelServiceName + "=" + d.serviceName
+
+	// Load initial endpoints
+	list, err := d.clientset.DiscoveryV1().EndpointSlices(d.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load initial endpoint slices: %w", err)
+	}
+
+	d.mu.Lock()
+	for i := range list.Items {
+		d.slices[list.Items[i].Name] = &list.Items[i]
+	}
+	d.mu.Unlock()
+
+	d.rebuild(true)
+
+	// Watch only the Service's slices; pods are watched for weight changes
+	sliceFactory := informers.NewSharedInformerFactoryWithOptions(
+		d.clientset,
+		time.Minute,
+		informers.WithNamespace(d.namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = selector
+		}),
+	)
+	podFactory := informers.NewSharedInformerFactoryWithOptions(
+		d.clientset,
+		time.Minute,
+		informers.WithNamespace(d.namespace),
+	)
+
+	sliceInformer := sliceFactory.Discovery().V1().EndpointSlices().Informer()
+	sliceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			d.setSlice(obj.(*discoveryv1.EndpointSlice))
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			d.setSlice(newObj.(*discoveryv1.EndpointSlice))
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			slice, ok := obj.(*discoveryv1.EndpointSlice)
+			if !ok {
+				return
+			}
+
+			d.mu.Lock()
+			delete(d.slices, slice.Name)
+			d.mu.Unlock()
+			d.rebuild(false)
+		},
+	})
+
+	podInformer := podFactory.Core().V1().Pods()
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod := oldObj.(*corev1.Pod)
+			newPod := newObj.(*corev1.Pod)
+			if podWeight(oldPod, d.weightKey) != podWeight(newPod, d.weightKey) {
+				d.rebuild(false)
+			}
+		},
+	})
+
+	// Start informers
+	sliceFactory.Start(d.stopCh)
+	podFactory.Start(d.stopCh)
+
+	// Wait for cache sync
+	for _, synced := range []map[reflect.Type]bool{
+		sliceFactory.WaitForCacheSync(d.stopCh),
+		podFactory.WaitForCacheSync(d.stopCh),
+	} {
+		for typ, ok := range synced {
+			if !ok {
+				return fmt.Errorf("failed to sync cache for %v", typ)
+			}
+		}
+	}
+
+	d.mu.Lock()
+	d.podLister = podInformer.Lister()
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Stop gracefully shuts down the discovery watcher.
+func (d *EndpointSliceDiscovery) Stop() {
+	if d.stopCh != nil {
+		close(d.stopCh)
+		d.stopCh = nil
+	}
+}
+
+// GetEndpoints returns the addresses of the serving, non-terminating endpoints.
+//
+// Thread-safe: Safe for concurrent calls.
+func (d *EndpointSliceDiscovery) GetEndpoints() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]string, len(d.endpoints))
+	copy(result, d.endpoints)
+	return result
+}
+
+// GetEndpointDetails returns the serving endpoints followed by the draining
+// ones, with their weights and pod names.
+//
+// Thread-safe: Safe for concurrent calls.
+func (d *EndpointSliceDiscovery) GetEndpointDetails() []Endpoint {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]Endpoint, len(d.details))
+	copy(result, d.details)
+	return result
+}
+
+// setSlice stores an added or updated EndpointSlice.
+func (d *EndpointSliceDiscovery) setSlice(slice *discoveryv1.EndpointSlice) {
+	d.mu.Lock()
+	d.slices[slice.Name] = slice
+	d.mu.Unlock()
+	d.rebuild(false)
+}
+
+// rebuild recomputes the endpoints from the stored slices and calls
+// onChange if they changed, or unconditionally when force is set.
+func (d *EndpointSliceDiscovery) rebuild(force bool) {
+	d.updateMu.Lock()
+	defer d.updateMu.Unlock()
+
+	d.mu.RLock()
+	names := make([]string, 0, len(d.slices))
+	for name := range d.slices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	slices := make([]*discoveryv1.EndpointSlice, len(names))
+	for i, name := range names {
+		slices[i] = d.slices[name]
+	}
+	lister := d.podLister
+	d.mu.RUnlock()
+
+	// An endpoint can appear in two slices while it moves between them;
+	// the ready copy wins
+	byAddress := make(map[string]Endpoint)
+	for _, slice := range slices {
+		port, ok := d.slicePort(slice)
+		if !ok {
+			continue
+		}
+
+		for _, ep := range slice.Endpoints {
+			endpoint, ok := d.endpoint(ep, port, lister)
+			if !ok {
+				continue
+			}
+			if existing, ok := byAddress[endpoint.Address]; ok && !existing.Draining {
+				continue
+			}
+			byAddress[endpoint.Address] = endpoint
+		}
+	}
+
+	var ready, draining []Endpoint
+	for _, endpoint := range byAddress {
+		if endpoint.Draining {
+			draining = append(draining, endpoint)
+		} else {
+			ready = append(ready, endpoint)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool { return ready[i].Address < ready[j].Address })
+	sort.Slice(draining, func(i, j int) bool { return draining[i].Address < draining[j].Address })
+
+	endpoints := make([]string, len(ready))
+	for i, endpoint := range ready {
+		endpoints[i] = endpoint.Address
+	}
+	details := append(ready, draining...)
+
+	d.mu.Lock()
+	changed := !reflect.DeepEqual(details, d.details)
+	d.endpoints = endpoints
+	d.details = details
+	d.mu.Unlock()
+
+	if (changed || force) && d.onChange != nil {
+		d.onChange(endpoints)
+	}
+}
+
+// slicePort returns the port of a slice's endpoints.
+//
+// With a port name configured, slices without that port use the configured
+// port, or are skipped if there is none. A port of 0 means the slice has no
+// port and endpoints are reported as plain IPs.
+func (d *EndpointSliceDiscovery) slicePort(slice *discoveryv1.EndpointSlice) (int, bool) {
+	if d.portName != "" {
+		for _, p := range slice.Ports {
+			if p.Name != nil && *p.Name == d.portName && p.Port != nil {
+				return int(*p.Port), true
+			}
+		}
+		return d.port, d.port > 0
+	}
+
+	if d.port > 0 {
+		return d.port, true
+	}
+	for _, p := range slice.Ports {
+		if p.Port != nil {
+			return int(*p.Port), true
+		}
+	}
+	return 0, true
+}
+
+// endpoint converts a slice endpoint according to its conditions.
+//
+// The serving condition decides whether the pod can take requests. The
+// ready condition is not used: for Services with publishNotReadyAddresses,
+// such as the chart's node Service, it is always true, even for pods that
+// fail their readiness probe or are terminating. Per the EndpointSlice API,
+// an unset serving condition has the value of ready, and an unset ready
+// condition means ready.
+//
+// Returns:
+//   - Endpoint: The endpoint; Draining is set for terminating pods that
+//     still serve
+//   - bool: False if the endpoint should not be reported
+func (d *EndpointSliceDiscovery) endpoint(ep discoveryv1.Endpoint, port int, lister corelisters.PodLister) (Endpoint, bool) {
+	if len(ep.Addresses) == 0 {
+		return Endpoint{}, false
+	}
+
+	serving := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+	if ep.Conditions.Serving != nil {
+		serving = *ep.Conditions.Serving
+	}
+	terminating := ep.Conditions.Terminating != nil && *ep.Conditions.Terminating
+	if !serving {
+		return Endpoint{}, false
+	}
+	draining := terminating
+
+	address := ep.Addresses[0]
+	if port > 0 {
+		address = net.JoinHostPort(address, strconv.Itoa(port))
+	}
+
+	endpoint := Endpoint{
+		Address:  address,
+		Weight:   targetWeight(d.clientset, lister, d.namespace, d.weightKey, ep.TargetRef),
+		Draining: draining,
+	}
+	if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+		endpoint.Name = ep.TargetRef.Name
+	} else if ep.Hostname != nil {
+		endpoint.Name = *ep.Hostname
+	}
+	if ep.Zone != nil {
+		endpoint.Zone = *ep.Zone
+	}
+	return endpoint, true
+}
@@ -0,0 +1,172 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// sliceEndpoint describes one endpoint of a test EndpointSlice.
+type sliceEndpoint struct {
+	ip          string
+	pod         string
+	ready       *bool
+	serving     *bool
+	terminating *bool
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func testSlice(name string, portName string, port int32, endpoints ...sliceEndpoint) *discoveryv1.EndpointSlice {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: testService},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports: []discoveryv1.EndpointPort{
+			{Name: &portName, Port: &port},
+		},
+	}
+	for _, ep := range endpoints {
+		slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+			Addresses: []string{ep.ip},
+			Conditions: discoveryv1.EndpointConditions{
+				Ready:       ep.ready,
+				Serving:     ep.serving,
+				Terminating: ep.terminating,
+			},
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: ep.pod, Namespace: testNamespace},
+		})
+	}
+	return slice
+}
+
+func startSliceDiscovery(t *testing.T, cfg Config, objects ...any) (*EndpointSliceDiscovery, *fake.Clientset, recorder) {
+	t.Helper()
+
+	clientset := fake.NewClientset()
+	for _, obj := range objects {
+		var err error
+		switch obj := obj.(type) {
+		case *discoveryv1.EndpointSlice:
+			_, err = clientset.DiscoveryV1().EndpointSlices(testNamespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		case *corev1.Pod:
+			_, err = clientset.CoreV1().Pods(testNamespace).Create(context.Background(), obj, metav1.CreateOptions{})
+		}
+		if err != nil {
+			t.Fatalf("create object: %v", err)
+		}
+	}
+
+	cfg.Namespace, cfg.ServiceName = testNamespace, testService
+	d := NewEndpointSliceDiscoveryWithClient(clientset, cfg)
+	t.Cleanup(d.Stop)
+
+	changes := make(recorder, 64)
+	if err := d.Start(context.Background(), changes.onChange); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return d, clientset, changes
+}
+
+func TestEndpointSliceDiscoveryConditions(t *testing.T) {
+	// With publishNotReadyAddresses every endpoint is ready; serving tells
+	// which pods actually pass their readiness probe
+	slice := testSlice("node-a", "grpc", 7070,
+		sliceEndpoint{ip: "10.0.0.1", pod: "node-0", ready: boolPtr(true), serving: boolPtr(true), terminating: boolPtr(false)},
+		sliceEndpoint{ip: "10.0.0.2", pod: "node-1", ready: boolPtr(true), serving: boolPtr(false), terminating: boolPtr(false)},
+		sliceEndpoint{ip: "10.0.0.3", pod: "node-2", ready: boolPtr(true), serving: boolPtr(true), terminating: boolPtr(true)},
+		sliceEndpoint{ip: "10.0.0.4", pod: "node-3", ready: boolPtr(true), serving: boolPtr(false), terminating: boolPtr(true)},
+		// Without publishNotReadyAddresses, a terminating pod is not ready
+		sliceEndpoint{ip: "10.0.0.5", pod: "node-4", ready: boolPtr(false), serving: boolPtr(true), terminating: boolPtr(true)},
+		// Conditions left unset mean ready and serving
+		sliceEndpoint{ip: "10.0.0.6", pod: "node-5"},
+	)
+	d, _, _ := startSliceDiscovery(t, Config{PortName: "grpc"}, slice)
+
+	got := d.GetEndpoints()
+	want := []string{"10.0.0.1:7070", "10.0.0.6:7070"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("GetEndpoints = %v, want %v", got, want)
+	}
+
+	draining := make(map[string]string)
+	for _, ep := range d.GetEndpointDetails() {
+		if ep.Draining {
+			draining[ep.Address] = ep.Name
+		}
+	}
+	if len(draining) != 2 || draining["10.0.0.3:7070"] != "node-2" || draining["10.0.0.5:7070"] != "node-4" {
+		t.Errorf("draining endpoints = %v, want node-2 and node-4", draining)
+	}
+}
+
+func TestEndpointSliceDiscoveryPorts(t *testing.T) {
+	named := testSlice("node-a", "grpc", 7070, sliceEndpoint{ip: "10.0.0.1", pod: "node-0"})
+	unnamed := testSlice("node-b", "other", 9999, sliceEndpoint{ip: "10.0.0.2", pod: "node-1"})
+
+	d, _, _ := startSliceDiscovery(t, Config{PortName: "grpc"}, named, unnamed)
+	if got := d.GetEndpoints(); len(got) != 1 || got[0] != "10.0.0.1:7070" {
+		t.Errorf("GetEndpoints by port name = %v, want [10.0.0.1:7070]", got)
+	}
+
+	// Slices without the named port fall back to the configured port
+	d, _, _ = startSliceDiscovery(t, Config{PortName: "grpc", Port: 8080}, named, unnamed)
+	if got := d.GetEndpoints(); len(got) != 2 || got[0] != "10.0.0.1:7070" || got[1] != "10.0.0.2:8080" {
+		t.Errorf("GetEndpoints with fallback port = %v, want [10.0.0.1:7070 10.0.0.2:8080]", got)
+	}
+}
+
+func TestEndpointSliceDiscoveryWeightsAndNames(t *testing.T) {
+	slice := testSlice("node-a", "grpc", 7070, sliceEndpoint{ip: "10.0.0.1", pod: "node-0"})
+	pod := testPod("node-0", map[string]string{DefaultWeightKey: "4"})
+
+	d, _, _ := startSliceDiscovery(t, Config{PortName: "grpc"}, slice, pod)
+	details := d.GetEndpointDetails()
+	if len(details) != 1 || details[0].Name != "node-0" || details[0].Weight != 4 {
+		t.Errorf("GetEndpointDetails = %+v, want node-0 with weight 4", details)
+	}
+}
+
+func TestEndpointSliceDiscoveryFollowsChanges(t *testing.T) {
+	ctx := context.Background()
+	d, clientset, changes := startSliceDiscovery(t, Config{PortName: "grpc"},
+		testSlice("node-a", "grpc", 7070, sliceEndpoint{ip: "10.0.0.1", pod: "node-0"}))
+	changes.until(t, 1)
+
+	// A second slice of the same Service is merged
+	slices := clientset.DiscoveryV1().EndpointSlices(testNamespace)
+	second := testSlice("node-b", "grpc", 7070, sliceEndpoint{ip: "10.0.0.2", pod: "node-1"})
+	if _, err := slices.Create(ctx, second, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create slice: %v", err)
+	}
+	changes.until(t, 2)
+
+	// The pod starts terminating: it drains instead of disappearing
+	draining := testSlice("node-b", "grpc", 7070, sliceEndpoint{
+		ip: "10.0.0.2", pod: "node-1", ready: boolPtr(true), serving: boolPtr(true), terminating: boolPtr(true),
+	})
+	if _, err := slices.Update(ctx, draining, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update slice: %v", err)
+	}
+	changes.until(t, 1)
+	if details := d.GetEndpointDetails(); len(details) != 2 || !details[1].Draining {
+		t.Errorf("GetEndpointDetails while draining = %+v, want node-1 draining", details)
+	}
+
+	if err := slices.Delete(ctx, "node-b", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete slice: %v", err)
+	}
+	for {
+		changes.next(t)
+		if len(d.GetEndpointDetails()) == 1 {
+			break
+		}
+	}
+}
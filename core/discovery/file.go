@@ -0,0 +1,142 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// FileConfig holds configuration for static file discovery.
+type FileConfig struct {
+	// Path is the YAML or JSON file listing the nodes
+	Path string
+
+	// Interval is the time between reads of the file
+	// Default: DefaultPollInterval
+	Interval time.Duration
+}
+
+// FileDiscovery implements service discovery from a static node list.
+//
+// The file is re-read every interval, so nodes can be added, removed,
+// reweighted or drained by editing it (or the ConfigMap it is mounted
+// from). An edit that leaves the file unreadable or invalid is logged and
+// ignored until it is fixed.
+//
+// File format (JSON with the same fields is accepted as well):
+//
+//	nodes:
+//	  - address: 10.0.0.1:8080
+//	    weight: 2
+//	    name: node-0
+//	  - address: cache-1.staging.internal:8080
+//	    draining: true
+//
+// Fields:
+//   - address: "HOST:PORT" of the node (required, unique)
+//   - weight: Relative capacity (optional, default DefaultWeight)
+//   - name: Stable identity of the node (optional)
+//   - draining: Stop sending new traffic to the node (optional)
+//
+// Thread-safety: All methods are safe for concurrent use.
+type FileDiscovery struct {
+	*poller
+
+	path string
+}
+
+// fileNodes is the document format of a node list file.
+type fileNodes struct {
+	Nodes []fileNode `json:"nodes"`
+}
+
+// fileNode is one entry of a node list file.
+type fileNode struct {
+	Address  string `json:"address"`
+	Weight   int    `json:"weight,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Draining bool   `json:"draining,omitempty"`
+}
+
+// NewFileDiscovery creates a static file discovery instance.
+//
+// Parameters:
+//   - cfg: File discovery configuration
+//
+// Returns:
+//   - *FileDiscovery: A new discovery instance ready to start
+//   - error: Error if no path is configured
+//
+// Example:
+//
+//	disco, err := discovery.NewFileDiscovery(discovery.FileConfig{
+//	    Path:     "/etc/yao-oracle/nodes.yaml",
+//	    Interval: 5 * time.Second,
+//	})
+//	if err != nil {
+//	    log.Fatal("Failed to create discovery:", err)
+//	}
+func NewFileDiscovery(cfg FileConfig) (*FileDiscovery, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("node list file path is required")
+	}
+
+	d := &FileDiscovery{path: cfg.Path}
+	d.poller = newPoller("file-discovery", cfg.Interval, d.load)
+	return d, nil
+}
+
+// load reads and validates the node list file.
+func (d *FileDiscovery) load(ctx context.Context) ([]Endpoint, error) {
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node list: %w", err)
+	}
+
+	return parseNodeList(data)
+}
+
+// parseNodeList parses a YAML or JSON node list.
+//
+// Returns:
+//   - []Endpoint: The nodes in file order
+//   - error: Error if the document is malformed, has unknown fields, or an
+//     entry is invalid
+func parseNodeList(data []byte) ([]Endpoint, error) {
+	var doc fileNodes
+	if err := yaml.UnmarshalStrict(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse node list: %w", err)
+	}
+
+	seen := make(map[string]bool, len(doc.Nodes))
+	endpoints := make([]Endpoint, 0, len(doc.Nodes))
+	for i, node := range doc.Nodes {
+		if _, _, err := net.SplitHostPort(node.Address); err != nil {
+			return nil, fmt.Errorf("nodes[%d]: address %q must be HOST:PORT", i, node.Address)
+		}
+		if seen[node.Address] {
+			return nil, fmt.Errorf("nodes[%d]: duplicate address %q", i, node.Address)
+		}
+		seen[node.Address] = true
+
+		if node.Weight < 0 {
+			return nil, fmt.Errorf("nodes[%d]: weight must not be negative, got %d", i, node.Weight)
+		}
+		weight := node.Weight
+		if weight == 0 {
+			weight = DefaultWeight
+		}
+
+		endpoints = append(endpoints, Endpoint{
+			Address:  node.Address,
+			Weight:   weight,
+			Name:     node.Name,
+			Draining: node.Draining,
+		})
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,128 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNodeList(t *testing.T) {
+	yamlList := `
+nodes:
+  - address: 10.0.0.1:8080
+    weight: 2
+    name: node-0
+  - address: cache-1.staging.internal:8080
+    draining: true
+`
+	jsonList := `{"nodes": [{"address": "10.0.0.1:8080", "weight": 2, "name": "node-0"}, {"address": "cache-1.staging.internal:8080", "draining": true}]}`
+	want := []Endpoint{
+		{Address: "10.0.0.1:8080", Weight: 2, Name: "node-0"},
+		{Address: "cache-1.staging.internal:8080", Weight: DefaultWeight, Draining: true},
+	}
+
+	for name, data := range map[string]string{"yaml": yamlList, "json": jsonList} {
+		got, err := parseNodeList([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: parseNodeList: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: parseNodeList = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestParseNodeListRejectsInvalidLists(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unknown field", "nodes:\n  - address: 10.0.0.1:8080\n    wieght: 2\n", "wieght"},
+		{"missing port", "nodes:\n  - address: 10.0.0.1\n", "HOST:PORT"},
+		{"duplicate", "nodes:\n  - address: 10.0.0.1:8080\n  - address: 10.0.0.1:8080\n", "duplicate"},
+		{"negative weight", "nodes:\n  - address: 10.0.0.1:8080\n    weight: -1\n", "negative"},
+		{"malformed", "nodes: [", "parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseNodeList([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseNodeList error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// writeFile replaces a file atomically, so that a poll never reads it half
+// written.
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		t.Fatalf("write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("rename %s: %v", tmp, err)
+	}
+}
+
+func TestFileDiscoveryReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes.yaml")
+	writeFile(t, path, "nodes:\n  - address: 10.0.0.1:8080\n")
+
+	d, err := NewFileDiscovery(FileConfig{Path: path, Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewFileDiscovery: %v", err)
+	}
+	defer d.Stop()
+
+	changes := make(recorder, 64)
+	if err := d.Start(context.Background(), changes.onChange); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if got := changes.next(t); len(got) != 1 || got[0] != "10.0.0.1:8080" {
+		t.Fatalf("initial endpoints = %v, want [10.0.0.1:8080]", got)
+	}
+
+	writeFile(t, path, "nodes:\n  - address: 10.0.0.1:8080\n    draining: true\n  - address: 10.0.0.2:8080\n")
+	if got := changes.next(t); len(got) != 1 || got[0] != "10.0.0.2:8080" {
+		t.Fatalf("endpoints after edit = %v, want [10.0.0.2:8080]", got)
+	}
+	if details := d.GetEndpointDetails(); len(details) != 2 || !details[0].Draining {
+		t.Errorf("GetEndpointDetails = %+v, want 10.0.0.1:8080 draining", details)
+	}
+
+	// An invalid edit keeps the previous nodes
+	writeFile(t, path, "nodes:\n  - address: no-port\n")
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case got := <-changes:
+		t.Fatalf("invalid file reported endpoints %v", got)
+	default:
+	}
+	if got := d.GetEndpoints(); len(got) != 1 || got[0] != "10.0.0.2:8080" {
+		t.Errorf("endpoints after invalid edit = %v, want the previous [10.0.0.2:8080]", got)
+	}
+}
+
+func TestFileDiscoveryStartFailsWithoutFile(t *testing.T) {
+	d, err := NewFileDiscovery(FileConfig{Path: filepath.Join(t.TempDir(), "missing.yaml")})
+	if err != nil {
+		t.Fatalf("NewFileDiscovery: %v", err)
+	}
+	defer d.Stop()
+
+	if err := d.Start(context.Background(), nil); err == nil {
+		t.Error("Start succeeded without a node list file")
+	}
+	if _, err := NewFileDiscovery(FileConfig{}); err == nil {
+		t.Error("NewFileDiscovery accepted an empty path")
+	}
+}
@@ -0,0 +1,160 @@
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/utils"
+)
+
+// DefaultPollInterval is the time between refreshes of polling discovery
+// backends (file and DNS) when no interval is configured.
+const DefaultPollInterval = 10 * time.Second
+
+// poller implements ServiceDiscovery and DetailedServiceDiscovery for
+// backends that re-read their source periodically.
+//
+// Each refresh calls load; onChange runs only when the result differs from
+// the previous one. A failed refresh is logged (once until the error
+// changes) and the previous endpoints are kept.
+type poller struct {
+	// load reads the current endpoints from the source
+	load func(ctx context.Context) ([]Endpoint, error)
+
+	// interval is the time between refreshes
+	interval time.Duration
+
+	logger *utils.Logger
+
+	// mu protects endpoints and details
+	mu sync.RWMutex
+
+	// endpoints holds the addresses of the non-draining endpoints
+	endpoints []string
+
+	// details holds all endpoints in the order load returned them
+	details []Endpoint
+
+	// stopCh signals the refresh loop to stop
+	stopCh   chan struct{}
+	stopOnce sync.Once
+
+	// onChange callback function
+	onChange func(endpoints []string)
+}
+
+// newPoller creates a poller; an interval <= 0 selects DefaultPollInterval.
+func newPoller(name string, interval time.Duration, load func(ctx context.Context) ([]Endpoint, error)) *poller {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &poller{
+		load:      load,
+		interval:  interval,
+		logger:    utils.NewLogger(name),
+		endpoints: []string{},
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start loads the endpoints, reports them to onChange and starts the
+// refresh loop.
+//
+// Returns:
+//   - error: Error if the initial load fails
+func (p *poller) Start(ctx context.Context, onChange func(endpoints []string)) error {
+	p.onChange = onChange
+
+	details, err := p.load(ctx)
+	if err != nil {
+		return err
+	}
+	p.set(details, true)
+
+	go p.run(ctx)
+	return nil
+}
+
+// Stop ends the refresh loop.
+func (p *poller) Stop() {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+}
+
+// GetEndpoints returns the addresses of the non-draining endpoints.
+//
+// Thread-safe: Safe for concurrent calls.
+func (p *poller) GetEndpoints() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make([]string, len(p.endpoints))
+	copy(result, p.endpoints)
+	return result
+}
+
+// GetEndpointDetails returns all endpoints with their metadata.
+//
+// Thread-safe: Safe for concurrent calls.
+func (p *poller) GetEndpointDetails() []Endpoint {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make([]Endpoint, len(p.details))
+	copy(result, p.details)
+	return result
+}
+
+// run refreshes the endpoints every interval until Stop is called or ctx
+// is done.
+func (p *poller) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	var lastErr string
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		details, err := p.load(ctx)
+		if err != nil {
+			if err.Error() != lastErr {
+				p.logger.Warn("Failed to refresh endpoints, keeping %d: %v", len(p.GetEndpointDetails()), err)
+				lastErr = err.Error()
+			}
+			continue
+		}
+		if lastErr != "" {
+			p.logger.Info("Endpoints refreshed again")
+			lastErr = ""
+		}
+		p.set(details, false)
+	}
+}
+
+// set stores the endpoints and calls onChange if they changed, or
+// unconditionally when force is set.
+func (p *poller) set(details []Endpoint, force bool) {
+	endpoints := make([]string, 0, len(details))
+	for _, endpoint := range details {
+		if !endpoint.Draining {
+			endpoints = append(endpoints, endpoint.Address)
+		}
+	}
+
+	p.mu.Lock()
+	changed := !reflect.DeepEqual(details, p.details)
+	p.endpoints = endpoints
+	p.details = details
+	p.mu.Unlock()
+
+	if (changed || force) && p.onChange != nil {
+		p.onChange(endpoints)
+	}
+}
@@ -0,0 +1,81 @@
+// Package gossip implements SWIM-style peer membership for Yao-Oracle
+// cache nodes.
+//
+// Members find each other through seed addresses and detect failures
+// without a central registry such as the Kubernetes API. Membership
+// implements discovery.ServiceDiscovery, so the proxy can consume it in
+// place of the Kubernetes discovery backends.
+//
+// # Protocol
+//
+// Every probe interval each member pings one other member, picked in a
+// shuffled round-robin order. Without an ack within the probe timeout, it
+// asks a few other members to ping the target on its behalf (ping-req).
+// If no ack arrives by the end of the interval, the target is marked
+// suspect. A suspect that does not refute the suspicion within the
+// suspicion timeout is declared dead.
+//
+// State changes are piggybacked on probe messages and retransmitted a
+// number of times that grows logarithmically with the cluster size, so
+// they reach every member with high probability in O(log n) intervals.
+//
+// # Incarnations
+//
+// Each member owns an incarnation number. A member that learns it is
+// suspected or declared dead refutes this by announcing itself alive with
+// a higher incarnation. Updates about a member are ordered by incarnation:
+//   - alive overrides any state with a lower incarnation
+//   - suspect overrides alive with the same or a lower incarnation
+//   - dead overrides alive and suspect with the same or a lower incarnation
+//
+// Incarnations start at the current Unix time in milliseconds, so a member
+// that restarts under the same name supersedes what others remember of
+// its previous run.
+//
+// # Metadata
+//
+// Members carry the address of the service they provide (Endpoint), a
+// failure domain (Zone) and a relative capacity (Weight). Members without
+// an Endpoint, such as proxies, take part in failure detection but are not
+// reported as endpoints.
+//
+// # Basic Usage
+//
+//	transport, err := gossip.NewUDPTransport(":7946", "10.0.0.5:7946")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	members, err := gossip.New(gossip.Config{
+//	    Name:      "yao-oracle-node-0",
+//	    Endpoint:  "10.0.0.5:8080",
+//	    Zone:      "zone-a",
+//	    Weight:    2,
+//	    Seeds:     []string{"10.0.0.6:7946", "10.0.0.7:7946"},
+//	    Transport: transport,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	if err := members.Start(ctx, func(endpoints []string) {
+//	    log.Printf("Cache nodes: %v", endpoints)
+//	}); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer members.Stop()
+//
+// # Testing
+//
+// MemoryNetwork connects members within one process. Dropping a member's
+// messages simulates a crash or a network partition:
+//
+//	network := gossip.NewMemoryNetwork()
+//	a, _ := gossip.New(gossip.Config{Name: "a", Transport: network.NewTransport("a")})
+//	b, _ := gossip.New(gossip.Config{Name: "b", Seeds: []string{"a"}, Transport: network.NewTransport("b")})
+//	network.SetDropped("a", true) // b suspects, then declares a dead
+//
+// # Thread Safety
+//
+// All Membership methods are safe for concurrent use.
+package gossip
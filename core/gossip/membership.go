@@ -0,0 +1,835 @@
+package gossip
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/discovery"
+	"github.com/eggybyte-technology/yao-oracle/core/utils"
+)
+
+// Default protocol settings, used when Config leaves them unset.
+const (
+	DefaultProbeInterval    = time.Second
+	DefaultProbeTimeout     = 500 * time.Millisecond
+	DefaultIndirectChecks   = 3
+	DefaultSuspicionTimeout = 5 * time.Second
+	DefaultDeadRetention    = time.Minute
+	DefaultRetransmitMult   = 4
+)
+
+const (
+	// maxPiggyback is the most updates piggybacked on one message
+	maxPiggyback = 8
+
+	// syncChunkSize is the most members sent in one MessageSync
+	syncChunkSize = 32
+
+	// leaveFanout is the number of members told directly about a leave
+	leaveFanout = 3
+)
+
+// State is the health of a member as seen by the cluster.
+type State uint8
+
+const (
+	// StateAlive members answer probes
+	StateAlive State = iota
+
+	// StateSuspect members failed a probe and may be down
+	StateSuspect
+
+	// StateDead members failed to refute a suspicion, or left
+	StateDead
+)
+
+// String returns the lowercase name of the state.
+func (s State) String() string {
+	switch s {
+	case StateAlive:
+		return "alive"
+	case StateSuspect:
+		return "suspect"
+	case StateDead:
+		return "dead"
+	default:
+		return fmt.Sprintf("state(%d)", uint8(s))
+	}
+}
+
+// Member describes a cluster member and its state.
+type Member struct {
+	// Name is the unique, stable identity of the member (e.g., the pod name)
+	Name string `json:"name"`
+
+	// Addr is the gossip address of the member
+	Addr string `json:"addr"`
+
+	// Endpoint is the address of the service the member provides; empty for
+	// members that only observe the cluster
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Zone is the failure domain of the member (optional)
+	Zone string `json:"zone,omitempty"`
+
+	// Weight is the relative capacity of the member
+	Weight int `json:"weight,omitempty"`
+
+	// State is the health of the member
+	State State `json:"state"`
+
+	// Incarnation orders updates about the member; only the member itself
+	// increases it
+	Incarnation uint64 `json:"incarnation"`
+}
+
+// Config holds configuration for gossip membership.
+type Config struct {
+	// Name is the unique, stable identity of this member (required)
+	Name string
+
+	// Endpoint is the address of the service this member provides
+	// Leave empty for members that only observe the cluster (e.g., proxies)
+	Endpoint string
+
+	// Zone is the failure domain of this member (optional)
+	Zone string
+
+	// Weight is the relative capacity of this member
+	// Default: discovery.DefaultWeight
+	Weight int
+
+	// Seeds are gossip addresses of members to join through
+	// The member's own address is skipped; leave empty to start a new cluster
+	Seeds []string
+
+	// Transport delivers messages (required)
+	Transport Transport
+
+	// ProbeInterval is the time between probes of one member
+	// Default: DefaultProbeInterval
+	ProbeInterval time.Duration
+
+	// ProbeTimeout is the time to wait for a direct ack before asking other
+	// members to probe; must be less than ProbeInterval
+	// Default: DefaultProbeTimeout (capped at half the ProbeInterval)
+	ProbeTimeout time.Duration
+
+	// IndirectChecks is the number of members asked to probe on our behalf
+	// Default: DefaultIndirectChecks
+	IndirectChecks int
+
+	// SuspicionTimeout is the time a suspect has to refute before it is
+	// declared dead
+	// Default: DefaultSuspicionTimeout
+	SuspicionTimeout time.Duration
+
+	// DeadRetention is how long dead members are remembered, so that stale
+	// updates cannot revive them
+	// Default: DefaultDeadRetention
+	DeadRetention time.Duration
+
+	// RetransmitMult scales how often an update is piggybacked:
+	// RetransmitMult * ceil(log10(members + 1)) times
+	// Default: DefaultRetransmitMult
+	RetransmitMult int
+}
+
+// memberState is a known member with the time of its last state change.
+type memberState struct {
+	Member
+	changed time.Time
+}
+
+// relay is a pending ping sent on behalf of another member.
+type relay struct {
+	to  string
+	seq uint64
+}
+
+// broadcast is an update waiting to be piggybacked.
+type broadcast struct {
+	member    Member
+	transmits int
+}
+
+// Membership tracks cluster members with the SWIM protocol.
+//
+// It implements discovery.DetailedServiceDiscovery: the endpoints are the
+// Endpoint addresses of the alive and suspect members, including this one,
+// ordered by member name. Suspects stay endpoints until they are declared
+// dead, as they are usually just slow.
+type Membership struct {
+	cfg       Config
+	transport Transport
+	logger    *utils.Logger
+
+	// mu protects the member table and protocol state
+	mu         sync.Mutex
+	self       Member
+	leaving    bool
+	members    map[string]*memberState
+	seq        uint64
+	acks       map[uint64]chan struct{}
+	relays     map[uint64]relay
+	queue      []*broadcast
+	probeOrder []string
+	lastJoin   time.Time
+
+	// notifyMu serializes endpoint updates so that onChange sees them in order
+	notifyMu sync.Mutex
+	onChange func(endpoints []string)
+
+	// viewMu protects endpoints and details
+	viewMu    sync.RWMutex
+	endpoints []string
+	details   []discovery.Endpoint
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// Compile-time interface check
+var _ discovery.DetailedServiceDiscovery = (*Membership)(nil)
+
+// New creates a gossip membership for the local member.
+//
+// Parameters:
+//   - cfg: Membership configuration
+//
+// Returns:
+//   - *Membership: The membership, ready to start
+//   - error: Error if the configuration is invalid
+//
+// Example:
+//
+//	members, err := gossip.New(gossip.Config{
+//	    Name:      "yao-oracle-node-0",
+//	    Endpoint:  "10.0.0.5:8080",
+//	    Seeds:     []string{"10.0.0.6:7946"},
+//	    Transport: transport,
+//	})
+func New(cfg Config) (*Membership, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("member name is required")
+	}
+	if cfg.Transport == nil {
+		return nil, fmt.Errorf("transport is required")
+	}
+	if cfg.Weight < 0 {
+		return nil, fmt.Errorf("weight must not be negative, got %d", cfg.Weight)
+	}
+
+	if cfg.Weight == 0 {
+		cfg.Weight = discovery.DefaultWeight
+	}
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = DefaultProbeInterval
+	}
+	if cfg.ProbeTimeout <= 0 || cfg.ProbeTimeout >= cfg.ProbeInterval {
+		cfg.ProbeTimeout = min(DefaultProbeTimeout, cfg.ProbeInterval/2)
+	}
+	if cfg.IndirectChecks <= 0 {
+		cfg.IndirectChecks = DefaultIndirectChecks
+	}
+	if cfg.SuspicionTimeout <= 0 {
+		cfg.SuspicionTimeout = DefaultSuspicionTimeout
+	}
+	if cfg.DeadRetention <= 0 {
+		cfg.DeadRetention = DefaultDeadRetention
+	}
+	if cfg.RetransmitMult <= 0 {
+		cfg.RetransmitMult = DefaultRetransmitMult
+	}
+
+	m := &Membership{
+		cfg:       cfg,
+		transport: cfg.Transport,
+		logger:    utils.NewLogger("gossip"),
+		self: Member{
+			Name:        cfg.Name,
+			Addr:        cfg.Transport.Addr(),
+			Endpoint:    cfg.Endpoint,
+			Zone:        cfg.Zone,
+			Weight:      cfg.Weight,
+			State:       StateAlive,
+			Incarnation: uint64(time.Now().UnixMilli()),
+		},
+		members: make(map[string]*memberState),
+		acks:    make(map[uint64]chan struct{}),
+		relays:  make(map[uint64]relay),
+		stopCh:  make(chan struct{}),
+	}
+	m.enqueue(m.self)
+	m.notify(false)
+	return m, nil
+}
+
+// Start joins the cluster through the seeds and starts failure detection.
+//
+// The current endpoints (this member's own, if it has an Endpoint) are
+// reported to onChange before Start returns. Seeds that cannot be reached
+// are retried while no other member is known, so the first member of a
+// cluster can start before the others.
+//
+// Returns:
+//   - error: Always nil; the signature satisfies discovery.ServiceDiscovery
+func (m *Membership) Start(ctx context.Context, onChange func(endpoints []string)) error {
+	m.notifyMu.Lock()
+	m.onChange = onChange
+	m.notifyMu.Unlock()
+
+	m.wg.Add(2)
+	go m.receive()
+	go m.probeLoop(ctx)
+
+	m.join()
+	m.notify(true)
+
+	m.logger.Info("Member %s started at %s with %d seeds", m.cfg.Name, m.transport.Addr(), len(m.cfg.Seeds))
+	return nil
+}
+
+// Stop leaves the cluster and releases the transport.
+//
+// A few members are told directly that this member is dead, so the
+// cluster does not have to detect the departure by probing.
+func (m *Membership) Stop() {
+	m.stopOnce.Do(func() {
+		m.mu.Lock()
+		m.leaving = true
+		m.self.Incarnation++
+		m.self.State = StateDead
+		leave := m.self
+		targets := m.randomMembers(leaveFanout, "")
+		m.mu.Unlock()
+
+		for _, target := range targets {
+			m.send(target.Addr, &Message{Type: MessageSync, Updates: []Member{leave}})
+		}
+
+		close(m.stopCh)
+		if err := m.transport.Close(); err != nil {
+			m.logger.Warn("Failed to close gossip transport: %v", err)
+		}
+		m.wg.Wait()
+		m.logger.Info("Member %s left the cluster", m.cfg.Name)
+	})
+}
+
+// GetEndpoints returns the Endpoint addresses of the alive and suspect
+// members, ordered by member name.
+//
+// Thread-safe: Safe for concurrent calls.
+func (m *Membership) GetEndpoints() []string {
+	m.viewMu.RLock()
+	defer m.viewMu.RUnlock()
+
+	result := make([]string, len(m.endpoints))
+	copy(result, m.endpoints)
+	return result
+}
+
+// GetEndpointDetails returns the endpoints with the name, zone and weight
+// of their members.
+//
+// Thread-safe: Safe for concurrent calls.
+func (m *Membership) GetEndpointDetails() []discovery.Endpoint {
+	m.viewMu.RLock()
+	defer m.viewMu.RUnlock()
+
+	result := make([]discovery.Endpoint, len(m.details))
+	copy(result, m.details)
+	return result
+}
+
+// Members returns all known members, including this one and members
+// remembered as dead, ordered by name.
+func (m *Membership) Members() []Member {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]Member, 0, len(m.members)+1)
+	result = append(result, m.self)
+	for _, ms := range m.members {
+		result = append(result, ms.Member)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// LocalMember returns this member as announced to the cluster.
+func (m *Membership) LocalMember() Member {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.self
+}
+
+// join announces this member to the seeds.
+func (m *Membership) join() {
+	m.mu.Lock()
+	m.lastJoin = time.Now()
+	self := m.self
+	m.mu.Unlock()
+
+	for _, seed := range m.cfg.Seeds {
+		if seed == self.Addr {
+			continue
+		}
+		m.send(seed, &Message{Type: MessageJoin, Updates: []Member{self}})
+	}
+}
+
+// receive handles incoming messages until the transport is closed.
+func (m *Membership) receive() {
+	defer m.wg.Done()
+
+	for msg := range m.transport.Receive() {
+		m.handle(msg)
+	}
+}
+
+// handle applies the updates of a message and answers it.
+func (m *Membership) handle(msg *Message) {
+	m.mu.Lock()
+	changed := false
+	for _, update := range msg.Updates {
+		if m.apply(update) {
+			changed = true
+		}
+	}
+	m.mu.Unlock()
+
+	switch msg.Type {
+	case MessagePing:
+		// A member we consider suspect or dead learns it here and can refute
+		ack := &Message{Type: MessageAck, Seq: msg.Seq}
+		m.mu.Lock()
+		if sender, ok := m.memberAt(msg.From); ok && sender.State != StateAlive {
+			ack.Updates = append(ack.Updates, sender)
+		}
+		m.mu.Unlock()
+		m.send(msg.From, ack)
+
+	case MessageAck:
+		m.mu.Lock()
+		if ch, ok := m.acks[msg.Seq]; ok {
+			delete(m.acks, msg.Seq)
+			close(ch)
+		}
+		r, relayed := m.relays[msg.Seq]
+		delete(m.relays, msg.Seq)
+		m.mu.Unlock()
+
+		if relayed {
+			m.send(r.to, &Message{Type: MessageAck, Seq: r.seq})
+		}
+
+	case MessagePingReq:
+		m.mu.Lock()
+		m.seq++
+		seq := m.seq
+		m.relays[seq] = relay{to: msg.From, seq: msg.Seq}
+		m.mu.Unlock()
+
+		time.AfterFunc(m.cfg.ProbeInterval, func() {
+			m.mu.Lock()
+			delete(m.relays, seq)
+			m.mu.Unlock()
+		})
+		m.send(msg.Target, &Message{Type: MessagePing, Seq: seq})
+
+	case MessageJoin:
+		m.sync(msg.From)
+	}
+
+	if changed {
+		m.notify(false)
+	}
+}
+
+// sync sends the full member list, in chunks, to a joining member.
+// Dead members are included so that a member restarting under the same
+// name learns it has to refute its old death.
+func (m *Membership) sync(to string) {
+	m.mu.Lock()
+	list := make([]Member, 0, len(m.members)+1)
+	list = append(list, m.self)
+	for _, ms := range m.members {
+		list = append(list, ms.Member)
+	}
+	m.mu.Unlock()
+
+	for start := 0; start < len(list); start += syncChunkSize {
+		end := min(start+syncChunkSize, len(list))
+		m.send(to, &Message{Type: MessageSync, Updates: list[start:end]})
+	}
+}
+
+// send delivers a message, piggybacking pending updates on all but sync
+// messages. Errors are logged at debug level; the protocol treats them as
+// lost messages.
+func (m *Membership) send(to string, msg *Message) {
+	msg.From = m.transport.Addr()
+	if msg.Type != MessageSync {
+		m.mu.Lock()
+		msg.Updates = append(msg.Updates, m.piggyback()...)
+		m.mu.Unlock()
+	}
+
+	if err := m.transport.Send(to, msg); err != nil {
+		m.logger.Debug("Failed to send gossip message to %s: %v", to, err)
+	}
+}
+
+// apply merges an update into the member table.
+//
+// Returns true if the update was accepted. Accepted updates are queued for
+// dissemination. Updates about this member that claim it is suspect or
+// dead are refuted. The caller must hold the lock.
+func (m *Membership) apply(update Member) bool {
+	if update.Name == "" {
+		return false
+	}
+
+	if update.Name == m.self.Name {
+		if m.leaving || update.Incarnation < m.self.Incarnation {
+			return false
+		}
+		if update.State == StateAlive && update.Incarnation == m.self.Incarnation {
+			return false
+		}
+
+		m.self.Incarnation = update.Incarnation + 1
+		m.enqueue(m.self)
+		m.logger.Warn("Refuting %s state of this member with incarnation %d", update.State, m.self.Incarnation)
+		return false
+	}
+
+	current, known := m.members[update.Name]
+	if !known {
+		m.members[update.Name] = &memberState{Member: update, changed: time.Now()}
+		if update.State == StateDead {
+			// Remember the death so stale updates cannot revive the member
+			return false
+		}
+
+		m.enqueue(update)
+		m.logger.Info("Member %s joined at %s (endpoint %q, zone %q, weight %d)",
+			update.Name, update.Addr, update.Endpoint, update.Zone, update.Weight)
+		return true
+	}
+
+	var accept bool
+	switch update.State {
+	case StateAlive:
+		accept = update.Incarnation > current.Incarnation
+	case StateSuspect:
+		accept = update.Incarnation > current.Incarnation ||
+			(update.Incarnation == current.Incarnation && current.State == StateAlive)
+	case StateDead:
+		accept = update.Incarnation > current.Incarnation ||
+			(update.Incarnation == current.Incarnation && current.State != StateDead)
+	}
+	if !accept {
+		return false
+	}
+
+	previous := current.State
+	current.Member = update
+	current.changed = time.Now()
+	m.enqueue(update)
+
+	switch {
+	case update.State == previous:
+	case update.State == StateAlive && previous == StateDead:
+		m.logger.Info("Member %s rejoined at %s", update.Name, update.Addr)
+	case update.State == StateAlive:
+		m.logger.Info("Member %s refuted suspicion", update.Name)
+	case update.State == StateSuspect:
+		m.logger.Warn("Member %s is suspect", update.Name)
+	case update.State == StateDead:
+		m.logger.Warn("Member %s is dead", update.Name)
+	}
+	return true
+}
+
+// enqueue queues an update for dissemination, replacing an older update
+// about the same member. The caller must hold the lock.
+func (m *Membership) enqueue(update Member) {
+	for _, b := range m.queue {
+		if b.member.Name == update.Name {
+			b.member = update
+			b.transmits = 0
+			return
+		}
+	}
+	m.queue = append(m.queue, &broadcast{member: update})
+}
+
+// piggyback takes the least transmitted updates for one message and drops
+// updates that were sent often enough. The caller must hold the lock.
+func (m *Membership) piggyback() []Member {
+	if len(m.queue) == 0 {
+		return nil
+	}
+
+	limit := m.cfg.RetransmitMult * int(math.Ceil(math.Log10(float64(len(m.members)+2))))
+	sort.SliceStable(m.queue, func(i, j int) bool { return m.queue[i].transmits < m.queue[j].transmits })
+
+	n := min(maxPiggyback, len(m.queue))
+	updates := make([]Member, n)
+	for i := 0; i < n; i++ {
+		updates[i] = m.queue[i].member
+		m.queue[i].transmits++
+	}
+
+	kept := m.queue[:0]
+	for _, b := range m.queue {
+		if b.transmits < limit {
+			kept = append(kept, b)
+		}
+	}
+	m.queue = kept
+	return updates
+}
+
+// probeLoop probes one member per interval until stopped.
+func (m *Membership) probeLoop(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if m.reap() {
+			m.notify(false)
+		}
+		m.rejoin()
+		m.probe(ctx)
+	}
+}
+
+// reap declares suspects dead after the suspicion timeout and forgets dead
+// members after the retention period.
+//
+// Returns true if a member was declared dead.
+func (m *Membership) reap() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	changed := false
+	for name, ms := range m.members {
+		switch {
+		case ms.State == StateSuspect && now.Sub(ms.changed) >= m.cfg.SuspicionTimeout:
+			dead := ms.Member
+			dead.State = StateDead
+			if m.apply(dead) {
+				changed = true
+			}
+		case ms.State == StateDead && now.Sub(ms.changed) >= m.cfg.DeadRetention:
+			delete(m.members, name)
+		}
+	}
+	return changed
+}
+
+// rejoin contacts the seeds again while no other member is alive.
+func (m *Membership) rejoin() {
+	if len(m.cfg.Seeds) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	alone := len(m.liveMembers("")) == 0
+	due := time.Since(m.lastJoin) >= m.cfg.SuspicionTimeout
+	m.mu.Unlock()
+
+	if alone && due {
+		m.join()
+	}
+}
+
+// probe pings the next member, asks others to ping it if it does not
+// answer, and marks it suspect if no ack arrives within the interval.
+func (m *Membership) probe(ctx context.Context) {
+	m.mu.Lock()
+	target, ok := m.nextTarget()
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	m.seq++
+	seq := m.seq
+	acked := make(chan struct{})
+	m.acks[seq] = acked
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.acks, seq)
+		m.mu.Unlock()
+	}()
+
+	// A suspect is told so directly, giving it the chance to refute
+	ping := &Message{Type: MessagePing, Seq: seq}
+	if target.State == StateSuspect {
+		ping.Updates = append(ping.Updates, target)
+	}
+	m.send(target.Addr, ping)
+	if m.waitAck(ctx, acked, m.cfg.ProbeTimeout) {
+		return
+	}
+
+	m.mu.Lock()
+	helpers := m.randomMembers(m.cfg.IndirectChecks, target.Name)
+	m.mu.Unlock()
+	for _, helper := range helpers {
+		m.send(helper.Addr, &Message{Type: MessagePingReq, Seq: seq, Target: target.Addr})
+	}
+	if m.waitAck(ctx, acked, m.cfg.ProbeInterval-m.cfg.ProbeTimeout) {
+		return
+	}
+
+	m.mu.Lock()
+	changed := false
+	if current, ok := m.members[target.Name]; ok && current.State == StateAlive && current.Incarnation == target.Incarnation {
+		suspect := current.Member
+		suspect.State = StateSuspect
+		changed = m.apply(suspect)
+	}
+	m.mu.Unlock()
+
+	if changed {
+		m.notify(false)
+	}
+}
+
+// waitAck waits for an ack until the timeout.
+//
+// Returns true if the ack arrived.
+func (m *Membership) waitAck(ctx context.Context, acked <-chan struct{}, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-acked:
+		return true
+	case <-timer.C:
+	case <-m.stopCh:
+	case <-ctx.Done():
+	}
+	return false
+}
+
+// nextTarget returns the next live member in a shuffled round-robin order.
+// The caller must hold the lock.
+func (m *Membership) nextTarget() (Member, bool) {
+	for attempt := 0; attempt < 2; attempt++ {
+		for len(m.probeOrder) > 0 {
+			name := m.probeOrder[0]
+			m.probeOrder = m.probeOrder[1:]
+			if ms, ok := m.members[name]; ok && ms.State != StateDead {
+				return ms.Member, true
+			}
+		}
+
+		// Start a new round
+		for _, member := range m.liveMembers("") {
+			m.probeOrder = append(m.probeOrder, member.Name)
+		}
+		rand.Shuffle(len(m.probeOrder), func(i, j int) {
+			m.probeOrder[i], m.probeOrder[j] = m.probeOrder[j], m.probeOrder[i]
+		})
+	}
+	return Member{}, false
+}
+
+// memberAt returns the member with a gossip address. The caller must hold
+// the lock.
+func (m *Membership) memberAt(addr string) (Member, bool) {
+	for _, ms := range m.members {
+		if ms.Addr == addr {
+			return ms.Member, true
+		}
+	}
+	return Member{}, false
+}
+
+// randomMembers returns up to n random live members other than exclude.
+// The caller must hold the lock.
+func (m *Membership) randomMembers(n int, exclude string) []Member {
+	members := m.liveMembers(exclude)
+	rand.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+	return members[:min(n, len(members))]
+}
+
+// liveMembers returns the other members that are alive or suspect, except
+// exclude. The caller must hold the lock.
+func (m *Membership) liveMembers(exclude string) []Member {
+	members := make([]Member, 0, len(m.members))
+	for name, ms := range m.members {
+		if name != exclude && ms.State != StateDead {
+			members = append(members, ms.Member)
+		}
+	}
+	return members
+}
+
+// notify recomputes the endpoints and calls onChange if they changed, or
+// unconditionally when force is set.
+func (m *Membership) notify(force bool) {
+	m.notifyMu.Lock()
+	defer m.notifyMu.Unlock()
+
+	m.mu.Lock()
+	members := make([]Member, 0, len(m.members)+1)
+	members = append(members, m.self)
+	for _, ms := range m.members {
+		members = append(members, ms.Member)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+
+	endpoints := []string{}
+	var details []discovery.Endpoint
+	for _, member := range members {
+		if member.State == StateDead || member.Endpoint == "" {
+			continue
+		}
+		endpoints = append(endpoints, member.Endpoint)
+		details = append(details, discovery.Endpoint{
+			Address: member.Endpoint,
+			Weight:  member.Weight,
+			Name:    member.Name,
+			Zone:    member.Zone,
+		})
+	}
+
+	m.viewMu.Lock()
+	changed := !reflect.DeepEqual(details, m.details)
+	m.endpoints = endpoints
+	m.details = details
+	m.viewMu.Unlock()
+
+	if (changed || force) && m.onChange != nil {
+		m.onChange(endpoints)
+	}
+}
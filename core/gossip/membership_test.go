@@ -0,0 +1,221 @@
+package gossip
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testConfig returns a member configuration with timings short enough for
+// tests.
+func testConfig(network *MemoryNetwork, name string, seeds ...string) Config {
+	return Config{
+		Name:             name,
+		Endpoint:         name + ":8080",
+		Seeds:            seeds,
+		Transport:        network.NewTransport(name + ":7946"),
+		ProbeInterval:    20 * time.Millisecond,
+		ProbeTimeout:     5 * time.Millisecond,
+		SuspicionTimeout: 200 * time.Millisecond,
+		DeadRetention:    10 * time.Second,
+	}
+}
+
+// startMember creates and starts a member; it is stopped when the test ends.
+func startMember(t *testing.T, cfg Config) *Membership {
+	t.Helper()
+
+	m, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New(%s): %v", cfg.Name, err)
+	}
+	if err := m.Start(context.Background(), nil); err != nil {
+		t.Fatalf("Start(%s): %v", cfg.Name, err)
+	}
+	t.Cleanup(m.Stop)
+	return m
+}
+
+// startCluster starts n members named node-0 to node-n-1 that join through
+// node-0.
+func startCluster(t *testing.T, network *MemoryNetwork, n int) []*Membership {
+	t.Helper()
+
+	members := make([]*Membership, n)
+	for i := range members {
+		members[i] = startMember(t, testConfig(network, fmt.Sprintf("node-%d", i), "node-0:7946"))
+	}
+	return members
+}
+
+// eventually fails the test if cond does not hold within a few seconds.
+func eventually(t *testing.T, cond func() bool, format string, args ...any) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf(format, args...)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// stateOf returns the state of a member as seen by m.
+func stateOf(m *Membership, name string) (Member, bool) {
+	for _, member := range m.Members() {
+		if member.Name == name {
+			return member, true
+		}
+	}
+	return Member{}, false
+}
+
+func TestMembershipConverges(t *testing.T) {
+	network := NewMemoryNetwork()
+	members := startCluster(t, network, 5)
+
+	for _, m := range members {
+		eventually(t, func() bool { return len(m.GetEndpoints()) == 5 },
+			"%s sees %v, want all 5 endpoints", m.LocalMember().Name, m.GetEndpoints())
+	}
+
+	want := []string{"node-0:8080", "node-1:8080", "node-2:8080", "node-3:8080", "node-4:8080"}
+	for i, endpoint := range members[4].GetEndpoints() {
+		if endpoint != want[i] {
+			t.Fatalf("endpoints = %v, want %v ordered by name", members[4].GetEndpoints(), want)
+		}
+	}
+}
+
+func TestMembershipSpreadsMetadata(t *testing.T) {
+	network := NewMemoryNetwork()
+	seed := startMember(t, testConfig(network, "node-0"))
+
+	cfg := testConfig(network, "node-1", "node-0:7946")
+	cfg.Zone, cfg.Weight = "zone-b", 4
+	startMember(t, cfg)
+
+	// Observers join without an endpoint of their own
+	observer := testConfig(network, "proxy-0", "node-0:7946")
+	observer.Endpoint = ""
+	startMember(t, observer)
+
+	eventually(t, func() bool { return len(seed.Members()) == 3 }, "seed knows %d members, want 3", len(seed.Members()))
+	eventually(t, func() bool { return len(seed.GetEndpointDetails()) == 2 }, "seed has endpoints %v, want 2", seed.GetEndpoints())
+
+	details := seed.GetEndpointDetails()
+	if details[1].Name != "node-1" || details[1].Zone != "zone-b" || details[1].Weight != 4 {
+		t.Errorf("endpoint of node-1 = %+v, want zone-b with weight 4", details[1])
+	}
+}
+
+func TestMembershipDetectsCrash(t *testing.T) {
+	network := NewMemoryNetwork()
+	members := startCluster(t, network, 4)
+	for _, m := range members {
+		eventually(t, func() bool { return len(m.GetEndpoints()) == 4 }, "cluster did not converge")
+	}
+
+	// node-3 stops answering without leaving
+	network.SetDropped("node-3:7946", true)
+	for _, m := range members[:3] {
+		eventually(t, func() bool {
+			member, ok := stateOf(m, "node-3")
+			return ok && member.State == StateDead
+		}, "%s does not see the crashed member as dead", m.LocalMember().Name)
+		eventually(t, func() bool { return len(m.GetEndpoints()) == 3 },
+			"%s still routes to the crashed member: %v", m.LocalMember().Name, m.GetEndpoints())
+	}
+}
+
+func TestMembershipRefutesSuspicion(t *testing.T) {
+	network := NewMemoryNetwork()
+	members := make([]*Membership, 3)
+	for i := range members {
+		cfg := testConfig(network, fmt.Sprintf("node-%d", i), "node-0:7946")
+		cfg.SuspicionTimeout = 5 * time.Second
+		members[i] = startMember(t, cfg)
+	}
+	for _, m := range members {
+		eventually(t, func() bool { return len(m.GetEndpoints()) == 3 }, "cluster did not converge")
+	}
+	before := members[2].LocalMember().Incarnation
+
+	// node-2 misses probes long enough to be suspected, then recovers
+	network.SetDropped("node-2:7946", true)
+	eventually(t, func() bool {
+		member, _ := stateOf(members[0], "node-2")
+		return member.State == StateSuspect
+	}, "node-0 does not suspect the unreachable member")
+	network.SetDropped("node-2:7946", false)
+
+	for _, m := range members[:2] {
+		eventually(t, func() bool {
+			member, _ := stateOf(m, "node-2")
+			return member.State == StateAlive && member.Incarnation > before
+		}, "%s did not accept the refutation of node-2", m.LocalMember().Name)
+	}
+	if after := members[2].LocalMember().Incarnation; after <= before {
+		t.Errorf("incarnation of node-2 = %d after refuting, want more than %d", after, before)
+	}
+}
+
+func TestMembershipLeave(t *testing.T) {
+	network := NewMemoryNetwork()
+	members := make([]*Membership, 3)
+	for i := range members {
+		cfg := testConfig(network, fmt.Sprintf("node-%d", i), "node-0:7946")
+		// Far longer than the test waits: only the leave message can
+		// mark the member dead
+		cfg.SuspicionTimeout = time.Minute
+		members[i] = startMember(t, cfg)
+	}
+	for _, m := range members {
+		eventually(t, func() bool { return len(m.GetEndpoints()) == 3 }, "cluster did not converge")
+	}
+
+	members[2].Stop()
+	for _, m := range members[:2] {
+		eventually(t, func() bool {
+			member, _ := stateOf(m, "node-2")
+			return member.State == StateDead
+		}, "%s does not see the member that left as dead", m.LocalMember().Name)
+	}
+}
+
+func TestMembershipRejoinsAfterRestart(t *testing.T) {
+	network := NewMemoryNetwork()
+	members := startCluster(t, network, 3)
+	for _, m := range members {
+		eventually(t, func() bool { return len(m.GetEndpoints()) == 3 }, "cluster did not converge")
+	}
+
+	members[2].Stop()
+	eventually(t, func() bool { return len(members[0].GetEndpoints()) == 2 }, "member that left is still an endpoint")
+
+	// The same member comes back at the same address
+	time.Sleep(5 * time.Millisecond)
+	startMember(t, testConfig(network, "node-2", "node-0:7946"))
+	for _, m := range members[:2] {
+		eventually(t, func() bool {
+			member, _ := stateOf(m, "node-2")
+			return member.State == StateAlive && len(m.GetEndpoints()) == 3
+		}, "%s does not see the restarted member", m.LocalMember().Name)
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	network := NewMemoryNetwork()
+
+	if _, err := New(Config{Transport: network.NewTransport("a:7946")}); err == nil {
+		t.Error("New accepted a member without a name")
+	}
+	if _, err := New(Config{Name: "a"}); err == nil {
+		t.Error("New accepted a member without a transport")
+	}
+	if _, err := New(Config{Name: "a", Transport: network.NewTransport("a:7946"), Weight: -1}); err == nil {
+		t.Error("New accepted a negative weight")
+	}
+}
@@ -0,0 +1,286 @@
+package gossip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// MessageType identifies the kind of a gossip message.
+type MessageType uint8
+
+const (
+	// MessagePing probes a member; it answers with MessageAck
+	MessagePing MessageType = iota
+
+	// MessageAck answers a ping, possibly relayed for a ping-req
+	MessageAck
+
+	// MessagePingReq asks a member to ping Target on the sender's behalf
+	MessagePingReq
+
+	// MessageJoin announces a new member; it answers with MessageSync
+	MessageJoin
+
+	// MessageSync carries the full member list of the sender in Updates
+	MessageSync
+)
+
+// Message is the unit exchanged between members.
+type Message struct {
+	// Type is the kind of message
+	Type MessageType `json:"type"`
+
+	// From is the gossip address of the sender
+	From string `json:"from"`
+
+	// Seq matches acks to pings
+	Seq uint64 `json:"seq,omitempty"`
+
+	// Target is the address to probe (MessagePingReq only)
+	Target string `json:"target,omitempty"`
+
+	// Updates are member states piggybacked on the message, or the member
+	// list of a MessageSync
+	Updates []Member `json:"updates,omitempty"`
+}
+
+// Transport delivers messages between members.
+//
+// Delivery is unreliable: messages may be lost, duplicated or reordered,
+// which the protocol tolerates. Implementations must be safe for
+// concurrent use.
+type Transport interface {
+	// Addr returns the address other members reach this member at
+	Addr() string
+
+	// Send delivers a message to the member at an address
+	Send(to string, msg *Message) error
+
+	// Receive returns the channel of incoming messages; it is closed by Close
+	Receive() <-chan *Message
+
+	// Close releases the transport
+	Close() error
+}
+
+// maxPacketSize is the largest UDP payload accepted by UDPTransport.
+const maxPacketSize = 65507
+
+// UDPTransport sends messages as JSON datagrams over UDP.
+type UDPTransport struct {
+	conn      *net.UDPConn
+	advertise string
+	messages  chan *Message
+	closeOnce sync.Once
+}
+
+// NewUDPTransport listens for gossip messages on a UDP address.
+//
+// Parameters:
+//   - bindAddr: Local address to listen on (e.g., ":7946")
+//   - advertise: Address other members use to reach this one (e.g.,
+//     "10.0.0.5:7946"); empty selects the listening address, which is only
+//     useful when bindAddr names a specific IP
+//
+// Returns:
+//   - *UDPTransport: The transport, already receiving
+//   - error: Error if the address cannot be bound
+func NewUDPTransport(bindAddr, advertise string) (*UDPTransport, error) {
+	addr, err := net.ResolveUDPAddr("udp", bindAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gossip bind address %s: %w", bindAddr, err)
+	}
+
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", bindAddr, err)
+	}
+
+	if advertise == "" {
+		advertise = conn.LocalAddr().String()
+	}
+
+	t := &UDPTransport{
+		conn:      conn,
+		advertise: advertise,
+		messages:  make(chan *Message, 256),
+	}
+	go t.read()
+	return t, nil
+}
+
+// Addr returns the advertised address.
+func (t *UDPTransport) Addr() string {
+	return t.advertise
+}
+
+// Send encodes a message and sends it as one datagram.
+func (t *UDPTransport) Send(to string, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if len(data) > maxPacketSize {
+		return fmt.Errorf("message of %d bytes exceeds the UDP limit", len(data))
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", to)
+	if err != nil {
+		return fmt.Errorf("invalid member address %s: %w", to, err)
+	}
+
+	_, err = t.conn.WriteToUDP(data, addr)
+	return err
+}
+
+// Receive returns the channel of incoming messages.
+func (t *UDPTransport) Receive() <-chan *Message {
+	return t.messages
+}
+
+// Close stops listening; the Receive channel is closed once reading stops.
+func (t *UDPTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() { err = t.conn.Close() })
+	return err
+}
+
+// read decodes incoming datagrams until the connection is closed.
+// Malformed datagrams are dropped, as are messages that arrive while the
+// receive buffer is full.
+func (t *UDPTransport) read() {
+	defer close(t.messages)
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := t.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		var msg Message
+		if err := json.Unmarshal(buf[:n], &msg); err != nil {
+			continue
+		}
+
+		select {
+		case t.messages <- &msg:
+		default:
+		}
+	}
+}
+
+// MemoryNetwork connects in-process transports, for tests.
+//
+// Messages are delivered asynchronously through buffered channels. Members
+// can be cut off with SetDropped to simulate crashes and partitions.
+type MemoryNetwork struct {
+	mu         sync.RWMutex
+	transports map[string]*MemoryTransport
+	dropped    map[string]bool
+}
+
+// NewMemoryNetwork creates an empty in-process network.
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{
+		transports: make(map[string]*MemoryTransport),
+		dropped:    make(map[string]bool),
+	}
+}
+
+// NewTransport attaches a transport at an address, replacing any previous
+// transport at the same address.
+func (n *MemoryNetwork) NewTransport(addr string) *MemoryTransport {
+	t := &MemoryTransport{
+		network:  n,
+		addr:     addr,
+		messages: make(chan *Message, 1024),
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.transports[addr] = t
+	return t
+}
+
+// SetDropped controls whether messages to and from an address are
+// silently discarded.
+func (n *MemoryNetwork) SetDropped(addr string, dropped bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dropped[addr] = dropped
+}
+
+// deliver passes a copy of a message to the transport at an address.
+func (n *MemoryNetwork) deliver(from, to string, msg *Message) error {
+	n.mu.RLock()
+	t, ok := n.transports[to]
+	dropped := n.dropped[from] || n.dropped[to]
+	n.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("no member at %s", to)
+	}
+	if dropped {
+		return nil
+	}
+
+	// Receivers must not share the sender's slices
+	copied := *msg
+	copied.Updates = append([]Member(nil), msg.Updates...)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return fmt.Errorf("no member at %s", to)
+	}
+	select {
+	case t.messages <- &copied:
+	default:
+	}
+	return nil
+}
+
+// MemoryTransport is a Transport on a MemoryNetwork.
+type MemoryTransport struct {
+	network  *MemoryNetwork
+	addr     string
+	messages chan *Message
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Addr returns the transport's address on the network.
+func (t *MemoryTransport) Addr() string {
+	return t.addr
+}
+
+// Send delivers a message to the transport at an address.
+func (t *MemoryTransport) Send(to string, msg *Message) error {
+	return t.network.deliver(t.addr, to, msg)
+}
+
+// Receive returns the channel of incoming messages.
+func (t *MemoryTransport) Receive() <-chan *Message {
+	return t.messages
+}
+
+// Close detaches the transport from the network.
+func (t *MemoryTransport) Close() error {
+	t.network.mu.Lock()
+	if t.network.transports[t.addr] == t {
+		delete(t.network.transports, t.addr)
+	}
+	t.network.mu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.messages)
+	}
+	return nil
+}
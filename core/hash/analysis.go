@@ -0,0 +1,122 @@
+package hash
+
+import (
+	"math"
+	"sort"
+)
+
+// DistributionStats summarizes how a set of keys is spread across nodes.
+type DistributionStats struct {
+	// Counts maps each node to the number of keys it owns
+	Counts map[string]int
+
+	// Keys is the total number of keys measured
+	Keys int
+
+	// Min and Max are the smallest and largest per-node key counts
+	Min int
+	Max int
+
+	// StdDev is the standard deviation of each node's actual/expected key
+	// count ratio, where the expected count follows the node's weight
+	// (0.0 means perfectly balanced)
+	StdDev float64
+
+	// MaxOverload is the largest ratio of a node's actual key count to its
+	// weighted expected key count (1.0 means perfectly balanced)
+	MaxOverload float64
+}
+
+// MeasureDistribution places every key and reports how evenly the keys are
+// spread, taking node weights into account.
+//
+// Parameters:
+//   - p: Placement to measure
+//   - keys: Sample keys, ideally resembling production key patterns
+//
+// Returns:
+//   - DistributionStats: Per-node counts and balance metrics
+//
+// Example:
+//
+//	stats := hash.MeasureDistribution(ring, keys)
+//	fmt.Printf("max overload: %.3f\n", stats.MaxOverload)
+func MeasureDistribution(p Placement, keys []string) DistributionStats {
+	stats := DistributionStats{
+		Counts: make(map[string]int),
+		Keys:   len(keys),
+	}
+
+	nodes := p.Nodes()
+	for _, node := range nodes {
+		stats.Counts[node] = 0
+	}
+	for _, key := range keys {
+		if node := p.GetNode(key); node != "" {
+			stats.Counts[node]++
+		}
+	}
+
+	if len(nodes) == 0 || len(keys) == 0 {
+		return stats
+	}
+
+	totalWeight := 0
+	for _, node := range nodes {
+		totalWeight += p.Weight(node)
+	}
+
+	stats.Min = math.MaxInt
+	var sumSquares float64
+	for _, node := range nodes {
+		count := stats.Counts[node]
+		stats.Min = min(stats.Min, count)
+		stats.Max = max(stats.Max, count)
+
+		expected := float64(len(keys)) * float64(p.Weight(node)) / float64(totalWeight)
+		ratio := float64(count) / expected
+		stats.MaxOverload = math.Max(stats.MaxOverload, ratio)
+		sumSquares += (ratio - 1) * (ratio - 1)
+	}
+	stats.StdDev = math.Sqrt(sumSquares / float64(len(nodes)))
+
+	return stats
+}
+
+// RemapFraction returns the fraction of keys whose owner differs between
+// two placements, typically the same placement before and after a
+// membership change.
+//
+// The ideal value when adding one node to N is 1/(N+1); consistent
+// algorithms stay close to it, while modulo hashing moves almost all keys.
+//
+// Example:
+//
+//	before, _ := hash.NewPlacement(cfg)
+//	after, _ := hash.NewPlacement(cfg)
+//	// ... add the same nodes to both, plus one extra node to after
+//	fmt.Printf("moved: %.1f%%\n", 100*hash.RemapFraction(before, after, keys))
+func RemapFraction(before, after Placement, keys []string) float64 {
+	if len(keys) == 0 {
+		return 0
+	}
+
+	moved := 0
+	for _, key := range keys {
+		if before.GetNode(key) != after.GetNode(key) {
+			moved++
+		}
+	}
+	return float64(moved) / float64(len(keys))
+}
+
+// SortedNodes returns the nodes of a distribution ordered by name,
+// for stable reporting.
+func (s DistributionStats) SortedNodes() []string {
+	nodes := make([]string, 0, len(s.Counts))
+	for node := range s.Counts {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
@@ -0,0 +1,192 @@
+package hash
+
+import "math"
+
+// LoadAware is implemented by placements that support consistent hashing
+// with bounded loads.
+type LoadAware interface {
+	// SetLoadBound enables bounded loads with the given epsilon.
+	// An epsilon <= 0 disables bounded loads.
+	SetLoadBound(epsilon float64)
+
+	// SetLoads replaces the load figures of all nodes.
+	SetLoads(loads map[string]float64)
+
+	// Owner returns the node that owns key regardless of load. GetNode
+	// returns the same node unless the owner is above its load bound.
+	Owner(key string) string
+
+	// GetNodeBounded returns the first of the key's n replicas that is
+	// within its load bound, or the primary if none is.
+	GetNodeBounded(key string, n int) string
+}
+
+// SetLoadBound enables consistent hashing with bounded loads
+// (Mirrokni, Thorup, Zadimoghaddam 2016).
+//
+// With bounded loads, GetNode walks clockwise from the key's position and
+// returns the first node whose load, including the request being placed,
+// does not exceed
+//
+//	ceil((1+epsilon) * (totalLoad+1) * weight / totalWeight)
+//
+// so no node carries more than (1+epsilon) times its weighted share of the
+// load while another node has room. GetNodeBounded applies the same bound
+// but only considers the key's first n replicas. Owner and GetNodes are not
+// affected: ownership and replica sets never depend on load.
+//
+// Load figures are supplied by the caller with SetLoads or AddLoad.
+//
+// Parameters:
+//   - epsilon: Allowed overload above the average. Smaller values balance
+//     more tightly but move more reads off the primary. Typical values
+//     are 0.1 to 0.5. Values <= 0 disable bounded loads.
+//
+// Thread-safety: Safe for concurrent calls
+//
+// Example:
+//
+//	ring.SetLoadBound(0.25) // no node above 125% of its share
+//	ring.SetLoads(map[string]float64{"node-0:8080": 1200, "node-1:8080": 300})
+func (r *Ring) SetLoadBound(epsilon float64) {
+	if epsilon < 0 || math.IsNaN(epsilon) {
+		epsilon = 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loadBound = epsilon
+}
+
+// LoadBound returns the bounded-load epsilon, or 0 if bounded loads are disabled.
+func (r *Ring) LoadBound() float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.loadBound
+}
+
+// SetLoads replaces the load figures of all nodes.
+//
+// Nodes missing from loads are treated as idle, and entries for nodes that
+// are not in the ring are ignored. Negative loads are treated as zero.
+//
+// Parameters:
+//   - loads: Current load per node, in any unit (for example requests per second)
+//
+// Thread-safety: Safe for concurrent calls
+func (r *Ring) SetLoads(loads map[string]float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.loads = make(map[string]float64, len(r.nodes))
+	r.totalLoad = 0
+	for _, node := range r.nodes {
+		if load := loads[node]; load > 0 {
+			r.loads[node] = load
+			r.totalLoad += load
+		}
+	}
+}
+
+// AddLoad adjusts the load of a single node by delta.
+//
+// This suits callers that account for assignments one at a time, such as
+// simulations placing keys in sequence. The load never drops below zero.
+//
+// Thread-safety: Safe for concurrent calls
+func (r *Ring) AddLoad(node string, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.weights[node]; !exists {
+		return
+	}
+
+	load := math.Max(r.loads[node]+delta, 0)
+	r.totalLoad += load - r.loads[node]
+	r.loads[node] = load
+}
+
+// Load returns the current load of a node, or 0 if unknown.
+func (r *Ring) Load(node string) float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.loads[node]
+}
+
+// GetNodeBounded returns the node that should serve a read of key among
+// its first n replicas (see GetNodes).
+//
+// The replicas are tried in GetNodes order and the first one within its
+// load bound is returned. If every replica is at its bound, or bounded
+// loads are disabled, the primary (Owner) is returned. Since only nodes
+// holding a copy of the key are candidates, the result is safe to read
+// from whenever all n replicas are written.
+//
+// Parameters:
+//   - key: The cache key to look up
+//   - n: Number of replicas the key is stored on. Capped at the ring size;
+//     n <= 1 always returns the primary.
+//
+// Returns:
+//   - string: A node from GetNodes(key, n), or empty string if the ring is empty
+//
+// Thread-safety: Safe for concurrent calls (read lock only)
+//
+// Example:
+//
+//	ring.SetLoadBound(0.25)
+//	node := ring.GetNodeBounded("user:12345", 3) // one of the 3 replicas
+func (r *Ring) GetNodeBounded(key string, n int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return ""
+	}
+
+	start := r.search(r.hashKey(key))
+	if r.loadBound <= 0 || n <= 1 {
+		return r.ring[start].node
+	}
+	return r.boundedNode(start, min(n, len(r.nodes)))
+}
+
+// boundedNode walks clockwise from ring index start over the first n
+// distinct nodes and returns the first one that is within its load bound.
+// If every one of them is at its bound, the owner is returned. The caller
+// must hold the read lock.
+func (r *Ring) boundedNode(start, n int) string {
+	owner := r.ring[start].node
+	if r.withinBound(owner) {
+		return owner
+	}
+
+	checked := map[string]bool{owner: true}
+	for i := 1; i < len(r.ring) && len(checked) < n; i++ {
+		node := r.ring[(start+i)%len(r.ring)].node
+		if checked[node] {
+			continue
+		}
+		checked[node] = true
+
+		if r.withinBound(node) {
+			return node
+		}
+	}
+
+	return owner
+}
+
+// withinBound reports whether placing one more unit of load on node keeps
+// it within its bounded-load capacity. The caller must hold the read lock.
+func (r *Ring) withinBound(node string) bool {
+	totalWeight := 0
+	for _, w := range r.weights {
+		totalWeight += w
+	}
+
+	share := float64(r.weights[node]) / float64(totalWeight)
+	capacity := math.Ceil((1 + r.loadBound) * (r.totalLoad + 1) * share)
+	return r.loads[node]+1 <= capacity
+}
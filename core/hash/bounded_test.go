@@ -0,0 +1,222 @@
+package hash
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// placeZipf places Zipf-distributed requests with GetNodeBounded over n
+// replicas, adding one unit of load per request, and returns the number of
+// requests placed on each node.
+func placeZipf(ring *Ring, requests, n int) map[string]float64 {
+	keys := testKeys(10000)
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, uint64(len(keys)-1))
+
+	placed := make(map[string]float64)
+	for i := 0; i < requests; i++ {
+		node := ring.GetNodeBounded(keys[zipf.Uint64()], n)
+		ring.AddLoad(node, 1)
+		placed[node]++
+	}
+	return placed
+}
+
+func TestGetNodeBoundedMaxLoad(t *testing.T) {
+	for _, epsilon := range []float64{0.1, 0.25, 0.5} {
+		t.Run(fmt.Sprintf("epsilon=%g", epsilon), func(t *testing.T) {
+			ring := newTestRing(10)
+			ring.SetLoadBound(epsilon)
+
+			const requests = 100000
+			placed := placeZipf(ring, requests, ring.Size())
+
+			avg := float64(requests) / float64(ring.Size())
+			limit := math.Ceil((1 + epsilon) * avg)
+			for node, load := range placed {
+				if load > limit {
+					t.Errorf("node %s carries %.0f requests, bound is %.0f (max/avg %.3f > %.3f)",
+						node, load, limit, load/avg, 1+epsilon)
+				}
+			}
+		})
+	}
+}
+
+func TestGetNodeBoundedWeightedMaxLoad(t *testing.T) {
+	ring := NewRing(150)
+	ring.AddWeightedNode("big:8080", 300)
+	for i := 0; i < 4; i++ {
+		ring.AddNode(fmt.Sprintf("cache-%d:8080", i))
+	}
+	ring.SetLoadBound(0.25)
+
+	const requests = 50000
+	placed := placeZipf(ring, requests, ring.Size())
+
+	totalWeight := 300 + 4*DefaultWeight
+	for node, load := range placed {
+		share := float64(ring.Weight(node)) / float64(totalWeight)
+		if limit := math.Ceil(1.25 * requests * share); load > limit {
+			t.Errorf("node %s (weight %d) carries %.0f requests, bound is %.0f",
+				node, ring.Weight(node), load, limit)
+		}
+	}
+}
+
+func TestGetNodeBoundedUnboundedSkew(t *testing.T) {
+	ring := newTestRing(10)
+
+	const requests = 100000
+	placed := placeZipf(ring, requests, ring.Size())
+
+	// Without a bound the hottest keys pile up on their owners, which is
+	// what the bounded tests above guard against
+	avg := float64(requests) / float64(ring.Size())
+	maxLoad := 0.0
+	for _, load := range placed {
+		maxLoad = math.Max(maxLoad, load)
+	}
+	if maxLoad/avg <= 1.5 {
+		t.Errorf("unbounded max/avg = %.3f, expected the Zipf sample to overload a node", maxLoad/avg)
+	}
+}
+
+func TestGetNodeBoundedStaysInReplicaSet(t *testing.T) {
+	ring := newTestRing(6)
+	ring.SetLoadBound(0.1)
+
+	for _, key := range testKeys(2000) {
+		node := ring.GetNodeBounded(key, 3)
+		if !contains(ring.GetNodes(key, 3), node) {
+			t.Fatalf("GetNodeBounded(%q, 3) = %s is not one of %v", key, node, ring.GetNodes(key, 3))
+		}
+		ring.AddLoad(node, 1)
+	}
+}
+
+func TestGetNodeBoundedFallsBackToPrimary(t *testing.T) {
+	ring := newTestRing(4)
+	ring.SetLoadBound(0.25)
+
+	key := "hot-key"
+	replicas := ring.GetNodes(key, 2)
+
+	// Both replicas far above their share, the other nodes idle
+	ring.SetLoads(map[string]float64{replicas[0]: 1000, replicas[1]: 1000})
+
+	if got := ring.GetNodeBounded(key, 2); got != replicas[0] {
+		t.Errorf("GetNodeBounded with all replicas overloaded = %s, want primary %s", got, replicas[0])
+	}
+	if got := ring.GetNodeBounded(key, 1); got != replicas[0] {
+		t.Errorf("GetNodeBounded(key, 1) = %s, want primary %s", got, replicas[0])
+	}
+
+	// Only the primary overloaded: the backup serves
+	ring.SetLoads(map[string]float64{replicas[0]: 1000})
+	if got := ring.GetNodeBounded(key, 2); got != replicas[1] {
+		t.Errorf("GetNodeBounded with overloaded primary = %s, want backup %s", got, replicas[1])
+	}
+}
+
+func TestOwnerIgnoresLoads(t *testing.T) {
+	ring := newTestRing(5)
+	keys := testKeys(1000)
+
+	owners := make(map[string]string, len(keys))
+	replicas := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		owners[key] = ring.GetNode(key)
+		replicas[key] = ring.GetNodes(key, 3)
+	}
+
+	ring.SetLoadBound(0.1)
+	ring.SetLoads(map[string]float64{"cache-0:8080": 1e6, "cache-1:8080": 1e6})
+
+	for _, key := range keys {
+		if got := ring.Owner(key); got != owners[key] {
+			t.Fatalf("Owner(%q) changed with load: %s -> %s", key, owners[key], got)
+		}
+		if got := ring.GetNodes(key, 3); changedMembers(replicas[key], got) != 0 || got[0] != replicas[key][0] {
+			t.Fatalf("GetNodes(%q, 3) changed with load: %v -> %v", key, replicas[key], got)
+		}
+	}
+}
+
+// placeZipfPrimary places Zipf-distributed requests with GetNode, adding
+// one unit of load per request, and returns the number of requests placed
+// on each node.
+func placeZipfPrimary(ring *Ring, requests int) map[string]float64 {
+	keys := testKeys(10000)
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, uint64(len(keys)-1))
+
+	placed := make(map[string]float64)
+	for i := 0; i < requests; i++ {
+		node := ring.GetNode(keys[zipf.Uint64()])
+		ring.AddLoad(node, 1)
+		placed[node]++
+	}
+	return placed
+}
+
+func TestGetNodeMaxLoad(t *testing.T) {
+	for _, epsilon := range []float64{0.1, 0.25, 0.5} {
+		t.Run(fmt.Sprintf("epsilon=%g", epsilon), func(t *testing.T) {
+			ring := newTestRing(10)
+			ring.SetLoadBound(epsilon)
+
+			const requests = 100000
+			placed := placeZipfPrimary(ring, requests)
+
+			avg := float64(requests) / float64(ring.Size())
+			limit := math.Ceil((1 + epsilon) * avg)
+			for node, load := range placed {
+				if load > limit {
+					t.Errorf("node %s carries %.0f requests, bound is %.0f (max/avg %.3f > %.3f)",
+						node, load, limit, load/avg, 1+epsilon)
+				}
+			}
+		})
+	}
+}
+
+func TestGetNodeSkipsOverloadedOwner(t *testing.T) {
+	ring := newTestRing(4)
+	ring.SetLoadBound(0.25)
+
+	key := "hot-key"
+	owner := ring.Owner(key)
+	next := ring.GetNodes(key, 2)[1]
+
+	if got := ring.GetNode(key); got != owner {
+		t.Errorf("GetNode without load = %s, want owner %s", got, owner)
+	}
+
+	ring.SetLoads(map[string]float64{owner: 1000})
+	if got := ring.GetNode(key); got != next {
+		t.Errorf("GetNode with overloaded owner = %s, want next node %s", got, next)
+	}
+	if got := ring.Owner(key); got != owner {
+		t.Errorf("Owner with overloaded owner = %s, want %s", got, owner)
+	}
+
+	ring.SetLoadBound(0)
+	if got := ring.GetNode(key); got != owner {
+		t.Errorf("GetNode with bound disabled = %s, want owner %s", got, owner)
+	}
+}
+
+func TestGetNodeBoundedDisabled(t *testing.T) {
+	ring := newTestRing(5)
+	ring.SetLoads(map[string]float64{"cache-0:8080": 1e6})
+
+	for _, key := range testKeys(500) {
+		if got, want := ring.GetNodeBounded(key, 3), ring.GetNode(key); got != want {
+			t.Fatalf("GetNodeBounded(%q) without a bound = %s, want owner %s", key, got, want)
+		}
+	}
+	if got := NewRing(150).GetNodeBounded("key", 3); got != "" {
+		t.Errorf("GetNodeBounded on empty ring = %q, want empty", got)
+	}
+}
@@ -0,0 +1,298 @@
+package hash
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// HashRange is an inclusive range [Start, End] of the 64-bit hash space.
+type HashRange struct {
+	Start uint64
+	End   uint64
+}
+
+// Fraction returns the share of the 64-bit hash space covered by the range.
+func (h HashRange) Fraction() float64 {
+	return (float64(h.End-h.Start) + 1) / (math.MaxUint64 + 1.0)
+}
+
+// Contains reports whether a hash value falls inside the range.
+func (h HashRange) Contains(hash uint64) bool {
+	return hash >= h.Start && hash <= h.End
+}
+
+// OwnedRange is a hash range together with the node that owns it.
+type OwnedRange struct {
+	HashRange
+	Node string
+}
+
+// ReplicaRange is a hash range together with its replica nodes in
+// preference order, as returned by GetNodes for keys in the range.
+type ReplicaRange struct {
+	HashRange
+	Nodes []string
+}
+
+// RangeMove is a hash range whose owner changes between two ring states.
+type RangeMove struct {
+	HashRange
+
+	// From is the owner before the change ("" if the old ring was empty)
+	From string
+
+	// To is the owner after the change ("" if the new ring is empty)
+	To string
+}
+
+// Transfer aggregates all moves between one pair of nodes.
+type Transfer struct {
+	From string
+	To   string
+
+	// Fraction is the share of the hash space moving from From to To
+	Fraction float64
+}
+
+// OwnershipDiff describes how key ownership changes between two ring states.
+type OwnershipDiff struct {
+	// Moves lists every hash range that changes owner, in hash order.
+	// Adjacent ranges with the same old and new owner are merged.
+	Moves []RangeMove
+
+	// Transfers aggregates Moves per (From, To) pair, largest first
+	Transfers []Transfer
+
+	// MovedFraction is the share of the hash space that changes owner.
+	// With uniformly hashed keys it estimates the fraction of keys to move.
+	MovedFraction float64
+}
+
+// Clone returns an independent copy of the ring, including weights, zones,
+// load bound and loads. It is typically used to build a proposed ring state
+// for Diff without touching the live ring.
+//
+// Example:
+//
+//	proposed := ring.Clone()
+//	proposed.AddNode("cache-node-4:8080")
+//	diff := ring.Diff(proposed)
+func (r *Ring) Clone() *Ring {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clone := &Ring{
+		nodes:        append([]string(nil), r.nodes...),
+		virtualNodes: r.virtualNodes,
+		weights:      make(map[string]int, len(r.weights)),
+		zones:        make(map[string]string, len(r.zones)),
+		ring:         append([]virtualNode(nil), r.ring...),
+		hashFn:       r.hashFn,
+		loadBound:    r.loadBound,
+		loads:        make(map[string]float64, len(r.loads)),
+		totalLoad:    r.totalLoad,
+	}
+	for node, weight := range r.weights {
+		clone.weights[node] = weight
+	}
+	for node, zone := range r.zones {
+		clone.zones[node] = zone
+	}
+	for node, load := range r.loads {
+		clone.loads[node] = load
+	}
+	return clone
+}
+
+// Ranges returns the hash ranges owned by each node, in hash order.
+//
+// Every virtual node owns the range from just after its predecessor up to
+// and including its own position. The range that wraps past the top of the
+// hash space is returned as two ranges, one at each end.
+//
+// Returns:
+//   - []OwnedRange: Ranges covering the whole hash space, or nil if empty
+func (r *Ring) Ranges() []OwnedRange {
+	r.mu.RLock()
+	points := append([]virtualNode(nil), r.ring...)
+	r.mu.RUnlock()
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	ranges := make([]OwnedRange, 0, len(points)+1)
+	ranges = append(ranges, OwnedRange{HashRange{0, points[0].hash}, points[0].node})
+	for i := 1; i < len(points); i++ {
+		if points[i].hash == points[i-1].hash {
+			// Colliding virtual node; the first one in sort order owns the point
+			continue
+		}
+		ranges = append(ranges, OwnedRange{HashRange{points[i-1].hash + 1, points[i].hash}, points[i].node})
+	}
+	if last := points[len(points)-1].hash; last < math.MaxUint64 {
+		ranges = append(ranges, OwnedRange{HashRange{last + 1, math.MaxUint64}, points[0].node})
+	}
+	return ranges
+}
+
+// Diff computes which hash ranges change owner when moving from this ring
+// state to next.
+//
+// Both rings must use the same hash function. The result is exact for the
+// hash space; MovedFraction estimates the fraction of keys that move.
+//
+// Parameters:
+//   - next: The proposed or new ring state
+//
+// Returns:
+//   - *OwnershipDiff: Moved ranges, per-node transfers and moved fraction
+//
+// Example:
+//
+//	proposed := ring.Clone()
+//	proposed.AddNode("cache-node-4:8080")
+//	diff := ring.Diff(proposed)
+//	fmt.Printf("%.1f%% of keys move\n", diff.MovedFraction*100)
+//	for _, t := range diff.Transfers {
+//	    fmt.Printf("%s -> %s: %.1f%%\n", t.From, t.To, t.Fraction*100)
+//	}
+func (r *Ring) Diff(next *Ring) *OwnershipDiff {
+	before := r.Ranges()
+	after := next.Ranges()
+
+	diff := &OwnershipDiff{}
+
+	// Sweep both range lists; every output segment lies within exactly one
+	// range of each ring
+	var start uint64
+	i, j := 0, 0
+	for {
+		from, to := "", ""
+		end := uint64(math.MaxUint64)
+		if i < len(before) {
+			from = before[i].Node
+			end = before[i].End
+		}
+		if j < len(after) {
+			to = after[j].Node
+			end = min(end, after[j].End)
+		}
+
+		if from != to {
+			diff.addMove(RangeMove{HashRange{start, end}, from, to})
+		}
+
+		if end == math.MaxUint64 {
+			break
+		}
+		if i < len(before) && before[i].End == end {
+			i++
+		}
+		if j < len(after) && after[j].End == end {
+			j++
+		}
+		start = end + 1
+	}
+
+	transfers := make(map[[2]string]float64)
+	for _, move := range diff.Moves {
+		fraction := move.Fraction()
+		diff.MovedFraction += fraction
+		transfers[[2]string{move.From, move.To}] += fraction
+	}
+	for pair, fraction := range transfers {
+		diff.Transfers = append(diff.Transfers, Transfer{From: pair[0], To: pair[1], Fraction: fraction})
+	}
+	sort.Slice(diff.Transfers, func(a, b int) bool {
+		if diff.Transfers[a].Fraction != diff.Transfers[b].Fraction {
+			return diff.Transfers[a].Fraction > diff.Transfers[b].Fraction
+		}
+		if diff.Transfers[a].From != diff.Transfers[b].From {
+			return diff.Transfers[a].From < diff.Transfers[b].From
+		}
+		return diff.Transfers[a].To < diff.Transfers[b].To
+	})
+
+	return diff
+}
+
+// addMove appends a move, merging it into the previous one when they are
+// adjacent and have the same owners.
+func (d *OwnershipDiff) addMove(move RangeMove) {
+	if n := len(d.Moves); n > 0 {
+		last := &d.Moves[n-1]
+		if last.From == move.From && last.To == move.To && last.End+1 == move.Start {
+			last.End = move.End
+			return
+		}
+	}
+	d.Moves = append(d.Moves, move)
+}
+
+// KeyHash returns the ring position of a key, for matching keys against
+// the hash ranges of Ranges and Diff.
+func (r *Ring) KeyHash(key string) uint64 {
+	return r.hashKey(key)
+}
+
+// ReplicaRanges partitions the hash space by replica set: every key in a
+// range is replicated on the same n nodes (see GetNodes). Adjacent ranges
+// with identical replica sets are merged.
+//
+// Parameters:
+//   - n: Number of replicas per key (capped at the number of nodes)
+//
+// Returns:
+//   - []ReplicaRange: Ranges covering the whole hash space, in hash order
+//
+// Example:
+//
+//	for _, rr := range ring.ReplicaRanges(3) {
+//	    fmt.Printf("[%d, %d] -> %v\n", rr.Start, rr.End, rr.Nodes)
+//	}
+func (r *Ring) ReplicaRanges(n int) []ReplicaRange {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 || n <= 0 {
+		return nil
+	}
+	n = min(n, len(r.nodes))
+
+	// replicasAt returns the first n distinct nodes clockwise from index i
+	replicasAt := func(i int) []string {
+		result := make([]string, 0, n)
+		for j := 0; j < len(r.ring) && len(result) < n; j++ {
+			node := r.ring[(i+j)%len(r.ring)].node
+			if !slices.Contains(result, node) {
+				result = append(result, node)
+			}
+		}
+		return result
+	}
+
+	var ranges []ReplicaRange
+	add := func(start, end uint64, nodes []string) {
+		if last := len(ranges) - 1; last >= 0 && ranges[last].End+1 == start && slices.Equal(ranges[last].Nodes, nodes) {
+			ranges[last].End = end
+			return
+		}
+		ranges = append(ranges, ReplicaRange{HashRange{start, end}, nodes})
+	}
+
+	first := replicasAt(0)
+	add(0, r.ring[0].hash, first)
+	for i := 1; i < len(r.ring); i++ {
+		if r.ring[i].hash == r.ring[i-1].hash {
+			// Colliding virtual node; the first one in sort order owns the point
+			continue
+		}
+		add(r.ring[i-1].hash+1, r.ring[i].hash, replicasAt(i))
+	}
+	if last := r.ring[len(r.ring)-1].hash; last < math.MaxUint64 {
+		add(last+1, math.MaxUint64, first)
+	}
+	return ranges
+}
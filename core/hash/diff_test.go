@@ -0,0 +1,273 @@
+package hash
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+)
+
+// pinnedHash returns a hash function that places the given virtual nodes
+// and keys at fixed positions and hashes everything else with XXHash.
+func pinnedHash(positions map[string]uint64) HashFunc {
+	return func(data []byte) uint64 {
+		if pos, ok := positions[string(data)]; ok {
+			return pos
+		}
+		return XXHash(data)
+	}
+}
+
+// newPinnedRing returns a ring with one virtual node per unit of weight and
+// nodes a, b and c at positions 100, 200 and 300.
+func newPinnedRing() *Ring {
+	ring := NewRingWithHash(1, pinnedHash(map[string]uint64{
+		"a#0": 100,
+		"a#1": 150,
+		"b#0": 200,
+		"c#0": 300,
+		"d#0": 250,
+		"e#0": 1000,
+	}))
+	ring.AddNode("a")
+	ring.AddNode("b")
+	ring.AddNode("c")
+	return ring
+}
+
+// findRange returns the index of the range containing hash.
+func findRange[T interface{ Contains(uint64) bool }](ranges []T, hash uint64) int {
+	for i, r := range ranges {
+		if r.Contains(hash) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRanges(t *testing.T) {
+	want := []OwnedRange{
+		{HashRange{0, 100}, "a"},
+		{HashRange{101, 200}, "b"},
+		{HashRange{201, 300}, "c"},
+		{HashRange{301, math.MaxUint64}, "a"}, // wraps around to the first node
+	}
+	if got := newPinnedRing().Ranges(); !slices.Equal(got, want) {
+		t.Errorf("Ranges() = %v, want %v", got, want)
+	}
+
+	if got := NewRing(150).Ranges(); got != nil {
+		t.Errorf("Ranges() on empty ring = %v, want nil", got)
+	}
+}
+
+func TestRangesMatchOwner(t *testing.T) {
+	ring := newTestRing(5)
+	ranges := ring.Ranges()
+
+	if ranges[0].Start != 0 || ranges[len(ranges)-1].End != math.MaxUint64 {
+		t.Fatalf("ranges cover [%d, %d], want the whole hash space", ranges[0].Start, ranges[len(ranges)-1].End)
+	}
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Start != ranges[i-1].End+1 {
+			t.Fatalf("range %d starts at %d, previous ends at %d", i, ranges[i].Start, ranges[i-1].End)
+		}
+	}
+
+	for _, key := range testKeys(2000) {
+		i := findRange(ranges, ring.KeyHash(key))
+		if i < 0 {
+			t.Fatalf("no range contains %q", key)
+		}
+		if ranges[i].Node != ring.Owner(key) {
+			t.Fatalf("range of %q is owned by %s, want owner %s", key, ranges[i].Node, ring.Owner(key))
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(r *Ring)
+		want   []RangeMove
+	}{
+		{
+			name:   "no change",
+			change: func(r *Ring) {},
+			want:   nil,
+		},
+		{
+			name:   "add node",
+			change: func(r *Ring) { r.AddNode("d") },
+			want:   []RangeMove{{HashRange{201, 250}, "c", "d"}},
+		},
+		{
+			name:   "add node in wrap-around range",
+			change: func(r *Ring) { r.AddNode("e") },
+			want:   []RangeMove{{HashRange{301, 1000}, "a", "e"}},
+		},
+		{
+			name:   "remove node",
+			change: func(r *Ring) { r.RemoveNode("b") },
+			want:   []RangeMove{{HashRange{101, 200}, "b", "c"}},
+		},
+		{
+			name:   "remove node owning wrap-around range",
+			change: func(r *Ring) { r.RemoveNode("a") },
+			want: []RangeMove{
+				{HashRange{0, 100}, "a", "b"},
+				{HashRange{301, math.MaxUint64}, "a", "b"},
+			},
+		},
+		{
+			name:   "increase weight",
+			change: func(r *Ring) { r.UpdateWeight("a", 2) },
+			want:   []RangeMove{{HashRange{101, 150}, "b", "a"}},
+		},
+		{
+			name:   "remove last node",
+			change: func(r *Ring) { r.RemoveNode("a"); r.RemoveNode("b"); r.RemoveNode("c") },
+			want: []RangeMove{
+				{HashRange{0, 100}, "a", ""},
+				{HashRange{101, 200}, "b", ""},
+				{HashRange{201, 300}, "c", ""},
+				{HashRange{301, math.MaxUint64}, "a", ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := newPinnedRing()
+			after := before.Clone()
+			tt.change(after)
+
+			diff := before.Diff(after)
+			if !slices.Equal(diff.Moves, tt.want) {
+				t.Fatalf("Moves = %v, want %v", diff.Moves, tt.want)
+			}
+
+			var moved, transferred float64
+			for _, move := range tt.want {
+				moved += move.Fraction()
+			}
+			for _, transfer := range diff.Transfers {
+				transferred += transfer.Fraction
+			}
+			if diff.MovedFraction != moved || math.Abs(transferred-moved) > 1e-12 {
+				t.Errorf("MovedFraction = %g, transfers sum to %g, want %g", diff.MovedFraction, transferred, moved)
+			}
+		})
+	}
+}
+
+func TestDiffMatchesOwners(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(r *Ring)
+	}{
+		{"add node", func(r *Ring) { r.AddNode("cache-5:8080") }},
+		{"remove node", func(r *Ring) { r.RemoveNode("cache-2:8080") }},
+		{"weight change", func(r *Ring) { r.UpdateWeight("cache-1:8080", 3) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := newTestRing(5)
+			after := before.Clone()
+			tt.change(after)
+
+			diff := before.Diff(after)
+			for _, key := range testKeys(5000) {
+				from, to := before.Owner(key), after.Owner(key)
+				i := findRange(diff.Moves, before.KeyHash(key))
+
+				switch {
+				case from == to && i >= 0:
+					t.Fatalf("%q stays on %s but falls into move %v", key, from, diff.Moves[i])
+				case from != to && i < 0:
+					t.Fatalf("%q moves from %s to %s but no move covers it", key, from, to)
+				case from != to && (diff.Moves[i].From != from || diff.Moves[i].To != to):
+					t.Fatalf("%q moves from %s to %s, move says %s -> %s", key, from, to, diff.Moves[i].From, diff.Moves[i].To)
+				}
+			}
+
+			for i := 1; i < len(diff.Moves); i++ {
+				prev, move := diff.Moves[i-1], diff.Moves[i]
+				if move.Start <= prev.End {
+					t.Fatalf("moves %v and %v overlap or are out of order", prev, move)
+				}
+				if move.Start == prev.End+1 && move.From == prev.From && move.To == prev.To {
+					t.Fatalf("adjacent moves %v and %v were not merged", prev, move)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffMovedFractionOnAdd(t *testing.T) {
+	for _, nodes := range []int{3, 5, 10} {
+		t.Run(fmt.Sprintf("nodes=%d", nodes), func(t *testing.T) {
+			before := newTestRing(nodes)
+			after := before.Clone()
+			added := fmt.Sprintf("cache-%d:8080", nodes)
+			after.AddNode(added)
+
+			diff := before.Diff(after)
+
+			// The new node takes its share from all others and nothing else moves
+			want := 1 / float64(nodes+1)
+			if math.Abs(diff.MovedFraction-want) > 0.3*want {
+				t.Errorf("MovedFraction = %.4f, want about %.4f", diff.MovedFraction, want)
+			}
+			for _, move := range diff.Moves {
+				if move.To != added {
+					t.Fatalf("move %v does not go to the added node %s", move, added)
+				}
+			}
+		})
+	}
+}
+
+func TestReplicaRanges(t *testing.T) {
+	ring := newPinnedRing()
+
+	want := []ReplicaRange{
+		{HashRange{0, 100}, []string{"a", "b"}},
+		{HashRange{101, 200}, []string{"b", "c"}},
+		{HashRange{201, 300}, []string{"c", "a"}},
+		{HashRange{301, math.MaxUint64}, []string{"a", "b"}}, // wraps around
+	}
+	got := ring.ReplicaRanges(2)
+	if !slices.EqualFunc(got, want, func(a, b ReplicaRange) bool {
+		return a.HashRange == b.HashRange && slices.Equal(a.Nodes, b.Nodes)
+	}) {
+		t.Errorf("ReplicaRanges(2) = %v, want %v", got, want)
+	}
+
+	// With every node a replica, ranges only differ in preference order
+	if got := ring.ReplicaRanges(5); len(got) != 4 || len(got[0].Nodes) != 3 {
+		t.Errorf("ReplicaRanges(5) = %v, want 4 ranges of 3 nodes", got)
+	}
+	if got := ring.ReplicaRanges(0); got != nil {
+		t.Errorf("ReplicaRanges(0) = %v, want nil", got)
+	}
+}
+
+func TestReplicaRangesMatchGetNodes(t *testing.T) {
+	ring := newTestRing(6)
+
+	for _, n := range []int{1, 2, 3} {
+		ranges := ring.ReplicaRanges(n)
+		for _, key := range testKeys(2000) {
+			i := findRange(ranges, ring.KeyHash(key))
+			if i < 0 {
+				t.Fatalf("no replica range contains %q", key)
+			}
+			if !slices.Equal(ranges[i].Nodes, ring.GetNodes(key, n)) {
+				t.Fatalf("replica range of %q = %v, want GetNodes(%q, %d) = %v",
+					key, ranges[i].Nodes, key, n, ring.GetNodes(key, n))
+			}
+		}
+	}
+}
@@ -22,7 +22,8 @@
 // # Thread Safety
 //
 // All Ring methods are safe for concurrent use. Reads can proceed in
-// parallel, while writes (AddNode, RemoveNode) acquire an exclusive lock.
+// parallel, while writes (AddNode, AddWeightedNode, UpdateWeight, RemoveNode)
+// acquire an exclusive lock.
 //
 // # Virtual Nodes
 //
@@ -31,9 +32,84 @@
 // and distribution quality. Higher values (e.g., 500) provide better
 // distribution but increase memory consumption.
 //
+// # Replica Sets
+//
+// GetNodes returns the first N distinct physical nodes clockwise from a
+// key, for replication and failover. GetNodesZoneAware additionally spreads
+// the replicas across failure domains assigned with SetZone:
+//
+//	ring.SetZone("cache-0:8080", "zone-a")
+//	ring.SetZone("cache-1:8080", "zone-b")
+//	replicas := ring.GetNodesZoneAware("user:12345", 2)
+//
+// # Weighted Nodes
+//
+// Nodes with different capacities can be given a weight. A node's virtual
+// node count is the ring's per-node count multiplied by its weight:
+//
+//	ring := hash.NewRing(150)
+//	ring.AddWeightedNode("cache-small:8080", 1) // 150 virtual nodes
+//	ring.AddWeightedNode("cache-large:8080", 4) // 600 virtual nodes
+//
+//	// Change capacity in place; only keys near the node's virtual nodes move
+//	ring.UpdateWeight("cache-small:8080", 2)
+//
 // # Hash Function
 //
-// The ring uses CRC32 (IEEE polynomial) as the hash function. This provides
-// fast hashing with good distribution properties. The hash values are uint32,
-// so the ring has 2^32 possible positions.
+// NewRing uses CRC32 (IEEE polynomial) for backward compatibility. CRC32 has
+// weak avalanche behaviour and visibly skews similar keys such as
+// "user:1", "user:2"; new deployments should prefer xxHash or Murmur3:
+//
+//	ring := hash.NewRingWithHash(150, hash.XXHash)
+//
+// Ring positions are uint64. Virtual nodes whose hashes collide are kept
+// side by side and ordered by node name, so a collision never drops a
+// virtual node and every process resolves it identically.
+//
+// # Placement Algorithms
+//
+// Placement abstracts over the key-to-node mapping. NewPlacement builds one
+// from a PlacementConfig:
+//
+//   - ketama: Ring, virtual nodes on a hash ring (default)
+//   - jump: JumpHash, jump consistent hash over weighted bucket slots
+//   - rendezvous: Rendezvous, weighted highest random weight hashing
+//   - maglev: Maglev, permutation-filled lookup table
+//
+// MeasureDistribution and RemapFraction quantify balance and key movement;
+// cmd/hashbench runs them across all algorithms and hash functions.
+//
+// # Hash Tags
+//
+// Placements hash keys exactly as given. Callers that co-locate related keys
+// pass a routing key instead of the cache key; the proxy uses kv.RoutingKey,
+// which reduces a namespaced key with a tag in braces to its namespace and
+// tag. Every lookup of a key, bounded or not, must then use the same routing
+// key.
+//
+// # Bounded Loads
+//
+// Ring supports consistent hashing with bounded loads. With an epsilon set,
+// GetNode skips nodes whose load exceeds (1+epsilon) times their weighted
+// share of the total and continues clockwise, and GetNodeBounded does the
+// same within a key's first n replicas:
+//
+//	ring.SetLoadBound(0.25)
+//	ring.SetLoads(requestsPerSecond) // refreshed periodically by the caller
+//	node := ring.GetNode(key)              // any node within its bound
+//	replica := ring.GetNodeBounded(key, 3) // one of the key's 3 replicas
+//
+// Owner and GetNodes ignore loads, so owners and replica sets stay stable
+// and every process agrees on them.
+//
+// # Ownership Diffs
+//
+// Diff compares two ring states and lists the hash ranges that change
+// owner, which drives rebalancing and previews of topology changes:
+//
+//	proposed := ring.Clone()
+//	proposed.AddNode("cache-node-4:8080")
+//	diff := ring.Diff(proposed)
+//	// diff.Moves: [start, end] ranges with old -> new owner
+//	// diff.MovedFraction: share of the keyspace that moves
 package hash
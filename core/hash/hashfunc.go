@@ -0,0 +1,91 @@
+package hash
+
+import (
+	"fmt"
+	"hash/crc32"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/spaolacci/murmur3"
+)
+
+// HashFunc maps arbitrary bytes to a position in a 64-bit hash space.
+//
+// Implementations must be deterministic across processes and platforms,
+// since every proxy instance has to place keys identically.
+type HashFunc func(data []byte) uint64
+
+// Supported hash function names for PlacementConfig.HashFunction.
+const (
+	// HashCRC32 is the CRC32 (IEEE) checksum. It is fast but has weak
+	// avalanche behaviour and only has 32 bits of entropy.
+	// It is the default for backward compatibility with existing rings.
+	HashCRC32 = "crc32"
+
+	// HashXXHash is the 64-bit xxHash (XXH64), recommended for new deployments.
+	HashXXHash = "xxhash"
+
+	// HashMurmur3 is the 64-bit MurmurHash3 (x64, first half of the 128-bit digest).
+	HashMurmur3 = "murmur3"
+)
+
+// CRC32 hashes data with the CRC32 IEEE polynomial.
+//
+// The checksum occupies the upper 32 bits so that, like the other hash
+// functions, it spans the full 64-bit hash space; hash range sizes are then
+// directly comparable across functions. Scaling preserves order, so a ring
+// places keys exactly as a 32-bit CRC32 ring does.
+func CRC32(data []byte) uint64 {
+	return uint64(crc32.ChecksumIEEE(data)) << 32
+}
+
+// XXHash hashes data with 64-bit xxHash.
+func XXHash(data []byte) uint64 {
+	return xxhash.Sum64(data)
+}
+
+// Murmur3 hashes data with 64-bit MurmurHash3.
+func Murmur3(data []byte) uint64 {
+	return murmur3.Sum64(data)
+}
+
+// HashFuncByName returns the hash function registered under name.
+//
+// Parameters:
+//   - name: One of HashCRC32, HashXXHash, HashMurmur3. Empty selects HashCRC32.
+//
+// Returns:
+//   - HashFunc: The hash function
+//   - error: Error if the name is unknown
+//
+// Example:
+//
+//	fn, err := hash.HashFuncByName("xxhash")
+//	if err != nil {
+//	    return err
+//	}
+//	ring := hash.NewRingWithHash(150, fn)
+func HashFuncByName(name string) (HashFunc, error) {
+	switch name {
+	case "", HashCRC32:
+		return CRC32, nil
+	case HashXXHash:
+		return XXHash, nil
+	case HashMurmur3:
+		return Murmur3, nil
+	default:
+		return nil, fmt.Errorf("unknown hash function '%s' (supported: %s, %s, %s)",
+			name, HashCRC32, HashXXHash, HashMurmur3)
+	}
+}
+
+// mix64 is the SplitMix64 finalizer. It spreads the entropy of hash
+// functions with narrow output (such as CRC32) over all 64 bits, which
+// algorithms that derive probabilities or offsets from the hash rely on.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
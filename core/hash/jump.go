@@ -0,0 +1,260 @@
+package hash
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// JumpHash places keys with Lamping and Veach's jump consistent hash.
+//
+// Jump hash needs no memory per key range and spreads keys almost perfectly
+// evenly, but it addresses numbered buckets rather than named nodes. Each
+// node occupies weight consecutive bucket slots, and nodes are laid out in
+// natural name order (runs of digits compare by value, so "node-10" follows
+// "node-9"). The layout depends only on the set of nodes and weights, never
+// on the order of adds and removals, so every proxy agrees on key ownership
+// however its view of the cluster evolved:
+//
+//   - Adding a node that sorts last (such as the next StatefulSet ordinal),
+//     or removing the last one, moves only the keys of the affected slots,
+//     the optimal 1/N fraction.
+//   - Adding or removing a node elsewhere in the order, or changing a
+//     weight, shifts every following slot and moves their keys as well.
+//
+// JumpHash is safe for concurrent use.
+type JumpHash struct {
+	// mu protects concurrent access to all fields
+	mu sync.RWMutex
+
+	// nodes contains the identifiers of all nodes in natural order
+	nodes []string
+
+	// weights maps each node to its weight (number of slots)
+	weights map[string]int
+
+	// slots maps each jump hash bucket to the node owning it
+	slots []string
+
+	// hashFn hashes keys before they are fed to the jump function
+	hashFn HashFunc
+}
+
+// NewJumpHash creates an empty jump consistent hash placement.
+//
+// Parameters:
+//   - hashFn: Hash function for keys. If nil, CRC32 is used.
+//
+// Returns:
+//   - *JumpHash: A new empty placement ready to accept nodes via AddNode
+func NewJumpHash(hashFn HashFunc) *JumpHash {
+	if hashFn == nil {
+		hashFn = CRC32
+	}
+
+	return &JumpHash{
+		weights: make(map[string]int),
+		hashFn:  hashFn,
+	}
+}
+
+// AddNode adds a node with DefaultWeight. Duplicates are ignored.
+func (j *JumpHash) AddNode(node string) {
+	j.AddWeightedNode(node, DefaultWeight)
+}
+
+// AddWeightedNode adds a node owning weight bucket slots.
+// If weight <= 0, DefaultWeight is used. Duplicates are ignored.
+func (j *JumpHash) AddWeightedNode(node string, weight int) {
+	if weight <= 0 {
+		weight = DefaultWeight
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, exists := j.weights[node]; exists {
+		return
+	}
+
+	i, _ := slices.BinarySearchFunc(j.nodes, node, naturalCompare)
+	j.nodes = slices.Insert(j.nodes, i, node)
+	j.weights[node] = weight
+	j.layout()
+}
+
+// UpdateWeight changes the number of slots owned by an existing node.
+//
+// Returns:
+//   - bool: True if the node exists, false otherwise
+func (j *JumpHash) UpdateWeight(node string, weight int) bool {
+	if weight <= 0 {
+		weight = DefaultWeight
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	current, exists := j.weights[node]
+	if !exists {
+		return false
+	}
+	if current != weight {
+		j.weights[node] = weight
+		j.layout()
+	}
+	return true
+}
+
+// Weight returns the weight of a node, or 0 if the node is unknown.
+func (j *JumpHash) Weight(node string) int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.weights[node]
+}
+
+// RemoveNode removes a node and its slots. Unknown nodes are ignored.
+func (j *JumpHash) RemoveNode(node string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, exists := j.weights[node]; !exists {
+		return
+	}
+
+	j.nodes = slices.DeleteFunc(j.nodes, func(n string) bool { return n == node })
+	delete(j.weights, node)
+	j.layout()
+}
+
+// GetNode returns the node owning the key's bucket, or "" if empty.
+func (j *JumpHash) GetNode(key string) string {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if len(j.slots) == 0 {
+		return ""
+	}
+	return j.slots[jumpBucket(mix64(j.hashFn([]byte(key))), len(j.slots))]
+}
+
+// GetNodes returns up to n distinct nodes for the key.
+//
+// Jump hash has no notion of a successor, so further candidates are found
+// by re-hashing the key with successive seeds. If that does not yield
+// enough distinct nodes, the remaining nodes are appended in slot order.
+func (j *JumpHash) GetNodes(key string, n int) []string {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if n <= 0 || len(j.slots) == 0 {
+		return nil
+	}
+	if n > len(j.nodes) {
+		n = len(j.nodes)
+	}
+
+	result := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	add := func(node string) {
+		if !seen[node] {
+			seen[node] = true
+			result = append(result, node)
+		}
+	}
+
+	keyHash := j.hashFn([]byte(key))
+	for seed := uint64(0); seed < uint64(4*len(j.slots)) && len(result) < n; seed++ {
+		add(j.slots[jumpBucket(mix64(keyHash+seed*0x9e3779b97f4a7c15), len(j.slots))])
+	}
+	for _, node := range j.slots {
+		if len(result) == n {
+			break
+		}
+		add(node)
+	}
+
+	return result
+}
+
+// Nodes returns a copy of all nodes in natural order.
+func (j *JumpHash) Nodes() []string {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	nodes := make([]string, len(j.nodes))
+	copy(nodes, j.nodes)
+	return nodes
+}
+
+// Size returns the number of nodes.
+func (j *JumpHash) Size() int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return len(j.nodes)
+}
+
+// layout rebuilds the bucket slots from the nodes in natural order. The
+// caller must hold the write lock.
+func (j *JumpHash) layout() {
+	j.slots = j.slots[:0]
+	for _, node := range j.nodes {
+		for i := 0; i < j.weights[node]; i++ {
+			j.slots = append(j.slots, node)
+		}
+	}
+}
+
+// naturalCompare orders node identifiers by name, comparing runs of digits
+// by their numeric value, so that "node-9" sorts before "node-10".
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			da, db := digitRun(a), digitRun(b)
+			na, nb := strings.TrimLeft(a[:da], "0"), strings.TrimLeft(b[:db], "0")
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			// Equal values: fewer leading zeros first
+			if c := cmp.Compare(da, db); c != 0 {
+				return c
+			}
+			a, b = a[da:], b[db:]
+			continue
+		}
+		if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// digitRun returns the length of the run of digits at the start of s.
+func digitRun(s string) int {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// jumpBucket is the jump consistent hash function from "A Fast, Minimal
+// Memory, Consistent Hash Algorithm" (Lamping, Veach 2014).
+func jumpBucket(key uint64, buckets int) int {
+	var b, next int64 = -1, 0
+	for next < int64(buckets) {
+		b = next
+		key = key*2862933555777941757 + 1
+		next = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,267 @@
+package hash
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultMaglevTableSize is the Maglev lookup table size used when none is
+// configured. It must be prime and much larger than the number of nodes;
+// 65537 keeps the per-node imbalance well below 1% for up to ~100 nodes.
+const DefaultMaglevTableSize = 65537
+
+// Maglev places keys with the lookup table from "Maglev: A Fast and
+// Reliable Software Network Load Balancer" (Eisenbud et al., 2016).
+//
+// Each node fills table slots following its own permutation of the table,
+// taking turns with the other nodes, so every node owns almost exactly its
+// weighted share of the table. Lookups are a single hash and array index.
+// Membership changes rebuild the table; most slots keep their owner, but
+// the disruption is slightly higher than with Ring or Rendezvous.
+//
+// Nodes take turns in sorted order, so the table does not depend on the
+// order in which nodes were added.
+//
+// Maglev is safe for concurrent use.
+type Maglev struct {
+	// mu protects concurrent access to all fields
+	mu sync.RWMutex
+
+	// nodes contains the identifiers of all nodes in insertion order
+	nodes []string
+
+	// weights maps each node to its weight (table turns per round)
+	weights map[string]int
+
+	// table maps each slot to the owning node
+	table []string
+
+	// size is the (prime) lookup table size
+	size int
+
+	// hashFn hashes keys and node identifiers
+	hashFn HashFunc
+}
+
+// NewMaglev creates an empty Maglev placement.
+//
+// Parameters:
+//   - tableSize: Lookup table size, rounded up to the next prime.
+//     If <= 0, DefaultMaglevTableSize is used.
+//   - hashFn: Hash function for keys and nodes. If nil, CRC32 is used.
+//
+// Returns:
+//   - *Maglev: A new empty placement ready to accept nodes via AddNode
+func NewMaglev(tableSize int, hashFn HashFunc) *Maglev {
+	if tableSize <= 0 {
+		tableSize = DefaultMaglevTableSize
+	}
+	if hashFn == nil {
+		hashFn = CRC32
+	}
+
+	return &Maglev{
+		weights: make(map[string]int),
+		size:    nextPrime(tableSize),
+		hashFn:  hashFn,
+	}
+}
+
+// AddNode registers a node with DefaultWeight. Duplicates are ignored.
+func (m *Maglev) AddNode(node string) {
+	m.AddWeightedNode(node, DefaultWeight)
+}
+
+// AddWeightedNode registers a node with the given weight and rebuilds the
+// lookup table. If weight <= 0, DefaultWeight is used. Duplicates are ignored.
+func (m *Maglev) AddWeightedNode(node string, weight int) {
+	if weight <= 0 {
+		weight = DefaultWeight
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.weights[node]; exists {
+		return
+	}
+
+	m.nodes = append(m.nodes, node)
+	m.weights[node] = weight
+	m.populate()
+}
+
+// UpdateWeight changes the weight of an existing node and rebuilds the
+// lookup table.
+//
+// Returns:
+//   - bool: True if the node exists, false otherwise
+func (m *Maglev) UpdateWeight(node string, weight int) bool {
+	if weight <= 0 {
+		weight = DefaultWeight
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, exists := m.weights[node]
+	if !exists {
+		return false
+	}
+	if current != weight {
+		m.weights[node] = weight
+		m.populate()
+	}
+	return true
+}
+
+// Weight returns the weight of a node, or 0 if the node is unknown.
+func (m *Maglev) Weight(node string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.weights[node]
+}
+
+// RemoveNode unregisters a node and rebuilds the lookup table.
+// Unknown nodes are ignored.
+func (m *Maglev) RemoveNode(node string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.weights[node]; !exists {
+		return
+	}
+
+	for i, n := range m.nodes {
+		if n == node {
+			m.nodes = append(m.nodes[:i], m.nodes[i+1:]...)
+			break
+		}
+	}
+	delete(m.weights, node)
+	m.populate()
+}
+
+// GetNode returns the node owning the key's table slot, or "" if empty.
+func (m *Maglev) GetNode(key string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.table) == 0 {
+		return ""
+	}
+	return m.table[m.slot(key)]
+}
+
+// GetNodes returns up to n distinct nodes, walking the table forward from
+// the key's slot.
+func (m *Maglev) GetNodes(key string, n int) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if n <= 0 || len(m.table) == 0 {
+		return nil
+	}
+	if n > len(m.nodes) {
+		n = len(m.nodes)
+	}
+
+	result := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	start := m.slot(key)
+	for i := 0; i < len(m.table) && len(result) < n; i++ {
+		node := m.table[(start+i)%len(m.table)]
+		if !seen[node] {
+			seen[node] = true
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// Nodes returns a copy of all nodes in insertion order.
+func (m *Maglev) Nodes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	nodes := make([]string, len(m.nodes))
+	copy(nodes, m.nodes)
+	return nodes
+}
+
+// Size returns the number of nodes.
+func (m *Maglev) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.nodes)
+}
+
+// slot returns the table index for a key. The caller must hold the read lock.
+func (m *Maglev) slot(key string) int {
+	return int(mix64(m.hashFn([]byte(key))) % uint64(len(m.table)))
+}
+
+// populate rebuilds the lookup table from the current nodes and weights.
+// The caller must hold the write lock.
+func (m *Maglev) populate() {
+	if len(m.nodes) == 0 {
+		m.table = nil
+		return
+	}
+
+	nodes := make([]string, len(m.nodes))
+	copy(nodes, m.nodes)
+	sort.Strings(nodes)
+
+	size := uint64(m.size)
+	offsets := make([]uint64, len(nodes))
+	skips := make([]uint64, len(nodes))
+	next := make([]uint64, len(nodes))
+	for i, node := range nodes {
+		h := m.hashFn([]byte(node))
+		offsets[i] = mix64(h) % size
+		skips[i] = mix64(h^0x9e3779b97f4a7c15)%(size-1) + 1
+	}
+
+	table := make([]string, m.size)
+	filled := 0
+	for filled < m.size {
+		for i, node := range nodes {
+			for turn := 0; turn < m.weights[node] && filled < m.size; turn++ {
+				// Advance along the node's permutation to its next free slot
+				slot := (offsets[i] + next[i]*skips[i]) % size
+				for table[slot] != "" {
+					next[i]++
+					slot = (offsets[i] + next[i]*skips[i]) % size
+				}
+				table[slot] = node
+				next[i]++
+				filled++
+			}
+		}
+	}
+
+	m.table = table
+}
+
+// nextPrime returns the smallest prime >= n.
+func nextPrime(n int) int {
+	if n <= 2 {
+		return 2
+	}
+	if n%2 == 0 {
+		n++
+	}
+	for ; ; n += 2 {
+		prime := true
+		for d := 3; d*d <= n; d += 2 {
+			if n%d == 0 {
+				prime = false
+				break
+			}
+		}
+		if prime {
+			return n
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package hash
+
+import "fmt"
+
+// Placement maps keys to nodes.
+//
+// All implementations are deterministic: two placements built with the same
+// configuration and the same set of (node, weight) pairs map every key to the
+// same node. This lets independent proxy instances agree on key ownership
+// without coordination. No implementation depends on the order in which
+// nodes were added or removed.
+//
+// Implementations are safe for concurrent use.
+type Placement interface {
+	// AddNode registers a node with DefaultWeight. Duplicates are ignored.
+	AddNode(node string)
+
+	// AddWeightedNode registers a node whose share of the key space is
+	// proportional to weight. Duplicates are ignored.
+	AddWeightedNode(node string, weight int)
+
+	// UpdateWeight changes the weight of an existing node.
+	// Returns false if the node is unknown.
+	UpdateWeight(node string, weight int) bool
+
+	// Weight returns the weight of a node, or 0 if the node is unknown.
+	Weight(node string) int
+
+	// RemoveNode unregisters a node. Unknown nodes are ignored.
+	RemoveNode(node string)
+
+	// GetNode returns the node responsible for key, or "" if empty.
+	GetNode(key string) string
+
+	// GetNodes returns up to n distinct nodes for key in preference order.
+	// The first entry equals GetNode(key), unless a load bound (see
+	// LoadAware) diverts GetNode away from the owner.
+	GetNodes(key string, n int) []string
+
+	// Nodes returns a copy of all registered nodes.
+	Nodes() []string
+
+	// Size returns the number of registered nodes.
+	Size() int
+}
+
+// Supported placement algorithms for PlacementConfig.Algorithm.
+const (
+	// AlgorithmKetama is a consistent hash ring with virtual nodes (Ring).
+	// It is the default for backward compatibility.
+	AlgorithmKetama = "ketama"
+
+	// AlgorithmJump is Lamping and Veach's jump consistent hash (JumpHash).
+	AlgorithmJump = "jump"
+
+	// AlgorithmRendezvous is weighted rendezvous / highest random weight
+	// hashing (Rendezvous).
+	AlgorithmRendezvous = "rendezvous"
+
+	// AlgorithmMaglev is Google's Maglev lookup table hashing (Maglev).
+	AlgorithmMaglev = "maglev"
+)
+
+// DefaultVirtualNodes is the number of virtual nodes per unit of weight
+// used by the ketama ring when PlacementConfig.VirtualNodes is not set.
+const DefaultVirtualNodes = 150
+
+// PlacementConfig selects and tunes a placement algorithm.
+//
+// The zero value selects a ketama ring with CRC32 and 150 virtual nodes,
+// which matches the historical behaviour of the proxy.
+type PlacementConfig struct {
+	// Algorithm is one of AlgorithmKetama (default), AlgorithmJump,
+	// AlgorithmRendezvous or AlgorithmMaglev
+	Algorithm string
+
+	// HashFunction is one of HashCRC32 (default), HashXXHash or HashMurmur3
+	HashFunction string
+
+	// VirtualNodes is the number of virtual nodes per unit of weight
+	// Only used by the ketama ring. Default: 150
+	VirtualNodes int
+
+	// TableSize is the Maglev lookup table size, rounded up to a prime
+	// Only used by Maglev. Default: 65537
+	TableSize int
+
+	// LoadBound is the bounded-load epsilon (see Ring.SetLoadBound)
+	// Only supported by the ketama ring. Default: 0 (disabled)
+	LoadBound float64
+}
+
+// NewPlacement creates an empty placement for the given configuration.
+//
+// Parameters:
+//   - cfg: Algorithm and hash function selection. Empty fields use defaults.
+//
+// Returns:
+//   - Placement: A new placement ready to accept nodes
+//   - error: Error if the algorithm or hash function is unknown, or if
+//     a load bound is requested for an algorithm that does not support it
+//
+// Example:
+//
+//	p, err := hash.NewPlacement(hash.PlacementConfig{
+//	    Algorithm:    hash.AlgorithmMaglev,
+//	    HashFunction: hash.HashXXHash,
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	p.AddNode("cache-node-1:8080")
+func NewPlacement(cfg PlacementConfig) (Placement, error) {
+	hashFn, err := HashFuncByName(cfg.HashFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.LoadBound > 0 && cfg.Algorithm != "" && cfg.Algorithm != AlgorithmKetama {
+		return nil, fmt.Errorf("bounded loads are only supported by the '%s' algorithm, not '%s'",
+			AlgorithmKetama, cfg.Algorithm)
+	}
+
+	switch cfg.Algorithm {
+	case "", AlgorithmKetama:
+		virtualNodes := cfg.VirtualNodes
+		if virtualNodes <= 0 {
+			virtualNodes = DefaultVirtualNodes
+		}
+		ring := NewRingWithHash(virtualNodes, hashFn)
+		ring.SetLoadBound(cfg.LoadBound)
+		return ring, nil
+	case AlgorithmJump:
+		return NewJumpHash(hashFn), nil
+	case AlgorithmRendezvous:
+		return NewRendezvous(hashFn), nil
+	case AlgorithmMaglev:
+		return NewMaglev(cfg.TableSize, hashFn), nil
+	default:
+		return nil, fmt.Errorf("unknown placement algorithm '%s' (supported: %s, %s, %s, %s)",
+			cfg.Algorithm, AlgorithmKetama, AlgorithmJump, AlgorithmRendezvous, AlgorithmMaglev)
+	}
+}
+
+// Compile-time interface checks
+var (
+	_ Placement = (*Ring)(nil)
+	_ Placement = (*JumpHash)(nil)
+	_ Placement = (*Rendezvous)(nil)
+	_ Placement = (*Maglev)(nil)
+	_ LoadAware = (*Ring)(nil)
+)
@@ -0,0 +1,281 @@
+package hash
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+var benchAlgorithms = []string{AlgorithmKetama, AlgorithmJump, AlgorithmRendezvous, AlgorithmMaglev}
+
+var benchHashFunctions = []string{HashCRC32, HashXXHash, HashMurmur3}
+
+// newTestPlacement builds a placement of the given algorithm holding nodes
+// cache-0 to cache-<nodes-1>.
+func newTestPlacement(t *testing.T, algorithm, hashFunction string, nodes int) Placement {
+	t.Helper()
+
+	p, err := NewPlacement(PlacementConfig{Algorithm: algorithm, HashFunction: hashFunction})
+	if err != nil {
+		t.Fatalf("NewPlacement(%s, %s): %v", algorithm, hashFunction, err)
+	}
+	for i := 0; i < nodes; i++ {
+		p.AddNode(fmt.Sprintf("cache-%d:8080", i))
+	}
+	return p
+}
+
+// maxOverload bounds the busiest node's key count relative to its share.
+// Ketama balances with 150 virtual nodes per node; the other algorithms
+// balance almost perfectly.
+var maxOverload = map[string]float64{
+	AlgorithmKetama:     1.3,
+	AlgorithmJump:       1.05,
+	AlgorithmRendezvous: 1.05,
+	AlgorithmMaglev:     1.05,
+}
+
+func TestPlacementDistribution(t *testing.T) {
+	keys := testKeys(100000)
+
+	for _, algorithm := range benchAlgorithms {
+		for _, hashFunction := range benchHashFunctions {
+			t.Run(algorithm+"/"+hashFunction, func(t *testing.T) {
+				stats := MeasureDistribution(newTestPlacement(t, algorithm, hashFunction, 10), keys)
+				if stats.MaxOverload > maxOverload[algorithm] {
+					t.Errorf("max overload %.3f exceeds %.2f (counts %v)", stats.MaxOverload, maxOverload[algorithm], stats.Counts)
+				}
+				if stats.Min == 0 {
+					t.Errorf("a node owns no keys: %v", stats.Counts)
+				}
+			})
+		}
+	}
+}
+
+func TestPlacementWeightedDistribution(t *testing.T) {
+	keys := testKeys(100000)
+
+	for _, algorithm := range benchAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			p := newTestPlacement(t, algorithm, HashXXHash, 4)
+			p.AddWeightedNode("big:8080", 3*DefaultWeight)
+
+			stats := MeasureDistribution(p, keys)
+			if stats.MaxOverload > maxOverload[algorithm] {
+				t.Errorf("max overload %.3f exceeds %.2f (counts %v)", stats.MaxOverload, maxOverload[algorithm], stats.Counts)
+			}
+			if share := float64(stats.Counts["big:8080"]) / float64(len(keys)); share < 0.35 || share > 0.5 {
+				t.Errorf("node with 3/7 of the weight owns %.3f of the keys", share)
+			}
+		})
+	}
+}
+
+func TestPlacementRemapOnAdd(t *testing.T) {
+	keys := testKeys(100000)
+	const ideal = 1.0 / 11
+
+	for _, algorithm := range benchAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			before := newTestPlacement(t, algorithm, HashXXHash, 10)
+			after := newTestPlacement(t, algorithm, HashXXHash, 11)
+
+			if moved := RemapFraction(before, after, keys); moved < 0.75*ideal || moved > 1.25*ideal {
+				t.Errorf("adding an 11th node moved %.4f of the keys, want about %.4f", moved, ideal)
+			}
+
+			// Maglev rebuilds its table, so a few slots change between old nodes
+			if algorithm == AlgorithmMaglev {
+				return
+			}
+			for _, key := range keys {
+				if old, now := before.GetNode(key), after.GetNode(key); old != now && now != "cache-10:8080" {
+					t.Fatalf("%q moved from %s to %s, not to the new node", key, old, now)
+				}
+			}
+		})
+	}
+}
+
+func TestPlacementRemapOnRemove(t *testing.T) {
+	keys := testKeys(100000)
+	const ideal = 1.0 / 10
+
+	for _, algorithm := range benchAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			before := newTestPlacement(t, algorithm, HashXXHash, 10)
+			after := newTestPlacement(t, algorithm, HashXXHash, 10)
+
+			// Jump hash only remaps optimally when the last node in
+			// natural order leaves, as when a StatefulSet scales in
+			removed := "cache-4:8080"
+			if algorithm == AlgorithmJump {
+				removed = "cache-9:8080"
+			}
+			after.RemoveNode(removed)
+
+			if moved := RemapFraction(before, after, keys); moved < 0.75*ideal || moved > 1.25*ideal {
+				t.Errorf("removing %s moved %.4f of the keys, want about %.4f", removed, moved, ideal)
+			}
+			if algorithm == AlgorithmMaglev {
+				return
+			}
+			for _, key := range keys {
+				if old, now := before.GetNode(key), after.GetNode(key); old != now && old != removed {
+					t.Fatalf("%q moved from %s to %s, but only keys of %s should move", key, old, now, removed)
+				}
+			}
+		})
+	}
+}
+
+func TestPlacementIndependentOfMembershipHistory(t *testing.T) {
+	keys := testKeys(20000)
+
+	for _, algorithm := range benchAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			// A proxy that saw the cluster grow one node at a time
+			grown := newTestPlacement(t, algorithm, HashXXHash, 12)
+			grown.UpdateWeight("cache-3:8080", 2*DefaultWeight)
+
+			// A proxy that started later, saw the nodes in random order,
+			// a node that left again and a weight change
+			restarted, err := NewPlacement(PlacementConfig{Algorithm: algorithm, HashFunction: HashXXHash})
+			if err != nil {
+				t.Fatalf("NewPlacement: %v", err)
+			}
+			restarted.AddNode("cache-99:8080")
+			for _, i := range rand.New(rand.NewSource(7)).Perm(12) {
+				weight := DefaultWeight
+				if i == 3 {
+					weight = 2 * DefaultWeight
+				}
+				restarted.AddWeightedNode(fmt.Sprintf("cache-%d:8080", i), weight)
+			}
+			restarted.RemoveNode("cache-99:8080")
+			restarted.UpdateWeight("cache-7:8080", 3*DefaultWeight)
+			restarted.UpdateWeight("cache-7:8080", DefaultWeight)
+
+			for _, key := range keys {
+				if a, b := grown.GetNode(key), restarted.GetNode(key); a != b {
+					t.Fatalf("GetNode(%q) = %s on one proxy and %s on the other", key, a, b)
+				}
+			}
+		})
+	}
+}
+
+func TestNaturalCompare(t *testing.T) {
+	ordered := []string{
+		"cache-0:8080",
+		"cache-1:8080",
+		"cache-2:8080",
+		"cache-9:8080",
+		"cache-10:8080",
+		"cache-11:8080",
+		"cache-100:8080",
+		"node-1",
+		"node-1a",
+		"node-01", // equal numbers: fewer leading zeros first
+		"node-2",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := naturalCompare(ordered[i], ordered[j]); got != want {
+				t.Errorf("naturalCompare(%q, %q) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func newBenchPlacement(b *testing.B, algorithm, hashFunction string, nodes int) Placement {
+	b.Helper()
+
+	p, err := NewPlacement(PlacementConfig{Algorithm: algorithm, HashFunction: hashFunction})
+	if err != nil {
+		b.Fatalf("NewPlacement(%s, %s): %v", algorithm, hashFunction, err)
+	}
+	for i := 0; i < nodes; i++ {
+		p.AddNode(fmt.Sprintf("yao-oracle-node-%d.yao-oracle-node:8080", i))
+	}
+	return p
+}
+
+func benchKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("namespace:user:%d", i)
+	}
+	return keys
+}
+
+// BenchmarkGetNode measures the lookup cost of GetNode for every placement
+// algorithm and hash function on 10 nodes.
+//
+// Run with:
+//
+//	go test ./core/hash -run '^$' -bench GetNode
+func BenchmarkGetNode(b *testing.B) {
+	keys := benchKeys(1 << 14)
+
+	for _, algorithm := range benchAlgorithms {
+		for _, hashFunction := range benchHashFunctions {
+			b.Run(algorithm+"/"+hashFunction, func(b *testing.B) {
+				p := newBenchPlacement(b, algorithm, hashFunction, 10)
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					p.GetNode(keys[i&(len(keys)-1)])
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkGetNodes measures the cost of looking up a 3-node replica set
+// for every placement algorithm on 10 nodes.
+func BenchmarkGetNodes(b *testing.B) {
+	keys := benchKeys(1 << 14)
+
+	for _, algorithm := range benchAlgorithms {
+		b.Run(algorithm, func(b *testing.B) {
+			p := newBenchPlacement(b, algorithm, HashCRC32, 10)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				p.GetNodes(keys[i&(len(keys)-1)], 3)
+			}
+		})
+	}
+}
+
+// BenchmarkGetNodeParallel measures GetNode under concurrent callers, as in
+// the proxy where every request goroutine performs a lookup.
+func BenchmarkGetNodeParallel(b *testing.B) {
+	keys := benchKeys(1 << 14)
+
+	for _, algorithm := range benchAlgorithms {
+		b.Run(algorithm, func(b *testing.B) {
+			p := newBenchPlacement(b, algorithm, HashCRC32, 10)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					p.GetNode(keys[i&(len(keys)-1)])
+					i++
+				}
+			})
+		})
+	}
+}
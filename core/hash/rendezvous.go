@@ -0,0 +1,200 @@
+package hash
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Rendezvous places keys with weighted rendezvous (highest random weight)
+// hashing.
+//
+// Every node computes a score for the key and the highest score wins.
+// Adding or removing a node only moves the keys that node wins or loses,
+// which is the theoretical minimum, and GetNodes returns a natural replica
+// order without a ring walk. Lookups cost O(N) in the number of nodes,
+// which is negligible for typical cache clusters of tens of nodes.
+//
+// Weights use logarithmic scoring (score = -weight / ln(u)), so a node's
+// share of keys is exactly proportional to its weight.
+//
+// Rendezvous is safe for concurrent use.
+type Rendezvous struct {
+	// mu protects concurrent access to all fields
+	mu sync.RWMutex
+
+	// nodes contains the identifiers of all nodes in insertion order
+	nodes []string
+
+	// weights maps each node to its weight
+	weights map[string]int
+
+	// seeds caches the hash of each node identifier
+	seeds map[string]uint64
+
+	// hashFn hashes keys and node identifiers
+	hashFn HashFunc
+}
+
+// NewRendezvous creates an empty rendezvous hashing placement.
+//
+// Parameters:
+//   - hashFn: Hash function for keys and nodes. If nil, CRC32 is used.
+//
+// Returns:
+//   - *Rendezvous: A new empty placement ready to accept nodes via AddNode
+func NewRendezvous(hashFn HashFunc) *Rendezvous {
+	if hashFn == nil {
+		hashFn = CRC32
+	}
+
+	return &Rendezvous{
+		weights: make(map[string]int),
+		seeds:   make(map[string]uint64),
+		hashFn:  hashFn,
+	}
+}
+
+// AddNode registers a node with DefaultWeight. Duplicates are ignored.
+func (r *Rendezvous) AddNode(node string) {
+	r.AddWeightedNode(node, DefaultWeight)
+}
+
+// AddWeightedNode registers a node with the given weight.
+// If weight <= 0, DefaultWeight is used. Duplicates are ignored.
+func (r *Rendezvous) AddWeightedNode(node string, weight int) {
+	if weight <= 0 {
+		weight = DefaultWeight
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.weights[node]; exists {
+		return
+	}
+
+	r.nodes = append(r.nodes, node)
+	r.weights[node] = weight
+	r.seeds[node] = mix64(r.hashFn([]byte(node)))
+}
+
+// UpdateWeight changes the weight of an existing node.
+//
+// Returns:
+//   - bool: True if the node exists, false otherwise
+func (r *Rendezvous) UpdateWeight(node string, weight int) bool {
+	if weight <= 0 {
+		weight = DefaultWeight
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.weights[node]; !exists {
+		return false
+	}
+	r.weights[node] = weight
+	return true
+}
+
+// Weight returns the weight of a node, or 0 if the node is unknown.
+func (r *Rendezvous) Weight(node string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.weights[node]
+}
+
+// RemoveNode unregisters a node. Unknown nodes are ignored.
+func (r *Rendezvous) RemoveNode(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, n := range r.nodes {
+		if n == node {
+			r.nodes = append(r.nodes[:i], r.nodes[i+1:]...)
+			break
+		}
+	}
+	delete(r.weights, node)
+	delete(r.seeds, node)
+}
+
+// GetNode returns the node with the highest score for the key, or "" if empty.
+func (r *Rendezvous) GetNode(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keyHash := r.hashFn([]byte(key))
+
+	best := ""
+	bestScore := math.Inf(-1)
+	for _, node := range r.nodes {
+		score := r.score(keyHash, node)
+		if score > bestScore || (score == bestScore && node < best) {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+// GetNodes returns up to n distinct nodes ordered by descending score.
+func (r *Rendezvous) GetNodes(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n <= 0 || len(r.nodes) == 0 {
+		return nil
+	}
+
+	keyHash := r.hashFn([]byte(key))
+
+	type candidate struct {
+		node  string
+		score float64
+	}
+	candidates := make([]candidate, len(r.nodes))
+	for i, node := range r.nodes {
+		candidates[i] = candidate{node: node, score: r.score(keyHash, node)}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].node < candidates[j].node
+	})
+
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+	result := make([]string, n)
+	for i := range result {
+		result[i] = candidates[i].node
+	}
+	return result
+}
+
+// Nodes returns a copy of all nodes in insertion order.
+func (r *Rendezvous) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := make([]string, len(r.nodes))
+	copy(nodes, r.nodes)
+	return nodes
+}
+
+// Size returns the number of nodes.
+func (r *Rendezvous) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.nodes)
+}
+
+// score computes the weighted rendezvous score of a node for a key hash.
+// The caller must hold the read lock.
+func (r *Rendezvous) score(keyHash uint64, node string) float64 {
+	// Map the combined hash to a uniform value in the open interval (0, 1)
+	u := (float64(mix64(keyHash^r.seeds[node])>>11) + 0.5) / (1 << 53)
+	return -float64(r.weights[node]) / math.Log(u)
+}
@@ -2,17 +2,22 @@ package hash
 
 import (
 	"fmt"
-	"hash/crc32"
 	"sort"
 	"sync"
 )
 
+// DefaultWeight is the weight assigned to nodes added via AddNode.
+//
+// A node with weight w owns w times as many virtual nodes as a node with
+// DefaultWeight, and therefore roughly w times as many keys.
+const DefaultWeight = 1
+
 // Ring represents a consistent hash ring that distributes keys across
 // multiple nodes using virtual nodes for better balance.
 //
 // Ring is safe for concurrent use. Read operations (GetNode, Nodes, Size)
-// can proceed in parallel, while write operations (AddNode, RemoveNode)
-// acquire an exclusive lock.
+// can proceed in parallel, while write operations (AddNode, AddWeightedNode,
+// UpdateWeight, RemoveNode) acquire an exclusive lock.
 //
 // Virtual nodes (replicas) improve distribution uniformity. Higher replica
 // counts provide better load balancing but increase memory usage.
@@ -23,16 +28,45 @@ type Ring struct {
 	// nodes contains the identifiers of all physical nodes in the ring
 	nodes []string
 
-	// virtualNodes is the number of virtual nodes per physical node
+	// virtualNodes is the number of virtual nodes per unit of weight
 	// Higher values provide better distribution but increase memory
 	virtualNodes int
 
-	// ring contains all virtual node hashes in sorted order
-	// for efficient binary search during key lookup
-	ring []uint32
+	// weights maps each physical node to its weight
+	// A node owns virtualNodes * weight virtual nodes
+	weights map[string]int
+
+	// zones maps physical nodes to their failure domain (optional)
+	// Used by GetNodesZoneAware to spread replicas across zones
+	zones map[string]string
+
+	// ring contains all virtual nodes sorted by (hash, node)
+	// for efficient binary search during key lookup. Colliding virtual
+	// nodes are kept side by side instead of overwriting each other, and
+	// the node name breaks the tie so every process resolves a collision
+	// the same way regardless of insertion order.
+	ring []virtualNode
+
+	// hashFn positions keys and virtual nodes on the ring
+	hashFn HashFunc
+
+	// loadBound is the bounded-load epsilon; 0 disables bounded loads
+	// When enabled, GetNodeBounded skips replicas loaded above
+	// (1+loadBound) times their weighted share of the total load
+	loadBound float64
 
-	// hashMap maps each virtual node hash to its physical node identifier
-	hashMap map[uint32]string
+	// loads maps each physical node to its current load as reported by
+	// SetLoads/AddLoad (for example requests per second)
+	loads map[string]float64
+
+	// totalLoad is the sum of all values in loads
+	totalLoad float64
+}
+
+// virtualNode is a single point on the ring owned by a physical node.
+type virtualNode struct {
+	hash uint64
+	node string
 }
 
 // NewRing creates a new consistent hash ring with the specified number of
@@ -54,19 +88,46 @@ type Ring struct {
 //	// Create a ring with higher replication for better distribution
 //	highRepRing := hash.NewRing(500)
 func NewRing(virtualNodes int) *Ring {
+	return NewRingWithHash(virtualNodes, CRC32)
+}
+
+// NewRingWithHash creates a new consistent hash ring (ketama-style) that
+// positions keys and virtual nodes with the given hash function.
+//
+// Parameters:
+//   - virtualNodes: Number of virtual nodes per unit of weight.
+//     If <= 0, defaults to 150.
+//   - hashFn: Hash function for ring positions. If nil, CRC32 is used.
+//
+// Returns:
+//   - *Ring: A new empty ring ready to accept nodes via AddNode
+//
+// Example:
+//
+//	ring := hash.NewRingWithHash(150, hash.XXHash)
+func NewRingWithHash(virtualNodes int, hashFn HashFunc) *Ring {
 	if virtualNodes <= 0 {
 		virtualNodes = 150
 	}
+	if hashFn == nil {
+		hashFn = CRC32
+	}
 
 	return &Ring{
 		virtualNodes: virtualNodes,
-		hashMap:      make(map[uint32]string),
+		weights:      make(map[string]int),
+		zones:        make(map[string]string),
+		hashFn:       hashFn,
+		loads:        make(map[string]float64),
 	}
 }
 
 // AddNode registers a new physical node in the hash ring by creating
 // multiple virtual nodes (replicas) for better key distribution.
 //
+// The node is added with DefaultWeight. Use AddWeightedNode for nodes
+// with different capacities.
+//
 // Parameters:
 //   - node: Unique identifier for the physical node (e.g., "cache-0:8080",
 //     "10.0.1.5:8080"). Must not be empty. Duplicate node identifiers
@@ -87,30 +148,111 @@ func NewRing(virtualNodes int) *Ring {
 //	ring.AddNode("cache-node-2:8080")
 //	ring.AddNode("cache-node-1:8080") // Ignored - already exists
 func (r *Ring) AddNode(node string) {
+	r.AddWeightedNode(node, DefaultWeight)
+}
+
+// AddWeightedNode registers a new physical node whose share of the key
+// space is proportional to its weight.
+//
+// Parameters:
+//   - node: Unique identifier for the physical node. Duplicate node
+//     identifiers are silently ignored; use UpdateWeight to change the
+//     weight of an existing node.
+//   - weight: Relative capacity of the node. The node receives
+//     r.virtualNodes * weight virtual nodes. If <= 0, DefaultWeight is used.
+//
+// Side effects:
+//   - Acquires write lock on the ring
+//   - Re-sorts the hash ring
+//   - Keys may be remapped to the new node (consistent hashing property)
+//
+// Thread-safety: Safe for concurrent calls
+//
+// Example:
+//
+//	ring := hash.NewRing(150)
+//	ring.AddWeightedNode("cache-small:8080", 1) // 4 GB pod
+//	ring.AddWeightedNode("cache-large:8080", 4) // 16 GB pod, ~4x the keys
+func (r *Ring) AddWeightedNode(node string, weight int) {
+	if weight <= 0 {
+		weight = DefaultWeight
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	// Check if node already exists
-	for _, n := range r.nodes {
-		if n == node {
-			return
-		}
+	if _, exists := r.weights[node]; exists {
+		return
 	}
 
 	r.nodes = append(r.nodes, node)
+	r.weights[node] = weight
+
+	r.addVirtualNodes(node, 0, r.virtualNodes*weight)
+	r.sortRing()
+}
+
+// UpdateWeight changes the weight of an existing node without removing it.
+//
+// Virtual nodes are identified by their index, so raising the weight only
+// adds new virtual nodes and lowering it only removes the highest-indexed
+// ones. Keys move only between this node and its ring neighbours; all
+// other key assignments stay stable.
+//
+// Parameters:
+//   - node: Identifier of the physical node to update
+//   - weight: New relative capacity. If <= 0, DefaultWeight is used.
+//
+// Returns:
+//   - bool: True if the node exists in the ring, false otherwise
+//
+// Thread-safety: Safe for concurrent calls
+//
+// Example:
+//
+//	// Pod was resized from 4 GB to 16 GB
+//	ring.UpdateWeight("cache-node-1:8080", 4)
+func (r *Ring) UpdateWeight(node string, weight int) bool {
+	if weight <= 0 {
+		weight = DefaultWeight
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Add virtual nodes
-	for i := 0; i < r.virtualNodes; i++ {
-		virtualKey := fmt.Sprintf("%s#%d", node, i)
-		hash := r.hashKey(virtualKey)
-		r.ring = append(r.ring, hash)
-		r.hashMap[hash] = node
+	current, exists := r.weights[node]
+	if !exists {
+		return false
+	}
+	if current == weight {
+		return true
 	}
 
-	// Sort ring
-	sort.Slice(r.ring, func(i, j int) bool {
-		return r.ring[i] < r.ring[j]
-	})
+	oldCount := r.virtualNodes * current
+	newCount := r.virtualNodes * weight
+
+	if newCount > oldCount {
+		r.addVirtualNodes(node, oldCount, newCount)
+		r.sortRing()
+	} else {
+		r.removeVirtualNodes(node, newCount, oldCount)
+	}
+
+	r.weights[node] = weight
+	return true
+}
+
+// Weight returns the weight of a node.
+//
+// Returns:
+//   - int: The node's weight, or 0 if the node is not in the ring
+//
+// Thread-safety: Safe for concurrent calls (read lock only)
+func (r *Ring) Weight(node string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.weights[node]
 }
 
 // RemoveNode unregisters a physical node from the hash ring by removing
@@ -143,14 +285,16 @@ func (r *Ring) RemoveNode(node string) {
 			break
 		}
 	}
+	delete(r.weights, node)
+	delete(r.zones, node)
+	r.totalLoad -= r.loads[node]
+	delete(r.loads, node)
 
 	// Remove virtual nodes
-	newRing := make([]uint32, 0)
-	for _, hash := range r.ring {
-		if r.hashMap[hash] != node {
-			newRing = append(newRing, hash)
-		} else {
-			delete(r.hashMap, hash)
+	newRing := make([]virtualNode, 0, len(r.ring))
+	for _, vn := range r.ring {
+		if vn.node != node {
+			newRing = append(newRing, vn)
 		}
 	}
 	r.ring = newRing
@@ -167,11 +311,16 @@ func (r *Ring) RemoveNode(node string) {
 //     Returns empty string if no nodes are available in the ring.
 //
 // The algorithm:
-//  1. Hashes the key using CRC32
+//  1. Hashes the key using the ring's hash function (CRC32 by default)
 //  2. Uses binary search to find the first virtual node with hash >= key hash
 //  3. Wraps around to the first node if we're past the end (ring property)
 //  4. Returns the physical node associated with that virtual node
 //
+// With bounded loads enabled (see SetLoadBound), a node whose load exceeds
+// its bound is skipped and the walk continues clockwise to the next node
+// with room, so the result depends on the load figures. Use Owner for the
+// load-independent owner that every caller agrees on.
+//
 // Thread-safety: Safe for concurrent calls (read lock only)
 //
 // Example:
@@ -189,19 +338,192 @@ func (r *Ring) GetNode(key string) string {
 		return ""
 	}
 
-	hash := r.hashKey(key)
+	start := r.search(r.hashKey(key))
+	if r.loadBound <= 0 {
+		return r.ring[start].node
+	}
+	return r.boundedNode(start, len(r.nodes))
+}
 
-	// Binary search for the first node with hash >= key hash
-	idx := sort.Search(len(r.ring), func(i int) bool {
-		return r.ring[i] >= hash
-	})
+// Owner returns the node that owns key: the first node clockwise from the
+// key's position, regardless of load.
+//
+// Owner equals GetNode when bounded loads are disabled. It only depends on
+// the key and the ring membership, so every process agrees on it.
+//
+// Returns:
+//   - string: The owning node, or empty string if the ring is empty
+//
+// Thread-safety: Safe for concurrent calls (read lock only)
+func (r *Ring) Owner(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// Wrap around if we're past the end
-	if idx == len(r.ring) {
-		idx = 0
+	if len(r.ring) == 0 {
+		return ""
 	}
 
-	return r.hashMap[r.ring[idx]]
+	return r.ring[r.search(r.hashKey(key))].node
+}
+
+// GetNodes returns the first n distinct physical nodes clockwise from the
+// key's position on the ring.
+//
+// The first element is the owner (see Owner), so the result can be used as
+// a replica set with a stable primary that does not change with load.
+// Because the walk follows the ring, adding or removing a node changes at
+// most one member of any key's replica set.
+//
+// Parameters:
+//   - key: The cache key to look up
+//   - n: Number of distinct nodes to return. Capped at the ring size.
+//
+// Returns:
+//   - []string: Up to n distinct node identifiers, primary first.
+//     Returns nil if the ring is empty or n <= 0.
+//
+// Thread-safety: Safe for concurrent calls (read lock only)
+//
+// Example:
+//
+//	replicas := ring.GetNodes("user:12345", 3)
+//	// replicas[0] is the primary, replicas[1:] are the backups
+func (r *Ring) GetNodes(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 || n <= 0 {
+		return nil
+	}
+	if n > len(r.nodes) {
+		n = len(r.nodes)
+	}
+
+	start := r.search(r.hashKey(key))
+	result := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+
+	for i := 0; i < len(r.ring) && len(result) < n; i++ {
+		node := r.ring[(start+i)%len(r.ring)].node
+		if !seen[node] {
+			seen[node] = true
+			result = append(result, node)
+		}
+	}
+
+	return result
+}
+
+// GetNodesZoneAware returns n distinct physical nodes for a key, spreading
+// them across as many zones as possible.
+//
+// The ring is walked clockwise from the key's position as in GetNodes, but
+// a node is skipped while its zone is already represented. Once every zone
+// has been used, the remaining slots are filled with the next distinct
+// nodes in ring order. Nodes without a zone (see SetZone) are each treated
+// as their own zone.
+//
+// Parameters:
+//   - key: The cache key to look up
+//   - n: Number of distinct nodes to return. Capped at the ring size.
+//
+// Returns:
+//   - []string: Up to n distinct node identifiers. The first element is
+//     always the owner (see Owner).
+//
+// Thread-safety: Safe for concurrent calls (read lock only)
+//
+// Example:
+//
+//	ring.SetZone("cache-0:8080", "us-east-1a")
+//	ring.SetZone("cache-1:8080", "us-east-1a")
+//	ring.SetZone("cache-2:8080", "us-east-1b")
+//	replicas := ring.GetNodesZoneAware("user:12345", 2) // one node per zone
+func (r *Ring) GetNodesZoneAware(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 || n <= 0 {
+		return nil
+	}
+	if n > len(r.nodes) {
+		n = len(r.nodes)
+	}
+
+	start := r.search(r.hashKey(key))
+	result := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	usedZones := make(map[string]bool, n)
+
+	// First pass: at most one node per zone
+	for i := 0; i < len(r.ring) && len(result) < n; i++ {
+		node := r.ring[(start+i)%len(r.ring)].node
+		if seen[node] {
+			continue
+		}
+
+		zone := r.zoneOf(node)
+		if usedZones[zone] {
+			continue
+		}
+
+		seen[node] = true
+		usedZones[zone] = true
+		result = append(result, node)
+	}
+
+	// Second pass: fewer zones than replicas, fill in ring order
+	for i := 0; i < len(r.ring) && len(result) < n; i++ {
+		node := r.ring[(start+i)%len(r.ring)].node
+		if !seen[node] {
+			seen[node] = true
+			result = append(result, node)
+		}
+	}
+
+	return result
+}
+
+// SetZone assigns a failure domain (e.g., an availability zone or rack)
+// to a node for zone-aware replica placement.
+//
+// Zones do not affect GetNode or GetNodes; they are only used by
+// GetNodesZoneAware.
+//
+// Parameters:
+//   - node: Identifier of a physical node in the ring
+//   - zone: Zone name. An empty zone clears the assignment.
+//
+// Returns:
+//   - bool: True if the node exists in the ring, false otherwise
+//
+// Thread-safety: Safe for concurrent calls
+func (r *Ring) SetZone(node, zone string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.weights[node]; !exists {
+		return false
+	}
+
+	if zone == "" {
+		delete(r.zones, node)
+	} else {
+		r.zones[node] = zone
+	}
+	return true
+}
+
+// Zone returns the zone assigned to a node.
+//
+// Returns:
+//   - string: The node's zone, or empty string if none is assigned
+//
+// Thread-safety: Safe for concurrent calls (read lock only)
+func (r *Ring) Zone(node string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.zones[node]
 }
 
 // Nodes returns a copy of all physical nodes currently in the ring.
@@ -244,6 +566,78 @@ func (r *Ring) Size() int {
 }
 
 // hashKey computes the hash for a given key.
-func (r *Ring) hashKey(key string) uint32 {
-	return crc32.ChecksumIEEE([]byte(key))
+func (r *Ring) hashKey(key string) uint64 {
+	return r.hashFn([]byte(key))
+}
+
+// addVirtualNodes creates the virtual nodes with indices [from, to) for a
+// physical node. The caller must hold the write lock and re-sort the ring.
+func (r *Ring) addVirtualNodes(node string, from, to int) {
+	for i := from; i < to; i++ {
+		r.ring = append(r.ring, virtualNode{
+			hash: r.hashFn([]byte(virtualNodeKey(node, i))),
+			node: node,
+		})
+	}
+}
+
+// removeVirtualNodes removes the virtual nodes with indices [from, to) of a
+// physical node. The caller must hold the write lock. The ring stays sorted.
+func (r *Ring) removeVirtualNodes(node string, from, to int) {
+	// Count per hash so that a node's own colliding virtual nodes outside
+	// the range are kept
+	removed := make(map[uint64]int, to-from)
+	for i := from; i < to; i++ {
+		removed[r.hashFn([]byte(virtualNodeKey(node, i)))]++
+	}
+
+	newRing := make([]virtualNode, 0, len(r.ring))
+	for _, vn := range r.ring {
+		if vn.node == node && removed[vn.hash] > 0 {
+			removed[vn.hash]--
+			continue
+		}
+		newRing = append(newRing, vn)
+	}
+	r.ring = newRing
+}
+
+// sortRing sorts the virtual nodes by hash, breaking ties by node name.
+func (r *Ring) sortRing() {
+	sort.Slice(r.ring, func(i, j int) bool {
+		if r.ring[i].hash != r.ring[j].hash {
+			return r.ring[i].hash < r.ring[j].hash
+		}
+		return r.ring[i].node < r.ring[j].node
+	})
+}
+
+// virtualNodeKey returns the hash input for the i-th virtual node of a
+// physical node.
+func virtualNodeKey(node string, i int) string {
+	return fmt.Sprintf("%s#%d", node, i)
+}
+
+// search returns the index of the first virtual node with hash >= the given
+// hash, wrapping around to 0 past the end. The ring must not be empty.
+func (r *Ring) search(hash uint64) int {
+	// Binary search for the first node with hash >= key hash
+	idx := sort.Search(len(r.ring), func(i int) bool {
+		return r.ring[i].hash >= hash
+	})
+
+	// Wrap around if we're past the end
+	if idx == len(r.ring) {
+		idx = 0
+	}
+	return idx
+}
+
+// zoneOf returns the zone used for placement decisions. Nodes without a
+// zone form their own single-node zone. The caller must hold the lock.
+func (r *Ring) zoneOf(node string) string {
+	if zone, ok := r.zones[node]; ok {
+		return "zone:" + zone
+	}
+	return "node:" + node
 }
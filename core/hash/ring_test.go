@@ -0,0 +1,165 @@
+package hash
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newTestRing(nodes int) *Ring {
+	ring := NewRing(150)
+	for i := 0; i < nodes; i++ {
+		ring.AddNode(fmt.Sprintf("cache-%d:8080", i))
+	}
+	return ring
+}
+
+func testKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	return keys
+}
+
+func contains(nodes []string, node string) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// changedMembers counts the members of after that are not in before.
+func changedMembers(before, after []string) int {
+	inBefore := make(map[string]bool, len(before))
+	for _, node := range before {
+		inBefore[node] = true
+	}
+	changed := 0
+	for _, node := range after {
+		if !inBefore[node] {
+			changed++
+		}
+	}
+	return changed
+}
+
+func TestGetNodesDistinct(t *testing.T) {
+	ring := newTestRing(5)
+
+	for _, key := range testKeys(1000) {
+		replicas := ring.GetNodes(key, 3)
+		if len(replicas) != 3 {
+			t.Fatalf("GetNodes(%q, 3) returned %d nodes", key, len(replicas))
+		}
+
+		seen := make(map[string]bool)
+		for _, node := range replicas {
+			if seen[node] {
+				t.Fatalf("GetNodes(%q, 3) = %v contains %s twice", key, replicas, node)
+			}
+			seen[node] = true
+		}
+
+		if replicas[0] != ring.GetNode(key) {
+			t.Fatalf("GetNodes(%q, 3)[0] = %s, GetNode = %s", key, replicas[0], ring.GetNode(key))
+		}
+	}
+}
+
+func TestGetNodesCapped(t *testing.T) {
+	ring := newTestRing(3)
+
+	if got := ring.GetNodes("key", 10); len(got) != 3 {
+		t.Errorf("GetNodes(key, 10) on 3 nodes returned %d nodes, want 3", len(got))
+	}
+	if got := ring.GetNodes("key", 0); got != nil {
+		t.Errorf("GetNodes(key, 0) = %v, want nil", got)
+	}
+	if got := NewRing(150).GetNodes("key", 3); got != nil {
+		t.Errorf("GetNodes on empty ring = %v, want nil", got)
+	}
+}
+
+func TestGetNodesStableOnAdd(t *testing.T) {
+	ring := newTestRing(5)
+	keys := testKeys(2000)
+
+	before := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		before[key] = ring.GetNodes(key, 3)
+	}
+
+	ring.AddNode("cache-5:8080")
+
+	moved := 0
+	for _, key := range keys {
+		after := ring.GetNodes(key, 3)
+		changed := changedMembers(before[key], after)
+		if changed > 1 {
+			t.Fatalf("adding a node changed %d replicas of %q: %v -> %v", changed, key, before[key], after)
+		}
+		if changed == 1 {
+			if !contains(after, "cache-5:8080") {
+				t.Fatalf("replica set of %q gained %v instead of the new node", key, after)
+			}
+			moved++
+		}
+	}
+
+	// Roughly 3/6 of the keys should gain the new node as a replica
+	if moved == 0 || moved > len(keys)*3/4 {
+		t.Errorf("adding a node changed %d of %d replica sets", moved, len(keys))
+	}
+}
+
+func TestGetNodesStableOnRemove(t *testing.T) {
+	ring := newTestRing(5)
+	keys := testKeys(2000)
+
+	before := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		before[key] = ring.GetNodes(key, 3)
+	}
+
+	ring.RemoveNode("cache-2:8080")
+
+	for _, key := range keys {
+		after := ring.GetNodes(key, 3)
+		changed := changedMembers(before[key], after)
+
+		hadRemoved := contains(before[key], "cache-2:8080")
+		if !hadRemoved && changed != 0 {
+			t.Fatalf("replica set of %q changed without losing a member: %v -> %v", key, before[key], after)
+		}
+		if hadRemoved && changed != 1 {
+			t.Fatalf("replica set of %q changed %d members after losing one: %v -> %v", key, changed, before[key], after)
+		}
+	}
+}
+
+func TestGetNodesZoneAwareSpreadsZones(t *testing.T) {
+	ring := newTestRing(6)
+	for i := 0; i < 6; i++ {
+		ring.SetZone(fmt.Sprintf("cache-%d:8080", i), fmt.Sprintf("zone-%d", i%3))
+	}
+
+	for _, key := range testKeys(500) {
+		replicas := ring.GetNodesZoneAware(key, 3)
+		if len(replicas) != 3 {
+			t.Fatalf("GetNodesZoneAware(%q, 3) returned %d nodes", key, len(replicas))
+		}
+		if replicas[0] != ring.GetNode(key) {
+			t.Fatalf("GetNodesZoneAware(%q, 3)[0] = %s, GetNode = %s", key, replicas[0], ring.GetNode(key))
+		}
+
+		zones := make(map[string]bool)
+		for _, node := range replicas {
+			zones[ring.Zone(node)] = true
+		}
+		if len(zones) != 3 {
+			t.Fatalf("GetNodesZoneAware(%q, 3) = %v uses %d zones, want 3", key, replicas, len(zones))
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package kv
 
 import (
+	"bytes"
+	"sort"
 	"sync"
 	"time"
 )
@@ -17,6 +19,10 @@ type Entry struct {
 	// ExpiresAt is the expiration timestamp
 	// Zero value (time.Time{}) means the entry never expires
 	ExpiresAt time.Time
+
+	// Timestamp is the write timestamp in Unix nanoseconds, used to order
+	// writes to replicas. Zero means the entry was written without one.
+	Timestamp int64
 }
 
 // IsExpired checks if the entry has expired based on current time.
@@ -327,3 +333,369 @@ func (c *Cache) GetTTL(key string) int32 {
 
 	return int32(remaining.Seconds())
 }
+
+// ScanEntry is a live cache entry returned by Scan.
+type ScanEntry struct {
+	// Key is the cache key
+	Key string
+
+	// Value is the stored data
+	Value []byte
+
+	// ExpiresAt is the expiration timestamp (zero means no expiration)
+	ExpiresAt time.Time
+
+	// Timestamp is the write timestamp in Unix nanoseconds (zero if unversioned)
+	Timestamp int64
+}
+
+// Scan returns the live entries whose keys satisfy match, in key order.
+//
+// Scan takes a snapshot of the matching keys, so entries written after the
+// call starts may or may not be included. Callers iterate by passing the
+// last key of the previous page as after.
+//
+// Parameters:
+//   - match: Filter applied to every key. If nil, all keys match.
+//   - after: Only keys strictly greater than after are returned ("" for the first page)
+//   - limit: Maximum number of entries to return. If <= 0, all matching entries are returned.
+//
+// Returns:
+//   - []ScanEntry: Matching entries in ascending key order
+//
+// Thread-safety: Safe for concurrent calls. The read lock is held while
+// collecting keys, which is O(n) in the cache size.
+//
+// Example:
+//
+//	entries := cache.Scan(func(key string) bool {
+//	    return strings.HasPrefix(key, "game-app:")
+//	}, "", 100)
+func (c *Cache) Scan(match func(key string) bool, after string, limit int) []ScanEntry {
+	c.mu.RLock()
+	entries := make([]ScanEntry, 0)
+	for key, entry := range c.store {
+		if after != "" && key <= after {
+			continue
+		}
+		if entry.IsExpired() {
+			continue
+		}
+		if match != nil && !match(key) {
+			continue
+		}
+		entries = append(entries, ScanEntry{
+			Key:       key,
+			Value:     entry.Value,
+			ExpiresAt: entry.ExpiresAt,
+			Timestamp: entry.Timestamp,
+		})
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// SetIfAbsent stores a key-value pair only if the key does not hold a live
+// entry. It is used when copying data between nodes, where a value written
+// by a client must never be overwritten by an older copy.
+//
+// Parameters:
+//   - key: The cache key
+//   - value: The data to store
+//   - expiresAt: Absolute expiration time. Zero means no expiration.
+//   - timestamp: Write timestamp of the copied entry in Unix nanoseconds (0 if unversioned)
+//
+// Returns:
+//   - bool: True if the value was stored, false if a live entry already existed
+//
+// Thread-safety: Safe for concurrent calls
+func (c *Cache) SetIfAbsent(key string, value []byte, expiresAt time.Time, timestamp int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, exists := c.store[key]; exists && !entry.IsExpired() {
+		return false
+	}
+
+	c.store[key] = &Entry{
+		Value:     value,
+		ExpiresAt: expiresAt,
+		Timestamp: timestamp,
+	}
+	c.sets++
+	return true
+}
+
+// GetEntry retrieves a copy of a live entry, including its expiration time
+// and write timestamp.
+//
+// Parameters:
+//   - key: The cache key to look up
+//
+// Returns:
+//   - Entry: A copy of the entry if found and not expired
+//   - bool: True if the key was found and not expired, false otherwise
+//
+// Behavior: Updates hits and misses like Get.
+//
+// Thread-safety: Safe for concurrent calls
+func (c *Cache) GetEntry(key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.store[key]
+	if !exists || entry.IsExpired() {
+		if exists {
+			delete(c.store, key)
+		}
+		c.misses++
+		return Entry{}, false
+	}
+
+	c.hits++
+	return *entry, true
+}
+
+// SetVersioned stores a key-value pair unless the cache holds a live entry
+// with a newer write timestamp (last write wins).
+//
+// Replicas may receive writes for the same key in different orders; storing
+// only the newest write makes every replica converge to the same value.
+//
+// Parameters:
+//   - key: The cache key
+//   - value: The data to store
+//   - ttl: Time-to-live duration. Use 0 for no expiration.
+//   - timestamp: Write timestamp in Unix nanoseconds
+//
+// Returns:
+//   - bool: True if the value was stored, false if a newer entry exists
+//
+// Thread-safety: Safe for concurrent calls
+//
+// Example:
+//
+//	cache.SetVersioned("user:123", data, time.Minute, time.Now().UnixNano())
+func (c *Cache) SetVersioned(key string, value []byte, ttl time.Duration, timestamp int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, exists := c.store[key]; exists && !existing.IsExpired() && existing.Timestamp > timestamp {
+		return false
+	}
+
+	entry := &Entry{
+		Value:     value,
+		Timestamp: timestamp,
+	}
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	c.store[key] = entry
+	c.sets++
+	return true
+}
+
+// Op is one change applied by Apply.
+type Op struct {
+	// Key is the cache key
+	Key string
+
+	// Delete removes the key instead of storing Value
+	Delete bool
+
+	// Value is the data to store
+	Value []byte
+
+	// TTL is the time-to-live. Use 0 for no expiration.
+	TTL time.Duration
+
+	// Timestamp is the write timestamp in Unix nanoseconds. If set, the
+	// value is not stored over a live entry with a newer timestamp, as in
+	// SetVersioned.
+	Timestamp int64
+}
+
+// Apply performs several changes under a single lock, so that concurrent
+// readers observe either none or all of them.
+//
+// Parameters:
+//   - ops: The changes to apply, in order
+//
+// Returns:
+//   - []bool: One result per op. For a delete, whether the key existed; for
+//     a set, whether the value was stored (false if a newer entry exists)
+//
+// Thread-safety: Safe for concurrent calls
+//
+// Example:
+//
+//	applied := cache.Apply([]kv.Op{
+//	    {Key: "report:1", Value: data1, TTL: time.Hour},
+//	    {Key: "report:0", Delete: true},
+//	})
+func (c *Cache) Apply(ops []Op) []bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	applied := make([]bool, len(ops))
+	for i, op := range ops {
+		existing, exists := c.store[op.Key]
+		if op.Delete {
+			delete(c.store, op.Key)
+			applied[i] = exists
+			continue
+		}
+
+		if op.Timestamp > 0 && exists && !existing.IsExpired() && existing.Timestamp > op.Timestamp {
+			continue
+		}
+
+		entry := &Entry{
+			Value:     op.Value,
+			Timestamp: op.Timestamp,
+		}
+		if op.TTL > 0 {
+			entry.ExpiresAt = now.Add(op.TTL)
+		}
+		c.store[op.Key] = entry
+		c.sets++
+		applied[i] = true
+	}
+	return applied
+}
+
+// CheckKind selects the condition a Check tests.
+type CheckKind int
+
+const (
+	// CheckExists holds if the key has a live value
+	CheckExists CheckKind = iota
+
+	// CheckNotExists holds if the key is missing or expired
+	CheckNotExists
+
+	// CheckVersion holds if the key has a live value whose version, the
+	// write timestamp, equals Check.Version
+	CheckVersion
+
+	// CheckValue holds if the key has a live value equal to Check.Value
+	CheckValue
+)
+
+// Check is a condition evaluated by Transaction.
+type Check struct {
+	// Key is the cache key
+	Key string
+
+	// Kind selects the condition
+	Kind CheckKind
+
+	// Version is the expected version for CheckVersion
+	Version int64
+
+	// Value is the expected value for CheckValue
+	Value []byte
+}
+
+// holds reports whether the check is satisfied by an entry.
+func (ch Check) holds(entry *Entry, exists bool) bool {
+	live := exists && !entry.IsExpired()
+	switch ch.Kind {
+	case CheckExists:
+		return live
+	case CheckNotExists:
+		return !live
+	case CheckVersion:
+		return live && entry.Timestamp == ch.Version
+	case CheckValue:
+		return live && bytes.Equal(entry.Value, ch.Value)
+	default:
+		return false
+	}
+}
+
+// TxnResult is the outcome of one op of a transaction.
+type TxnResult struct {
+	// Existed reports whether the key had a live value before the op
+	Existed bool
+
+	// Version is the version written by a set (0 for a delete)
+	Version int64
+}
+
+// Transaction evaluates checks and, only if all of them hold, applies ops,
+// all under a single lock. No other write can happen between the checks and
+// the ops, and readers observe either none or all of the ops.
+//
+// Every set is stored with a new version: op.Timestamp if it is newer than
+// the key's current version, otherwise the current version plus one. Unlike
+// Apply, a set is never skipped because of a newer entry.
+//
+// Parameters:
+//   - checks: Conditions that must all hold
+//   - ops: The changes to apply, in order
+//
+// Returns:
+//   - int: Index of the first check that did not hold, or -1 if ops were applied
+//   - []TxnResult: One result per op (nil if a check failed)
+//
+// Thread-safety: Safe for concurrent calls
+//
+// Example:
+//
+//	failed, results := cache.Transaction(
+//	    []kv.Check{{Key: "acct:1", Kind: kv.CheckVersion, Version: v}},
+//	    []kv.Op{{Key: "acct:1", Value: updated, Timestamp: time.Now().UnixNano()}},
+//	)
+//	if failed >= 0 {
+//	    // acct:1 changed since it was read; retry
+//	}
+func (c *Cache) Transaction(checks []Check, ops []Op) (int, []TxnResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, check := range checks {
+		existing, exists := c.store[check.Key]
+		if !check.holds(existing, exists) {
+			return i, nil
+		}
+	}
+
+	now := time.Now()
+	results := make([]TxnResult, len(ops))
+	for i, op := range ops {
+		existing, exists := c.store[op.Key]
+		results[i].Existed = exists && !existing.IsExpired()
+		if op.Delete {
+			delete(c.store, op.Key)
+			continue
+		}
+
+		version := op.Timestamp
+		if exists && existing.Timestamp >= version {
+			version = existing.Timestamp + 1
+		}
+		entry := &Entry{
+			Value:     op.Value,
+			Timestamp: version,
+		}
+		if op.TTL > 0 {
+			entry.ExpiresAt = now.Add(op.TTL)
+		}
+		c.store[op.Key] = entry
+		c.sets++
+		results[i].Version = version
+	}
+	return -1, results
+}
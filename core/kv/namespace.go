@@ -0,0 +1,127 @@
+package kv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// namespaceMarker starts every namespaced cache key. Namespace names cannot
+// contain control characters, so keys written in the legacy
+// "namespace:key" format never start with it.
+const namespaceMarker = "\x00"
+
+// NamespaceKey encodes a namespace and a client key into a cache key.
+//
+// The namespace is length-prefixed, so the encoding is unambiguous for any
+// namespace name and key: namespace "a:b" with key "c" and namespace "a"
+// with key "b:c" produce different cache keys. SplitNamespaceKey reverses
+// the encoding.
+//
+// Parameters:
+//   - namespace: Namespace name
+//   - key: Client key
+//
+// Returns:
+//   - string: Cache key stored on the nodes
+//
+// Example:
+//
+//	kv.NamespaceKey("game-app", "user:1") // "\x008:game-app:user:1"
+func NamespaceKey(namespace, key string) string {
+	return NamespacePrefix(namespace) + key
+}
+
+// NamespacePrefix returns the prefix shared by all cache keys of a
+// namespace. No key of another namespace starts with it.
+//
+// Parameters:
+//   - namespace: Namespace name
+//
+// Returns:
+//   - string: Prefix for ScanRange and MerkleTree key filters
+func NamespacePrefix(namespace string) string {
+	return namespaceMarker + strconv.Itoa(len(namespace)) + ":" + namespace + ":"
+}
+
+// SplitNamespaceKey parses a cache key built by NamespaceKey.
+//
+// Parameters:
+//   - cacheKey: Cache key as stored on the nodes
+//
+// Returns:
+//   - string: Namespace name
+//   - string: Client key
+//   - bool: False if the key is not in the namespaced encoding, e.g. a key
+//     written in the legacy "namespace:key" format
+//
+// Example:
+//
+//	ns, key, ok := kv.SplitNamespaceKey(cacheKey)
+//	if ok {
+//	    log.Printf("namespace=%s key=%s", ns, key)
+//	}
+func SplitNamespaceKey(cacheKey string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(cacheKey, namespaceMarker)
+	if !ok {
+		return "", "", false
+	}
+
+	length, rest, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", "", false
+	}
+	n, err := strconv.Atoi(length)
+	if err != nil || n < 0 || strconv.Itoa(n) != length || len(rest) <= n || rest[n] != ':' {
+		return "", "", false
+	}
+	return rest[:n], rest[n+1:], true
+}
+
+// RoutingKey returns the part of a cache key that determines its placement.
+//
+// When the client key of a namespaced cache key contains a hash tag, the
+// text between the first '{' and the first '}' after it, only the namespace
+// and the tag are placed. Keys sharing a tag within one namespace therefore
+// land on the same node, while the same tag in another namespace is placed
+// independently. Keys without a tag, keys with an empty tag such as "{}",
+// and keys not in the namespaced encoding are returned unchanged.
+//
+// Proxies hash the routing key to choose nodes, and nodes hash it when they
+// filter scans and build Merkle trees by hash range, so both sides agree on
+// which range a key falls into.
+//
+// Parameters:
+//   - cacheKey: Cache key as stored on the nodes
+//
+// Returns:
+//   - string: Key to hash for placement
+//
+// Example:
+//
+//	profile := kv.NamespaceKey("shop", "{user:42}:profile")
+//	cart := kv.NamespaceKey("shop", "{user:42}:cart")
+//	kv.RoutingKey(profile) == kv.RoutingKey(cart) // true
+func RoutingKey(cacheKey string) string {
+	namespace, key, ok := SplitNamespaceKey(cacheKey)
+	if !ok {
+		return cacheKey
+	}
+	if tag, ok := hashTag(key); ok {
+		return NamespaceKey(namespace, tag)
+	}
+	return cacheKey
+}
+
+// hashTag returns the non-empty text between the first '{' of a client key
+// and the first '}' after it.
+func hashTag(key string) (string, bool) {
+	open := strings.IndexByte(key, '{')
+	if open < 0 {
+		return "", false
+	}
+	tagLen := strings.IndexByte(key[open+1:], '}')
+	if tagLen <= 0 {
+		return "", false
+	}
+	return key[open+1 : open+1+tagLen], true
+}
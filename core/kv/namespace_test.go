@@ -0,0 +1,35 @@
+package kv
+
+import "testing"
+
+func TestRoutingKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"tagged", NamespaceKey("shop", "{user:42}:cart"), NamespaceKey("shop", "user:42")},
+		{"tag in the middle", NamespaceKey("shop", "cart:{user:42}"), NamespaceKey("shop", "user:42")},
+		{"first tag wins", NamespaceKey("shop", "{a}{b}"), NamespaceKey("shop", "a")},
+		{"untagged", NamespaceKey("shop", "user:42"), NamespaceKey("shop", "user:42")},
+		{"empty tag", NamespaceKey("shop", "{}:cart"), NamespaceKey("shop", "{}:cart")},
+		{"unclosed tag", NamespaceKey("shop", "{user:42"), NamespaceKey("shop", "{user:42")},
+		{"not namespaced", "shop:{user:42}:cart", "shop:{user:42}:cart"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoutingKey(tt.key); got != tt.want {
+				t.Errorf("RoutingKey(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutingKeyKeepsNamespaces(t *testing.T) {
+	a := RoutingKey(NamespaceKey("shop", "{user:42}:cart"))
+	b := RoutingKey(NamespaceKey("blog", "{user:42}:cart"))
+	if a == b {
+		t.Errorf("the same tag in two namespaces routes by the same key %q", a)
+	}
+}
@@ -19,6 +19,27 @@ type Metrics struct {
 	cacheHits   atomic.Int64
 	cacheMisses atomic.Int64
 
+	// Replication metrics
+	readRepairs         atomic.Int64
+	antiEntropyRepaired atomic.Int64
+
+	// Hinted handoff metrics
+	hintsStored   atomic.Int64
+	hintsReplayed atomic.Int64
+	hintsDropped  atomic.Int64
+	hintsExpired  atomic.Int64
+
+	// Retry and hedging metrics
+	retries              atomic.Int64
+	hedges               atomic.Int64
+	hedgeWins            atomic.Int64
+	retryBudgetExhausted atomic.Int64
+
+	// Request coalescing metrics
+	getFlights    atomic.Int64
+	coalescedGets atomic.Int64
+	nearCacheHits atomic.Int64
+
 	// Per-namespace metrics
 	mu               sync.RWMutex
 	namespaceMetrics map[string]*NamespaceMetrics
@@ -70,6 +91,150 @@ func (m *Metrics) IncCacheMisses() {
 	m.cacheMisses.Add(1)
 }
 
+// IncReadRepairs increments the counter of stale replicas repaired after
+// a quorum read.
+func (m *Metrics) IncReadRepairs() {
+	m.readRepairs.Add(1)
+}
+
+// GetReadRepairs returns the number of stale replicas repaired.
+func (m *Metrics) GetReadRepairs() int64 {
+	return m.readRepairs.Load()
+}
+
+// AddAntiEntropyRepaired adds to the counter of keys copied between
+// replicas by anti-entropy.
+func (m *Metrics) AddAntiEntropyRepaired(n int64) {
+	m.antiEntropyRepaired.Add(n)
+}
+
+// GetAntiEntropyRepaired returns the number of keys repaired by anti-entropy.
+func (m *Metrics) GetAntiEntropyRepaired() int64 {
+	return m.antiEntropyRepaired.Load()
+}
+
+// IncHintsStored increments the counter of writes stored as hints.
+func (m *Metrics) IncHintsStored() {
+	m.hintsStored.Add(1)
+}
+
+// IncHintsReplayed increments the counter of hints replayed to their owner.
+func (m *Metrics) IncHintsReplayed() {
+	m.hintsReplayed.Add(1)
+}
+
+// IncHintsDropped increments the counter of writes rejected because the
+// hint store was full.
+func (m *Metrics) IncHintsDropped() {
+	m.hintsDropped.Add(1)
+}
+
+// AddHintsExpired adds to the counter of hints discarded after their TTL.
+func (m *Metrics) AddHintsExpired(n int64) {
+	m.hintsExpired.Add(n)
+}
+
+// GetHintsStored returns the number of writes stored as hints.
+func (m *Metrics) GetHintsStored() int64 {
+	return m.hintsStored.Load()
+}
+
+// GetHintsReplayed returns the number of hints replayed to their owner.
+func (m *Metrics) GetHintsReplayed() int64 {
+	return m.hintsReplayed.Load()
+}
+
+// GetHintsDropped returns the number of writes rejected by a full hint store.
+func (m *Metrics) GetHintsDropped() int64 {
+	return m.hintsDropped.Load()
+}
+
+// GetHintsExpired returns the number of hints discarded after their TTL.
+func (m *Metrics) GetHintsExpired() int64 {
+	return m.hintsExpired.Load()
+}
+
+// IncRetries increments the counter of retried node requests.
+func (m *Metrics) IncRetries() {
+	m.retries.Add(1)
+}
+
+// IncHedges increments the counter of hedged replica reads.
+func (m *Metrics) IncHedges() {
+	m.hedges.Add(1)
+}
+
+// IncHedgeWins increments the counter of hedged reads that answered first.
+func (m *Metrics) IncHedgeWins() {
+	m.hedgeWins.Add(1)
+}
+
+// IncRetryBudgetExhausted increments the counter of retries and hedges
+// skipped because the retry budget was used up.
+func (m *Metrics) IncRetryBudgetExhausted() {
+	m.retryBudgetExhausted.Add(1)
+}
+
+// GetRetries returns the number of retried node requests.
+func (m *Metrics) GetRetries() int64 {
+	return m.retries.Load()
+}
+
+// GetHedges returns the number of hedged replica reads.
+func (m *Metrics) GetHedges() int64 {
+	return m.hedges.Load()
+}
+
+// GetHedgeWins returns the number of hedged reads that answered first.
+func (m *Metrics) GetHedgeWins() int64 {
+	return m.hedgeWins.Load()
+}
+
+// GetRetryBudgetExhausted returns the number of retries and hedges skipped
+// because the retry budget was used up.
+func (m *Metrics) GetRetryBudgetExhausted() int64 {
+	return m.retryBudgetExhausted.Load()
+}
+
+// IncGetFlights increments the counter of Gets that were sent to the cache
+// nodes.
+func (m *Metrics) IncGetFlights() {
+	m.getFlights.Add(1)
+}
+
+// IncCoalescedGets increments the counter of Gets that shared the node read
+// of a concurrent Get of the same key.
+func (m *Metrics) IncCoalescedGets() {
+	m.coalescedGets.Add(1)
+}
+
+// GetCoalescedGets returns the number of Gets served by a shared node read.
+func (m *Metrics) GetCoalescedGets() int64 {
+	return m.coalescedGets.Load()
+}
+
+// GetCoalescingRatio returns the fraction of Gets that shared the node read
+// of a concurrent Get (0.0 to 1.0).
+func (m *Metrics) GetCoalescingRatio() float64 {
+	coalesced := m.coalescedGets.Load()
+	total := coalesced + m.getFlights.Load()
+	if total == 0 {
+		return 0.0
+	}
+	return float64(coalesced) / float64(total)
+}
+
+// IncNearCacheHits increments the counter of Gets served from the proxy
+// near cache.
+func (m *Metrics) IncNearCacheHits() {
+	m.nearCacheHits.Add(1)
+}
+
+// GetNearCacheHits returns the number of Gets served from the near cache.
+func (m *Metrics) GetNearCacheHits() int64 {
+	return m.nearCacheHits.Load()
+}
+
 // GetRequestsTotal returns the total number of requests.
 func (m *Metrics) GetRequestsTotal() int64 {
 	return m.requestsTotal.Load()
@@ -399,6 +399,41 @@ func (m *MockProxyClient) BatchGet(ctx context.Context, in *oraclev1.ProxyBatchG
 	return &oraclev1.ProxyBatchGetResponse{}, fmt.Errorf("not implemented in mock")
 }
 
+// BatchSet implements the mock BatchSet RPC call.
+func (m *MockProxyClient) BatchSet(ctx context.Context, in *oraclev1.ProxyBatchSetRequest, opts ...grpc.CallOption) (*oraclev1.ProxyBatchWriteResponse, error) {
+	return &oraclev1.ProxyBatchWriteResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// BatchDelete implements the mock BatchDelete RPC call.
+func (m *MockProxyClient) BatchDelete(ctx context.Context, in *oraclev1.ProxyBatchDeleteRequest, opts ...grpc.CallOption) (*oraclev1.ProxyBatchWriteResponse, error) {
+	return &oraclev1.ProxyBatchWriteResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// SetStream implements the mock SetStream RPC call.
+func (m *MockProxyClient) SetStream(ctx context.Context, opts ...grpc.CallOption) (oraclev1.ProxyService_SetStreamClient, error) {
+	return nil, fmt.Errorf("not implemented in mock")
+}
+
+// GetStream implements the mock GetStream RPC call.
+func (m *MockProxyClient) GetStream(ctx context.Context, in *oraclev1.ProxyGetStreamRequest, opts ...grpc.CallOption) (oraclev1.ProxyService_GetStreamClient, error) {
+	return nil, fmt.Errorf("not implemented in mock")
+}
+
+// PreviewTopology implements the mock PreviewTopology RPC call.
+func (m *MockProxyClient) PreviewTopology(ctx context.Context, in *oraclev1.ProxyPreviewTopologyRequest, opts ...grpc.CallOption) (*oraclev1.ProxyPreviewTopologyResponse, error) {
+	return &oraclev1.ProxyPreviewTopologyResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// Rebalance implements the mock Rebalance RPC call.
+func (m *MockProxyClient) Rebalance(ctx context.Context, in *oraclev1.ProxyRebalanceRequest, opts ...grpc.CallOption) (*oraclev1.ProxyRebalanceResponse, error) {
+	return &oraclev1.ProxyRebalanceResponse{}, nil
+}
+
+// Transaction implements the mock Transaction RPC call.
+func (m *MockProxyClient) Transaction(ctx context.Context, in *oraclev1.ProxyTransactionRequest, opts ...grpc.CallOption) (*oraclev1.ProxyTransactionResponse, error) {
+	return &oraclev1.ProxyTransactionResponse{}, fmt.Errorf("not implemented in mock")
+}
+
 // MockNodeClient implements a mock gRPC node client for testing.
 type MockNodeClient struct {
 	nodeData *MockNode
@@ -443,3 +478,63 @@ func (m *MockNodeClient) Set(ctx context.Context, in *oraclev1.SetRequest, opts
 func (m *MockNodeClient) Delete(ctx context.Context, in *oraclev1.DeleteRequest, opts ...grpc.CallOption) (*oraclev1.DeleteResponse, error) {
 	return &oraclev1.DeleteResponse{}, fmt.Errorf("not implemented in mock")
 }
+
+// MultiGet implements the mock MultiGet RPC call (not used in dashboard).
+func (m *MockNodeClient) MultiGet(ctx context.Context, in *oraclev1.MultiGetRequest, opts ...grpc.CallOption) (*oraclev1.MultiGetResponse, error) {
+	return &oraclev1.MultiGetResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// MultiSet implements the mock MultiSet RPC call (not used in dashboard).
+func (m *MockNodeClient) MultiSet(ctx context.Context, in *oraclev1.MultiSetRequest, opts ...grpc.CallOption) (*oraclev1.MultiSetResponse, error) {
+	return &oraclev1.MultiSetResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// MultiDelete implements the mock MultiDelete RPC call (not used in dashboard).
+func (m *MockNodeClient) MultiDelete(ctx context.Context, in *oraclev1.MultiDeleteRequest, opts ...grpc.CallOption) (*oraclev1.MultiDeleteResponse, error) {
+	return &oraclev1.MultiDeleteResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// SetStream implements the mock SetStream RPC call (not used in dashboard).
+func (m *MockNodeClient) SetStream(ctx context.Context, opts ...grpc.CallOption) (oraclev1.NodeService_SetStreamClient, error) {
+	return nil, fmt.Errorf("not implemented in mock")
+}
+
+// GetStream implements the mock GetStream RPC call (not used in dashboard).
+func (m *MockNodeClient) GetStream(ctx context.Context, in *oraclev1.GetStreamRequest, opts ...grpc.CallOption) (oraclev1.NodeService_GetStreamClient, error) {
+	return nil, fmt.Errorf("not implemented in mock")
+}
+
+// ScanRange implements the mock ScanRange RPC call (not used in dashboard).
+func (m *MockNodeClient) ScanRange(ctx context.Context, in *oraclev1.ScanRangeRequest, opts ...grpc.CallOption) (oraclev1.NodeService_ScanRangeClient, error) {
+	return nil, fmt.Errorf("not implemented in mock")
+}
+
+// Import implements the mock Import RPC call (not used in dashboard).
+func (m *MockNodeClient) Import(ctx context.Context, in *oraclev1.ImportRequest, opts ...grpc.CallOption) (*oraclev1.ImportResponse, error) {
+	return &oraclev1.ImportResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// MerkleTree implements the mock MerkleTree RPC call (not used in dashboard).
+func (m *MockNodeClient) MerkleTree(ctx context.Context, in *oraclev1.MerkleTreeRequest, opts ...grpc.CallOption) (*oraclev1.MerkleTreeResponse, error) {
+	return &oraclev1.MerkleTreeResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// Replicate implements the mock Replicate RPC call (not used in dashboard).
+func (m *MockNodeClient) Replicate(ctx context.Context, opts ...grpc.CallOption) (oraclev1.NodeService_ReplicateClient, error) {
+	return nil, fmt.Errorf("not implemented in mock")
+}
+
+// Promote implements the mock Promote RPC call (not used in dashboard).
+func (m *MockNodeClient) Promote(ctx context.Context, in *oraclev1.PromoteRequest, opts ...grpc.CallOption) (*oraclev1.PromoteResponse, error) {
+	return &oraclev1.PromoteResponse{}, fmt.Errorf("not implemented in mock")
+}
+
+// WatchKeys implements the mock WatchKeys RPC call (not used in dashboard).
+func (m *MockNodeClient) WatchKeys(ctx context.Context, opts ...grpc.CallOption) (oraclev1.NodeService_WatchKeysClient, error) {
+	return nil, fmt.Errorf("not implemented in mock")
+}
+
+// Transaction implements the mock Transaction RPC call (not used in dashboard).
+func (m *MockNodeClient) Transaction(ctx context.Context, in *oraclev1.TransactionRequest, opts ...grpc.CallOption) (*oraclev1.TransactionResponse, error) {
+	return &oraclev1.TransactionResponse{}, fmt.Errorf("not implemented in mock")
+}
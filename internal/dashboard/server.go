@@ -256,6 +256,11 @@ func (s *Server) Run(port int) error {
 		api.GET("/metrics/namespaces", s.authMiddleware(), s.handleMetricsNamespaces)
 		api.GET("/metrics/nodes", s.authMiddleware(), s.handleMetricsNodes)
 		api.GET("/metrics/proxy", s.authMiddleware(), s.handleMetricsProxy)
+
+		// Topology planning (auth required)
+		api.POST("/topology/preview", s.authMiddleware(), s.handleTopologyPreview)
+		api.GET("/rebalance", s.authMiddleware(), s.handleRebalance)
+		api.POST("/rebalance/:action", s.authMiddleware(), s.handleRebalance)
 	}
 
 	// Mark service as healthy and ready
@@ -517,6 +522,124 @@ func (s *Server) handleMetricsProxy(c *gin.Context) {
 	})
 }
 
+// topologyPreviewRequest is the body of a topology preview request.
+type topologyPreviewRequest struct {
+	AddNodes     []string         `json:"addNodes"`
+	RemoveNodes  []string         `json:"removeNodes"`
+	Weights      map[string]int32 `json:"weights"`
+	IncludeMoves bool             `json:"includeMoves"`
+}
+
+// handleTopologyPreview returns the share of the keyspace that would move
+// if nodes were added or removed, so operators can judge a scale-up or
+// scale-down before applying it.
+func (s *Server) handleTopologyPreview(c *gin.Context) {
+	ctx := context.Background()
+
+	if s.proxyClient == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "proxy not configured"})
+		return
+	}
+
+	var req topologyPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := s.proxyClient.PreviewTopology(ctx, &oraclev1.ProxyPreviewTopologyRequest{
+		AddNodes:     req.AddNodes,
+		RemoveNodes:  req.RemoveNodes,
+		Weights:      req.Weights,
+		IncludeMoves: req.IncludeMoves,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfers := make([]map[string]interface{}, 0, len(resp.Transfers))
+	for _, t := range resp.Transfers {
+		transfers = append(transfers, map[string]interface{}{
+			"from":     t.FromNode,
+			"to":       t.ToNode,
+			"fraction": t.Fraction,
+		})
+	}
+
+	moves := make([]map[string]interface{}, 0, len(resp.Moves))
+	for _, m := range resp.Moves {
+		moves = append(moves, map[string]interface{}{
+			// Hash values exceed JavaScript's safe integer range
+			"start": fmt.Sprintf("%d", m.Start),
+			"end":   fmt.Sprintf("%d", m.End),
+			"from":  m.FromNode,
+			"to":    m.ToNode,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"movedFraction": resp.MovedFraction,
+		"nodesBefore":   resp.NodesBefore,
+		"nodesAfter":    resp.NodesAfter,
+		"transfers":     transfers,
+		"moves":         moves,
+	})
+}
+
+// handleRebalance reports the progress of key migration after a ring
+// change. POST /api/rebalance/pause and /api/rebalance/resume control it.
+func (s *Server) handleRebalance(c *gin.Context) {
+	ctx := context.Background()
+
+	if s.proxyClient == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "proxy not configured"})
+		return
+	}
+
+	action := oraclev1.RebalanceAction_REBALANCE_ACTION_STATUS
+	switch c.Param("action") {
+	case "":
+	case "pause":
+		action = oraclev1.RebalanceAction_REBALANCE_ACTION_PAUSE
+	case "resume":
+		action = oraclev1.RebalanceAction_REBALANCE_ACTION_RESUME
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown action, expected 'pause' or 'resume'"})
+		return
+	}
+
+	resp, err := s.proxyClient.Rebalance(ctx, &oraclev1.ProxyRebalanceRequest{Action: action})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sources := make([]map[string]interface{}, 0, len(resp.Sources))
+	for _, src := range resp.Sources {
+		sources = append(sources, map[string]interface{}{
+			"node":         src.Node,
+			"ranges":       src.Ranges,
+			"done":         src.Done,
+			"keysMigrated": src.KeysMigrated,
+			"attempts":     src.Attempts,
+			"lastError":    src.LastError,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"active":        resp.Active,
+		"paused":        resp.Paused,
+		"movedFraction": resp.MovedFraction,
+		"keysScanned":   resp.KeysScanned,
+		"keysMigrated":  resp.KeysMigrated,
+		"keysSkipped":   resp.KeysSkipped,
+		"errors":        resp.Errors,
+		"startedAt":     resp.StartedAt,
+		"sources":       sources,
+	})
+}
+
 // handleWebSocket handles WebSocket connections (stub for now).
 func (s *Server) handleWebSocket(c *gin.Context) {
 	// WebSocket support is planned but not yet implemented
@@ -0,0 +1,153 @@
+package node
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+)
+
+// Merkle tree depth limits; a tree has 2^depth leaves.
+const (
+	DefaultMerkleDepth = 10
+	MaxMerkleDepth     = 16
+)
+
+// merkleRebuildInterval bounds how long entry digests are reused before the
+// index is rebuilt from the cache. Expired and evicted entries leave the
+// cache without a mutation, so the index drifts slowly between rebuilds.
+const merkleRebuildInterval = 5 * time.Minute
+
+// merkleIndex keeps a digest of every cache entry, updated on each committed
+// mutation, so that Merkle trees can be built without rehashing values.
+type merkleIndex struct {
+	mu      sync.Mutex
+	digests map[string]uint64
+	builtAt time.Time
+}
+
+// entryDigest hashes the parts of an entry that replicas must agree on. The
+// expiration time is left out: replicas of the same write expire at
+// slightly different instants.
+func entryDigest(key string, value []byte, timestamp int64) uint64 {
+	d := xxhash.New()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(len(key)))
+	d.Write(buf[:])
+	d.WriteString(key)
+	d.Write(value)
+	binary.BigEndian.PutUint64(buf[:], uint64(timestamp))
+	d.Write(buf[:])
+	return d.Sum64()
+}
+
+// updateMerkle refreshes the digest of a key after a committed mutation.
+func (s *Server) updateMerkle(key string) {
+	idx := &s.merkle
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	// Not built yet; the first tree request builds it from the cache
+	if idx.digests == nil {
+		return
+	}
+
+	// Read under the index lock so the latest of concurrent updates wins
+	entry, found := s.cache.GetEntry(key)
+	if !found {
+		delete(idx.digests, key)
+		return
+	}
+	idx.digests[key] = entryDigest(key, entry.Value, entry.Timestamp)
+}
+
+// resetMerkle drops the index, e.g. after the cache was cleared.
+func (s *Server) resetMerkle() {
+	s.merkle.mu.Lock()
+	defer s.merkle.mu.Unlock()
+	s.merkle.digests = nil
+}
+
+// merkleDigests returns a copy of the entry digests, rebuilding the index
+// from the cache if it is missing or older than merkleRebuildInterval.
+func (s *Server) merkleDigests(match func(key string) bool) map[string]uint64 {
+	idx := &s.merkle
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.digests == nil || time.Since(idx.builtAt) > merkleRebuildInterval {
+		entries := s.cache.Scan(nil, "", 0)
+		idx.digests = make(map[string]uint64, len(entries))
+		for _, entry := range entries {
+			idx.digests[entry.Key] = entryDigest(entry.Key, entry.Value, entry.Timestamp)
+		}
+		idx.builtAt = time.Now()
+	}
+
+	digests := make(map[string]uint64)
+	for key, digest := range idx.digests {
+		if match(key) {
+			digests[key] = digest
+		}
+	}
+	return digests
+}
+
+// MerkleTree returns a Merkle tree over the entries in the requested hash
+// ranges.
+//
+// Leaf i covers the i-th of 2^depth equal slices of the 64-bit key hash
+// space and combines the digests of its entries; inner nodes hash their two
+// children. Two replicas hold the same entries in a slice exactly when
+// their leaves match (barring hash collisions), so anti-entropy only needs
+// to exchange the keys of differing leaves.
+func (s *Server) MerkleTree(ctx context.Context, req *oraclev1.MerkleTreeRequest) (*oraclev1.MerkleTreeResponse, error) {
+	depth := int(req.Depth)
+	if depth <= 0 {
+		depth = DefaultMerkleDepth
+	}
+	if depth > MaxMerkleDepth {
+		return nil, status.Errorf(codes.InvalidArgument, "merkle tree depth %d exceeds maximum %d", depth, MaxMerkleDepth)
+	}
+
+	match, err := keyMatcher(req.Ranges, req.HashFunction, req.KeyPrefixes)
+	if err != nil {
+		return nil, err
+	}
+	hashFn, _ := hash.HashFuncByName(req.HashFunction)
+
+	digests := s.merkleDigests(match)
+
+	// XOR makes a leaf independent of the order its entries are visited in
+	leaves := make([]uint64, 1<<depth)
+	for key, digest := range digests {
+		leaves[hashFn([]byte(kv.RoutingKey(key)))>>(64-depth)] ^= digest
+	}
+
+	level := leaves
+	for len(level) > 1 {
+		next := make([]uint64, len(level)/2)
+		var buf [16]byte
+		for i := range next {
+			binary.BigEndian.PutUint64(buf[:8], level[2*i])
+			binary.BigEndian.PutUint64(buf[8:], level[2*i+1])
+			next[i] = xxhash.Sum64(buf[:])
+		}
+		level = next
+	}
+
+	return &oraclev1.MerkleTreeResponse{
+		Root:   level[0],
+		Leaves: leaves,
+		Keys:   int64(len(digests)),
+	}, nil
+}
@@ -0,0 +1,159 @@
+package node
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+)
+
+// DefaultScanBatchSize is the number of entries per ScanRange response
+// when the request does not specify one.
+const DefaultScanBatchSize = 100
+
+// ScanRange streams the entries whose key hashes fall into the requested
+// hash ranges, in ascending key order.
+//
+// Keys are hashed with the same function as the proxy ring, so the ranges
+// of an ownership diff select exactly the keys that changed owner. Batches
+// are additionally capped by the gRPC message size. A scan can be resumed
+// after an interruption by passing the last received key as after.
+func (s *Server) ScanRange(req *oraclev1.ScanRangeRequest, stream oraclev1.NodeService_ScanRangeServer) error {
+	match, err := keyMatcher(req.Ranges, req.HashFunction, req.KeyPrefixes)
+	if err != nil {
+		return err
+	}
+
+	batchSize := int(req.BatchSize)
+	if batchSize <= 0 {
+		batchSize = DefaultScanBatchSize
+	}
+	maxBatchBytes := s.maxMessageSize - chunkEnvelopeBytes
+
+	entries := s.cache.Scan(match, req.After, 0)
+
+	batch := &oraclev1.ScanRangeResponse{}
+	batchBytes := 0
+	flush := func() error {
+		if len(batch.Entries) == 0 {
+			return nil
+		}
+		if err := stream.Send(batch); err != nil {
+			return err
+		}
+		batch = &oraclev1.ScanRangeResponse{}
+		batchBytes = 0
+		return nil
+	}
+
+	for _, entry := range entries {
+		var ttlMs int64
+		if !entry.ExpiresAt.IsZero() {
+			ttlMs = time.Until(entry.ExpiresAt).Milliseconds()
+			if ttlMs <= 0 {
+				// Expired while the scan was running
+				continue
+			}
+		}
+
+		size := len(entry.Key) + len(entry.Value)
+		if len(batch.Entries) >= batchSize || (len(batch.Entries) > 0 && batchBytes+size > maxBatchBytes) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		batch.Entries = append(batch.Entries, &oraclev1.MigrationEntry{
+			Key:       entry.Key,
+			Value:     entry.Value,
+			TtlMs:     ttlMs,
+			Timestamp: entry.Timestamp,
+		})
+		batchBytes += size
+	}
+
+	return flush()
+}
+
+// keyMatcher returns a filter for keys whose hash falls into one of the
+// ranges and that start with one of the prefixes (any prefix if empty).
+func keyMatcher(reqRanges []*oraclev1.KeyHashRange, hashFunction string, prefixes []string) (func(key string) bool, error) {
+	hashFn, err := hash.HashFuncByName(hashFunction)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ranges := make([]hash.HashRange, 0, len(reqRanges))
+	for _, r := range reqRanges {
+		if r.Start > r.End {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid hash range [%d, %d]", r.Start, r.End)
+		}
+		ranges = append(ranges, hash.HashRange{Start: r.Start, End: r.End})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	return func(key string) bool {
+		if len(prefixes) > 0 && !slices.ContainsFunc(prefixes, func(prefix string) bool {
+			return strings.HasPrefix(key, prefix)
+		}) {
+			return false
+		}
+
+		h := hashFn([]byte(kv.RoutingKey(key)))
+		// Find the last range starting at or before h
+		i := sort.Search(len(ranges), func(i int) bool {
+			return ranges[i].Start > h
+		}) - 1
+		return i >= 0 && ranges[i].Contains(h)
+	}, nil
+}
+
+// Import stores migrated entries, preserving their remaining TTL.
+//
+// By default, keys that already hold a live value are skipped: a value
+// written by a client after the ring change is always newer than the
+// migrated copy. Versioned imports, used by anti-entropy repair, also
+// replace entries with an older write timestamp.
+func (s *Server) Import(ctx context.Context, req *oraclev1.ImportRequest) (*oraclev1.ImportResponse, error) {
+	resp := &oraclev1.ImportResponse{}
+	now := time.Now()
+
+	for _, entry := range req.Entries {
+		var expiresAt time.Time
+		if entry.TtlMs > 0 {
+			expiresAt = now.Add(time.Duration(entry.TtlMs) * time.Millisecond)
+		}
+
+		imported := s.commit(loggedMutation{
+			op:        oraclev1.MutationOp_MUTATION_OP_SET,
+			key:       entry.Key,
+			value:     entry.Value,
+			expiresAt: expiresAt,
+			timestamp: entry.Timestamp,
+		}, func() bool {
+			if req.Versioned && entry.Timestamp > 0 {
+				return s.cache.SetVersioned(entry.Key, entry.Value, time.Duration(entry.TtlMs)*time.Millisecond, entry.Timestamp)
+			}
+			return s.cache.SetIfAbsent(entry.Key, entry.Value, expiresAt, entry.Timestamp)
+		})
+		if imported {
+			resp.Imported++
+			resp.ImportedKeys = append(resp.ImportedKeys, entry.Key)
+		} else {
+			resp.Skipped++
+		}
+	}
+
+	return resp, nil
+}
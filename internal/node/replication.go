@@ -0,0 +1,636 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// DefaultReplicationLogSize is the number of mutations a primary keeps for
+// followers that fall behind. A follower further behind receives a full
+// snapshot instead.
+const DefaultReplicationLogSize = 100000
+
+// Replication roles reported in StatsResponse.
+const (
+	RolePrimary    = "primary"
+	RoleFollower   = "follower"
+	RoleStandalone = "standalone"
+)
+
+// replicationBatchSize is the maximum number of mutations per message.
+const replicationBatchSize = 100
+
+// replicationRetryInterval is how long a primary waits before reconnecting
+// to a follower after the stream failed.
+const replicationRetryInterval = 2 * time.Second
+
+// loggedMutation is a committed change kept in the replication log.
+type loggedMutation struct {
+	seq        uint64
+	op         oraclev1.MutationOp
+	key        string
+	value      []byte
+	expiresAt  time.Time
+	timestamp  int64
+	appendedAt time.Time
+}
+
+// toProto converts a logged mutation for sending, with its remaining TTL.
+func (m *loggedMutation) toProto(now time.Time) *oraclev1.Mutation {
+	pm := &oraclev1.Mutation{
+		Seq:       m.seq,
+		Op:        m.op,
+		Key:       m.key,
+		Value:     m.value,
+		Timestamp: m.timestamp,
+	}
+	if !m.expiresAt.IsZero() {
+		// Keep already expired entries expiring immediately rather than never
+		pm.TtlMs = max(m.expiresAt.Sub(now).Milliseconds(), 1)
+	}
+	return pm
+}
+
+// replicationLog is a bounded, in-order log of the mutations committed on a
+// primary. Sequence numbers start at 1 and are only meaningful within the
+// log's epoch, which changes on every restart.
+type replicationLog struct {
+	mu       sync.Mutex
+	epoch    int64
+	capacity int
+	entries  []loggedMutation
+	head     uint64
+
+	// notify is closed and replaced whenever a mutation is appended
+	notify chan struct{}
+}
+
+// newReplicationLog creates an empty log that keeps up to capacity mutations.
+func newReplicationLog(capacity int) *replicationLog {
+	if capacity <= 0 {
+		capacity = DefaultReplicationLogSize
+	}
+	return &replicationLog{
+		epoch:    time.Now().UnixNano(),
+		capacity: capacity,
+		notify:   make(chan struct{}),
+	}
+}
+
+// append adds a mutation and wakes waiting senders. The caller must hold
+// the lock, so that log order matches the order of cache writes.
+func (l *replicationLog) append(m loggedMutation) {
+	l.head++
+	m.seq = l.head
+	m.appendedAt = time.Now()
+
+	l.entries = append(l.entries, m)
+	if len(l.entries) > l.capacity {
+		l.entries = l.entries[len(l.entries)-l.capacity:]
+	}
+
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// since returns the mutations after seq, limited by count and total size.
+//
+// Returns:
+//   - []loggedMutation: The next mutations, empty if the follower is up to date
+//   - <-chan struct{}: Closed when further mutations are appended
+//   - bool: False if the mutations after seq are no longer in the log
+func (l *replicationLog) since(seq uint64, maxCount, maxBytes int) ([]loggedMutation, <-chan struct{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if seq > l.head {
+		return nil, l.notify, false
+	}
+	if seq == l.head {
+		return nil, l.notify, true
+	}
+	if len(l.entries) == 0 || l.entries[0].seq > seq+1 {
+		return nil, l.notify, false
+	}
+
+	start := int(seq + 1 - l.entries[0].seq)
+	size := 0
+	end := start
+	for end < len(l.entries) && end-start < maxCount {
+		size += len(l.entries[end].key) + len(l.entries[end].value)
+		if end > start && size > maxBytes {
+			break
+		}
+		end++
+	}
+
+	batch := make([]loggedMutation, end-start)
+	copy(batch, l.entries[start:end])
+	return batch, l.notify, true
+}
+
+// lag returns the head sequence number and the time the oldest mutation
+// after seq was appended (zero if there is none or it left the log).
+func (l *replicationLog) lag(seq uint64) (uint64, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if seq >= l.head || len(l.entries) == 0 || l.entries[0].seq > seq+1 {
+		return l.head, time.Time{}
+	}
+	return l.head, l.entries[seq+1-l.entries[0].seq].appendedAt
+}
+
+// followerState tracks the replication stream to one follower.
+type followerState struct {
+	addr      string
+	connected atomic.Bool
+	acked     atomic.Uint64
+}
+
+// primaryState tracks the stream this node receives as a follower.
+type primaryState struct {
+	mu         sync.Mutex
+	primary    string
+	epoch      int64
+	applied    uint64
+	connected  bool
+	promoted   bool
+	generation int
+}
+
+// SetReplication configures this node as a primary that streams its
+// mutations to the given followers. It must be called before Run.
+//
+// Parameters:
+//   - nodeID: Identity reported to followers (e.g. the pod name)
+//   - followers: Follower node addresses (e.g. ["cache-node-1:8080"])
+//   - logSize: Number of mutations kept for lagging followers (0 = default)
+//
+// Example:
+//
+//	server := node.NewServer()
+//	server.SetReplication("cache-node-0", []string{"cache-node-0-backup:8080"}, 0)
+func (s *Server) SetReplication(nodeID string, followers []string, logSize int) {
+	if len(followers) == 0 {
+		return
+	}
+
+	s.nodeID = nodeID
+	s.replLog = newReplicationLog(logSize)
+	for _, addr := range followers {
+		s.followers = append(s.followers, &followerState{addr: addr})
+	}
+}
+
+// commit applies a change to the cache and, on a primary, appends it to the
+// replication log under the same lock so that followers see changes in
+// commit order. Changes that apply reports as no-ops are not replicated.
+func (s *Server) commit(m loggedMutation, apply func() bool) bool {
+	if s.replLog == nil {
+		if !apply() {
+			return false
+		}
+		s.updateMerkle(m.key)
+		s.notifyChange(m.key)
+		return true
+	}
+
+	s.replLog.mu.Lock()
+	defer s.replLog.mu.Unlock()
+
+	if !apply() {
+		return false
+	}
+	s.replLog.append(m)
+	s.updateMerkle(m.key)
+	s.notifyChange(m.key)
+	return true
+}
+
+// commitBatch applies several changes at once, like commit. The changes
+// become visible together and are replicated in order; followers apply
+// them one by one.
+func (s *Server) commitBatch(ms []loggedMutation, apply func() []bool) []bool {
+	if s.replLog != nil {
+		s.replLog.mu.Lock()
+		defer s.replLog.mu.Unlock()
+	}
+
+	applied := apply()
+	for i, m := range ms {
+		if !applied[i] {
+			continue
+		}
+		if s.replLog != nil {
+			s.replLog.append(m)
+		}
+		s.updateMerkle(m.key)
+		s.notifyChange(m.key)
+	}
+	return applied
+}
+
+// startReplication starts one sender per configured follower.
+func (s *Server) startReplication() {
+	for _, f := range s.followers {
+		go s.replicateTo(f)
+	}
+}
+
+// replicateTo keeps a replication stream to one follower open until the
+// node stops, reconnecting after failures.
+func (s *Server) replicateTo(f *followerState) {
+	for {
+		err := s.streamTo(f)
+		f.connected.Store(false)
+		if status.Code(err) == codes.FailedPrecondition {
+			// The follower was promoted in place of this node
+			s.deposed.Store(true)
+			s.logger.Error("Follower %s was promoted; this node no longer serves as primary", f.addr)
+			return
+		}
+		if err != nil {
+			s.logger.Warn("Replication to %s interrupted: %v", f.addr, err)
+		}
+
+		select {
+		case <-s.stopCh:
+			return
+		case <-time.After(replicationRetryInterval):
+		}
+	}
+}
+
+// streamTo runs one replication stream: it resumes after the follower's
+// last acknowledged mutation, or sends a snapshot if those mutations are no
+// longer in the log.
+func (s *Server) streamTo(f *followerState) error {
+	conn, err := grpc.NewClient(f.addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(s.maxMessageSize),
+			grpc.MaxCallSendMsgSize(s.maxMessageSize),
+		),
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := oraclev1.NewNodeServiceClient(conn).Replicate(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := stream.Send(&oraclev1.ReplicateRequest{
+		Primary: s.nodeID,
+		Epoch:   s.replLog.epoch,
+	}); err != nil {
+		return err
+	}
+	ack, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	f.connected.Store(true)
+	f.acked.Store(ack.AckedSeq)
+	s.logger.Info("Replicating to %s from seq %d", f.addr, ack.AckedSeq)
+
+	// Acknowledgements arrive independently of sends
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			f.acked.Store(resp.AckedSeq)
+		}
+	}()
+
+	maxBatchBytes := s.maxMessageSize - chunkEnvelopeBytes
+	sent := ack.AckedSeq
+	synced := sent > 0
+	for {
+		batch, wait, ok := s.replLog.since(sent, replicationBatchSize, maxBatchBytes)
+
+		// A follower that has applied nothing from this epoch may still hold
+		// data from a previous one, so it starts from a snapshot as well
+		if !ok || !synced {
+			if !ok {
+				s.logger.Warn("Follower %s is behind the replication log; sending a snapshot", f.addr)
+			}
+			if sent, err = s.sendSnapshot(stream); err != nil {
+				return err
+			}
+			synced = true
+			continue
+		}
+
+		if len(batch) == 0 {
+			select {
+			case <-wait:
+				continue
+			case err := <-recvErr:
+				if errors.Is(err, io.EOF) {
+					return fmt.Errorf("follower closed the stream")
+				}
+				return err
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		now := time.Now()
+		req := &oraclev1.ReplicateRequest{Mutations: make([]*oraclev1.Mutation, len(batch))}
+		for i := range batch {
+			req.Mutations[i] = batch[i].toProto(now)
+		}
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+		sent = batch[len(batch)-1].seq
+	}
+}
+
+// sendSnapshot sends all live entries to a follower, which replaces its
+// data with them. Mutations committed while the snapshot is taken are sent
+// again afterwards; applying them twice is harmless. The follower only
+// acknowledges the snapshot once its last message arrived.
+//
+// Returns:
+//   - uint64: The sequence number the snapshot corresponds to
+//   - error: Error if sending failed
+func (s *Server) sendSnapshot(stream oraclev1.NodeService_ReplicateClient) (uint64, error) {
+	s.replLog.mu.Lock()
+	seq := s.replLog.head
+	s.replLog.mu.Unlock()
+
+	entries := s.cache.Scan(nil, "", 0)
+	maxBatchBytes := s.maxMessageSize - chunkEnvelopeBytes
+
+	req := &oraclev1.ReplicateRequest{FullSync: true, SnapshotSeq: seq}
+	size := 0
+	now := time.Now()
+	for _, entry := range entries {
+		entrySize := len(entry.Key) + len(entry.Value)
+		if len(req.Mutations) >= replicationBatchSize || (len(req.Mutations) > 0 && size+entrySize > maxBatchBytes) {
+			if err := stream.Send(req); err != nil {
+				return 0, err
+			}
+			req = &oraclev1.ReplicateRequest{SnapshotSeq: seq}
+			size = 0
+		}
+
+		m := loggedMutation{
+			seq:       seq,
+			op:        oraclev1.MutationOp_MUTATION_OP_SET,
+			key:       entry.Key,
+			value:     entry.Value,
+			expiresAt: entry.ExpiresAt,
+			timestamp: entry.Timestamp,
+		}
+		req.Mutations = append(req.Mutations, m.toProto(now))
+		size += entrySize
+	}
+
+	// The last (or only, if the cache is empty) message of the snapshot
+	req.SnapshotDone = true
+	if err := stream.Send(req); err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("Sent snapshot of %d entries at seq %d", len(entries), seq)
+	return seq, nil
+}
+
+// Replicate receives mutations from a primary and applies them in order,
+// acknowledging the last applied sequence number after every message.
+// During a snapshot the follower acknowledges 0 until the snapshot's last
+// message arrived, so a stream cut mid-snapshot is followed by a new one.
+//
+// Only one stream is active at a time; a new stream supersedes the previous
+// one. After Promote, streams are rejected with FAILED_PRECONDITION.
+func (s *Server) Replicate(stream oraclev1.NodeService_ReplicateServer) error {
+	hello, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	s.following.mu.Lock()
+	if s.following.promoted {
+		s.following.mu.Unlock()
+		return status.Error(codes.FailedPrecondition, "node was promoted to primary and no longer accepts replication")
+	}
+	if s.following.primary != hello.Primary || s.following.epoch != hello.Epoch {
+		// A new primary, or the primary restarted: sequence numbers start over
+		s.following.primary = hello.Primary
+		s.following.epoch = hello.Epoch
+		s.following.applied = 0
+	}
+	s.following.generation++
+	generation := s.following.generation
+	s.following.connected = true
+	applied := s.following.applied
+	s.following.mu.Unlock()
+
+	s.logger.Info("Following primary %s from seq %d", hello.Primary, applied)
+
+	defer func() {
+		s.following.mu.Lock()
+		if s.following.generation == generation {
+			s.following.connected = false
+		}
+		s.following.mu.Unlock()
+	}()
+
+	if err := stream.Send(&oraclev1.ReplicateResponse{AckedSeq: applied}); err != nil {
+		return err
+	}
+
+	// A snapshot is in progress between its full_sync and snapshot_done
+	// messages; the data is partial until then
+	snapshotting := false
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		s.following.mu.Lock()
+		superseded := s.following.generation != generation
+		promoted := s.following.promoted
+		s.following.mu.Unlock()
+		if promoted {
+			return status.Error(codes.FailedPrecondition, "node was promoted to primary")
+		}
+		if superseded {
+			return status.Error(codes.Aborted, "replication stream superseded by a newer one")
+		}
+
+		if req.FullSync {
+			s.cache.Clear()
+			s.resetMerkle()
+			s.notifyAll()
+			snapshotting = true
+		}
+		var last uint64
+		for _, m := range req.Mutations {
+			s.applyMutation(m)
+			last = max(last, m.Seq)
+		}
+
+		s.following.mu.Lock()
+		switch {
+		case req.FullSync && !req.SnapshotDone:
+			// Nothing counts as applied until the whole snapshot arrived
+			s.following.applied = 0
+		case snapshotting && req.SnapshotDone:
+			s.following.applied = req.SnapshotSeq
+			snapshotting = false
+		case !snapshotting && last > s.following.applied:
+			s.following.applied = last
+		}
+		applied = s.following.applied
+		s.following.mu.Unlock()
+
+		if err := stream.Send(&oraclev1.ReplicateResponse{AckedSeq: applied}); err != nil {
+			return err
+		}
+	}
+}
+
+// applyMutation applies one replicated change. It goes through commit, so a
+// follower with followers of its own passes the change on.
+func (s *Server) applyMutation(m *oraclev1.Mutation) {
+	var expiresAt time.Time
+	if m.TtlMs > 0 {
+		expiresAt = time.Now().Add(time.Duration(m.TtlMs) * time.Millisecond)
+	}
+	logged := loggedMutation{
+		op:        m.Op,
+		key:       m.Key,
+		value:     m.Value,
+		expiresAt: expiresAt,
+		timestamp: m.Timestamp,
+	}
+
+	switch m.Op {
+	case oraclev1.MutationOp_MUTATION_OP_DELETE:
+		s.commit(logged, func() bool {
+			return s.cache.Delete(m.Key)
+		})
+	default:
+		ttl := time.Duration(m.TtlMs) * time.Millisecond
+		s.commit(logged, func() bool {
+			if m.Timestamp > 0 {
+				return s.cache.SetVersioned(m.Key, m.Value, ttl, m.Timestamp)
+			}
+			s.cache.Set(m.Key, m.Value, ttl)
+			return true
+		})
+	}
+}
+
+// Promote turns this follower into a primary. The active replication
+// stream is ended and later streams are rejected, so a former primary that
+// comes back cannot overwrite data written after the failover; the former
+// primary then reports itself unhealthy (see Health).
+//
+// Promotion is idempotent: every proxy failing over the same primary gets
+// the same response, and the applied sequence number no longer changes.
+func (s *Server) Promote(ctx context.Context, req *oraclev1.PromoteRequest) (*oraclev1.PromoteResponse, error) {
+	s.following.mu.Lock()
+	defer s.following.mu.Unlock()
+
+	if !s.following.promoted {
+		s.following.promoted = true
+		s.following.generation++
+		s.following.connected = false
+		s.logger.Warn("Promoted to primary (was following %q at seq %d)", s.following.primary, s.following.applied)
+	}
+
+	return &oraclev1.PromoteResponse{
+		FormerPrimary: s.following.primary,
+		AppliedSeq:    s.following.applied,
+	}, nil
+}
+
+// replicationStatus reports the node's role and the state of its
+// replication streams for StatsResponse.
+func (s *Server) replicationStatus() (string, []*oraclev1.ReplicationStatus) {
+	var statuses []*oraclev1.ReplicationStatus
+	role := RoleStandalone
+
+	// A promoted follower keeps reporting how far it got, so that proxies
+	// failing over later pick the same node (see Promote)
+	s.following.mu.Lock()
+	if s.following.primary != "" {
+		role = RoleFollower
+		statuses = append(statuses, &oraclev1.ReplicationStatus{
+			Peer:      s.following.primary,
+			Connected: s.following.connected,
+			LastSeq:   s.following.applied,
+		})
+	}
+	if s.following.promoted {
+		role = RolePrimary
+	}
+	s.following.mu.Unlock()
+
+	if s.replLog == nil {
+		return role, statuses
+	}
+	if role == RoleStandalone {
+		role = RolePrimary
+	}
+
+	now := time.Now()
+	for _, f := range s.followers {
+		acked := f.acked.Load()
+		head, oldest := s.replLog.lag(acked)
+
+		st := &oraclev1.ReplicationStatus{
+			Peer:      f.addr,
+			Connected: f.connected.Load(),
+			LastSeq:   head,
+			AckedSeq:  acked,
+		}
+		if head > acked {
+			st.LagMutations = head - acked
+		}
+		if !oldest.IsZero() {
+			st.LagMs = now.Sub(oldest).Milliseconds()
+		}
+		statuses = append(statuses, st)
+	}
+	return role, statuses
+}
@@ -0,0 +1,219 @@
+package node
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// startNode serves a node on a loopback port and returns its address and a
+// client connected to it.
+func startNode(t *testing.T, s *Server) (string, oraclev1.NodeServiceClient) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	srv := grpc.NewServer()
+	oraclev1.RegisterNodeServiceServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	addr := lis.Addr().String()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial %s: %v", addr, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return addr, oraclev1.NewNodeServiceClient(conn)
+}
+
+// openReplication opens a Replicate stream to a follower as primary
+// "primary-0" and returns it with the follower's first acknowledgement.
+// Canceling the returned function cuts the stream.
+func openReplication(t *testing.T, client oraclev1.NodeServiceClient) (oraclev1.NodeService_ReplicateClient, context.CancelFunc, uint64) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	stream, err := client.Replicate(ctx)
+	if err != nil {
+		t.Fatalf("replicate: %v", err)
+	}
+	if err := stream.Send(&oraclev1.ReplicateRequest{Primary: "primary-0", Epoch: 1}); err != nil {
+		t.Fatalf("send hello: %v", err)
+	}
+	return stream, cancel, recvAck(t, stream)
+}
+
+// sendAndAck sends one message on a Replicate stream and returns the
+// follower's acknowledgement.
+func sendAndAck(t *testing.T, stream oraclev1.NodeService_ReplicateClient, req *oraclev1.ReplicateRequest) uint64 {
+	t.Helper()
+
+	if err := stream.Send(req); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	return recvAck(t, stream)
+}
+
+func recvAck(t *testing.T, stream oraclev1.NodeService_ReplicateClient) uint64 {
+	t.Helper()
+
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv ack: %v", err)
+	}
+	return resp.AckedSeq
+}
+
+func setMutation(seq uint64, key string) *oraclev1.Mutation {
+	return &oraclev1.Mutation{
+		Seq:   seq,
+		Op:    oraclev1.MutationOp_MUTATION_OP_SET,
+		Key:   key,
+		Value: []byte("v-" + key),
+	}
+}
+
+func TestReplicateAcksSnapshotOnlyWhenComplete(t *testing.T) {
+	_, client := startNode(t, NewServer())
+
+	stream, cut, ack := openReplication(t, client)
+	if ack != 0 {
+		t.Fatalf("first ack = %d, want 0", ack)
+	}
+
+	// The stream is cut after the first of two snapshot messages
+	if ack := sendAndAck(t, stream, &oraclev1.ReplicateRequest{
+		FullSync:    true,
+		SnapshotSeq: 5,
+		Mutations:   []*oraclev1.Mutation{setMutation(5, "k1")},
+	}); ack != 0 {
+		t.Fatalf("ack of a partial snapshot = %d, want 0", ack)
+	}
+	cut()
+
+	// The next stream does not resume from the partial copy
+	stream, cut, ack = openReplication(t, client)
+	if ack != 0 {
+		t.Fatalf("ack after a cut snapshot = %d, want 0 to get a new snapshot", ack)
+	}
+
+	if ack := sendAndAck(t, stream, &oraclev1.ReplicateRequest{
+		FullSync:    true,
+		SnapshotSeq: 5,
+		Mutations:   []*oraclev1.Mutation{setMutation(5, "k1")},
+	}); ack != 0 {
+		t.Fatalf("ack of a partial snapshot = %d, want 0", ack)
+	}
+	if ack := sendAndAck(t, stream, &oraclev1.ReplicateRequest{
+		SnapshotSeq:  5,
+		SnapshotDone: true,
+		Mutations:    []*oraclev1.Mutation{setMutation(5, "k2")},
+	}); ack != 5 {
+		t.Fatalf("ack of a complete snapshot = %d, want 5", ack)
+	}
+	if ack := sendAndAck(t, stream, &oraclev1.ReplicateRequest{
+		Mutations: []*oraclev1.Mutation{setMutation(6, "k3")},
+	}); ack != 6 {
+		t.Fatalf("ack of mutation 6 = %d, want 6", ack)
+	}
+	cut()
+
+	// A stream cut after the snapshot resumes where it left off
+	if _, _, ack = openReplication(t, client); ack != 6 {
+		t.Errorf("ack after reconnecting = %d, want 6", ack)
+	}
+
+	for _, key := range []string{"k1", "k2", "k3"} {
+		resp, err := client.Get(context.Background(), &oraclev1.GetRequest{Key: key})
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		if !resp.Found || string(resp.Value) != "v-"+key {
+			t.Errorf("follower holds %s = %q (found %v), want %q", key, resp.Value, resp.Found, "v-"+key)
+		}
+	}
+}
+
+func TestPromoteDeposesPrimary(t *testing.T) {
+	followerAddr, follower := startNode(t, NewServer())
+
+	primary := NewServer()
+	primary.SetReplication("primary-0", []string{followerAddr}, 0)
+	_, primaryClient := startNode(t, primary)
+	primary.startReplication()
+	t.Cleanup(func() { primary.Stop() })
+
+	ctx := context.Background()
+	set := func(key string) {
+		t.Helper()
+		if _, err := primaryClient.Set(ctx, &oraclev1.SetRequest{Key: key, Value: []byte("v")}); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	set("k1")
+
+	// Wait for the follower to catch up
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := follower.Get(ctx, &oraclev1.GetRequest{Key: "k1"})
+		if err == nil && resp.Found {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("follower never received k1")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	first, err := follower.Promote(ctx, &oraclev1.PromoteRequest{})
+	if err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	again, err := follower.Promote(ctx, &oraclev1.PromoteRequest{})
+	if err != nil {
+		t.Fatalf("second promote: %v", err)
+	}
+	if first.FormerPrimary != "primary-0" || first.AppliedSeq == 0 || again.AppliedSeq != first.AppliedSeq {
+		t.Errorf("promotions returned %v and %v, want the same state following primary-0", first, again)
+	}
+
+	stats, err := follower.Stats(ctx, &oraclev1.StatsRequest{})
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.Role != RolePrimary || len(stats.Replication) != 1 || stats.Replication[0].LastSeq != first.AppliedSeq {
+		t.Errorf("promoted follower reports role %s with %v, want primary at seq %d", stats.Role, stats.Replication, first.AppliedSeq)
+	}
+
+	// The next mutation hits the rejected stream and deposes the primary
+	set("k2")
+	for {
+		resp, err := primaryClient.Health(ctx, &oraclev1.HealthRequest{})
+		if err != nil {
+			t.Fatalf("health: %v", err)
+		}
+		if !resp.Healthy {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("primary still healthy after its follower was promoted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if resp, _ := follower.Get(ctx, &oraclev1.GetRequest{Key: "k2"}); resp.Found {
+		t.Error("promoted follower applied a mutation from its former primary")
+	}
+}
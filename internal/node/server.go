@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
@@ -19,6 +20,16 @@ import (
 	"github.com/eggybyte-technology/yao-oracle/core/utils"
 )
 
+// DefaultMaxMessageSize is the default gRPC message size limit in bytes.
+//
+// It must match the limit configured on the proxy so that every value the
+// proxy accepts can also be received by the node.
+const DefaultMaxMessageSize = 16 * 1024 * 1024
+
+// errNewerValue is the message of a versioned write that was not stored
+// because the key holds a value with a newer timestamp.
+const errNewerValue = "not stored: a newer value is already stored"
+
 // Server implements the NodeService gRPC server.
 type Server struct {
 	oraclev1.UnimplementedNodeServiceServer
@@ -28,6 +39,25 @@ type Server struct {
 	healthChecker *health.Checker
 	logger        *utils.Logger
 	startTime     time.Time
+
+	// maxMessageSize is the gRPC message size limit in bytes
+	maxMessageSize int
+
+	// Primary-backup replication: nodeID, replLog and followers are set on
+	// a primary; following tracks the stream received as a follower.
+	// deposed is set once a follower of this primary was promoted
+	nodeID    string
+	replLog   *replicationLog
+	followers []*followerState
+	following primaryState
+	deposed   atomic.Bool
+	stopCh    chan struct{}
+
+	// merkle indexes entry digests for anti-entropy
+	merkle merkleIndex
+
+	// watches tracks the WatchKeys streams of proxies
+	watches watchRegistry
 }
 
 // NewServer creates a new node server instance.
@@ -38,6 +68,21 @@ func NewServer() *Server {
 		healthChecker: health.NewChecker(),
 		logger:        utils.NewLogger("node"),
 		startTime:     time.Now(),
+		stopCh:        make(chan struct{}),
+
+		maxMessageSize: DefaultMaxMessageSize,
+	}
+}
+
+// SetMaxMessageSize configures the gRPC message size limit in bytes.
+//
+// It must be called before Run to take effect.
+//
+// Parameters:
+//   - size: Maximum message size in bytes. Values <= 0 are ignored.
+func (s *Server) SetMaxMessageSize(size int) {
+	if size > 0 {
+		s.maxMessageSize = size
 	}
 }
 
@@ -45,30 +90,89 @@ func NewServer() *Server {
 func (s *Server) Get(ctx context.Context, req *oraclev1.GetRequest) (*oraclev1.GetResponse, error) {
 	s.metrics.IncRequests()
 
-	value, found := s.cache.Get(req.Key)
+	resp := s.get(req.Key)
+	if resp.Found {
+		s.metrics.IncRequestsOK()
+	}
+	return resp, nil
+}
+
+// get reads a key from the cache and counts the hit or miss.
+func (s *Server) get(key string) *oraclev1.GetResponse {
+	entry, found := s.cache.GetEntry(key)
 	if !found {
 		s.metrics.IncCacheMisses()
 		return &oraclev1.GetResponse{
 			Found: false,
-		}, nil
+		}
 	}
 
 	s.metrics.IncCacheHits()
-	s.metrics.IncRequestsOK()
 
-	return &oraclev1.GetResponse{
-		Found: true,
-		Value: value,
-		Ttl:   s.cache.GetTTL(req.Key),
-	}, nil
+	resp := &oraclev1.GetResponse{
+		Found:     true,
+		Value:     entry.Value,
+		Timestamp: entry.Timestamp,
+	}
+	if !entry.ExpiresAt.IsZero() {
+		remaining := time.Until(entry.ExpiresAt)
+		resp.Ttl = int32(remaining.Seconds())
+		// Round up so that an entry about to expire is not reported as
+		// having no expiration
+		resp.TtlMs = max(remaining.Milliseconds(), 1)
+	}
+
+	return resp
+}
+
+// MultiGet retrieves several keys in one call.
+func (s *Server) MultiGet(ctx context.Context, req *oraclev1.MultiGetRequest) (*oraclev1.MultiGetResponse, error) {
+	s.metrics.IncRequests()
+
+	resp := &oraclev1.MultiGetResponse{
+		Results: make([]*oraclev1.GetResponse, len(req.Keys)),
+	}
+	for i, key := range req.Keys {
+		resp.Results[i] = s.get(key)
+	}
+
+	s.metrics.IncRequestsOK()
+	return resp, nil
 }
 
-// Set stores a key-value pair with optional TTL.
+// Set stores a key-value pair with optional TTL. A versioned write older
+// than the stored value is dropped and reported with Success false.
 func (s *Server) Set(ctx context.Context, req *oraclev1.SetRequest) (*oraclev1.SetResponse, error) {
 	s.metrics.IncRequests()
 
 	ttl := time.Duration(req.Ttl) * time.Second
-	s.cache.Set(req.Key, req.Value, ttl)
+
+	mutation := loggedMutation{
+		op:        oraclev1.MutationOp_MUTATION_OP_SET,
+		key:       req.Key,
+		value:     req.Value,
+		timestamp: req.Timestamp,
+	}
+	if ttl > 0 {
+		mutation.expiresAt = time.Now().Add(ttl)
+	}
+
+	stored := s.commit(mutation, func() bool {
+		// The proxy stamps client writes; an older write arriving late
+		// (such as a replayed hint) must not overwrite a newer one
+		if req.Timestamp > 0 {
+			return s.cache.SetVersioned(req.Key, req.Value, ttl, req.Timestamp)
+		}
+		s.cache.Set(req.Key, req.Value, ttl)
+		return true
+	})
+	if !stored {
+		s.metrics.IncRequestsOK()
+		return &oraclev1.SetResponse{
+			Success: false,
+			Message: errNewerValue,
+		}, nil
+	}
 
 	s.metrics.IncRequestsOK()
 
@@ -81,7 +185,12 @@ func (s *Server) Set(ctx context.Context, req *oraclev1.SetRequest) (*oraclev1.S
 func (s *Server) Delete(ctx context.Context, req *oraclev1.DeleteRequest) (*oraclev1.DeleteResponse, error) {
 	s.metrics.IncRequests()
 
-	existed := s.cache.Delete(req.Key)
+	existed := s.commit(loggedMutation{
+		op:  oraclev1.MutationOp_MUTATION_OP_DELETE,
+		key: req.Key,
+	}, func() bool {
+		return s.cache.Delete(req.Key)
+	})
 
 	s.metrics.IncRequestsOK()
 
@@ -91,8 +200,92 @@ func (s *Server) Delete(ctx context.Context, req *oraclev1.DeleteRequest) (*orac
 	}, nil
 }
 
+// MultiSet stores several key-value pairs under a single cache lock, so
+// that concurrent reads see all of them or none.
+func (s *Server) MultiSet(ctx context.Context, req *oraclev1.MultiSetRequest) (*oraclev1.MultiSetResponse, error) {
+	s.metrics.IncRequests()
+
+	now := time.Now()
+	ops := make([]kv.Op, len(req.Items))
+	mutations := make([]loggedMutation, len(req.Items))
+	for i, item := range req.Items {
+		ttl := time.Duration(item.Ttl) * time.Second
+		ops[i] = kv.Op{
+			Key:       item.Key,
+			Value:     item.Value,
+			TTL:       ttl,
+			Timestamp: item.Timestamp,
+		}
+		mutations[i] = loggedMutation{
+			op:        oraclev1.MutationOp_MUTATION_OP_SET,
+			key:       item.Key,
+			value:     item.Value,
+			timestamp: item.Timestamp,
+		}
+		if ttl > 0 {
+			mutations[i].expiresAt = now.Add(ttl)
+		}
+	}
+
+	applied := s.commitBatch(mutations, func() []bool {
+		return s.cache.Apply(ops)
+	})
+
+	resp := &oraclev1.MultiSetResponse{
+		Results: make([]*oraclev1.SetResponse, len(applied)),
+	}
+	for i, stored := range applied {
+		resp.Results[i] = &oraclev1.SetResponse{Success: stored}
+		if !stored {
+			resp.Results[i].Message = errNewerValue
+		}
+	}
+
+	s.metrics.IncRequestsOK()
+	return resp, nil
+}
+
+// MultiDelete removes several keys under a single cache lock.
+func (s *Server) MultiDelete(ctx context.Context, req *oraclev1.MultiDeleteRequest) (*oraclev1.MultiDeleteResponse, error) {
+	s.metrics.IncRequests()
+
+	ops := make([]kv.Op, len(req.Keys))
+	mutations := make([]loggedMutation, len(req.Keys))
+	for i, key := range req.Keys {
+		ops[i] = kv.Op{Key: key, Delete: true}
+		mutations[i] = loggedMutation{
+			op:  oraclev1.MutationOp_MUTATION_OP_DELETE,
+			key: key,
+		}
+	}
+
+	existed := s.commitBatch(mutations, func() []bool {
+		return s.cache.Apply(ops)
+	})
+
+	resp := &oraclev1.MultiDeleteResponse{
+		Results: make([]*oraclev1.DeleteResponse, len(existed)),
+	}
+	for i := range existed {
+		resp.Results[i] = &oraclev1.DeleteResponse{
+			Success: true,
+			Existed: existed[i],
+		}
+	}
+
+	s.metrics.IncRequestsOK()
+	return resp, nil
+}
+
 // Health checks if the node is healthy and ready to serve.
 func (s *Server) Health(ctx context.Context, req *oraclev1.HealthRequest) (*oraclev1.HealthResponse, error) {
+	if s.deposed.Load() {
+		// Proxies that still route here fail over to the promoted follower
+		return &oraclev1.HealthResponse{
+			Healthy: false,
+			Message: "Node was replaced by a promoted follower",
+		}, nil
+	}
 	return &oraclev1.HealthResponse{
 		Healthy: true,
 		Message: "Node is healthy",
@@ -106,6 +299,8 @@ func (s *Server) Stats(ctx context.Context, req *oraclev1.StatsRequest) (*oracle
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
+	role, replication := s.replicationStatus()
+
 	return &oraclev1.StatsResponse{
 		TotalKeys:       int64(s.cache.Size()),
 		MemoryUsedBytes: int64(m.Alloc),
@@ -113,6 +308,8 @@ func (s *Server) Stats(ctx context.Context, req *oraclev1.StatsRequest) (*oracle
 		RequestsTotal:   s.metrics.GetRequestsTotal(),
 		Hits:            hits,
 		Misses:          misses,
+		Role:            role,
+		Replication:     replication,
 	}, nil
 }
 
@@ -123,7 +320,10 @@ func (s *Server) Run(port int) error {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(s.maxMessageSize),
+		grpc.MaxSendMsgSize(s.maxMessageSize),
+	)
 	oraclev1.RegisterNodeServiceServer(grpcServer, s)
 
 	// Register gRPC health check service
@@ -135,6 +335,9 @@ func (s *Server) Run(port int) error {
 	s.healthChecker.SetHealthy(true)
 	s.healthChecker.SetReady(true)
 
+	// Stream mutations to followers, if this node is a primary
+	s.startReplication()
+
 	s.logger.Info("Node server listening on :%d", port)
 
 	if err := grpcServer.Serve(lis); err != nil {
@@ -167,6 +370,9 @@ func (s *Server) Stop() error {
 	s.healthChecker.SetReady(false)
 	s.healthChecker.SetHealthy(false)
 
+	// Stop replication senders
+	close(s.stopCh)
+
 	// Stop health checker
 	return s.healthChecker.Stop()
 }
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+func TestSetVersioned(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp int64
+		want      bool
+		wantValue string
+	}{
+		{name: "newer write", timestamp: 200, want: true, wantValue: "v2"},
+		{name: "same timestamp", timestamp: 100, want: true, wantValue: "v2"},
+		{name: "older write", timestamp: 50, want: false, wantValue: "v1"},
+		{name: "unversioned write", timestamp: 0, want: true, wantValue: "v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer()
+			ctx := context.Background()
+
+			if _, err := s.Set(ctx, &oraclev1.SetRequest{Key: "k", Value: []byte("v1"), Timestamp: 100}); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+
+			resp, err := s.Set(ctx, &oraclev1.SetRequest{Key: "k", Value: []byte("v2"), Timestamp: tt.timestamp})
+			if err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			if resp.Success != tt.want || (!resp.Success && resp.Message == "") {
+				t.Errorf("Set = %v, want Success %v with a message if dropped", resp, tt.want)
+			}
+
+			got, err := s.Get(ctx, &oraclev1.GetRequest{Key: "k"})
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if string(got.Value) != tt.wantValue {
+				t.Errorf("node holds %q, want %q", got.Value, tt.wantValue)
+			}
+		})
+	}
+}
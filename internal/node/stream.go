@@ -0,0 +1,196 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// DefaultChunkSize is the chunk size used by GetStream when the client
+// does not request one.
+const DefaultChunkSize = 1024 * 1024
+
+// chunkEnvelopeBytes is the headroom reserved for protobuf framing when
+// clamping chunk sizes to the gRPC message size limit.
+const chunkEnvelopeBytes = 4 * 1024
+
+// maxStreamPrealloc caps the buffer preallocated from a declared total_size
+// so that a bogus header cannot force a huge allocation up front.
+const maxStreamPrealloc = 64 * 1024 * 1024
+
+// castagnoli is the CRC32-C table used for stream checksums.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// SetStream stores a value uploaded as a stream of chunks.
+//
+// The upload must start with a header and end with a trailer. Chunks are
+// buffered in memory and the value is committed to the cache atomically
+// only after the trailer arrives and both the size and the CRC32-C
+// checksum match. An aborted or corrupted upload leaves the cache untouched.
+func (s *Server) SetStream(stream oraclev1.NodeService_SetStreamServer) error {
+	s.metrics.IncRequests()
+
+	first, err := stream.Recv()
+	if err != nil {
+		s.metrics.IncRequestsError()
+		return status.Errorf(codes.InvalidArgument, "failed to receive stream header: %v", err)
+	}
+
+	header := first.GetHeader()
+	if header == nil {
+		s.metrics.IncRequestsError()
+		return status.Error(codes.InvalidArgument, "first stream message must be a header")
+	}
+	if header.TotalSize < 0 {
+		s.metrics.IncRequestsError()
+		return status.Errorf(codes.InvalidArgument, "total_size cannot be negative, got %d", header.TotalSize)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(int(min(header.TotalSize, maxStreamPrealloc)))
+	checksum := crc32.New(castagnoli)
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			s.metrics.IncRequestsError()
+			return status.Errorf(codes.InvalidArgument, "stream for key '%s' ended without trailer", header.Key)
+		}
+		if err != nil {
+			s.metrics.IncRequestsError()
+			return err
+		}
+
+		switch payload := msg.Payload.(type) {
+		case *oraclev1.SetStreamRequest_Chunk:
+			if int64(buf.Len()+len(payload.Chunk)) > header.TotalSize {
+				s.metrics.IncRequestsError()
+				return status.Errorf(codes.InvalidArgument,
+					"stream for key '%s' exceeds declared total_size %d", header.Key, header.TotalSize)
+			}
+			buf.Write(payload.Chunk)
+			checksum.Write(payload.Chunk)
+
+		case *oraclev1.SetStreamRequest_Trailer:
+			if int64(buf.Len()) != header.TotalSize {
+				s.metrics.IncRequestsError()
+				return status.Errorf(codes.DataLoss,
+					"stream for key '%s' received %d bytes, expected %d", header.Key, buf.Len(), header.TotalSize)
+			}
+			if sum := checksum.Sum32(); sum != payload.Trailer.Checksum {
+				s.metrics.IncRequestsError()
+				return status.Errorf(codes.DataLoss,
+					"stream for key '%s' checksum mismatch: got %08x, expected %08x", header.Key, sum, payload.Trailer.Checksum)
+			}
+
+			// Commit the complete value in a single cache write
+			ttl := time.Duration(header.Ttl) * time.Second
+			mutation := loggedMutation{
+				op:        oraclev1.MutationOp_MUTATION_OP_SET,
+				key:       header.Key,
+				value:     buf.Bytes(),
+				timestamp: header.Timestamp,
+			}
+			if ttl > 0 {
+				mutation.expiresAt = time.Now().Add(ttl)
+			}
+			stored := s.commit(mutation, func() bool {
+				if header.Timestamp > 0 {
+					return s.cache.SetVersioned(header.Key, mutation.value, ttl, header.Timestamp)
+				}
+				s.cache.Set(header.Key, mutation.value, ttl)
+				return true
+			})
+			s.metrics.IncRequestsOK()
+
+			resp := &oraclev1.SetResponse{Success: stored}
+			if !stored {
+				resp.Message = errNewerValue
+			}
+			return stream.SendAndClose(resp)
+
+		default:
+			s.metrics.IncRequestsError()
+			return status.Error(codes.InvalidArgument, "unexpected header after stream start")
+		}
+	}
+}
+
+// GetStream sends a cached value back as a stream of chunks.
+//
+// The first message is always a header. If the key exists, the header is
+// followed by the value split into chunks and a trailer carrying the
+// CRC32-C checksum of the complete value.
+func (s *Server) GetStream(req *oraclev1.GetStreamRequest, stream oraclev1.NodeService_GetStreamServer) error {
+	s.metrics.IncRequests()
+
+	value, found := s.cache.Get(req.Key)
+	if !found {
+		s.metrics.IncCacheMisses()
+		s.metrics.IncRequestsOK()
+		return stream.Send(&oraclev1.GetStreamResponse{
+			Payload: &oraclev1.GetStreamResponse_Header{
+				Header: &oraclev1.GetStreamHeader{Found: false},
+			},
+		})
+	}
+
+	s.metrics.IncCacheHits()
+
+	if err := stream.Send(&oraclev1.GetStreamResponse{
+		Payload: &oraclev1.GetStreamResponse_Header{
+			Header: &oraclev1.GetStreamHeader{
+				Found:     true,
+				Ttl:       s.cache.GetTTL(req.Key),
+				TotalSize: int64(len(value)),
+			},
+		},
+	}); err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+
+	chunkSize := s.chunkSize(int(req.ChunkSize))
+	for offset := 0; offset < len(value); offset += chunkSize {
+		end := min(offset+chunkSize, len(value))
+		if err := stream.Send(&oraclev1.GetStreamResponse{
+			Payload: &oraclev1.GetStreamResponse_Chunk{Chunk: value[offset:end]},
+		}); err != nil {
+			s.metrics.IncRequestsError()
+			return err
+		}
+	}
+
+	if err := stream.Send(&oraclev1.GetStreamResponse{
+		Payload: &oraclev1.GetStreamResponse_Trailer{
+			Trailer: &oraclev1.StreamTrailer{Checksum: crc32.Checksum(value, castagnoli)},
+		},
+	}); err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+
+	s.metrics.IncRequestsOK()
+	return nil
+}
+
+// chunkSize returns the effective chunk size for a GetStream request,
+// clamped so that every chunk fits into a single gRPC message.
+func (s *Server) chunkSize(requested int) int {
+	size := requested
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	if limit := s.maxMessageSize - chunkEnvelopeBytes; limit > 0 && size > limit {
+		size = limit
+	}
+	return size
+}
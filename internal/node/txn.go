@@ -0,0 +1,109 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// txnCheckKinds maps protocol check types to cache check kinds.
+var txnCheckKinds = map[oraclev1.TxnCheckType]kv.CheckKind{
+	oraclev1.TxnCheckType_TXN_CHECK_TYPE_EXISTS:         kv.CheckExists,
+	oraclev1.TxnCheckType_TXN_CHECK_TYPE_NOT_EXISTS:     kv.CheckNotExists,
+	oraclev1.TxnCheckType_TXN_CHECK_TYPE_VERSION_EQUALS: kv.CheckVersion,
+	oraclev1.TxnCheckType_TXN_CHECK_TYPE_VALUE_EQUALS:   kv.CheckValue,
+}
+
+// Transaction applies mutations only if all checks hold.
+//
+// Checks and mutations run under a single cache lock, so no other write can
+// interleave. Stored values get a fresh version that is newer than the
+// key's previous one; mutations are replicated to followers like MultiSet
+// and MultiDelete.
+func (s *Server) Transaction(ctx context.Context, req *oraclev1.TransactionRequest) (*oraclev1.TransactionResponse, error) {
+	s.metrics.IncRequests()
+
+	checks := make([]kv.Check, len(req.Checks))
+	for i, check := range req.Checks {
+		kind, ok := txnCheckKinds[check.Type]
+		if !ok {
+			s.metrics.IncRequestsError()
+			return nil, status.Errorf(codes.InvalidArgument, "unknown check type %d for key '%s'", check.Type, check.Key)
+		}
+		checks[i] = kv.Check{
+			Key:     check.Key,
+			Kind:    kind,
+			Version: check.Version,
+			Value:   check.Value,
+		}
+	}
+
+	now := time.Now()
+	ops := make([]kv.Op, len(req.Mutations))
+	mutations := make([]loggedMutation, len(req.Mutations))
+	for i, m := range req.Mutations {
+		if m.Delete {
+			ops[i] = kv.Op{Key: m.Key, Delete: true}
+			mutations[i] = loggedMutation{
+				op:  oraclev1.MutationOp_MUTATION_OP_DELETE,
+				key: m.Key,
+			}
+			continue
+		}
+
+		ttl := time.Duration(m.Ttl) * time.Second
+		ops[i] = kv.Op{
+			Key:       m.Key,
+			Value:     m.Value,
+			TTL:       ttl,
+			Timestamp: now.UnixNano(),
+		}
+		mutations[i] = loggedMutation{
+			op:    oraclev1.MutationOp_MUTATION_OP_SET,
+			key:   m.Key,
+			value: m.Value,
+		}
+		if ttl > 0 {
+			mutations[i].expiresAt = now.Add(ttl)
+		}
+	}
+
+	failed := -1
+	var results []kv.TxnResult
+	s.commitBatch(mutations, func() []bool {
+		failed, results = s.cache.Transaction(checks, ops)
+
+		applied := make([]bool, len(ops))
+		for i := range results {
+			applied[i] = true
+			// Followers must store the version chosen by the cache
+			mutations[i].timestamp = results[i].Version
+		}
+		return applied
+	})
+
+	if failed >= 0 {
+		s.metrics.IncRequestsOK()
+		return &oraclev1.TransactionResponse{FailedCheck: int32(failed)}, nil
+	}
+
+	resp := &oraclev1.TransactionResponse{
+		Succeeded: true,
+		Results:   make([]*oraclev1.TxnResult, len(results)),
+	}
+	for i, result := range results {
+		resp.Results[i] = &oraclev1.TxnResult{
+			Key:     req.Mutations[i].Key,
+			Existed: result.Existed,
+			Version: result.Version,
+		}
+	}
+
+	s.metrics.IncRequestsOK()
+	return resp, nil
+}
@@ -0,0 +1,151 @@
+package node
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// maxWatchedKeys bounds the number of keys a single WatchKeys stream may
+// watch, so that a misbehaving client cannot make every commit expensive.
+const maxWatchedKeys = 10000
+
+// keyWatcher is the state of one WatchKeys stream.
+type keyWatcher struct {
+	mu      sync.Mutex
+	keys    map[string]bool
+	changed map[string]bool
+	all     bool
+
+	// notify is signaled when changes are pending
+	notify chan struct{}
+}
+
+// watchRegistry tracks the active WatchKeys streams of a node.
+type watchRegistry struct {
+	mu       sync.RWMutex
+	watchers map[*keyWatcher]bool
+}
+
+// add registers a watcher.
+func (r *watchRegistry) add(w *keyWatcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watchers == nil {
+		r.watchers = make(map[*keyWatcher]bool)
+	}
+	r.watchers[w] = true
+}
+
+// remove unregisters a watcher.
+func (r *watchRegistry) remove(w *keyWatcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.watchers, w)
+}
+
+// signal wakes up the sender of a watcher; w.mu must be held.
+func (w *keyWatcher) signal() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// notifyChange reports a committed change of a key to the watchers of it.
+func (s *Server) notifyChange(key string) {
+	s.watches.mu.RLock()
+	defer s.watches.mu.RUnlock()
+
+	for w := range s.watches.watchers {
+		w.mu.Lock()
+		if w.keys[key] {
+			w.changed[key] = true
+			w.signal()
+		}
+		w.mu.Unlock()
+	}
+}
+
+// notifyAll reports to every watcher that all keys may have changed.
+func (s *Server) notifyAll() {
+	s.watches.mu.RLock()
+	defer s.watches.mu.RUnlock()
+
+	for w := range s.watches.watchers {
+		w.mu.Lock()
+		w.all = true
+		w.signal()
+		w.mu.Unlock()
+	}
+}
+
+// WatchKeys reports changes to the keys named by the client.
+//
+// Each request replaces the set of watched keys. Whenever watched keys are
+// set or deleted, the node sends them in the next response; changes that
+// happen while a response is being sent are batched into the following one.
+// Keys that expire are not reported.
+func (s *Server) WatchKeys(stream oraclev1.NodeService_WatchKeysServer) error {
+	w := &keyWatcher{
+		keys:    make(map[string]bool),
+		changed: make(map[string]bool),
+		notify:  make(chan struct{}, 1),
+	}
+	s.watches.add(w)
+	defer s.watches.remove(w)
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+
+			keys := make(map[string]bool, len(req.Keys))
+			for _, key := range req.Keys[:min(len(req.Keys), maxWatchedKeys)] {
+				keys[key] = true
+			}
+			w.mu.Lock()
+			w.keys = keys
+			for key := range w.changed {
+				if !keys[key] {
+					delete(w.changed, key)
+				}
+			}
+			w.mu.Unlock()
+		}
+	}()
+
+	for {
+		select {
+		case err := <-recvErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-s.stopCh:
+			return nil
+		case <-w.notify:
+		}
+
+		w.mu.Lock()
+		resp := &oraclev1.WatchKeysResponse{AllChanged: w.all}
+		for key := range w.changed {
+			resp.Keys = append(resp.Keys, key)
+		}
+		w.changed = make(map[string]bool)
+		w.all = false
+		w.mu.Unlock()
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
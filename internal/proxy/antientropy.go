@@ -0,0 +1,397 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+)
+
+// Default anti-entropy settings, used when AntiEntropyConfig leaves them unset.
+const (
+	DefaultAntiEntropyInterval      = 60 * time.Second
+	DefaultAntiEntropyKeysPerSecond = 500
+	DefaultAntiEntropyTreeDepth     = 10
+)
+
+// antiEntropyBatchSize is the number of keys scanned and imported per batch.
+const antiEntropyBatchSize = 100
+
+// replicaPair is two nodes that replicate the same keys. minReplicas is the
+// smallest replication factor for which both nodes are replicas of the
+// shared ranges.
+type replicaPair struct {
+	a, b        string
+	minReplicas int
+}
+
+// antiEntropy repairs replicas that drifted apart.
+//
+// Every round it partitions the ring by replica set, and for every pair of
+// replicas compares Merkle trees over the ranges they share. Only the keys
+// of differing leaves are scanned on both nodes; for each key the newest
+// write is copied to the other node. Keys missing on one node are copied
+// too, so like read repair, anti-entropy may bring back a key whose delete
+// did not reach every replica.
+type antiEntropy struct {
+	server *Server
+
+	mu  sync.Mutex
+	cfg config.AntiEntropyConfig
+}
+
+// newAntiEntropy creates an anti-entropy worker with default settings.
+func newAntiEntropy(s *Server) *antiEntropy {
+	return &antiEntropy{server: s}
+}
+
+// configure applies anti-entropy settings; nil selects defaults.
+func (ae *antiEntropy) configure(cfg *config.AntiEntropyConfig) {
+	var effective config.AntiEntropyConfig
+	if cfg != nil {
+		effective = *cfg
+	}
+	if effective.IntervalSeconds <= 0 {
+		effective.IntervalSeconds = int(DefaultAntiEntropyInterval / time.Second)
+	}
+	if effective.MaxKeysPerSecond <= 0 {
+		effective.MaxKeysPerSecond = DefaultAntiEntropyKeysPerSecond
+	}
+	if effective.TreeDepth <= 0 {
+		effective.TreeDepth = DefaultAntiEntropyTreeDepth
+	}
+
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.cfg = effective
+}
+
+// run performs anti-entropy rounds until the server is stopped.
+func (ae *antiEntropy) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-ae.server.stopCh
+		cancel()
+	}()
+
+	for {
+		ae.mu.Lock()
+		interval := time.Duration(ae.cfg.IntervalSeconds) * time.Second
+		ae.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		ae.round(ctx)
+	}
+}
+
+// round compares all replica pairs once and returns the number of keys
+// repaired.
+func (ae *antiEntropy) round(ctx context.Context) int64 {
+	ae.mu.Lock()
+	cfg := ae.cfg
+	ae.mu.Unlock()
+	if cfg.Disabled {
+		return 0
+	}
+
+	s := ae.server
+	s.mu.RLock()
+	ring, ok := s.ring.(*hash.Ring)
+	hashFunction := s.placement.HashFunction
+	s.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+
+	return ae.repairRing(ctx, ring, s.replicatedPrefixes(), hashFunction, cfg)
+}
+
+// repairRing compares all replica pairs of a ring over the keys of the
+// given replicated namespaces and returns the number of keys repaired.
+func (ae *antiEntropy) repairRing(ctx context.Context, ring *hash.Ring, prefixes []replicatedNamespace, hashFunction string, cfg config.AntiEntropyConfig) int64 {
+	s := ae.server
+	n := 1
+	for _, ns := range prefixes {
+		n = max(n, ns.replicas)
+	}
+	if n <= 1 {
+		return 0
+	}
+
+	// Group shared ranges by replica pair. A pair at positions i < j of a
+	// replica set shares the range only in namespaces with at least j+1
+	// replicas.
+	pairs := make(map[replicaPair][]hash.HashRange)
+	for _, rr := range ring.ReplicaRanges(n) {
+		for i := range rr.Nodes {
+			for j := i + 1; j < len(rr.Nodes); j++ {
+				pair := replicaPair{a: rr.Nodes[i], b: rr.Nodes[j], minReplicas: j + 1}
+				pairs[pair] = append(pairs[pair], rr.HashRange)
+			}
+		}
+	}
+
+	limiter := newKeyThrottle(cfg.MaxKeysPerSecond)
+	var repaired int64
+	for pair, ranges := range pairs {
+		var keyPrefixes []string
+		for _, ns := range prefixes {
+			if ns.replicas >= pair.minReplicas {
+				keyPrefixes = append(keyPrefixes, ns.prefix)
+			}
+		}
+		if len(keyPrefixes) == 0 {
+			continue
+		}
+
+		count, err := ae.repairPair(ctx, pair, ranges, keyPrefixes, hashFunction, cfg.TreeDepth, limiter)
+		repaired += count
+		if err != nil {
+			if ctx.Err() != nil {
+				return repaired
+			}
+			s.logger.Warn("Anti-entropy between %s and %s failed: %v", pair.a, pair.b, err)
+		}
+	}
+
+	if repaired > 0 {
+		s.logger.Info("Anti-entropy repaired %d keys across %d replica pairs", repaired, len(pairs))
+	} else {
+		s.logger.Debug("Anti-entropy found %d replica pairs in sync", len(pairs))
+	}
+	return repaired
+}
+
+// repairPair synchronizes the shared ranges of two replicas.
+//
+// Returns:
+//   - int64: Number of keys copied between the replicas
+//   - error: Error if a node could not be queried
+func (ae *antiEntropy) repairPair(ctx context.Context, pair replicaPair, ranges []hash.HashRange, keyPrefixes []string, hashFunction string, depth int, limiter *keyThrottle) (int64, error) {
+	s := ae.server
+	clientA, clientB := s.nodeClient(pair.a), s.nodeClient(pair.b)
+	if clientA == nil || clientB == nil {
+		return 0, errors.New("no connection to replica")
+	}
+
+	treeReq := &oraclev1.MerkleTreeRequest{
+		Ranges:       toKeyHashRanges(ranges),
+		HashFunction: hashFunction,
+		KeyPrefixes:  keyPrefixes,
+		Depth:        int32(depth),
+	}
+	treeA, err := clientA.MerkleTree(ctx, treeReq)
+	if err != nil {
+		return 0, err
+	}
+	treeB, err := clientB.MerkleTree(ctx, treeReq)
+	if err != nil {
+		return 0, err
+	}
+	if treeA.Root == treeB.Root || len(treeA.Leaves) != len(treeB.Leaves) {
+		return 0, nil
+	}
+
+	diverged := differingRanges(treeA.Leaves, treeB.Leaves, depth, ranges)
+	if len(diverged) == 0 {
+		return 0, nil
+	}
+
+	entriesA, err := ae.scan(ctx, clientA, diverged, keyPrefixes, hashFunction, limiter)
+	if err != nil {
+		return 0, err
+	}
+	entriesB, err := ae.scan(ctx, clientB, diverged, keyPrefixes, hashFunction, limiter)
+	if err != nil {
+		return 0, err
+	}
+
+	// The newest write wins; entries of equal timestamp (including
+	// unversioned ones) cannot be ordered and are left alone
+	var toA, toB []*oraclev1.MigrationEntry
+	for key, a := range entriesA {
+		b, ok := entriesB[key]
+		switch {
+		case !ok || a.Timestamp > b.Timestamp:
+			toB = append(toB, a)
+		case b.Timestamp > a.Timestamp:
+			toA = append(toA, b)
+		}
+	}
+	for key, b := range entriesB {
+		if _, ok := entriesA[key]; !ok {
+			toA = append(toA, b)
+		}
+	}
+
+	repairedA, err := ae.importEntries(ctx, clientA, toA, limiter)
+	if err != nil {
+		return repairedA, err
+	}
+	repairedB, err := ae.importEntries(ctx, clientB, toB, limiter)
+	return repairedA + repairedB, err
+}
+
+// scan reads all entries of the given ranges from a replica.
+func (ae *antiEntropy) scan(ctx context.Context, client oraclev1.NodeServiceClient, ranges []*oraclev1.KeyHashRange, keyPrefixes []string, hashFunction string, limiter *keyThrottle) (map[string]*oraclev1.MigrationEntry, error) {
+	stream, err := client.ScanRange(ctx, &oraclev1.ScanRangeRequest{
+		Ranges:       ranges,
+		HashFunction: hashFunction,
+		BatchSize:    antiEntropyBatchSize,
+		KeyPrefixes:  keyPrefixes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*oraclev1.MigrationEntry)
+	for {
+		batch, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range batch.Entries {
+			entries[entry.Key] = entry
+		}
+		if err := limiter.wait(ctx, len(batch.Entries)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// importEntries copies entries to a replica in batches; versioned imports
+// never overwrite a newer value written in the meantime.
+func (ae *antiEntropy) importEntries(ctx context.Context, client oraclev1.NodeServiceClient, entries []*oraclev1.MigrationEntry, limiter *keyThrottle) (int64, error) {
+	var repaired int64
+	for start := 0; start < len(entries); start += antiEntropyBatchSize {
+		batch := entries[start:min(start+antiEntropyBatchSize, len(entries))]
+		resp, err := client.Import(ctx, &oraclev1.ImportRequest{
+			Entries:   batch,
+			Versioned: true,
+		})
+		if err != nil {
+			return repaired, err
+		}
+		repaired += int64(resp.Imported)
+		ae.server.metrics.AddAntiEntropyRepaired(int64(resp.Imported))
+
+		if err := limiter.wait(ctx, len(batch)); err != nil {
+			return repaired, err
+		}
+	}
+	return repaired, nil
+}
+
+// differingRanges returns the parts of the shared ranges covered by leaves
+// that differ between two Merkle trees of the given depth.
+func differingRanges(leavesA, leavesB []uint64, depth int, shared []hash.HashRange) []*oraclev1.KeyHashRange {
+	var result []*oraclev1.KeyHashRange
+	width := uint64(1) << (64 - depth)
+
+	for i := range leavesA {
+		if leavesA[i] == leavesB[i] {
+			continue
+		}
+		leafStart := uint64(i) * width
+		leafEnd := leafStart + (width - 1)
+
+		// Shared ranges are sorted and disjoint
+		j := sort.Search(len(shared), func(j int) bool {
+			return shared[j].End >= leafStart
+		})
+		for ; j < len(shared) && shared[j].Start <= leafEnd; j++ {
+			result = append(result, &oraclev1.KeyHashRange{
+				Start: max(shared[j].Start, leafStart),
+				End:   min(shared[j].End, leafEnd),
+			})
+		}
+	}
+	return result
+}
+
+// toKeyHashRanges converts hash ranges to their protobuf representation.
+func toKeyHashRanges(ranges []hash.HashRange) []*oraclev1.KeyHashRange {
+	result := make([]*oraclev1.KeyHashRange, len(ranges))
+	for i, r := range ranges {
+		result[i] = &oraclev1.KeyHashRange{Start: r.Start, End: r.End}
+	}
+	return result
+}
+
+// keyThrottle limits the rate at which keys are processed.
+type keyThrottle struct {
+	perKey time.Duration
+	next   time.Time
+}
+
+// newKeyThrottle creates a throttle allowing keysPerSecond keys per second.
+func newKeyThrottle(keysPerSecond int) *keyThrottle {
+	return &keyThrottle{
+		perKey: time.Second / time.Duration(keysPerSecond),
+		next:   time.Now(),
+	}
+}
+
+// wait accounts for n processed keys and sleeps until they fit the rate.
+func (t *keyThrottle) wait(ctx context.Context, n int) error {
+	if now := time.Now(); t.next.Before(now) {
+		t.next = now
+	}
+	t.next = t.next.Add(time.Duration(n) * t.perKey)
+	select {
+	case <-time.After(time.Until(t.next)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// replicatedNamespace is the key prefix and replication factor of a
+// namespace.
+type replicatedNamespace struct {
+	prefix   string
+	replicas int
+}
+
+// replicatedPrefixes returns the key prefixes of all namespaces with more
+// than one replica.
+func (s *Server) replicatedPrefixes() []replicatedNamespace {
+	if s.informer == nil {
+		return nil
+	}
+
+	cfg := s.informer.GetConfig()
+	if cfg.Proxy == nil {
+		return nil
+	}
+
+	var result []replicatedNamespace
+	for i := range cfg.Proxy.Namespaces {
+		ns := &cfg.Proxy.Namespaces[i]
+		if n, _, _ := ns.Replication(); n > 1 {
+			result = append(result, replicatedNamespace{prefix: s.namespacePrefix(ns.Name), replicas: n})
+		}
+	}
+	return result
+}
+
+// SetAntiEntropyConfig applies anti-entropy settings; nil selects defaults.
+func (s *Server) SetAntiEntropyConfig(cfg *config.AntiEntropyConfig) {
+	s.antiEntropy.configure(cfg)
+}
@@ -0,0 +1,179 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	"github.com/eggybyte-technology/yao-oracle/internal/node"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// startNodes runs count in-process cache nodes on loopback listeners and
+// returns their addresses together with a client for each.
+func startNodes(t *testing.T, count int) ([]string, map[string]oraclev1.NodeServiceClient) {
+	t.Helper()
+
+	addrs := make([]string, count)
+	clients := make(map[string]oraclev1.NodeServiceClient, count)
+	for i := range addrs {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+
+		srv := grpc.NewServer()
+		oraclev1.RegisterNodeServiceServer(srv, node.NewServer())
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+
+		addrs[i] = lis.Addr().String()
+		conn, err := grpc.NewClient(addrs[i], grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("dial %s: %v", addrs[i], err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		clients[addrs[i]] = oraclev1.NewNodeServiceClient(conn)
+	}
+	return addrs, clients
+}
+
+// newTestProxy returns a proxy routing to the given nodes.
+func newTestProxy(t *testing.T, addrs []string) *Server {
+	t.Helper()
+
+	s := NewServer(nil)
+	s.SetNodes(addrs)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func setVersioned(t *testing.T, client oraclev1.NodeServiceClient, key, value string, timestamp int64) {
+	t.Helper()
+
+	_, err := client.Set(context.Background(), &oraclev1.SetRequest{
+		Key:       key,
+		Value:     []byte(value),
+		Timestamp: timestamp,
+	})
+	if err != nil {
+		t.Fatalf("set %q: %v", key, err)
+	}
+}
+
+func getValue(t *testing.T, client oraclev1.NodeServiceClient, key string) (string, bool) {
+	t.Helper()
+
+	resp, err := client.Get(context.Background(), &oraclev1.GetRequest{Key: key})
+	if err != nil {
+		t.Fatalf("get %q: %v", key, err)
+	}
+	return string(resp.Value), resp.Found
+}
+
+func TestAntiEntropyRepairsDivergedReplicas(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	s := newTestProxy(t, addrs)
+	ring := s.ring.(*hash.Ring)
+
+	const replicas = 3
+	ctx := context.Background()
+	base := time.Now().UnixNano()
+
+	keys := make([]string, 60)
+	for i := range keys {
+		keys[i] = kv.NamespaceKey("orders", fmt.Sprintf("order-%d", i))
+		owners := ring.GetNodes(keys[i], replicas)
+
+		switch i % 3 {
+		case 0:
+			// Written to the primary only
+			setVersioned(t, clients[owners[0]], keys[i], "v1", base+int64(i))
+		case 1:
+			// Every replica has a value, the last one the newest
+			for j, owner := range owners {
+				setVersioned(t, clients[owner], keys[i], fmt.Sprintf("v%d", j+1), base+int64(i*10+j))
+			}
+		case 2:
+			// Only the backups have it
+			for _, owner := range owners[1:] {
+				setVersioned(t, clients[owner], keys[i], "v1", base+int64(i))
+			}
+		}
+	}
+
+	// Keys of a namespace that is not replicated are left alone
+	other := kv.NamespaceKey("sessions", "session-1")
+	setVersioned(t, clients[addrs[0]], other, "only-here", base)
+
+	cfg := config.AntiEntropyConfig{MaxKeysPerSecond: 1 << 20, TreeDepth: 6}
+	prefixes := []replicatedNamespace{{prefix: kv.NamespacePrefix("orders"), replicas: replicas}}
+
+	if repaired := s.antiEntropy.repairRing(ctx, ring, prefixes, "", cfg); repaired == 0 {
+		t.Fatal("first round repaired no keys")
+	}
+
+	for i, key := range keys {
+		want := "v1"
+		if i%3 == 1 {
+			want = fmt.Sprintf("v%d", replicas)
+		}
+		for _, owner := range ring.GetNodes(key, replicas) {
+			got, found := getValue(t, clients[owner], key)
+			if !found || got != want {
+				t.Errorf("%q on %s = %q (found %v), want %q", key, owner, got, found, want)
+			}
+		}
+	}
+
+	for _, addr := range addrs[1:] {
+		if _, found := getValue(t, clients[addr], other); found {
+			t.Errorf("key of a non-replicated namespace was copied to %s", addr)
+		}
+	}
+
+	if repaired := s.antiEntropy.repairRing(ctx, ring, prefixes, "", cfg); repaired != 0 {
+		t.Errorf("second round repaired %d keys, want 0 for converged replicas", repaired)
+	}
+}
+
+func TestAntiEntropyRepairPairOnlyComparesSharedPrefixes(t *testing.T) {
+	addrs, clients := startNodes(t, 2)
+	s := newTestProxy(t, addrs)
+
+	ctx := context.Background()
+	base := time.Now().UnixNano()
+
+	replicated := kv.NamespaceKey("orders", "order-1")
+	unreplicated := kv.NamespaceKey("sessions", "session-1")
+	setVersioned(t, clients[addrs[0]], replicated, "new", base+1)
+	setVersioned(t, clients[addrs[1]], replicated, "old", base)
+	setVersioned(t, clients[addrs[1]], unreplicated, "local", base)
+
+	pair := replicaPair{a: addrs[0], b: addrs[1], minReplicas: 2}
+	full := []hash.HashRange{{Start: 0, End: ^uint64(0)}}
+	prefixes := []string{kv.NamespacePrefix("orders")}
+
+	repaired, err := s.antiEntropy.repairPair(ctx, pair, full, prefixes, "", 4, newKeyThrottle(1<<20))
+	if err != nil {
+		t.Fatalf("repairPair: %v", err)
+	}
+	if repaired != 1 {
+		t.Errorf("repairPair repaired %d keys, want 1", repaired)
+	}
+
+	if got, _ := getValue(t, clients[addrs[1]], replicated); got != "new" {
+		t.Errorf("older replica holds %q after repair, want %q", got, "new")
+	}
+	if _, found := getValue(t, clients[addrs[0]], unreplicated); found {
+		t.Error("key outside the compared prefixes was copied")
+	}
+}
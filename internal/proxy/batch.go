@@ -0,0 +1,744 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// DefaultBatchTimeout bounds a batch request that does not set its own
+// timeout.
+const DefaultBatchTimeout = 5 * time.Second
+
+// Batch fan-out limits: keys are sent to a node in calls of at most
+// batchChunkSize keys, and a batch in a replicated namespace runs at most
+// batchReplicaParallelism quorum reads at a time.
+const (
+	batchChunkSize          = 100
+	batchReplicaParallelism = 16
+)
+
+// BatchGet retrieves multiple keys in a single request.
+//
+// Request flow:
+//  1. Validate API key and size limits of all keys
+//  2. Serve hot keys from the near cache
+//  3. Group the remaining keys by owning node
+//  4. Send one MultiGet per node, all nodes in parallel
+//  5. Report the outcome of every key, including keys that failed
+//
+// Keys of replicated namespaces are read from a quorum of their replicas,
+// several keys in parallel. All node calls share one deadline; keys whose
+// node did not answer in time are reported with KEY_STATUS_ERROR.
+func (s *Server) BatchGet(ctx context.Context, req *oraclev1.ProxyBatchGetRequest) (*oraclev1.ProxyBatchGetResponse, error) {
+	s.metrics.IncRequests()
+
+	// Authenticate and get namespace
+	ns, ok := s.authenticateRequest(req.ApiKey)
+	if !ok {
+		s.metrics.IncRequestsError()
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	// Enforce namespace size limits before issuing any node calls
+	for _, key := range req.Keys {
+		if err := s.validateKey(ns, key); err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+	}
+
+	ctx, cancel := batchContext(ctx, req.TimeoutMs)
+	defer cancel()
+
+	items := make([]*oraclev1.BatchGetItem, len(req.Keys))
+	keys := make([]string, len(req.Keys))
+	generations := make([]uint64, len(req.Keys))
+	var pending []int
+	for i, key := range req.Keys {
+		items[i] = &oraclev1.BatchGetItem{Key: key}
+		keys[i] = s.namespaceKey(ns.Name, key)
+
+		s.hotKeys.record(ns.Name, keys[i])
+		resp, generation := s.hotKeys.lookup(keys[i])
+		if resp != nil {
+			s.metrics.IncNearCacheHits()
+			setBatchGetItem(items[i], &oraclev1.GetResponse{Found: resp.Found, Value: resp.Value, Ttl: resp.Ttl, Timestamp: resp.Version}, resp.Node)
+			continue
+		}
+		generations[i] = generation
+		pending = append(pending, i)
+	}
+
+	if n, _, r := ns.Replication(); n > 1 {
+		s.batchGetReplicated(ctx, items, keys, pending, n, r)
+	} else {
+		s.batchGetGrouped(ctx, items, keys, pending)
+	}
+
+	for _, i := range pending {
+		if items[i].Status != oraclev1.KeyStatus_KEY_STATUS_ERROR {
+			s.hotKeys.fill(keys[i], generations[i], &oraclev1.ProxyGetResponse{
+				Found:   items[i].Status == oraclev1.KeyStatus_KEY_STATUS_FOUND,
+				Value:   items[i].Value,
+				Ttl:     items[i].Ttl,
+				Node:    items[i].Node,
+				Version: items[i].Version,
+			})
+		}
+	}
+
+	results := make(map[string][]byte)
+	nodesUsed := make(map[string]bool)
+	for _, item := range items {
+		if item.Status == oraclev1.KeyStatus_KEY_STATUS_FOUND {
+			// Store result (using original key, not namespaced)
+			results[item.Key] = item.Value
+		}
+		if item.Node != "" {
+			nodesUsed[item.Node] = true
+		}
+	}
+
+	// Convert nodes used map to slice
+	nodesList := make([]string, 0, len(nodesUsed))
+	for node := range nodesUsed {
+		nodesList = append(nodesList, node)
+	}
+
+	s.metrics.IncRequestsOK()
+
+	return &oraclev1.ProxyBatchGetResponse{
+		Results:   results,
+		NodesUsed: nodesList,
+		Items:     items,
+	}, nil
+}
+
+// batchContext bounds a batch request by its timeout, or by
+// DefaultBatchTimeout if it sets none.
+func batchContext(ctx context.Context, timeoutMs int32) (context.Context, context.CancelFunc) {
+	timeout := DefaultBatchTimeout
+	if timeoutMs > 0 {
+		timeout = time.Duration(timeoutMs) * time.Millisecond
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// forEachNode runs fn for the items of every node, all nodes in parallel.
+func forEachNode(groups map[string][]int, fn func(node string, group []int)) {
+	var wg sync.WaitGroup
+	for node, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(node, group)
+		}()
+	}
+	wg.Wait()
+}
+
+// forEachLimited runs fn for every index, at most batchReplicaParallelism
+// at a time.
+func forEachLimited(indexes []int, fn func(i int)) {
+	sem := make(chan struct{}, batchReplicaParallelism)
+	var wg sync.WaitGroup
+	for _, i := range indexes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// setBatchGetItem records the node answer for one key of a batch.
+func setBatchGetItem(item *oraclev1.BatchGetItem, resp *oraclev1.GetResponse, node string) {
+	item.Node = node
+	item.Error = ""
+	if !resp.Found {
+		item.Status = oraclev1.KeyStatus_KEY_STATUS_NOT_FOUND
+		item.Value = nil
+		item.Ttl = 0
+		item.Version = 0
+		return
+	}
+	item.Status = oraclev1.KeyStatus_KEY_STATUS_FOUND
+	item.Value = resp.Value
+	item.Ttl = resp.Ttl
+	item.Version = resp.Timestamp
+}
+
+// failBatchGetItem records an error for one key of a batch.
+func failBatchGetItem(item *oraclev1.BatchGetItem, node string, err error) {
+	item.Status = oraclev1.KeyStatus_KEY_STATUS_ERROR
+	item.Node = node
+	item.Error = err.Error()
+}
+
+// batchGetReplicated reads keys of a replicated namespace, each from a
+// quorum of its replicas.
+func (s *Server) batchGetReplicated(ctx context.Context, items []*oraclev1.BatchGetItem, keys []string, pending []int, n, r int) {
+	forEachLimited(pending, func(i int) {
+		resp, node, err := s.readReplicas(ctx, keys[i], n, r)
+		if err != nil {
+			failBatchGetItem(items[i], node, err)
+			return
+		}
+		setBatchGetItem(items[i], resp, node)
+	})
+}
+
+// batchGetGrouped reads keys grouped by the node that owns them, all nodes
+// in parallel.
+func (s *Server) batchGetGrouped(ctx context.Context, items []*oraclev1.BatchGetItem, keys []string, pending []int) {
+	groups := make(map[string][]int)
+	for _, i := range pending {
+		node := s.selectNode(keys[i])
+		if node == "" {
+			failBatchGetItem(items[i], "", fmt.Errorf("no cache node available"))
+			continue
+		}
+
+		// Read around an ejected node, unless the key was handed off as a hint
+		if s.breakers.ejected(node) && s.hints.holderOf(node, keys[i]) == "" {
+			if next := s.breakers.readNode(keys[i], node); next != "" {
+				node = next
+			}
+		}
+		groups[node] = append(groups[node], i)
+	}
+
+	forEachNode(groups, func(node string, group []int) {
+		s.batchGetNode(ctx, node, items, keys, group)
+	})
+}
+
+// batchGetNode reads a group of keys from one node. Keys the node cannot
+// serve are read from the node holding their hints if the node is down,
+// from their previous owner if they are not migrated yet, and from their
+// legacy encoding if they are still stored under it.
+func (s *Server) batchGetNode(ctx context.Context, node string, items []*oraclev1.BatchGetItem, keys []string, group []int) {
+	s.batchGetFromNode(ctx, node, items, keys, group)
+
+	// Keys may still be stored under their legacy encoding
+	var missing []int
+	for _, i := range group {
+		if items[i].Status == oraclev1.KeyStatus_KEY_STATUS_NOT_FOUND {
+			missing = append(missing, i)
+		}
+	}
+	s.batchGetLegacy(ctx, items, keys, missing)
+}
+
+// batchGetFromNode reads a group of keys from one node, from the holders
+// of their hints if the node is down, and from their previous owner if
+// they are not migrated yet.
+func (s *Server) batchGetFromNode(ctx context.Context, node string, items []*oraclev1.BatchGetItem, keys []string, group []int) {
+	results, err := s.multiGet(ctx, node, keys, group)
+	if err != nil {
+		// Writes accepted while the node is down live on stand-in nodes
+		holders := make(map[string][]int)
+		for _, i := range group {
+			if holder := s.hints.holderOf(node, keys[i]); holder != "" && isUnavailable(err) {
+				holders[holder] = append(holders[holder], i)
+				continue
+			}
+			failBatchGetItem(items[i], node, fmt.Errorf("node error: %w", err))
+		}
+		for holder, held := range holders {
+			results, err := s.multiGet(ctx, holder, keys, held)
+			for j, i := range held {
+				if err != nil {
+					failBatchGetItem(items[i], holder, fmt.Errorf("node error: %w", err))
+					continue
+				}
+				setBatchGetItem(items[i], results[j], holder)
+			}
+		}
+		return
+	}
+
+	// Keys may not have been migrated to their new owner yet
+	fallbacks := make(map[string][]int)
+	for j, i := range group {
+		setBatchGetItem(items[i], results[j], node)
+		if !results[j].Found {
+			if fallback := s.rebalancer.fallbackNode(keys[i]); fallback != "" && fallback != node {
+				fallbacks[fallback] = append(fallbacks[fallback], i)
+			}
+		}
+	}
+	for fallback, missing := range fallbacks {
+		results, err := s.multiGet(ctx, fallback, keys, missing)
+		if err != nil {
+			continue
+		}
+		for j, i := range missing {
+			if results[j].Found {
+				setBatchGetItem(items[i], results[j], fallback)
+			}
+		}
+	}
+}
+
+// batchGetLegacy reads keys that were not found from their legacy
+// encoding, with one MultiGet per node holding legacy copies.
+func (s *Server) batchGetLegacy(ctx context.Context, items []*oraclev1.BatchGetItem, keys []string, missing []int) {
+	if len(missing) == 0 || !s.legacyKeys.active() {
+		return
+	}
+
+	legacyKeys := make([]string, len(keys))
+	groups := make(map[string][]int)
+	for _, i := range missing {
+		legacyKey, ok := s.legacyKeys.legacyKey(keys[i])
+		if !ok {
+			continue
+		}
+		legacyKeys[i] = legacyKey
+		node := s.ownerOf(legacyKey)
+		groups[node] = append(groups[node], i)
+	}
+
+	forEachNode(groups, func(node string, group []int) {
+		results, err := s.multiGet(ctx, node, legacyKeys, group)
+		if err != nil {
+			return
+		}
+		for j, i := range group {
+			if results[j].Found {
+				setBatchGetItem(items[i], results[j], node)
+			}
+		}
+	})
+}
+
+// multiGet reads the keys at the given indexes from a node, in calls of at
+// most batchChunkSize keys.
+//
+// Returns:
+//   - []*oraclev1.GetResponse: One result per index, in order
+//   - error: The first failed call; no results are returned then
+func (s *Server) multiGet(ctx context.Context, node string, keys []string, indexes []int) ([]*oraclev1.GetResponse, error) {
+	client := s.nodeClient(node)
+	if client == nil {
+		return nil, fmt.Errorf("node client not found: %s", node)
+	}
+
+	results := make([]*oraclev1.GetResponse, 0, len(indexes))
+	for start := 0; start < len(indexes); start += batchChunkSize {
+		chunk := indexes[start:min(start+batchChunkSize, len(indexes))]
+		req := &oraclev1.MultiGetRequest{Keys: make([]string, len(chunk))}
+		for j, i := range chunk {
+			req.Keys[j] = keys[i]
+		}
+
+		resp, err := client.MultiGet(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Results) != len(chunk) {
+			return nil, fmt.Errorf("node %s returned %d results for %d keys", node, len(resp.Results), len(chunk))
+		}
+		results = append(results, resp.Results...)
+	}
+	return results, nil
+}
+
+// BatchSet stores multiple key-value pairs in a single request.
+//
+// Request flow:
+//  1. Validate API key and size limits of all items
+//  2. Group the items by owning node
+//  3. Send one MultiSet per node, all nodes in parallel
+//  4. Report the outcome of every item
+//
+// Items for an unavailable node are stored as hints, as with Set. Items of
+// replicated namespaces are written to their replicas one key at a time,
+// several keys in parallel.
+//
+// In atomic mode all items must be owned by the same node; they are then
+// stored with a single MultiSet, which the node applies all at once. A
+// batch spanning several nodes fails with FAILED_PRECONDITION before
+// anything is written.
+func (s *Server) BatchSet(ctx context.Context, req *oraclev1.ProxyBatchSetRequest) (*oraclev1.ProxyBatchWriteResponse, error) {
+	s.metrics.IncRequests()
+
+	// Authenticate and get namespace
+	ns, ok := s.authenticateRequest(req.ApiKey)
+	if !ok {
+		s.metrics.IncRequestsError()
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	// Enforce namespace size limits before issuing any node calls
+	for _, item := range req.Items {
+		if err := s.validateKey(ns, item.Key); err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+		if err := s.validateValue(ns, item.Value); err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+	}
+
+	ctx, cancel := batchContext(ctx, req.TimeoutMs)
+	defer cancel()
+
+	// Writes are stamped so that a hint replayed later cannot overwrite them
+	timestamp := time.Now().UnixNano()
+	writes := make([]*oraclev1.SetRequest, len(req.Items))
+	keys := make([]string, len(req.Items))
+	results := make([]*oraclev1.BatchWriteResult, len(req.Items))
+	for i, item := range req.Items {
+		keys[i] = s.namespaceKey(ns.Name, item.Key)
+		writes[i] = &oraclev1.SetRequest{Key: keys[i], Value: item.Value, Ttl: item.Ttl, Timestamp: timestamp}
+		results[i] = &oraclev1.BatchWriteResult{Key: item.Key}
+	}
+
+	if n, w, _ := ns.Replication(); n > 1 {
+		if req.Atomic {
+			s.metrics.IncRequestsError()
+			return nil, status.Error(codes.FailedPrecondition, "atomic batches are not supported in replicated namespaces")
+		}
+
+		// Gets that start after the writes must not see older values
+		defer s.keysWritten(keys)
+
+		forEachLimited(allIndexes(len(keys)), func(i int) {
+			resp, err := s.setReplicated(ctx, keys[i], writes[i].Value, writes[i].Ttl, n, w)
+			if err != nil {
+				failBatchWrite(results[i], "", err)
+				return
+			}
+			results[i].Success = true
+			results[i].Node = resp.Node
+		})
+		return s.batchWriteResponse(results), nil
+	}
+
+	groups, err := s.groupWrites(keys, req.Atomic)
+	if err != nil {
+		s.metrics.IncRequestsError()
+		return nil, err
+	}
+	defer s.keysWritten(keys)
+
+	forEachNode(groups, func(node string, group []int) {
+		s.batchSetNode(ctx, node, writes, results, group, req.Atomic)
+	})
+	return s.batchWriteResponse(results), nil
+}
+
+// BatchDelete removes multiple keys in a single request.
+//
+// Keys are grouped by owning node and removed with one MultiDelete per node,
+// all nodes in parallel, like BatchSet; atomic mode has the same
+// restrictions. Copies still waiting to be migrated are removed from the
+// previous owner as well.
+func (s *Server) BatchDelete(ctx context.Context, req *oraclev1.ProxyBatchDeleteRequest) (*oraclev1.ProxyBatchWriteResponse, error) {
+	s.metrics.IncRequests()
+
+	// Authenticate and get namespace
+	ns, ok := s.authenticateRequest(req.ApiKey)
+	if !ok {
+		s.metrics.IncRequestsError()
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	// Enforce namespace size limits before issuing any node calls
+	for _, key := range req.Keys {
+		if err := s.validateKey(ns, key); err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+	}
+
+	ctx, cancel := batchContext(ctx, req.TimeoutMs)
+	defer cancel()
+
+	keys := make([]string, len(req.Keys))
+	results := make([]*oraclev1.BatchWriteResult, len(req.Keys))
+	for i, key := range req.Keys {
+		keys[i] = s.namespaceKey(ns.Name, key)
+		results[i] = &oraclev1.BatchWriteResult{Key: key}
+	}
+
+	if n, w, _ := ns.Replication(); n > 1 {
+		if req.Atomic {
+			s.metrics.IncRequestsError()
+			return nil, status.Error(codes.FailedPrecondition, "atomic batches are not supported in replicated namespaces")
+		}
+
+		// Gets that start after the deletes must not see older values
+		defer s.keysWritten(keys)
+
+		forEachLimited(allIndexes(len(keys)), func(i int) {
+			resp, err := s.deleteReplicated(ctx, keys[i], n, w)
+			if err != nil {
+				failBatchWrite(results[i], "", err)
+				return
+			}
+			results[i].Success = true
+			results[i].Existed = resp.Existed
+			results[i].Node = resp.Node
+		})
+		return s.batchWriteResponse(results), nil
+	}
+
+	groups, err := s.groupWrites(keys, req.Atomic)
+	if err != nil {
+		s.metrics.IncRequestsError()
+		return nil, err
+	}
+	defer s.keysWritten(keys)
+
+	forEachNode(groups, func(node string, group []int) {
+		s.batchDeleteNode(ctx, node, keys, results, group, req.Atomic)
+	})
+	return s.batchWriteResponse(results), nil
+}
+
+// allIndexes returns the indexes 0 to n-1.
+func allIndexes(n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+// keysWritten makes Gets that start after a batch write read the written
+// keys from the nodes again.
+func (s *Server) keysWritten(keys []string) {
+	for _, key := range keys {
+		s.keyWritten(key)
+	}
+}
+
+// groupWrites groups the keys of a batch write by owning node.
+//
+// Keys are grouped by their owner (see ownerOf), which only depends on the
+// ring membership, so an atomic batch of co-located keys is never split.
+// Load is recorded once the batch is accepted.
+//
+// Returns:
+//   - map[string][]int: Indexes of the keys owned by each node
+//   - error: FAILED_PRECONDITION if an atomic batch spans several nodes,
+//     UNAVAILABLE if no node is available
+func (s *Server) groupWrites(keys []string, atomic bool) (map[string][]int, error) {
+	groups, err := s.groupByOwner(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if atomic && len(groups) > 1 {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"atomic batch spans %d cache nodes; all keys must be owned by one node", len(groups))
+	}
+
+	for node, group := range groups {
+		for range group {
+			s.countRequest(node)
+		}
+	}
+	return groups, nil
+}
+
+// groupByOwner groups keys by owning node without recording load.
+func (s *Server) groupByOwner(keys []string) (map[string][]int, error) {
+	groups := make(map[string][]int)
+	for i, key := range keys {
+		node := s.ownerOf(key)
+		if node == "" {
+			return nil, status.Error(codes.Unavailable, "no cache node available")
+		}
+		groups[node] = append(groups[node], i)
+	}
+	return groups, nil
+}
+
+// failBatchWrite records an error for one item of a batch write.
+func failBatchWrite(result *oraclev1.BatchWriteResult, node string, err error) {
+	result.Success = false
+	result.Node = node
+	result.Error = err.Error()
+}
+
+// batchWriteResponse collects the results of a batch write.
+func (s *Server) batchWriteResponse(results []*oraclev1.BatchWriteResult) *oraclev1.ProxyBatchWriteResponse {
+	nodesUsed := make(map[string]bool)
+	for _, result := range results {
+		if result.Success && result.Node != "" {
+			nodesUsed[result.Node] = true
+		}
+	}
+
+	nodesList := make([]string, 0, len(nodesUsed))
+	for node := range nodesUsed {
+		nodesList = append(nodesList, node)
+	}
+
+	s.metrics.IncRequestsOK()
+
+	return &oraclev1.ProxyBatchWriteResponse{
+		Results:   results,
+		NodesUsed: nodesList,
+	}
+}
+
+// chunkSize returns the number of items sent per node call: all of them in
+// atomic mode, batchChunkSize otherwise.
+func chunkSize(group []int, atomic bool) int {
+	if atomic {
+		return max(len(group), 1)
+	}
+	return batchChunkSize
+}
+
+// batchSetNode stores a group of items on their owner. Items the node
+// cannot take because it is down are stored as hints, except in atomic
+// mode.
+func (s *Server) batchSetNode(ctx context.Context, node string, writes []*oraclev1.SetRequest, results []*oraclev1.BatchWriteResult, group []int, atomic bool) {
+	client := s.nodeClient(node)
+
+	size := chunkSize(group, atomic)
+	for start := 0; start < len(group); start += size {
+		chunk := group[start:min(start+size, len(group))]
+		req := &oraclev1.MultiSetRequest{Items: make([]*oraclev1.SetRequest, len(chunk))}
+		for j, i := range chunk {
+			req.Items[j] = writes[i]
+		}
+
+		var resp *oraclev1.MultiSetResponse
+		err := fmt.Errorf("node client not found: %s", node)
+		if client != nil {
+			resp, err = client.MultiSet(ctx, req)
+		}
+		if err == nil && len(resp.Results) != len(chunk) {
+			err = fmt.Errorf("node %s returned %d results for %d items", node, len(resp.Results), len(chunk))
+		}
+
+		if err != nil {
+			for _, i := range chunk {
+				// Keep the write on another node until the owner is back
+				if isUnavailable(err) && !atomic {
+					if hintResp, holder, ok := s.hints.store(ctx, node, writes[i]); ok {
+						results[i].Success = hintResp.Success
+						results[i].Node = holder
+						if !hintResp.Success {
+							results[i].Error = hintResp.Message
+						}
+						continue
+					}
+				}
+				failBatchWrite(results[i], node, fmt.Errorf("node error: %w", err))
+			}
+			continue
+		}
+
+		for j, i := range chunk {
+			results[i].Success = resp.Results[j].Success
+			results[i].Node = node
+			if !resp.Results[j].Success {
+				results[i].Error = resp.Results[j].Message
+			}
+
+			// The owner now holds a newer value than any pending hint or copy
+			s.hints.forget(node, writes[i].Key)
+			s.dropOverflowCopy(ctx, node, writes[i].Key)
+		}
+	}
+}
+
+// batchDeleteNode removes a group of keys from the previous owner of keys
+// that are being migrated and under their legacy encoding, and then from
+// their owner. The order matters for migrations running on other proxies
+// (see moveKeys).
+func (s *Server) batchDeleteNode(ctx context.Context, node string, keys []string, results []*oraclev1.BatchWriteResult, group []int, atomic bool) {
+	// Remove the copies that are still waiting to be migrated, and make
+	// sure an in-flight migration batch of this proxy skips them
+	groupKeys := make([]string, len(group))
+	for j, i := range group {
+		groupKeys[j] = keys[i]
+	}
+	legacyExisted := s.legacyKeys.delete(ctx, groupKeys)
+
+	fallbackExisted := make(map[int]bool)
+	fallbacks := make(map[string][]int)
+	for _, i := range group {
+		s.rebalancer.forget(keys[i])
+		if legacyExisted[keys[i]] {
+			fallbackExisted[i] = true
+		}
+		if fallback := s.rebalancer.fallbackNode(keys[i]); fallback != "" && fallback != node {
+			fallbacks[fallback] = append(fallbacks[fallback], i)
+		}
+	}
+	for fallback, moved := range fallbacks {
+		fallbackClient := s.nodeClient(fallback)
+		if fallbackClient == nil {
+			continue
+		}
+		req := &oraclev1.MultiDeleteRequest{Keys: make([]string, len(moved))}
+		for j, i := range moved {
+			req.Keys[j] = keys[i]
+		}
+		resp, err := fallbackClient.MultiDelete(ctx, req)
+		if err != nil || len(resp.Results) != len(moved) {
+			s.logger.Warn("Failed to delete %d keys from previous owner %s: %v", len(moved), fallback, err)
+			continue
+		}
+		for j, i := range moved {
+			fallbackExisted[i] = fallbackExisted[i] || resp.Results[j].Existed
+		}
+	}
+
+	client := s.nodeClient(node)
+
+	size := chunkSize(group, atomic)
+	for start := 0; start < len(group); start += size {
+		chunk := group[start:min(start+size, len(group))]
+		req := &oraclev1.MultiDeleteRequest{Keys: make([]string, len(chunk))}
+		for j, i := range chunk {
+			req.Keys[j] = keys[i]
+		}
+
+		var resp *oraclev1.MultiDeleteResponse
+		err := fmt.Errorf("node client not found: %s", node)
+		if client != nil {
+			resp, err = client.MultiDelete(ctx, req)
+		}
+		if err == nil && len(resp.Results) != len(chunk) {
+			err = fmt.Errorf("node %s returned %d results for %d keys", node, len(resp.Results), len(chunk))
+		}
+
+		if err != nil {
+			for _, i := range chunk {
+				failBatchWrite(results[i], node, fmt.Errorf("node error: %w", err))
+			}
+			continue
+		}
+
+		for j, i := range chunk {
+			results[i].Success = resp.Results[j].Success
+			results[i].Existed = resp.Results[j].Existed || fallbackExisted[i]
+			results[i].Node = node
+
+			// A pending hint would bring the key back when replayed
+			s.hints.forget(node, keys[i])
+			s.dropOverflowCopy(ctx, node, keys[i])
+		}
+	}
+}
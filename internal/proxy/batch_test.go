@@ -0,0 +1,302 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	"github.com/eggybyte-technology/yao-oracle/internal/node"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// recordingNode is an in-process cache node that records the size of every
+// MultiGet and MultiSet it serves. If stall is set, it holds MultiGets
+// until they are canceled.
+type recordingNode struct {
+	stall bool
+
+	mu    sync.Mutex
+	sizes map[string][]int
+}
+
+func (n *recordingNode) intercept(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	size := -1
+	switch r := req.(type) {
+	case *oraclev1.MultiGetRequest:
+		size = len(r.Keys)
+		if n.stall {
+			<-ctx.Done()
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	case *oraclev1.MultiSetRequest:
+		size = len(r.Items)
+	}
+	if size >= 0 {
+		n.mu.Lock()
+		n.sizes[info.FullMethod] = append(n.sizes[info.FullMethod], size)
+		n.mu.Unlock()
+	}
+	return handler(ctx, req)
+}
+
+// calls returns the sizes of the calls of a method, in order.
+func (n *recordingNode) calls(method string) []int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]int(nil), n.sizes[method]...)
+}
+
+// startRecordingNode runs a recording cache node on a loopback listener and
+// returns its address and a client for it.
+func startRecordingNode(t *testing.T, stall bool) (string, oraclev1.NodeServiceClient, *recordingNode) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	rec := &recordingNode{stall: stall, sizes: make(map[string][]int)}
+	srv := grpc.NewServer(grpc.UnaryInterceptor(rec.intercept))
+	oraclev1.RegisterNodeServiceServer(srv, node.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	addr := lis.Addr().String()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial %s: %v", addr, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return addr, oraclev1.NewNodeServiceClient(conn), rec
+}
+
+// keysOwnedBy returns count namespaced keys whose owner is owner.
+func keysOwnedBy(t *testing.T, s *Server, owner string, count int) []string {
+	t.Helper()
+
+	keys := make([]string, 0, count)
+	for i := 0; len(keys) < count; i++ {
+		if i == 100000 {
+			t.Fatalf("found %d of %d keys owned by %s", len(keys), count, owner)
+		}
+		key := kv.NamespaceKey("files", fmt.Sprintf("file-%d", i))
+		if s.ownerOf(key) == owner {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// batchGet reads keys as BatchGet does after authentication and returns
+// the item of every key.
+func batchGet(ctx context.Context, s *Server, keys []string) []*oraclev1.BatchGetItem {
+	items := make([]*oraclev1.BatchGetItem, len(keys))
+	for i, key := range keys {
+		items[i] = &oraclev1.BatchGetItem{Key: clientKey(key)}
+	}
+	s.batchGetGrouped(ctx, items, keys, allIndexes(len(keys)))
+	return items
+}
+
+func TestBatchGetKeyStatus(t *testing.T) {
+	addrs, clients := startNodes(t, 2)
+	down := unreachableAddr(t)
+	s := newTestProxy(t, append(addrs, down))
+
+	found := keyOwnedBy(t, s, addrs[0])
+	setVersioned(t, clients[addrs[0]], found, "v1", time.Now().UnixNano())
+	missing := keysOwnedBy(t, s, addrs[1], 1)[0]
+	failed := keyOwnedBy(t, s, down)
+
+	ctx, cancel := batchContext(context.Background(), 0)
+	defer cancel()
+	items := batchGet(ctx, s, []string{found, missing, failed})
+
+	want := []struct {
+		status oraclev1.KeyStatus
+		node   string
+	}{
+		{oraclev1.KeyStatus_KEY_STATUS_FOUND, addrs[0]},
+		{oraclev1.KeyStatus_KEY_STATUS_NOT_FOUND, addrs[1]},
+		{oraclev1.KeyStatus_KEY_STATUS_ERROR, down},
+	}
+	for i, item := range items {
+		if item.Status != want[i].status || item.Node != want[i].node {
+			t.Errorf("item %s = %s from %s, want %s from %s", item.Key, item.Status, item.Node, want[i].status, want[i].node)
+		}
+	}
+	if string(items[0].Value) != "v1" || items[0].Version == 0 {
+		t.Errorf("found item holds %q at version %d, want v1 with its version", items[0].Value, items[0].Version)
+	}
+	if items[1].Error != "" || items[2].Error == "" {
+		t.Errorf("errors = %q and %q, want one only for the unreachable node", items[1].Error, items[2].Error)
+	}
+}
+
+func TestBatchGetDeadline(t *testing.T) {
+	addrs, clients := startNodes(t, 1)
+	slow, _, _ := startRecordingNode(t, true)
+	s := newTestProxy(t, []string{addrs[0], slow})
+
+	fast := keyOwnedBy(t, s, addrs[0])
+	setVersioned(t, clients[addrs[0]], fast, "v1", time.Now().UnixNano())
+	stalled := keyOwnedBy(t, s, slow)
+
+	start := time.Now()
+	ctx, cancel := batchContext(context.Background(), 100)
+	defer cancel()
+	items := batchGet(ctx, s, []string{fast, stalled})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("batch took %v with a 100ms timeout", elapsed)
+	}
+	if items[0].Status != oraclev1.KeyStatus_KEY_STATUS_FOUND {
+		t.Errorf("key of the fast node = %s, want found", items[0].Status)
+	}
+	if items[1].Status != oraclev1.KeyStatus_KEY_STATUS_ERROR || items[1].Node != slow {
+		t.Errorf("key of the stalled node = %s from %s, want an error from %s", items[1].Status, items[1].Node, slow)
+	}
+}
+
+func TestBatchGetChunks(t *testing.T) {
+	addr, client, rec := startRecordingNode(t, false)
+	s := newTestProxy(t, []string{addr})
+
+	keys := keysOwnedBy(t, s, addr, 2*batchChunkSize+1)
+	for _, key := range keys[:batchChunkSize+1] {
+		setVersioned(t, client, key, "v-"+key, time.Now().UnixNano())
+	}
+
+	ctx, cancel := batchContext(context.Background(), 0)
+	defer cancel()
+	items := batchGet(ctx, s, keys)
+
+	calls := rec.calls(oraclev1.NodeService_MultiGet_FullMethodName)
+	if len(calls) != 3 || calls[0] != batchChunkSize || calls[1] != batchChunkSize || calls[2] != 1 {
+		t.Errorf("MultiGet calls of %v keys, want 2 of %d and 1 of 1", calls, batchChunkSize)
+	}
+
+	// Results of every chunk are matched to their keys
+	for i, item := range items {
+		want := oraclev1.KeyStatus_KEY_STATUS_NOT_FOUND
+		if i <= batchChunkSize {
+			want = oraclev1.KeyStatus_KEY_STATUS_FOUND
+		}
+		if item.Status != want {
+			t.Fatalf("item %d (%s) = %s, want %s", i, item.Key, item.Status, want)
+		}
+		if want == oraclev1.KeyStatus_KEY_STATUS_FOUND && string(item.Value) != "v-"+keys[i] {
+			t.Fatalf("item %d (%s) holds %q, want the value of its key", i, item.Key, item.Value)
+		}
+	}
+}
+
+func TestBatchSetChunks(t *testing.T) {
+	tests := []struct {
+		name   string
+		atomic bool
+		want   []int
+	}{
+		{name: "chunked", want: []int{batchChunkSize, batchChunkSize, 1}},
+		{name: "atomic in one call", atomic: true, want: []int{2*batchChunkSize + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, client, rec := startRecordingNode(t, false)
+			s := newTestProxy(t, []string{addr})
+
+			keys := keysOwnedBy(t, s, addr, 2*batchChunkSize+1)
+			writes := make([]*oraclev1.SetRequest, len(keys))
+			results := make([]*oraclev1.BatchWriteResult, len(keys))
+			for i, key := range keys {
+				writes[i] = &oraclev1.SetRequest{Key: key, Value: []byte("v1")}
+				results[i] = &oraclev1.BatchWriteResult{Key: clientKey(key)}
+			}
+
+			groups, err := s.groupWrites(keys, tt.atomic)
+			if err != nil {
+				t.Fatalf("groupWrites: %v", err)
+			}
+			s.batchSetNode(context.Background(), addr, writes, results, groups[addr], tt.atomic)
+
+			calls := rec.calls(oraclev1.NodeService_MultiSet_FullMethodName)
+			if fmt.Sprint(calls) != fmt.Sprint(tt.want) {
+				t.Errorf("MultiSet calls of %v items, want %v", calls, tt.want)
+			}
+			for i, result := range results {
+				if !result.Success || result.Node != addr {
+					t.Fatalf("result %d = %v, want stored on %s", i, result, addr)
+				}
+			}
+			if value, _ := getValue(t, client, keys[len(keys)-1]); value != "v1" {
+				t.Errorf("last key holds %q, want v1", value)
+			}
+		})
+	}
+}
+
+func TestBatchWriteAtomicAcrossNodes(t *testing.T) {
+	addrs, _ := startNodes(t, 3)
+	s := newTestProxy(t, addrs)
+
+	spread := []string{keyOwnedBy(t, s, addrs[0]), keyOwnedBy(t, s, addrs[1])}
+	if _, err := s.groupWrites(spread, true); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("atomic batch over two nodes = %v, want FAILED_PRECONDITION", err)
+	}
+	if _, err := s.groupWrites(spread, false); err != nil {
+		t.Errorf("non-atomic batch over two nodes: %v", err)
+	}
+
+	// Keys sharing a hash tag are owned by one node
+	tagged := []string{
+		kv.NamespaceKey("shop", "{user:42}:profile"),
+		kv.NamespaceKey("shop", "{user:42}:cart"),
+	}
+	groups, err := s.groupWrites(tagged, true)
+	if err != nil || len(groups) != 1 {
+		t.Errorf("atomic batch of tagged keys = %v, %v; want one node", groups, err)
+	}
+}
+
+func TestBatchSetAtomicSkipsHints(t *testing.T) {
+	addrs, clients := startNodes(t, 2)
+	down := unreachableAddr(t)
+	s := newTestProxy(t, append(addrs, down))
+
+	keys := keysOwnedBy(t, s, down, 2)
+	writes := make([]*oraclev1.SetRequest, len(keys))
+	results := make([]*oraclev1.BatchWriteResult, len(keys))
+	for i, key := range keys {
+		writes[i] = &oraclev1.SetRequest{Key: key, Value: []byte("v1"), Timestamp: time.Now().UnixNano()}
+		results[i] = &oraclev1.BatchWriteResult{Key: clientKey(key)}
+	}
+
+	s.batchSetNode(context.Background(), down, writes, results, allIndexes(len(keys)), true)
+
+	for _, result := range results {
+		if result.Success || result.Node != down || result.Error == "" {
+			t.Errorf("atomic write to a down node = %v, want an error from %s", result, down)
+		}
+	}
+	if stats := s.hints.stats(); stats.Pending != 0 {
+		t.Errorf("%d hints stored for an atomic batch", stats.Pending)
+	}
+	for _, addr := range addrs {
+		for _, key := range keys {
+			if _, found := getValue(t, clients[addr], key); found {
+				t.Errorf("node %s holds %s written by a failed atomic batch", addr, key)
+			}
+		}
+	}
+}
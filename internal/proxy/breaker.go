@@ -0,0 +1,408 @@
+package proxy
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+)
+
+// Default circuit breaker settings, used when CircuitBreakerConfig leaves
+// them unset.
+const (
+	DefaultBreakerConsecutiveErrors  = 5
+	DefaultBreakerLatencyPercentile  = 0.99
+	DefaultBreakerBaseEjection       = 10 * time.Second
+	DefaultBreakerMaxEjection        = 300 * time.Second
+	DefaultBreakerHalfOpenSuccesses  = 3
+	DefaultBreakerMaxEjectionPercent = 50
+)
+
+// Circuit breaker modes for reads of keys owned by an ejected node.
+const (
+	BreakerModeSkip     = "skip"
+	BreakerModeFailFast = "failfast"
+)
+
+// Circuit breaker states reported in NodeBreakerStatus.
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half_open"
+)
+
+// Latency window: the percentile is computed over the last
+// breakerLatencyWindow data-path requests, re-evaluated every
+// breakerLatencyEvalEvery samples once breakerLatencyMinSamples were seen.
+const (
+	breakerLatencyWindow     = 100
+	breakerLatencyEvalEvery  = 10
+	breakerLatencyMinSamples = 20
+)
+
+// breakerLatencyMethods are the node RPCs whose latency is tracked. Bulk
+// RPCs such as Import are slow by design and would skew the percentile.
+var breakerLatencyMethods = map[string]bool{
+	oraclev1.NodeService_Get_FullMethodName:    true,
+	oraclev1.NodeService_Set_FullMethodName:    true,
+	oraclev1.NodeService_Delete_FullMethodName: true,
+}
+
+// breakerExemptMethods are the node RPCs that bypass the breaker. Health
+// checks and stats are probes rather than traffic: they must reach ejected
+// nodes, and their outcome must not eject or re-admit a node.
+var breakerExemptMethods = map[string]bool{
+	oraclev1.NodeService_Health_FullMethodName: true,
+	oraclev1.NodeService_Stats_FullMethodName:  true,
+}
+
+// nodeBreaker is the circuit breaker of one cache node.
+type nodeBreaker struct {
+	state             string
+	consecutiveErrors int
+	ejections         int
+	openUntil         time.Time
+	reason            string
+
+	// Half-open probing: one probe is in flight at a time
+	probing        bool
+	probeSuccesses int
+
+	latencies  [breakerLatencyWindow]time.Duration
+	samples    int
+	latencyP   time.Duration
+	sinceCheck int
+}
+
+// breakerSet holds the circuit breakers of all ring nodes.
+type breakerSet struct {
+	server *Server
+
+	mu       sync.Mutex
+	cfg      config.CircuitBreakerConfig
+	breakers map[string]*nodeBreaker
+}
+
+// newBreakerSet creates circuit breakers with default settings.
+func newBreakerSet(s *Server) *breakerSet {
+	return &breakerSet{
+		server:   s,
+		breakers: make(map[string]*nodeBreaker),
+	}
+}
+
+// configure applies circuit breaker settings; nil selects defaults.
+func (b *breakerSet) configure(cfg *config.CircuitBreakerConfig) {
+	var effective config.CircuitBreakerConfig
+	if cfg != nil {
+		effective = *cfg
+	}
+	if effective.Mode == "" {
+		effective.Mode = BreakerModeSkip
+	}
+	if effective.ConsecutiveErrors <= 0 {
+		effective.ConsecutiveErrors = DefaultBreakerConsecutiveErrors
+	}
+	if effective.LatencyPercentile <= 0 {
+		effective.LatencyPercentile = DefaultBreakerLatencyPercentile
+	}
+	if effective.BaseEjectionSeconds <= 0 {
+		effective.BaseEjectionSeconds = int(DefaultBreakerBaseEjection / time.Second)
+	}
+	if effective.MaxEjectionSeconds <= 0 {
+		effective.MaxEjectionSeconds = int(DefaultBreakerMaxEjection / time.Second)
+	}
+	if effective.HalfOpenSuccesses <= 0 {
+		effective.HalfOpenSuccesses = DefaultBreakerHalfOpenSuccesses
+	}
+	if effective.MaxEjectionPercent <= 0 {
+		effective.MaxEjectionPercent = DefaultBreakerMaxEjectionPercent
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cfg = effective
+	if effective.Disabled {
+		// Re-admit every node; breakers start over if re-enabled
+		for node := range b.breakers {
+			b.breakers[node] = &nodeBreaker{state: breakerClosed}
+		}
+	}
+}
+
+// sync keeps one breaker per ring node, dropping those of removed nodes.
+func (b *breakerSet) sync(nodes []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current := make(map[string]*nodeBreaker, len(nodes))
+	for _, node := range nodes {
+		if br, ok := b.breakers[node]; ok {
+			current[node] = br
+		} else {
+			current[node] = &nodeBreaker{state: breakerClosed}
+		}
+	}
+	b.breakers = current
+}
+
+// readmit closes the breaker of a node and forgets its ejections, e.g.
+// after a promoted follower took over the node's position in the ring.
+func (b *breakerSet) readmit(node string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.breakers[node]; ok {
+		b.breakers[node] = &nodeBreaker{state: breakerClosed}
+	}
+}
+
+// isNodeFailure reports whether an error counts against a node: the node
+// was unreachable, too slow or failed internally. Errors caused by the
+// request itself, and requests canceled by the caller, do not count.
+func isNodeFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// allow decides whether a request may be sent to a node.
+//
+// Returns:
+//   - probe: True if the request is a half-open probe
+//   - ok: False if the node is ejected and the request must fail fast
+func (b *breakerSet) allow(node string) (probe, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.breakers[node]
+	if b.cfg.Disabled || br == nil {
+		return false, true
+	}
+
+	switch br.state {
+	case breakerOpen:
+		if time.Now().Before(br.openUntil) {
+			return false, false
+		}
+		br.state = breakerHalfOpen
+		br.probeSuccesses = 0
+		b.server.logger.Info("Circuit breaker for %s half-open, probing", node)
+		fallthrough
+	case breakerHalfOpen:
+		if br.probing {
+			return false, false
+		}
+		br.probing = true
+		return true, true
+	default:
+		return false, true
+	}
+}
+
+// record accounts for the outcome of a request to a node.
+func (b *breakerSet) record(node, method string, probe bool, latency time.Duration, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.breakers[node]
+	if br == nil {
+		return
+	}
+	failed := isNodeFailure(err)
+
+	if probe {
+		br.probing = false
+		switch {
+		case failed:
+			b.eject(node, br, "probe failed: "+status.Convert(err).Message())
+		case status.Code(err) != codes.Canceled:
+			br.probeSuccesses++
+			if br.probeSuccesses >= b.cfg.HalfOpenSuccesses {
+				*br = nodeBreaker{state: breakerClosed}
+				b.server.logger.Success("Circuit breaker for %s closed, node re-admitted", node)
+			}
+		}
+		return
+	}
+	if br.state != breakerClosed {
+		// A request admitted before the node was ejected
+		return
+	}
+
+	if failed {
+		br.consecutiveErrors++
+		if br.consecutiveErrors >= b.cfg.ConsecutiveErrors {
+			b.eject(node, br, "consecutive errors: "+status.Convert(err).Message())
+			return
+		}
+	} else if status.Code(err) != codes.Canceled {
+		br.consecutiveErrors = 0
+	}
+
+	if !breakerLatencyMethods[method] || b.cfg.LatencyThresholdMs <= 0 {
+		return
+	}
+	br.latencies[br.samples%breakerLatencyWindow] = latency
+	br.samples++
+	br.sinceCheck++
+	if br.samples < breakerLatencyMinSamples || br.sinceCheck < breakerLatencyEvalEvery {
+		return
+	}
+	br.sinceCheck = 0
+	br.latencyP = percentile(br.latencies[:min(br.samples, breakerLatencyWindow)], b.cfg.LatencyPercentile)
+	if threshold := time.Duration(b.cfg.LatencyThresholdMs) * time.Millisecond; br.latencyP > threshold {
+		b.eject(node, br, "latency above "+threshold.String())
+	}
+}
+
+// eject opens a node's breaker, unless that would eject more than the
+// allowed share of nodes; b.mu must be held.
+func (b *breakerSet) eject(node string, br *nodeBreaker, reason string) {
+	if br.state == breakerClosed {
+		ejected := 0
+		for _, other := range b.breakers {
+			if other.state != breakerClosed {
+				ejected++
+			}
+		}
+		limit := max(1, len(b.breakers)*b.cfg.MaxEjectionPercent/100)
+		if ejected >= limit {
+			b.server.logger.Warn("Not ejecting %s (%s): %d of %d nodes already ejected", node, reason, ejected, len(b.breakers))
+			br.consecutiveErrors = 0
+			return
+		}
+	}
+
+	// Every ejection without recovery in between doubles the duration
+	duration := time.Duration(b.cfg.BaseEjectionSeconds) * time.Second << min(br.ejections, 16)
+	duration = min(duration, time.Duration(b.cfg.MaxEjectionSeconds)*time.Second)
+
+	br.state = breakerOpen
+	br.ejections++
+	br.openUntil = time.Now().Add(duration)
+	br.reason = reason
+	br.probing = false
+	b.server.logger.Warn("Circuit breaker for %s open for %s: %s", node, duration, reason)
+}
+
+// percentile returns the p-th percentile of the samples.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted[min(int(p*float64(len(sorted))), len(sorted)-1)]
+}
+
+// interceptor returns a unary client interceptor that applies the breaker
+// of a node to every call on its connection, except for probes (see
+// breakerExemptMethods).
+func (b *breakerSet) interceptor(node string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if breakerExemptMethods[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		probe, ok := b.allow(node)
+		if !ok {
+			return status.Errorf(codes.Unavailable, "circuit breaker open for node %s", node)
+		}
+
+		started := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(node, method, probe, time.Since(started), err)
+		return err
+	}
+}
+
+// ejected reports whether requests to a node would currently fail fast. A
+// node whose ejection time is over is routed to again, so that its next
+// request becomes a probe.
+func (b *breakerSet) ejected(node string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.breakers[node]
+	if b.cfg.Disabled || br == nil {
+		return false
+	}
+	switch br.state {
+	case breakerOpen:
+		return time.Now().Before(br.openUntil)
+	case breakerHalfOpen:
+		return br.probing
+	default:
+		return false
+	}
+}
+
+// readNode returns the node to read a key from when its owner is ejected:
+// the next node in the ring that is not ejected, or "" in failfast mode or
+// if every node is ejected.
+func (b *breakerSet) readNode(key, owner string) string {
+	b.mu.Lock()
+	mode := b.cfg.Mode
+	b.mu.Unlock()
+	if mode != BreakerModeSkip {
+		return ""
+	}
+
+	s := b.server
+	s.mu.RLock()
+	candidates := s.ring.GetNodes(kv.RoutingKey(key), s.ring.Size())
+	s.mu.RUnlock()
+
+	for _, node := range candidates {
+		if node != owner && !b.ejected(node) {
+			return node
+		}
+	}
+	return ""
+}
+
+// status returns the breaker state of every ring node.
+func (b *breakerSet) status() []*oraclev1.NodeBreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	result := make([]*oraclev1.NodeBreakerStatus, 0, len(b.breakers))
+	for node, br := range b.breakers {
+		st := &oraclev1.NodeBreakerStatus{
+			Node:              node,
+			State:             br.state,
+			ConsecutiveErrors: int32(br.consecutiveErrors),
+			LatencyMs:         float64(br.latencyP) / float64(time.Millisecond),
+			Ejections:         int32(br.ejections),
+			Reason:            br.reason,
+		}
+		if br.state == breakerOpen && now.Before(br.openUntil) {
+			st.ProbeInMs = br.openUntil.Sub(now).Milliseconds()
+		}
+		result = append(result, st)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Node < result[j].Node
+	})
+	return result
+}
+
+// SetCircuitBreakerConfig applies circuit breaker settings; nil selects
+// defaults.
+func (s *Server) SetCircuitBreakerConfig(cfg *config.CircuitBreakerConfig) {
+	s.breakers.configure(cfg)
+}
@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+var errUnavailable = status.Error(codes.Unavailable, "connection refused")
+
+// newTestBreakers returns breakers for nodes a to d with the given settings.
+func newTestBreakers(t *testing.T, cfg config.CircuitBreakerConfig) *breakerSet {
+	t.Helper()
+
+	s := NewServer(nil)
+	t.Cleanup(s.Stop)
+	b := newBreakerSet(s)
+	b.configure(&cfg)
+	b.sync([]string{"a", "b", "c", "d"})
+	return b
+}
+
+// callThrough sends a call with the given method and outcome through the
+// breaker interceptor of a node.
+func callThrough(b *breakerSet, node, method string, err error) error {
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return err
+	}
+	return b.interceptor(node)(context.Background(), method, nil, nil, nil, invoker)
+}
+
+// endEjection lets the ejection of a node run out.
+func endEjection(b *breakerSet, node string) {
+	b.mu.Lock()
+	b.breakers[node].openUntil = time.Now().Add(-time.Millisecond)
+	b.mu.Unlock()
+}
+
+func breakerState(b *breakerSet, node string) (string, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br := b.breakers[node]
+	return br.state, time.Until(br.openUntil)
+}
+
+func TestBreakerEjectsAfterConsecutiveErrors(t *testing.T) {
+	b := newTestBreakers(t, config.CircuitBreakerConfig{ConsecutiveErrors: 3})
+	get := oraclev1.NodeService_Get_FullMethodName
+
+	// A success resets the count, and request errors do not count
+	callThrough(b, "a", get, errUnavailable)
+	callThrough(b, "a", get, errUnavailable)
+	callThrough(b, "a", get, nil)
+	callThrough(b, "a", get, status.Error(codes.InvalidArgument, "bad key"))
+	callThrough(b, "a", get, errUnavailable)
+	callThrough(b, "a", get, errUnavailable)
+	if state, _ := breakerState(b, "a"); state != breakerClosed {
+		t.Fatalf("state after 2 consecutive errors = %s, want closed", state)
+	}
+
+	callThrough(b, "a", get, errUnavailable)
+	if state, _ := breakerState(b, "a"); state != breakerOpen {
+		t.Fatalf("state after 3 consecutive errors = %s, want open", state)
+	}
+	if !b.ejected("a") {
+		t.Error("ejected(a) = false for an open breaker")
+	}
+	if err := callThrough(b, "a", get, nil); status.Code(err) != codes.Unavailable {
+		t.Errorf("call to an ejected node = %v, want it failed fast with UNAVAILABLE", err)
+	}
+}
+
+func TestBreakerHalfOpenProbing(t *testing.T) {
+	b := newTestBreakers(t, config.CircuitBreakerConfig{ConsecutiveErrors: 1, HalfOpenSuccesses: 2})
+	get := oraclev1.NodeService_Get_FullMethodName
+
+	callThrough(b, "a", get, errUnavailable)
+	endEjection(b, "a")
+	if b.ejected("a") {
+		t.Fatal("ejected(a) = true after the ejection time")
+	}
+
+	// One probe at a time is let through
+	probe, ok := b.allow("a")
+	if !probe || !ok {
+		t.Fatalf("allow after the ejection = %v, %v; want a probe", probe, ok)
+	}
+	if _, ok := b.allow("a"); ok {
+		t.Error("second request allowed while a probe is in flight")
+	}
+	if !b.ejected("a") {
+		t.Error("ejected(a) = false while a probe is in flight")
+	}
+	b.record("a", get, true, time.Millisecond, nil)
+
+	if state, _ := breakerState(b, "a"); state != breakerHalfOpen {
+		t.Fatalf("state after 1 of 2 probes = %s, want half_open", state)
+	}
+	if err := callThrough(b, "a", get, nil); err != nil {
+		t.Fatalf("second probe: %v", err)
+	}
+	if state, _ := breakerState(b, "a"); state != breakerClosed {
+		t.Fatalf("state after 2 successful probes = %s, want closed", state)
+	}
+}
+
+func TestBreakerFailedProbeDoublesEjection(t *testing.T) {
+	b := newTestBreakers(t, config.CircuitBreakerConfig{ConsecutiveErrors: 1, BaseEjectionSeconds: 10})
+	get := oraclev1.NodeService_Get_FullMethodName
+
+	callThrough(b, "a", get, errUnavailable)
+	if _, until := breakerState(b, "a"); until <= 9*time.Second || until > 10*time.Second {
+		t.Fatalf("first ejection lasts %v, want 10s", until)
+	}
+
+	endEjection(b, "a")
+	callThrough(b, "a", get, errUnavailable)
+	state, until := breakerState(b, "a")
+	if state != breakerOpen || until <= 19*time.Second || until > 20*time.Second {
+		t.Errorf("after a failed probe: %s for %v, want open for 20s", state, until)
+	}
+}
+
+func TestBreakerEjectsLatencyOutliers(t *testing.T) {
+	b := newTestBreakers(t, config.CircuitBreakerConfig{LatencyThresholdMs: 50, LatencyPercentile: 0.9})
+	get := oraclev1.NodeService_Get_FullMethodName
+
+	for range breakerLatencyMinSamples {
+		b.record("a", get, false, 10*time.Millisecond, nil)
+		b.record("b", get, false, 100*time.Millisecond, nil)
+		// Bulk requests are slow by design and not tracked
+		b.record("c", oraclev1.NodeService_Import_FullMethodName, false, time.Second, nil)
+	}
+
+	for node, want := range map[string]string{"a": breakerClosed, "b": breakerOpen, "c": breakerClosed} {
+		if state, _ := breakerState(b, node); state != want {
+			t.Errorf("state of %s = %s, want %s", node, state, want)
+		}
+	}
+}
+
+func TestBreakerMaxEjectionPercent(t *testing.T) {
+	b := newTestBreakers(t, config.CircuitBreakerConfig{ConsecutiveErrors: 1, MaxEjectionPercent: 50})
+	get := oraclev1.NodeService_Get_FullMethodName
+
+	for _, node := range []string{"a", "b", "c"} {
+		callThrough(b, node, get, errUnavailable)
+	}
+
+	ejected := 0
+	for _, node := range []string{"a", "b", "c", "d"} {
+		if b.ejected(node) {
+			ejected++
+		}
+	}
+	if ejected != 2 {
+		t.Errorf("%d of 4 nodes ejected, want at most 50%% (2)", ejected)
+	}
+}
+
+func TestBreakerIgnoresProbes(t *testing.T) {
+	b := newTestBreakers(t, config.CircuitBreakerConfig{ConsecutiveErrors: 1})
+	health := oraclev1.NodeService_Health_FullMethodName
+
+	// Failed health checks do not eject a node
+	for range 3 {
+		callThrough(b, "a", health, errUnavailable)
+	}
+	if state, _ := breakerState(b, "a"); state != breakerClosed {
+		t.Fatalf("state after failed health checks = %s, want closed", state)
+	}
+
+	// Health checks reach an ejected node and do not re-admit it
+	callThrough(b, "a", oraclev1.NodeService_Get_FullMethodName, errUnavailable)
+	reached := errors.New("reached")
+	if err := callThrough(b, "a", health, reached); err != reached {
+		t.Errorf("health check of an ejected node = %v, want it sent to the node", err)
+	}
+	endEjection(b, "a")
+	callThrough(b, "a", health, nil)
+	if state, _ := breakerState(b, "a"); state != breakerOpen {
+		t.Errorf("state after a successful health check = %s, want open until a real probe", state)
+	}
+}
@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/metrics"
+)
+
+// getFlight is one node read shared by concurrent Gets of the same key.
+type getFlight struct {
+	done chan struct{}
+	resp *oraclev1.ProxyGetResponse
+	err  error
+
+	// waiters is the number of Gets still waiting; the read is canceled
+	// when the last one gives up
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// getCoalescer deduplicates concurrent Gets of the same namespaced key, so
+// that a popular key causes one node read at a time instead of one per
+// request.
+//
+// The shared read runs detached from the individual requests: a waiter
+// whose context is canceled or whose deadline passes returns immediately
+// without affecting the others, and the read itself is only canceled once
+// no waiter is left. The read is thereby bounded by the latest deadline of
+// its waiters, never by the deadline of the Get that happened to start it.
+// Writes call forget, so a Get that starts after a write completed never
+// joins a read that started before it.
+type getCoalescer struct {
+	metrics *metrics.Metrics
+
+	mu      sync.Mutex
+	flights map[string]*getFlight
+}
+
+// newGetCoalescer creates an empty coalescer.
+func newGetCoalescer(m *metrics.Metrics) *getCoalescer {
+	return &getCoalescer{
+		metrics: m,
+		flights: make(map[string]*getFlight),
+	}
+}
+
+// do runs fetch for a key, or waits for a fetch of the same key that is
+// already in flight.
+//
+// The returned response may be shared with other callers and must not be
+// modified.
+//
+// Parameters:
+//   - ctx: Context of the calling request; it bounds how long this caller
+//     waits, and the shared read ends when the last waiter gives up
+//   - key: Namespaced cache key
+//   - fetch: Function that reads the key from the cache nodes
+//
+// Returns:
+//   - *oraclev1.ProxyGetResponse: The shared response
+//   - error: The shared error, or ctx.Err() if the caller gave up waiting
+func (c *getCoalescer) do(ctx context.Context, key string, fetch func(ctx context.Context) (*oraclev1.ProxyGetResponse, error)) (*oraclev1.ProxyGetResponse, error) {
+	c.mu.Lock()
+	f, joined := c.flights[key]
+	if joined {
+		f.waiters++
+		c.mu.Unlock()
+		c.metrics.IncCoalescedGets()
+	} else {
+		// Keep the caller's values (such as tracing metadata) but not its
+		// deadline; waiters that join later may be willing to wait longer
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &getFlight{
+			done:    make(chan struct{}),
+			waiters: 1,
+			cancel:  cancel,
+		}
+		c.flights[key] = f
+		c.mu.Unlock()
+		c.metrics.IncGetFlights()
+
+		go func() {
+			defer cancel()
+			f.resp, f.err = fetch(fetchCtx)
+
+			c.mu.Lock()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+			c.mu.Unlock()
+			close(f.done)
+		}()
+	}
+
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody is interested anymore; later Gets start a new read
+			f.cancel()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+		}
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// forget detaches the read in flight for a key, if any, so that later Gets
+// read the key again. Waiters that already joined still get its result.
+func (c *getCoalescer) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.flights, key)
+}
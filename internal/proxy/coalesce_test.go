@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/metrics"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+func TestGetCoalescerOutlivesFirstDeadline(t *testing.T) {
+	c := newGetCoalescer(metrics.NewMetrics())
+
+	var reads atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (*oraclev1.ProxyGetResponse, error) {
+		reads.Add(1)
+		select {
+		case <-release:
+			return &oraclev1.ProxyGetResponse{Found: true, Value: []byte("v")}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// The first caller starts the read and gives up before it completes
+	shortCtx, cancelShort := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancelShort()
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.do(shortCtx, "k", fetch)
+		firstErr <- err
+	}()
+
+	// The second caller joins the read and is willing to wait longer
+	for reads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	longCtx, cancelLong := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelLong()
+	second := make(chan *oraclev1.ProxyGetResponse, 1)
+	go func() {
+		resp, _ := c.do(longCtx, "k", fetch)
+		second <- resp
+	}()
+
+	waitForWaiters(t, c, "k", 2)
+	if err := <-firstErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("first caller error = %v, want deadline exceeded", err)
+	}
+
+	close(release)
+	resp := <-second
+	if resp == nil || string(resp.Value) != "v" {
+		t.Fatalf("second caller got %v, want the shared read's value", resp)
+	}
+	if n := reads.Load(); n != 1 {
+		t.Errorf("fetch ran %d times, want 1", n)
+	}
+}
+
+func TestGetCoalescerCancelsReadWithoutWaiters(t *testing.T) {
+	c := newGetCoalescer(metrics.NewMetrics())
+
+	canceled := make(chan struct{})
+	fetch := func(ctx context.Context) (*oraclev1.ProxyGetResponse, error) {
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.do(ctx, "k", fetch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("do error = %v, want deadline exceeded", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("shared read was not canceled after the last waiter left")
+	}
+}
+
+// waitForWaiters blocks until n callers wait for the read of key.
+func waitForWaiters(t *testing.T, c *getCoalescer, key string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		f := c.flights[key]
+		joined := f != nil && f.waiters == n
+		c.mu.Unlock()
+		if joined {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d callers did not join the read of %q", n, key)
+}
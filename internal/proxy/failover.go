@@ -0,0 +1,322 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+)
+
+// Default failover settings, used when FailoverConfig leaves them unset.
+const (
+	DefaultFailoverCheckInterval    = 2 * time.Second
+	DefaultFailoverFailureThreshold = 3
+)
+
+// Roles a node reports in StatsResponse (see node.RoleFollower): a follower
+// of a primary, or a primary, which a follower only becomes by promotion.
+const (
+	roleFollower = "follower"
+	rolePrimary  = "primary"
+)
+
+// failoverCheckTimeout bounds each primary health check and promotion call.
+const failoverCheckTimeout = time.Second
+
+// failoverMonitor health-checks primaries that have followers and promotes
+// a follower when its primary fails.
+//
+// A promoted follower takes over the primary's position in the ring: the
+// primary's client is replaced by the follower's, so key placement does not
+// change. The failover is permanent for the lifetime of the proxy. The
+// former primary learns of the promotion when the follower rejects its
+// replication stream and then fails health checks, so proxies that could
+// still reach it fail over as well.
+type failoverMonitor struct {
+	server *Server
+
+	mu       sync.Mutex
+	cfg      config.FailoverConfig
+	failures map[string]int
+
+	// promoted maps a failed primary to the follower now serving its keys
+	promoted map[string]string
+
+	// clients holds connections to followers, which are not ring members
+	clients map[string]oraclev1.NodeServiceClient
+}
+
+// newFailoverMonitor creates a monitor with failover disabled.
+func newFailoverMonitor(s *Server) *failoverMonitor {
+	return &failoverMonitor{
+		server:   s,
+		failures: make(map[string]int),
+		promoted: make(map[string]string),
+		clients:  make(map[string]oraclev1.NodeServiceClient),
+	}
+}
+
+// configure applies failover settings; nil disables failover.
+func (f *failoverMonitor) configure(cfg *config.FailoverConfig) {
+	var effective config.FailoverConfig
+	if cfg != nil {
+		effective = *cfg
+	}
+	if effective.CheckIntervalSeconds <= 0 {
+		effective.CheckIntervalSeconds = int(DefaultFailoverCheckInterval / time.Second)
+	}
+	if effective.FailureThreshold <= 0 {
+		effective.FailureThreshold = DefaultFailoverFailureThreshold
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cfg = effective
+}
+
+// run checks primaries until the server is stopped.
+func (f *failoverMonitor) run() {
+	for {
+		f.mu.Lock()
+		interval := time.Duration(f.cfg.CheckIntervalSeconds) * time.Second
+		f.mu.Unlock()
+
+		select {
+		case <-f.server.stopCh:
+			return
+		case <-time.After(interval):
+		}
+
+		f.check()
+	}
+}
+
+// check health-checks every primary that has followers and has not been
+// failed over yet, and re-checks the followers of those that were.
+func (f *failoverMonitor) check() {
+	f.mu.Lock()
+	primaries := make(map[string][]string, len(f.cfg.Followers))
+	failedOver := make(map[string][]string)
+	for primary, followers := range f.cfg.Followers {
+		if _, done := f.promoted[primary]; done {
+			failedOver[primary] = followers
+		} else {
+			primaries[primary] = followers
+		}
+	}
+	threshold := f.cfg.FailureThreshold
+	f.mu.Unlock()
+
+	for primary, followers := range failedOver {
+		f.converge(primary, followers)
+	}
+
+	for primary, followers := range primaries {
+		// Only ring members are routed to; others need no failover
+		client := f.server.nodeClient(primary)
+		if client == nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), failoverCheckTimeout)
+		resp, err := client.Health(ctx, &oraclev1.HealthRequest{})
+		cancel()
+
+		f.mu.Lock()
+		if err == nil && resp.Healthy {
+			f.failures[primary] = 0
+			f.mu.Unlock()
+			continue
+		}
+		f.failures[primary]++
+		failures := f.failures[primary]
+		f.mu.Unlock()
+
+		if err == nil {
+			err = errors.New(resp.Message)
+		}
+		f.server.logger.Warn("Primary %s failed health check (%d/%d): %v", primary, failures, threshold, err)
+		if failures >= threshold {
+			f.failover(primary, followers)
+		}
+	}
+}
+
+// followerCandidate is the state of one follower of a failed primary.
+type followerCandidate struct {
+	addr     string
+	client   oraclev1.NodeServiceClient
+	promoted bool
+	applied  uint64
+}
+
+// failover routes the keys of a failed primary to one of its followers.
+//
+// Proxies fail over independently, so they coordinate through the nodes:
+// a follower that another proxy already promoted is adopted instead of
+// promoting a second one. Otherwise the follower that applied the most
+// mutations is promoted, ties going to the lowest address, so proxies
+// seeing the same followers promote the same one. If proxies still
+// promoted different followers concurrently, every proxy settles on the
+// same promoted follower by that rule (see converge), since promoted nodes
+// no longer apply mutations.
+func (f *failoverMonitor) failover(primary string, followers []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), failoverCheckTimeout)
+	defer cancel()
+
+	candidates := f.candidates(ctx, primary, followers)
+	if chosen := pickFollower(candidates, true); chosen != nil {
+		f.route(primary, chosen.addr)
+		f.server.logger.Warn("Failed over %s to follower %s promoted by another proxy (replicated up to seq %d)", primary, chosen.addr, chosen.applied)
+		return
+	}
+
+	best := pickFollower(candidates, false)
+	if best == nil {
+		f.server.logger.Error("Cannot fail over %s: no follower available", primary)
+		return
+	}
+
+	resp, err := best.client.Promote(ctx, &oraclev1.PromoteRequest{})
+	if err != nil {
+		f.server.logger.Error("Failed to promote %s for %s: %v", best.addr, primary, err)
+		return
+	}
+
+	// Another proxy may have promoted a different follower meanwhile
+	chosen := best.addr
+	if other := pickFollower(f.candidates(ctx, primary, followers), true); other != nil {
+		chosen = other.addr
+	}
+	f.route(primary, chosen)
+	f.server.logger.Warn("Failed over %s to follower %s (%s replicated up to seq %d)", primary, chosen, best.addr, resp.AppliedSeq)
+}
+
+// converge re-checks the followers of a failed-over primary and switches
+// to another promoted follower if that one wins by the failover rule.
+func (f *failoverMonitor) converge(primary string, followers []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), failoverCheckTimeout)
+	defer cancel()
+
+	chosen := pickFollower(f.candidates(ctx, primary, followers), true)
+	f.mu.Lock()
+	current := f.promoted[primary]
+	f.mu.Unlock()
+	if chosen == nil || chosen.addr == current {
+		return
+	}
+
+	f.route(primary, chosen.addr)
+	f.server.logger.Warn("Switched failed-over %s from %s to follower %s promoted by another proxy", primary, current, chosen.addr)
+}
+
+// candidates queries the role and progress of a primary's followers.
+// Followers that cannot be reached are left out.
+func (f *failoverMonitor) candidates(ctx context.Context, primary string, followers []string) []followerCandidate {
+	var result []followerCandidate
+	for _, follower := range followers {
+		client := f.client(follower)
+		if client == nil {
+			continue
+		}
+
+		stats, err := client.Stats(ctx, &oraclev1.StatsRequest{})
+		if err != nil {
+			f.server.logger.Warn("Follower %s of %s is unavailable: %v", follower, primary, err)
+			continue
+		}
+
+		c := followerCandidate{addr: follower, client: client}
+		switch stats.Role {
+		case roleFollower:
+		case rolePrimary:
+			// A follower only becomes primary by promotion
+			c.promoted = true
+		default:
+			continue
+		}
+		for _, st := range stats.Replication {
+			c.applied = max(c.applied, st.LastSeq)
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// pickFollower returns the candidate with the most applied mutations,
+// ties going to the lowest address, among the promoted ones or among those
+// still following; nil if there is none.
+func pickFollower(candidates []followerCandidate, promoted bool) *followerCandidate {
+	var best *followerCandidate
+	for i := range candidates {
+		c := &candidates[i]
+		if c.promoted != promoted {
+			continue
+		}
+		if best == nil || c.applied > best.applied || (c.applied == best.applied && c.addr < best.addr) {
+			best = c
+		}
+	}
+	return best
+}
+
+// route sends the keys of a failed primary to a promoted follower. The
+// follower is dialed like a ring node, with the primary's retry policy and
+// circuit breaker, which starts over for the new node.
+func (f *failoverMonitor) route(primary, follower string) {
+	s := f.server
+
+	s.mu.Lock()
+	conn, err := s.dial(follower, primary)
+	if err != nil {
+		s.mu.Unlock()
+		s.logger.Error("Failed to connect to promoted follower %s: %v", follower, err)
+		return
+	}
+	if old, ok := s.nodeConns[primary]; ok {
+		if err := old.Close(); err != nil {
+			s.logger.Warn("Failed to close connection to node %s: %v", primary, err)
+		}
+	}
+	s.nodeConns[primary] = conn
+	s.nodeClients[primary] = oraclev1.NewNodeServiceClient(conn)
+	s.mu.Unlock()
+
+	s.breakers.readmit(primary)
+
+	f.mu.Lock()
+	f.promoted[primary] = follower
+	f.mu.Unlock()
+}
+
+// client returns the connection to a follower, dialing it on first use.
+// Followers that are not ring members have no circuit breaker of their own.
+func (f *failoverMonitor) client(addr string) oraclev1.NodeServiceClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if client, ok := f.clients[addr]; ok {
+		return client
+	}
+
+	f.server.mu.RLock()
+	conn, err := f.server.dial(addr, addr)
+	f.server.mu.RUnlock()
+	if err != nil {
+		f.server.logger.Error("Failed to connect to follower %s: %v", addr, err)
+		return nil
+	}
+
+	client := oraclev1.NewNodeServiceClient(conn)
+	f.clients[addr] = client
+	return client
+}
+
+// SetFailoverConfig applies follower failover settings; nil disables failover.
+func (s *Server) SetFailoverConfig(cfg *config.FailoverConfig) {
+	s.failover.configure(cfg)
+}
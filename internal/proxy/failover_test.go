@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// follow makes a node a follower that applied a snapshot up to seq by
+// replicating to it as a primary. The stream stays open until the test ends.
+func follow(t *testing.T, client oraclev1.NodeServiceClient, seq uint64) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	stream, err := client.Replicate(ctx)
+	if err != nil {
+		t.Fatalf("replicate: %v", err)
+	}
+	for _, req := range []*oraclev1.ReplicateRequest{
+		{Primary: "primary-0", Epoch: 1},
+		{FullSync: true, SnapshotSeq: seq, SnapshotDone: true},
+	} {
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("recv ack: %v", err)
+		}
+	}
+}
+
+func nodeRole(t *testing.T, client oraclev1.NodeServiceClient) string {
+	t.Helper()
+
+	stats, err := client.Stats(context.Background(), &oraclev1.StatsRequest{})
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	return stats.Role
+}
+
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		name string
+		// seqs are the sequence numbers the followers applied
+		seqs [2]uint64
+		// promoted is the follower another proxy promoted, -1 if none
+		promoted int
+		want     int
+	}{
+		{name: "most applied mutations", seqs: [2]uint64{3, 7}, promoted: -1, want: 1},
+		{name: "tie goes to the lowest address", seqs: [2]uint64{5, 5}, promoted: -1, want: 0},
+		{name: "adopt follower promoted by another proxy", seqs: [2]uint64{3, 7}, promoted: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs, clients := startNodes(t, 3)
+			primary := addrs[0]
+			followers := addrs[1:]
+			if followers[0] > followers[1] {
+				followers[0], followers[1] = followers[1], followers[0]
+			}
+			for i, follower := range followers {
+				follow(t, clients[follower], tt.seqs[i])
+			}
+			if tt.promoted >= 0 {
+				if _, err := clients[followers[tt.promoted]].Promote(context.Background(), &oraclev1.PromoteRequest{}); err != nil {
+					t.Fatalf("promote: %v", err)
+				}
+			}
+
+			s := newTestProxy(t, []string{primary})
+			s.SetFailoverConfig(&config.FailoverConfig{Followers: map[string][]string{primary: followers}})
+			s.failover.failover(primary, followers)
+
+			want := followers[tt.want]
+			if got := s.failover.promoted[primary]; got != want {
+				t.Fatalf("failed over %s to %q, want %s", primary, got, want)
+			}
+			for i, follower := range followers {
+				role := nodeRole(t, clients[follower])
+				if wantRole := map[bool]string{true: rolePrimary, false: roleFollower}[i == tt.want]; role != wantRole {
+					t.Errorf("follower %s has role %s, want %s", follower, role, wantRole)
+				}
+			}
+
+			// The primary's keys are served by the promoted follower
+			if role := nodeRole(t, s.nodeClient(primary)); role != rolePrimary {
+				t.Errorf("client of %s reaches a node with role %s, want the promoted follower", primary, role)
+			}
+		})
+	}
+}
+
+func TestFailoverConvergesOnPromotedFollower(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	primary, followers := addrs[0], addrs[1:]
+	follow(t, clients[followers[0]], 3)
+	follow(t, clients[followers[1]], 7)
+
+	s := newTestProxy(t, []string{primary})
+	s.SetFailoverConfig(&config.FailoverConfig{Followers: map[string][]string{primary: followers}})
+
+	// This proxy promoted the first follower while another promoted the
+	// second, which applied more mutations and wins
+	if _, err := clients[followers[0]].Promote(context.Background(), &oraclev1.PromoteRequest{}); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	s.failover.route(primary, followers[0])
+	if _, err := clients[followers[1]].Promote(context.Background(), &oraclev1.PromoteRequest{}); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+
+	s.failover.check()
+
+	if got := s.failover.promoted[primary]; got != followers[1] {
+		t.Errorf("failed over %s to %s, want %s", primary, got, followers[1])
+	}
+}
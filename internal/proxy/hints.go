@@ -0,0 +1,511 @@
+package proxy
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+)
+
+// Default hinted handoff settings, used when HintedHandoffConfig leaves them
+// unset.
+const (
+	DefaultMaxHints           = 10000
+	DefaultHintTTL            = time.Hour
+	DefaultHintReplayInterval = 5 * time.Second
+)
+
+// hintReplayBatchSize is the number of hints imported per request.
+const hintReplayBatchSize = 100
+
+// Timeouts of the background requests made for hints.
+const (
+	hintHealthCheckTimeout = time.Second
+	hintReplayTimeout      = 5 * time.Second
+	hintCleanupTimeout     = 2 * time.Second
+)
+
+// hint is a write accepted for an unavailable owner and stored on a
+// stand-in node until it can be replayed.
+type hint struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	timestamp int64
+	holder    string
+	createdAt time.Time
+}
+
+// hintedNode tracks the pending hints of one unavailable owner.
+type hintedNode struct {
+	hints     map[string]*hint
+	replaying bool
+	lastErr   string
+}
+
+// hintedHandoff stores writes for unavailable nodes as hints.
+//
+// The value is written to the next healthy node in the ring, which serves
+// reads for the key while the owner is down. The proxy keeps the hint,
+// bounded in number and age, and replays it to the owner once the owner
+// passes a health check again; the stand-in copy is then deleted. A later
+// write or delete of the key that reaches the owner directly supersedes the
+// hint. Client writes and hints carry the write timestamp, so a replayed
+// hint never overwrites a newer write that reached the owner some other way.
+//
+// Hints live in the memory of the proxy that stored them, and several
+// proxies may hold hints for the same key on the same stand-in node. Each
+// proxy only replays its own hints, and a stand-in copy is only deleted
+// while it still holds the value of the hint (see deleteFromHolder), so a
+// newer copy stored by another proxy stays until that proxy replays it.
+// The copies expire with the hint TTL, so the copies of hints lost in a
+// proxy restart do not stay behind.
+type hintedHandoff struct {
+	server *Server
+
+	mu      sync.Mutex
+	cfg     config.HintedHandoffConfig
+	nodes   map[string]*hintedNode
+	pending int
+}
+
+// newHintedHandoff creates a hint store with default settings.
+func newHintedHandoff(s *Server) *hintedHandoff {
+	return &hintedHandoff{
+		server: s,
+		nodes:  make(map[string]*hintedNode),
+	}
+}
+
+// configure applies hinted handoff settings; nil selects defaults.
+func (h *hintedHandoff) configure(cfg *config.HintedHandoffConfig) {
+	var effective config.HintedHandoffConfig
+	if cfg != nil {
+		effective = *cfg
+	}
+	if effective.MaxHints <= 0 {
+		effective.MaxHints = DefaultMaxHints
+	}
+	if effective.HintTTLSeconds <= 0 {
+		effective.HintTTLSeconds = int(DefaultHintTTL / time.Second)
+	}
+	if effective.ReplayIntervalSeconds <= 0 {
+		effective.ReplayIntervalSeconds = int(DefaultHintReplayInterval / time.Second)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cfg = effective
+}
+
+// isUnavailable reports whether a node error means the node could not be
+// reached, as opposed to the node rejecting the request.
+func isUnavailable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// store writes a value to a stand-in node for an unavailable owner and
+// records a hint for it.
+//
+// Parameters:
+//   - ctx: Context for the stand-in write
+//   - owner: The unavailable owner of the key
+//   - req: The write that failed on the owner, including its timestamp
+//
+// Returns:
+//   - *oraclev1.SetResponse: The stand-in node's response; Success is false
+//     if the node already holds a newer value, such as a hint stored by
+//     another proxy, and no hint is recorded then
+//   - string: The stand-in node that holds the value
+//   - bool: False if hinted handoff is disabled, the hint store is full or
+//     no other node accepted the write
+func (h *hintedHandoff) store(ctx context.Context, owner string, req *oraclev1.SetRequest) (*oraclev1.SetResponse, string, bool) {
+	key := req.Key
+	h.mu.Lock()
+	disabled := h.cfg.Disabled
+	full := h.pending >= h.cfg.MaxHints
+	ttl := time.Duration(h.cfg.HintTTLSeconds) * time.Second
+	h.mu.Unlock()
+	if disabled {
+		return nil, "", false
+	}
+	if full {
+		h.server.metrics.IncHintsDropped()
+		h.server.logger.Warn("Hint store full, rejecting write of %q for %s", key, owner)
+		return nil, "", false
+	}
+
+	// The stand-in copy lives no longer than the hint
+	holderReq := &oraclev1.SetRequest{
+		Key:       key,
+		Value:     req.Value,
+		Ttl:       int32(ttl / time.Second),
+		Timestamp: req.Timestamp,
+	}
+	if req.Ttl > 0 {
+		holderReq.Ttl = min(holderReq.Ttl, req.Ttl)
+	}
+
+	s := h.server
+	s.mu.RLock()
+	candidates := s.ring.GetNodes(kv.RoutingKey(key), s.ring.Size())
+	s.mu.RUnlock()
+
+	now := time.Now()
+	for _, node := range candidates {
+		if node == owner {
+			continue
+		}
+		client := s.nodeClient(node)
+		if client == nil {
+			continue
+		}
+		resp, err := client.Set(ctx, holderReq)
+		if err != nil {
+			s.logger.Warn("Failed to store hint for %s on %s: %v", owner, node, err)
+			continue
+		}
+		if !resp.Success {
+			return resp, node, true
+		}
+
+		hnt := &hint{
+			key:       key,
+			value:     req.Value,
+			timestamp: req.Timestamp,
+			holder:    node,
+			createdAt: now,
+		}
+		if req.Ttl > 0 {
+			hnt.expiresAt = now.Add(time.Duration(req.Ttl) * time.Second)
+		}
+		h.add(owner, hnt)
+
+		s.metrics.IncHintsStored()
+		s.logger.Debug("Stored hint for %s on %s: %q", owner, node, key)
+		return resp, node, true
+	}
+	return nil, "", false
+}
+
+// add records a hint, replacing an older hint for the same key.
+func (h *hintedHandoff) add(owner string, hnt *hint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hn := h.nodes[owner]
+	if hn == nil {
+		hn = &hintedNode{hints: make(map[string]*hint)}
+		h.nodes[owner] = hn
+	}
+	if _, exists := hn.hints[hnt.key]; !exists {
+		h.pending++
+	}
+	hn.hints[hnt.key] = hnt
+}
+
+// holderOf returns the stand-in node holding a hinted key of an owner, or ""
+// if there is no pending hint for the key.
+func (h *hintedHandoff) holderOf(owner, key string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if hn := h.nodes[owner]; hn != nil {
+		if hnt := hn.hints[key]; hnt != nil {
+			return hnt.holder
+		}
+	}
+	return ""
+}
+
+// forget drops the hint for a key after a newer write or delete reached its
+// owner, and deletes the stand-in copy in the background.
+func (h *hintedHandoff) forget(owner, key string) {
+	h.mu.Lock()
+	hn := h.nodes[owner]
+	if hn == nil || hn.hints[key] == nil {
+		h.mu.Unlock()
+		return
+	}
+	hnt := hn.hints[key]
+	h.remove(owner, hn, key)
+	h.mu.Unlock()
+
+	go h.deleteFromHolder(hnt)
+}
+
+// remove drops a hint; h.mu must be held.
+func (h *hintedHandoff) remove(owner string, hn *hintedNode, key string) {
+	delete(hn.hints, key)
+	h.pending--
+	if len(hn.hints) == 0 && !hn.replaying {
+		delete(h.nodes, owner)
+	}
+}
+
+// deleteFromHolder removes the stand-in copy of a hint, unless the holder
+// has become the key's owner in the meantime or the copy was replaced, for
+// example by a newer hint of another proxy.
+func (h *hintedHandoff) deleteFromHolder(hnt *hint) {
+	s := h.server
+	if s.ownerOf(hnt.key) == hnt.holder {
+		return
+	}
+	client := s.nodeClient(hnt.holder)
+	if client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hintCleanupTimeout)
+	defer cancel()
+	entry := &oraclev1.MigrationEntry{Key: hnt.key, Value: hnt.value, Timestamp: hnt.timestamp}
+	if _, err := deleteIfUnchanged(ctx, client, hnt.key, entry); err != nil {
+		s.logger.Warn("Failed to delete hinted copy of %q from %s: %v", hnt.key, hnt.holder, err)
+	}
+}
+
+// run expires and replays hints until the server is stopped.
+func (h *hintedHandoff) run() {
+	for {
+		h.mu.Lock()
+		interval := time.Duration(h.cfg.ReplayIntervalSeconds) * time.Second
+		h.mu.Unlock()
+
+		select {
+		case <-h.server.stopCh:
+			return
+		case <-time.After(interval):
+		}
+
+		h.expire()
+		h.replayAll()
+	}
+}
+
+// expire discards hints older than the hint TTL and hints whose value has
+// expired anyway.
+func (h *hintedHandoff) expire() {
+	now := time.Now()
+
+	h.mu.Lock()
+	ttl := time.Duration(h.cfg.HintTTLSeconds) * time.Second
+	var expired []*hint
+	for owner, hn := range h.nodes {
+		for key, hnt := range hn.hints {
+			if now.Sub(hnt.createdAt) > ttl || (!hnt.expiresAt.IsZero() && now.After(hnt.expiresAt)) {
+				expired = append(expired, hnt)
+				h.remove(owner, hn, key)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	h.server.metrics.AddHintsExpired(int64(len(expired)))
+	h.server.logger.Warn("Discarded %d expired hints", len(expired))
+	for _, hnt := range expired {
+		h.deleteFromHolder(hnt)
+	}
+}
+
+// replayAll replays the hints of every owner that is healthy again.
+func (h *hintedHandoff) replayAll() {
+	h.mu.Lock()
+	owners := make([]string, 0, len(h.nodes))
+	for owner, hn := range h.nodes {
+		if !hn.replaying && len(hn.hints) > 0 {
+			hn.replaying = true
+			owners = append(owners, owner)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, owner := range owners {
+		err := h.replay(owner)
+
+		h.mu.Lock()
+		if hn := h.nodes[owner]; hn != nil {
+			hn.replaying = false
+			hn.lastErr = ""
+			if err != nil {
+				hn.lastErr = err.Error()
+			}
+			if len(hn.hints) == 0 {
+				delete(h.nodes, owner)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// replay health-checks an owner and, if it is healthy, writes its hints back
+// to it.
+func (h *hintedHandoff) replay(owner string) error {
+	s := h.server
+
+	// An owner that left the ring gets no replay; its keys now belong to
+	// other nodes, which are written to instead
+	client := s.nodeClient(owner)
+	if client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), hintHealthCheckTimeout)
+		resp, err := client.Health(ctx, &oraclev1.HealthRequest{})
+		cancel()
+		if err != nil {
+			return err
+		}
+		if !resp.Healthy {
+			return status.Error(codes.Unavailable, resp.Message)
+		}
+	}
+
+	h.mu.Lock()
+	hints := make([]*hint, 0, len(h.nodes[owner].hints))
+	for _, hnt := range h.nodes[owner].hints {
+		hints = append(hints, hnt)
+	}
+	h.mu.Unlock()
+	sort.Slice(hints, func(i, j int) bool {
+		return hints[i].createdAt.Before(hints[j].createdAt)
+	})
+
+	s.logger.Info("Replaying %d hints to %s", len(hints), owner)
+	replayed := 0
+	for start := 0; start < len(hints); start += hintReplayBatchSize {
+		batch := hints[start:min(start+hintReplayBatchSize, len(hints))]
+		n, err := h.replayBatch(owner, client, batch)
+		replayed += n
+		if err != nil {
+			s.logger.Warn("Replay of hints to %s stopped after %d: %v", owner, replayed, err)
+			return err
+		}
+	}
+
+	s.logger.Success("Replayed %d hints to %s", replayed, owner)
+	return nil
+}
+
+// replayBatch imports a batch of hints into their owner and drops the hints
+// and stand-in copies that were delivered. Hints for keys written directly
+// to the owner in the meantime were forgotten and are skipped.
+//
+// If the owner left the ring (client is nil), each hint goes to the key's
+// current owner instead; a holder that now owns the key keeps its copy.
+func (h *hintedHandoff) replayBatch(owner string, client oraclev1.NodeServiceClient, batch []*hint) (int, error) {
+	s := h.server
+	now := time.Now()
+
+	byTarget := make(map[string][]*hint)
+	var delivered []*hint
+	for _, hnt := range batch {
+		if client != nil {
+			byTarget[owner] = append(byTarget[owner], hnt)
+			continue
+		}
+		switch target := s.ownerOf(hnt.key); target {
+		case "", hnt.holder:
+			delivered = append(delivered, hnt)
+		default:
+			byTarget[target] = append(byTarget[target], hnt)
+		}
+	}
+
+	for target, hints := range byTarget {
+		targetClient := s.nodeClient(target)
+		if targetClient == nil {
+			continue
+		}
+
+		entries := make([]*oraclev1.MigrationEntry, 0, len(hints))
+		for _, hnt := range hints {
+			entry := &oraclev1.MigrationEntry{
+				Key:       hnt.key,
+				Value:     hnt.value,
+				Timestamp: hnt.timestamp,
+			}
+			if !hnt.expiresAt.IsZero() {
+				entry.TtlMs = max(hnt.expiresAt.Sub(now).Milliseconds(), 1)
+			}
+			entries = append(entries, entry)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), hintReplayTimeout)
+		_, err := targetClient.Import(ctx, &oraclev1.ImportRequest{
+			Entries:   entries,
+			Versioned: true,
+		})
+		cancel()
+		if err != nil {
+			return h.finishReplay(owner, delivered), err
+		}
+		delivered = append(delivered, hints...)
+	}
+
+	return h.finishReplay(owner, delivered), nil
+}
+
+// finishReplay drops delivered hints and their stand-in copies.
+//
+// Returns:
+//   - int: Number of hints dropped; hints replaced or forgotten during the
+//     replay are left alone
+func (h *hintedHandoff) finishReplay(owner string, delivered []*hint) int {
+	h.mu.Lock()
+	hn := h.nodes[owner]
+	var cleanup []*hint
+	for _, hnt := range delivered {
+		if hn != nil && hn.hints[hnt.key] == hnt {
+			h.remove(owner, hn, hnt.key)
+			cleanup = append(cleanup, hnt)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, hnt := range cleanup {
+		h.server.metrics.IncHintsReplayed()
+		h.deleteFromHolder(hnt)
+	}
+	return len(cleanup)
+}
+
+// stats returns the hinted handoff counters and per-node status.
+func (h *hintedHandoff) stats() *oraclev1.HintStats {
+	m := h.server.metrics
+	stats := &oraclev1.HintStats{
+		Stored:   m.GetHintsStored(),
+		Replayed: m.GetHintsReplayed(),
+		Dropped:  m.GetHintsDropped(),
+		Expired:  m.GetHintsExpired(),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats.Pending = int64(h.pending)
+	for owner, hn := range h.nodes {
+		stats.Nodes = append(stats.Nodes, &oraclev1.HintedNodeStatus{
+			Node:      owner,
+			Pending:   int64(len(hn.hints)),
+			Replaying: hn.replaying,
+			LastError: hn.lastErr,
+		})
+	}
+	sort.Slice(stats.Nodes, func(i, j int) bool {
+		return stats.Nodes[i].Node < stats.Nodes[j].Node
+	})
+	return stats
+}
+
+// SetHintedHandoffConfig applies hinted handoff settings; nil selects
+// defaults.
+func (s *Server) SetHintedHandoffConfig(cfg *config.HintedHandoffConfig) {
+	s.hints.configure(cfg)
+}
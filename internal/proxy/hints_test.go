@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// storeHint hands off a write of key for its owner, as a Set does when the
+// owner is unreachable, and returns the owner and the stand-in node.
+func storeHint(t *testing.T, s *Server, key, value string, timestamp int64) (string, string) {
+	t.Helper()
+
+	owner := s.ownerOf(key)
+	resp, holder, ok := s.hints.store(context.Background(), owner, &oraclev1.SetRequest{
+		Key:       key,
+		Value:     []byte(value),
+		Timestamp: timestamp,
+	})
+	if !ok || !resp.Success {
+		t.Fatalf("store hint for %q = %v, %v; want it stored", key, resp, ok)
+	}
+	if holder == owner {
+		t.Fatalf("hint for %q stored on its owner %s", key, owner)
+	}
+	return owner, holder
+}
+
+// waitForValue waits until a node holds value for key ("" for none).
+func waitForValue(t *testing.T, client oraclev1.NodeServiceClient, key, value string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if got, _ := getValue(t, client, key); got == value {
+			return
+		}
+		if time.Now().After(deadline) {
+			got, _ := getValue(t, client, key)
+			t.Fatalf("node holds %q = %q, want %q", key, got, value)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHintStore(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	s := newTestProxy(t, addrs)
+	key := kv.NamespaceKey("sessions", "user-1")
+
+	owner, holder := storeHint(t, s, key, "v1", time.Now().UnixNano())
+
+	if got := s.hints.holderOf(owner, key); got != holder {
+		t.Errorf("holderOf = %q, want %s", got, holder)
+	}
+	if stats := s.hints.stats(); stats.Pending != 1 || stats.Stored != 1 {
+		t.Errorf("stats report %d pending and %d stored hints, want 1 and 1", stats.Pending, stats.Stored)
+	}
+
+	// The stand-in copy expires with the hint
+	resp, err := clients[holder].Get(context.Background(), &oraclev1.GetRequest{Key: key})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !resp.Found || string(resp.Value) != "v1" {
+		t.Fatalf("holder %s holds %q (found %v), want v1", holder, resp.Value, resp.Found)
+	}
+	if resp.TtlMs <= 0 || resp.TtlMs > DefaultHintTTL.Milliseconds() {
+		t.Errorf("stand-in copy TTL = %dms, want at most the hint TTL %v", resp.TtlMs, DefaultHintTTL)
+	}
+	if _, found := getValue(t, clients[owner], key); found {
+		t.Errorf("owner %s holds the hinted key before the replay", owner)
+	}
+}
+
+func TestHintStoreRejectsOlderWrite(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	s := newTestProxy(t, addrs)
+	key := kv.NamespaceKey("sessions", "user-1")
+
+	// Another proxy already handed off a newer write of the key
+	base := time.Now().UnixNano()
+	owner, holder := storeHint(t, s, key, "v1", base)
+	setVersioned(t, clients[holder], key, "v2", base+2)
+	s.hints.forget(owner, key)
+
+	resp, _, ok := s.hints.store(context.Background(), owner, &oraclev1.SetRequest{
+		Key:       key,
+		Value:     []byte("v0"),
+		Timestamp: base + 1,
+	})
+	if !ok || resp.Success {
+		t.Fatalf("store of an older write = %v, %v; want it reported as not stored", resp, ok)
+	}
+	if got := s.hints.holderOf(owner, key); got != "" {
+		t.Errorf("hint recorded on %s for a write that was not stored", got)
+	}
+	waitForValue(t, clients[holder], key, "v2")
+}
+
+func TestHintStoreDisabled(t *testing.T) {
+	addrs, _ := startNodes(t, 2)
+	s := newTestProxy(t, addrs)
+	s.SetHintedHandoffConfig(&config.HintedHandoffConfig{Disabled: true})
+	key := kv.NamespaceKey("sessions", "user-1")
+
+	if _, _, ok := s.hints.store(context.Background(), s.ownerOf(key), &oraclev1.SetRequest{Key: key}); ok {
+		t.Error("hint stored with hinted handoff disabled")
+	}
+}
+
+func TestHintExpire(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	s := newTestProxy(t, addrs)
+	key := kv.NamespaceKey("sessions", "user-1")
+
+	owner, holder := storeHint(t, s, key, "v1", time.Now().UnixNano())
+
+	s.hints.mu.Lock()
+	s.hints.nodes[owner].hints[key].createdAt = time.Now().Add(-2 * DefaultHintTTL)
+	s.hints.mu.Unlock()
+	s.hints.expire()
+
+	if stats := s.hints.stats(); stats.Pending != 0 || stats.Expired != 1 || len(stats.Nodes) != 0 {
+		t.Errorf("stats after expiry = %v, want no pending and 1 expired hint", stats)
+	}
+	waitForValue(t, clients[holder], key, "")
+	if _, found := getValue(t, clients[owner], key); found {
+		t.Error("expired hint was replayed to its owner")
+	}
+}
+
+func TestHintReplay(t *testing.T) {
+	tests := []struct {
+		name string
+		// change runs after the hint was stored at timestamp ts
+		change func(t *testing.T, clients map[string]oraclev1.NodeServiceClient, owner, holder, key string, ts int64)
+		// wantOwner and wantHolder are the values left, "" if none
+		wantOwner  string
+		wantHolder string
+	}{
+		{
+			name:      "owner back",
+			change:    func(t *testing.T, clients map[string]oraclev1.NodeServiceClient, owner, holder, key string, ts int64) {},
+			wantOwner: "v1",
+		},
+		{
+			name: "newer write on owner",
+			change: func(t *testing.T, clients map[string]oraclev1.NodeServiceClient, owner, holder, key string, ts int64) {
+				setVersioned(t, clients[owner], key, "v2", ts+1)
+			},
+			wantOwner: "v2",
+		},
+		{
+			name: "newer hint of another proxy",
+			change: func(t *testing.T, clients map[string]oraclev1.NodeServiceClient, owner, holder, key string, ts int64) {
+				setVersioned(t, clients[holder], key, "v2", ts+1)
+			},
+			wantOwner:  "v1",
+			wantHolder: "v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs, clients := startNodes(t, 3)
+			s := newTestProxy(t, addrs)
+			key := kv.NamespaceKey("sessions", "user-1")
+
+			ts := time.Now().UnixNano()
+			owner, holder := storeHint(t, s, key, "v1", ts)
+			tt.change(t, clients, owner, holder, key, ts)
+
+			s.hints.replayAll()
+
+			if stats := s.hints.stats(); stats.Pending != 0 || stats.Replayed != 1 || len(stats.Nodes) != 0 {
+				t.Errorf("stats after replay = %v, want no pending and 1 replayed hint", stats)
+			}
+			if value, _ := getValue(t, clients[owner], key); value != tt.wantOwner {
+				t.Errorf("owner holds %q, want %q", value, tt.wantOwner)
+			}
+			waitForValue(t, clients[holder], key, tt.wantHolder)
+		})
+	}
+}
+
+func TestHintReplayWaitsForOwner(t *testing.T) {
+	addrs, clients := startNodes(t, 2)
+	down := unreachableAddr(t)
+	s := newTestProxy(t, append(addrs, down))
+	key := keyOwnedBy(t, s, down)
+
+	_, holder := storeHint(t, s, key, "v1", time.Now().UnixNano())
+
+	s.hints.replayAll()
+
+	stats := s.hints.stats()
+	if stats.Pending != 1 || len(stats.Nodes) != 1 || stats.Nodes[0].LastError == "" {
+		t.Errorf("stats after a failed replay = %v, want the hint pending with an error", stats)
+	}
+	if value, _ := getValue(t, clients[holder], key); value != "v1" {
+		t.Errorf("holder holds %q, want v1 until the owner is back", value)
+	}
+}
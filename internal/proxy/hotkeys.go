@@ -0,0 +1,555 @@
+package proxy
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+)
+
+// Default hot key settings, used when HotKeyConfig leaves them unset.
+const (
+	DefaultHotKeyTopK                 = 10
+	DefaultHotKeyMinRequestsPerSecond = 100
+	DefaultNearCacheMaxStaleness      = time.Second
+	DefaultNearCacheMaxValueBytes     = 64 * 1024
+)
+
+// Count-Min sketch dimensions. With 4 rows of 2048 counters, the estimate of
+// a key exceeds its true count by more than 0.1% of all requests in the
+// window with a probability below 2%.
+const (
+	sketchDepth = 4
+	sketchWidth = 2048
+)
+
+// hotKeyDecayInterval is the period after which all counts are halved, so
+// that a count converges to twice the request rate per second.
+const hotKeyDecayInterval = time.Second
+
+// watchRetryInterval is the delay before a failed WatchKeys stream to a
+// node is opened again.
+const watchRetryInterval = time.Second
+
+// countMinSketch estimates request counts per key in constant memory. It
+// never underestimates; collisions can only inflate a count.
+type countMinSketch struct {
+	counts [sketchDepth][sketchWidth]uint32
+}
+
+// add counts one request for a key and returns its estimated count.
+//
+// Only the smallest counters of the key are incremented (conservative
+// update), which keeps the counts of colliding keys closer to the truth.
+func (c *countMinSketch) add(key string) uint32 {
+	h := xxhash.Sum64String(key)
+	h1, h2 := uint32(h), uint32(h>>32)
+
+	var idx [sketchDepth]uint32
+	estimate := ^uint32(0)
+	for i := range idx {
+		idx[i] = (h1 + uint32(i)*h2) % sketchWidth
+		estimate = min(estimate, c.counts[i][idx[i]])
+	}
+	if estimate == ^uint32(0) {
+		return estimate
+	}
+	for i := range idx {
+		if c.counts[i][idx[i]] == estimate {
+			c.counts[i][idx[i]]++
+		}
+	}
+	return estimate + 1
+}
+
+// decay halves all counts.
+func (c *countMinSketch) decay() {
+	for i := range c.counts {
+		for j := range c.counts[i] {
+			c.counts[i][j] >>= 1
+		}
+	}
+}
+
+// hotKeyEntry is a key tracked in a top-K heap.
+type hotKeyEntry struct {
+	key   string
+	count uint32
+	index int
+}
+
+// topKHeap is a min-heap of the most requested keys, so that the least
+// requested one can be replaced.
+type topKHeap []*hotKeyEntry
+
+func (h topKHeap) Len() int           { return len(h) }
+func (h topKHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h topKHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *topKHeap) Push(x any) {
+	e := x.(*hotKeyEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *topKHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// namespaceHotKeys tracks the most requested keys of one namespace.
+type namespaceHotKeys struct {
+	mu     sync.Mutex
+	sketch countMinSketch
+	top    topKHeap
+	byKey  map[string]*hotKeyEntry
+}
+
+// record counts a request for a namespaced key and keeps the k most
+// requested keys.
+func (n *namespaceHotKeys) record(key string, k int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	count := n.sketch.add(key)
+	if e, ok := n.byKey[key]; ok {
+		e.count = count
+		heap.Fix(&n.top, e.index)
+		return
+	}
+	if len(n.top) < k {
+		e := &hotKeyEntry{key: key, count: count}
+		heap.Push(&n.top, e)
+		n.byKey[key] = e
+		return
+	}
+	if len(n.top) > 0 && count > n.top[0].count {
+		// Replace the least requested key
+		delete(n.byKey, n.top[0].key)
+		n.top[0] = &hotKeyEntry{key: key, count: count}
+		n.byKey[key] = n.top[0]
+		heap.Fix(&n.top, 0)
+	}
+}
+
+// decay halves all counts and trims the heap to k keys.
+func (n *namespaceHotKeys) decay(k int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.sketch.decay()
+	for _, e := range n.top {
+		e.count >>= 1
+	}
+	for len(n.top) > k {
+		e := heap.Pop(&n.top).(*hotKeyEntry)
+		delete(n.byKey, e.key)
+	}
+}
+
+// snapshot returns the tracked keys, most requested first.
+func (n *namespaceHotKeys) snapshot() []hotKeyEntry {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	result := make([]hotKeyEntry, 0, len(n.top))
+	for _, e := range n.top {
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].count > result[j].count
+	})
+	return result
+}
+
+// nearEntry is the near cache slot of a hot key. The generation changes on
+// every invalidation, so that a read that started before the invalidation
+// cannot fill the slot afterwards.
+type nearEntry struct {
+	generation uint64
+	resp       *oraclev1.ProxyGetResponse
+	expires    time.Time
+}
+
+// hotKeys detects the most requested keys of each namespace and serves hot
+// keys from a near cache in the proxy.
+//
+// Requests are counted in a Count-Min sketch per namespace whose counts are
+// halved every second; a min-heap keeps the top K keys. Once a second, keys
+// whose estimated rate reaches the threshold become hot and get a near
+// cache slot. Slots are emptied when this proxy writes the key, when a
+// cache node reports a change through WatchKeys, and after the maximum
+// staleness at the latest.
+type hotKeys struct {
+	server *Server
+
+	cfg atomic.Pointer[config.HotKeyConfig]
+
+	mu         sync.Mutex
+	namespaces map[string]*namespaceHotKeys
+	watchers   map[string]*nodeWatch
+	watched    []string
+
+	nearMu sync.RWMutex
+	near   map[string]*nearEntry
+}
+
+// nodeWatch is the WatchKeys stream to one cache node.
+type nodeWatch struct {
+	cancel context.CancelFunc
+
+	// update is signaled when the set of hot keys changed
+	update chan struct{}
+}
+
+// newHotKeys creates hot key tracking with default settings.
+func newHotKeys(s *Server) *hotKeys {
+	return &hotKeys{
+		server:     s,
+		namespaces: make(map[string]*namespaceHotKeys),
+		watchers:   make(map[string]*nodeWatch),
+		near:       make(map[string]*nearEntry),
+	}
+}
+
+// configure applies hot key settings; nil selects defaults.
+func (h *hotKeys) configure(cfg *config.HotKeyConfig) {
+	var effective config.HotKeyConfig
+	if cfg != nil {
+		effective = *cfg
+	}
+	if effective.TopK <= 0 {
+		effective.TopK = DefaultHotKeyTopK
+	}
+	if effective.MinRequestsPerSecond <= 0 {
+		effective.MinRequestsPerSecond = DefaultHotKeyMinRequestsPerSecond
+	}
+	if effective.MaxStalenessMs <= 0 {
+		effective.MaxStalenessMs = int(DefaultNearCacheMaxStaleness / time.Millisecond)
+	}
+	if effective.MaxValueBytes <= 0 {
+		effective.MaxValueBytes = DefaultNearCacheMaxValueBytes
+	}
+	h.cfg.Store(&effective)
+}
+
+// record counts a Get of a namespaced key.
+func (h *hotKeys) record(namespace, key string) {
+	h.mu.Lock()
+	n, ok := h.namespaces[namespace]
+	if !ok {
+		n = &namespaceHotKeys{byKey: make(map[string]*hotKeyEntry)}
+		h.namespaces[namespace] = n
+	}
+	h.mu.Unlock()
+
+	n.record(key, h.cfg.Load().TopK)
+}
+
+// lookup returns the near cache entry of a key.
+//
+// Returns:
+//   - *oraclev1.ProxyGetResponse: The cached response, or nil
+//   - uint64: The slot generation to pass to fill (0 if the key is not hot)
+func (h *hotKeys) lookup(key string) (*oraclev1.ProxyGetResponse, uint64) {
+	h.nearMu.RLock()
+	defer h.nearMu.RUnlock()
+
+	slot, ok := h.near[key]
+	if !ok {
+		return nil, 0
+	}
+	if slot.resp == nil || time.Now().After(slot.expires) {
+		return nil, slot.generation
+	}
+	return slot.resp, slot.generation
+}
+
+// fill stores a response read from the nodes in the near cache, unless the
+// key was invalidated since lookup returned generation.
+func (h *hotKeys) fill(key string, generation uint64, resp *oraclev1.ProxyGetResponse) {
+	cfg := h.cfg.Load()
+	if generation == 0 || !cfg.NearCache || len(resp.Value) > cfg.MaxValueBytes {
+		return
+	}
+
+	ttl := time.Duration(cfg.MaxStalenessMs) * time.Millisecond
+	if resp.Ttl > 0 {
+		ttl = min(ttl, time.Duration(resp.Ttl)*time.Second)
+	}
+
+	h.nearMu.Lock()
+	defer h.nearMu.Unlock()
+	if slot, ok := h.near[key]; ok && slot.generation == generation {
+		slot.resp = resp
+		slot.expires = time.Now().Add(ttl)
+	}
+}
+
+// invalidate empties the near cache slot of a key.
+func (h *hotKeys) invalidate(key string) {
+	h.nearMu.Lock()
+	defer h.nearMu.Unlock()
+	if slot, ok := h.near[key]; ok {
+		slot.generation++
+		slot.resp = nil
+	}
+}
+
+// invalidateAll empties every near cache slot.
+func (h *hotKeys) invalidateAll() {
+	h.nearMu.Lock()
+	defer h.nearMu.Unlock()
+	for _, slot := range h.near {
+		slot.generation++
+		slot.resp = nil
+	}
+}
+
+// run decays the counts and refreshes the hot keys until the server stops.
+func (h *hotKeys) run() {
+	ticker := time.NewTicker(hotKeyDecayInterval)
+	defer ticker.Stop()
+	defer h.syncWatchers(nil)
+
+	for {
+		select {
+		case <-h.server.stopCh:
+			return
+		case <-ticker.C:
+		}
+		h.refresh()
+	}
+}
+
+// refresh decays the counts, gives every hot key a near cache slot and
+// keeps a WatchKeys stream open to every node while the near cache is on.
+func (h *hotKeys) refresh() {
+	cfg := h.cfg.Load()
+
+	h.mu.Lock()
+	namespaces := make([]*namespaceHotKeys, 0, len(h.namespaces))
+	for _, n := range h.namespaces {
+		namespaces = append(namespaces, n)
+	}
+	h.mu.Unlock()
+
+	hot := make(map[string]bool)
+	for _, n := range namespaces {
+		n.decay(cfg.TopK)
+		for _, e := range n.snapshot() {
+			if cfg.NearCache && rate(e.count) >= cfg.MinRequestsPerSecond {
+				hot[e.key] = true
+			}
+		}
+	}
+
+	h.nearMu.Lock()
+	for key := range h.near {
+		if !hot[key] {
+			delete(h.near, key)
+		}
+	}
+	for key := range hot {
+		if _, ok := h.near[key]; !ok {
+			h.near[key] = &nearEntry{generation: 1}
+		}
+	}
+	h.nearMu.Unlock()
+
+	watched := make([]string, 0, len(hot))
+	for key := range hot {
+		watched = append(watched, key)
+	}
+	sort.Strings(watched)
+
+	var nodes []string
+	if cfg.NearCache {
+		s := h.server
+		s.mu.RLock()
+		nodes = s.ring.Nodes()
+		s.mu.RUnlock()
+	}
+
+	h.mu.Lock()
+	changed := !slices.Equal(h.watched, watched)
+	h.watched = watched
+	h.mu.Unlock()
+
+	h.syncWatchers(nodes)
+	if changed {
+		h.mu.Lock()
+		for _, w := range h.watchers {
+			select {
+			case w.update <- struct{}{}:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// rate converts a decayed count to an estimated requests per second.
+func rate(count uint32) float64 {
+	return float64(count) / 2
+}
+
+// syncWatchers keeps one WatchKeys stream per node, closing those of nodes
+// that are gone.
+func (h *hotKeys) syncWatchers(nodes []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		current[node] = true
+		if _, ok := h.watchers[node]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		w := &nodeWatch{cancel: cancel, update: make(chan struct{}, 1)}
+		h.watchers[node] = w
+		go h.watch(ctx, node, w)
+	}
+	for node, w := range h.watchers {
+		if !current[node] {
+			w.cancel()
+			delete(h.watchers, node)
+		}
+	}
+}
+
+// watch keeps a WatchKeys stream open to a node until ctx is canceled.
+func (h *hotKeys) watch(ctx context.Context, node string, w *nodeWatch) {
+	for {
+		err := h.watchOnce(ctx, node, w)
+
+		// Changes may have been missed while the stream was down
+		h.invalidateAll()
+		if ctx.Err() != nil {
+			return
+		}
+		h.server.logger.Debug("Key watch on %s ended: %v", node, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+// watchOnce runs one WatchKeys stream, sending the hot keys whenever they
+// change and invalidating the keys the node reports.
+func (h *hotKeys) watchOnce(ctx context.Context, node string, w *nodeWatch) error {
+	client := h.server.nodeClient(node)
+	if client == nil {
+		return fmt.Errorf("node client not found: %s", node)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.WatchKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			if resp.AllChanged {
+				h.invalidateAll()
+				continue
+			}
+			for _, key := range resp.Keys {
+				h.invalidate(key)
+			}
+		}
+	}()
+
+	for {
+		h.mu.Lock()
+		keys := h.watched
+		h.mu.Unlock()
+		if err := stream.Send(&oraclev1.WatchKeysRequest{Keys: keys}); err != nil {
+			return err
+		}
+
+		select {
+		case err := <-recvErr:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.update:
+		}
+	}
+}
+
+// report returns the most requested keys of every namespace.
+func (h *hotKeys) report() []*oraclev1.NamespaceHotKeys {
+	cfg := h.cfg.Load()
+
+	h.mu.Lock()
+	names := make([]string, 0, len(h.namespaces))
+	for name := range h.namespaces {
+		names = append(names, name)
+	}
+	h.mu.Unlock()
+	sort.Strings(names)
+
+	result := make([]*oraclev1.NamespaceHotKeys, 0, len(names))
+	for _, name := range names {
+		h.mu.Lock()
+		n := h.namespaces[name]
+		h.mu.Unlock()
+
+		prefix := h.server.namespacePrefix(name)
+		entry := &oraclev1.NamespaceHotKeys{Namespace: name}
+		for _, e := range n.snapshot() {
+			if e.count == 0 {
+				continue
+			}
+			resp, _ := h.lookup(e.key)
+			entry.Keys = append(entry.Keys, &oraclev1.HotKey{
+				Key:               strings.TrimPrefix(e.key, prefix),
+				RequestsPerSecond: rate(e.count),
+				Hot:               rate(e.count) >= cfg.MinRequestsPerSecond,
+				Cached:            resp != nil,
+			})
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// SetHotKeyConfig applies hot key and near cache settings; nil selects
+// defaults (hot keys are reported, the near cache is off).
+func (s *Server) SetHotKeyConfig(cfg *config.HotKeyConfig) {
+	s.hotKeys.configure(cfg)
+	if cfg == nil || !cfg.NearCache {
+		s.hotKeys.invalidateAll()
+	}
+}
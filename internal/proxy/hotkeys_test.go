@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// newTestHotKeys returns hot key tracking with the given settings on a
+// proxy without nodes.
+func newTestHotKeys(t *testing.T, cfg *config.HotKeyConfig) *hotKeys {
+	t.Helper()
+
+	s := NewServer(nil)
+	t.Cleanup(s.Stop)
+	h := newHotKeys(s)
+	h.configure(cfg)
+	return h
+}
+
+func newNamespaceHotKeys() *namespaceHotKeys {
+	return &namespaceHotKeys{byKey: make(map[string]*hotKeyEntry)}
+}
+
+func TestCountMinSketchNeverUnderestimates(t *testing.T) {
+	var c countMinSketch
+	want := make(map[string]uint32)
+
+	// Far more keys than counters per row, so that keys collide
+	for i := range 20000 {
+		key := fmt.Sprintf("key-%d", i%5000)
+		want[key]++
+		c.add(key)
+	}
+
+	for key, count := range want {
+		// add returns the estimate including the request it counts
+		if got := c.add(key) - 1; got < count {
+			t.Fatalf("estimate of %q = %d, below its count %d", key, got, count)
+		}
+	}
+}
+
+func TestCountMinSketchDecay(t *testing.T) {
+	var c countMinSketch
+	for range 10 {
+		c.add("k")
+	}
+
+	c.decay()
+	if got := c.add("k"); got != 6 {
+		t.Errorf("estimate after decay and one more request = %d, want 6", got)
+	}
+}
+
+func TestNamespaceHotKeysRecord(t *testing.T) {
+	n := newNamespaceHotKeys()
+	record := func(key string, times int) {
+		for range times {
+			n.record(key, 2)
+		}
+	}
+
+	record("a", 5)
+	record("b", 3)
+	record("c", 3)
+	if got := keysOf(n.snapshot()); !equalKeys(got, "a", "b") {
+		t.Fatalf("top keys = %v, want a and b; c is not requested more than b", got)
+	}
+
+	// c overtakes b, the least requested key, and replaces it
+	record("c", 1)
+	snap := n.snapshot()
+	if got := keysOf(snap); !equalKeys(got, "a", "c") {
+		t.Fatalf("top keys = %v, want a and c", got)
+	}
+	if snap[0].key != "a" || snap[0].count != 5 || snap[1].count != 4 {
+		t.Errorf("snapshot = %v, want a (5) before c (4)", snap)
+	}
+	if _, ok := n.byKey["b"]; ok || len(n.byKey) != 2 {
+		t.Errorf("index holds %d keys including b = %v, want only the top keys", len(n.byKey), ok)
+	}
+}
+
+func TestNamespaceHotKeysDecay(t *testing.T) {
+	n := newNamespaceHotKeys()
+	for key, times := range map[string]int{"a": 8, "b": 4, "c": 2} {
+		for range times {
+			n.record(key, 3)
+		}
+	}
+
+	// Halve the counts and shrink to the two most requested keys
+	n.decay(2)
+	snap := n.snapshot()
+	if len(snap) != 2 || snap[0].key != "a" || snap[0].count != 4 || snap[1].key != "b" || snap[1].count != 2 {
+		t.Fatalf("snapshot after decay = %v, want a (4) and b (2)", snap)
+	}
+	if len(n.byKey) != 2 {
+		t.Errorf("index holds %d keys after decay, want 2", len(n.byKey))
+	}
+
+	// The sketch was halved too
+	n.record("a", 2)
+	if got := n.snapshot()[0].count; got != 5 {
+		t.Errorf("count of a after one more request = %d, want 5", got)
+	}
+}
+
+func TestHotKeysRefreshPromotesHotKeys(t *testing.T) {
+	h := newTestHotKeys(t, &config.HotKeyConfig{NearCache: true, MinRequestsPerSecond: 10})
+
+	for range 100 {
+		h.record("orders", "orders:hot")
+	}
+	h.record("orders", "orders:cold")
+
+	h.refresh()
+
+	if _, generation := h.lookup("orders:hot"); generation == 0 {
+		t.Error("key at 25 requests per second has no near cache slot")
+	}
+	if _, generation := h.lookup("orders:cold"); generation != 0 {
+		t.Error("cold key has a near cache slot")
+	}
+}
+
+func TestNearCacheFill(t *testing.T) {
+	const key = "orders:hot"
+	resp := &oraclev1.ProxyGetResponse{Found: true, Value: []byte("v1")}
+
+	tests := []struct {
+		name string
+		cfg  config.HotKeyConfig
+		// prepare runs after lookup returned generation
+		prepare func(h *hotKeys)
+		resp    *oraclev1.ProxyGetResponse
+		want    bool
+	}{
+		{
+			name:    "filled",
+			cfg:     config.HotKeyConfig{NearCache: true},
+			prepare: func(h *hotKeys) {},
+			resp:    resp,
+			want:    true,
+		},
+		{
+			name:    "invalidated after lookup",
+			cfg:     config.HotKeyConfig{NearCache: true},
+			prepare: func(h *hotKeys) { h.invalidate(key) },
+			resp:    resp,
+		},
+		{
+			name:    "all invalidated after lookup",
+			cfg:     config.HotKeyConfig{NearCache: true},
+			prepare: func(h *hotKeys) { h.invalidateAll() },
+			resp:    resp,
+		},
+		{
+			name:    "value too large",
+			cfg:     config.HotKeyConfig{NearCache: true, MaxValueBytes: 1},
+			prepare: func(h *hotKeys) {},
+			resp:    resp,
+		},
+		{
+			name:    "near cache off",
+			cfg:     config.HotKeyConfig{},
+			prepare: func(h *hotKeys) {},
+			resp:    resp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHotKeys(t, &tt.cfg)
+			h.near[key] = &nearEntry{generation: 1}
+
+			cached, generation := h.lookup(key)
+			if cached != nil || generation == 0 {
+				t.Fatalf("lookup of an empty slot = %v, %d", cached, generation)
+			}
+			tt.prepare(h)
+			h.fill(key, generation, tt.resp)
+
+			if cached, _ := h.lookup(key); (cached != nil) != tt.want {
+				t.Errorf("near cache holds %v, want filled = %v", cached, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearCacheExpires(t *testing.T) {
+	h := newTestHotKeys(t, &config.HotKeyConfig{NearCache: true, MaxStalenessMs: 20})
+	const key = "orders:hot"
+	h.near[key] = &nearEntry{generation: 1}
+
+	h.fill(key, 1, &oraclev1.ProxyGetResponse{Found: true, Value: []byte("v1")})
+	if cached, _ := h.lookup(key); cached == nil {
+		t.Fatal("near cache not filled")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if cached, generation := h.lookup(key); cached != nil || generation != 1 {
+		t.Errorf("lookup after the staleness bound = %v, %d; want an empty slot", cached, generation)
+	}
+}
+
+func keysOf(entries []hotKeyEntry) []string {
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// equalKeys reports whether got holds exactly the wanted keys, in any order.
+func equalKeys(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[string]bool, len(got))
+	for _, key := range got {
+		seen[key] = true
+	}
+	for _, key := range want {
+		if !seen[key] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,503 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	"github.com/eggybyte-technology/yao-oracle/core/utils"
+)
+
+// Default legacy key migration settings, used when LegacyKeysConfig leaves
+// them unset.
+const (
+	DefaultLegacyKeysPerSecond = 1000
+	DefaultLegacyKeysBatchSize = 100
+)
+
+// errLegacyLeaseLost stops a scan whose proxy no longer holds the lease.
+var errLegacyLeaseLost = errors.New("scan lease held by another proxy")
+
+// legacyKeysInterval is the time between scans for legacy keys.
+const legacyKeysInterval = 30 * time.Second
+
+// legacyKeysLeaseTTL bounds how long a proxy that stopped renewing its scan
+// lease blocks the scans of the others, in seconds.
+const legacyKeysLeaseTTL = 2 * int32(legacyKeysInterval/time.Second)
+
+// Keys the proxies share the migration state through. Namespace names
+// cannot be empty, so no client key collides with them.
+var (
+	// legacyKeysDoneKey is written once a complete scan found no legacy keys
+	legacyKeysDoneKey = kv.NamespaceKey("", "legacy-keys-done")
+
+	// legacyKeysLeaseKey names the single proxy allowed to scan
+	legacyKeysLeaseKey = kv.NamespaceKey("", "legacy-keys-lease")
+)
+
+// legacyKeys migrates keys written in the legacy "namespace:key" encoding
+// to kv.NamespaceKey.
+//
+// One proxy at a time, the holder of a lease stored on the nodes, streams
+// the keys starting with "<namespace>:" from all nodes and moves each one
+// to its current key on the current owners (see moveKeys): newer client
+// writes are never overwritten, and the legacy copy is only deleted if it
+// did not change while it was copied. Deletes remove the legacy copy before
+// the current one, so a concurrent move never resurrects a deleted key.
+// Legacy keys are ambiguous when one namespace name is a prefix of another
+// followed by ':'; they are assigned to the namespace with the longest
+// matching name.
+//
+// Scans repeat until one finds no legacy keys on any node; the scanning
+// proxy then writes a marker key that ends the migration on every proxy.
+// Until a proxy sees the marker, its reads that miss also look up the
+// legacy key. A marker lost with its node only costs one more scan.
+type legacyKeys struct {
+	server *Server
+	logger *utils.Logger
+
+	// id identifies this proxy as the holder of the scan lease
+	id string
+
+	mu   sync.Mutex
+	cfg  config.LegacyKeysConfig
+	done bool
+
+	migrated   atomic.Int64
+	skipped    atomic.Int64
+	rolledBack atomic.Int64
+	errors     atomic.Int64
+}
+
+// newLegacyKeys creates the legacy key migration for the server.
+func newLegacyKeys(s *Server) *legacyKeys {
+	host, _ := os.Hostname()
+	return &legacyKeys{
+		server: s,
+		logger: utils.NewLogger("legacy-keys"),
+		id:     host + "-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+}
+
+// configure applies legacy key settings; nil selects the defaults.
+func (l *legacyKeys) configure(cfg *config.LegacyKeysConfig) {
+	var effective config.LegacyKeysConfig
+	if cfg != nil {
+		effective = *cfg
+	}
+	if effective.MaxKeysPerSecond <= 0 {
+		effective.MaxKeysPerSecond = DefaultLegacyKeysPerSecond
+	}
+	if effective.BatchSize <= 0 {
+		effective.BatchSize = DefaultLegacyKeysBatchSize
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = effective
+}
+
+// active reports whether legacy keys may still exist and are migrated.
+func (l *legacyKeys) active() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return !l.cfg.Disabled && !l.done
+}
+
+// finish ends the migration on this proxy.
+func (l *legacyKeys) finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.done = true
+}
+
+// run scans for legacy keys until none are left. It returns when the
+// server is stopped.
+func (l *legacyKeys) run() {
+	for {
+		if l.active() {
+			l.step()
+		}
+
+		select {
+		case <-l.server.stopCh:
+			return
+		case <-time.After(legacyKeysInterval):
+		}
+	}
+}
+
+// step ends the migration if another proxy finished it, and otherwise scans
+// if this proxy holds the scan lease.
+func (l *legacyKeys) step() {
+	ctx, cancel := context.WithTimeout(context.Background(), legacyKeysInterval)
+	defer cancel()
+
+	if l.markedDone(ctx) {
+		l.finish()
+		l.logger.Info("Legacy key migration was completed by another proxy")
+		return
+	}
+	if held, err := l.renewLease(ctx); err != nil || !held {
+		return
+	}
+	l.scan()
+}
+
+// markedDone reports whether the marker of a completed migration exists.
+func (l *legacyKeys) markedDone(ctx context.Context) bool {
+	client := l.server.nodeClient(l.server.ownerOf(legacyKeysDoneKey))
+	if client == nil {
+		return false
+	}
+	resp, err := client.Get(ctx, &oraclev1.GetRequest{Key: legacyKeysDoneKey})
+	return err == nil && resp.Found
+}
+
+// markDone writes the marker of a completed migration.
+func (l *legacyKeys) markDone(ctx context.Context) error {
+	client := l.server.nodeClient(l.server.ownerOf(legacyKeysDoneKey))
+	if client == nil {
+		return errors.New("no connection to the owner of the migration marker")
+	}
+	_, err := client.Set(ctx, &oraclev1.SetRequest{Key: legacyKeysDoneKey, Value: []byte(l.id)})
+	return err
+}
+
+// renewLease acquires the scan lease, or extends it if this proxy already
+// holds it, and reports whether this proxy holds it now.
+func (l *legacyKeys) renewLease(ctx context.Context) (bool, error) {
+	client := l.server.nodeClient(l.server.ownerOf(legacyKeysLeaseKey))
+	if client == nil {
+		return false, errors.New("no connection to the owner of the scan lease")
+	}
+
+	checks := []*oraclev1.TxnCheck{
+		{Key: legacyKeysLeaseKey, Type: oraclev1.TxnCheckType_TXN_CHECK_TYPE_NOT_EXISTS},
+		{Key: legacyKeysLeaseKey, Type: oraclev1.TxnCheckType_TXN_CHECK_TYPE_VALUE_EQUALS, Value: []byte(l.id)},
+	}
+	for _, check := range checks {
+		resp, err := client.Transaction(ctx, &oraclev1.TransactionRequest{
+			Checks:    []*oraclev1.TxnCheck{check},
+			Mutations: []*oraclev1.TxnMutation{{Key: legacyKeysLeaseKey, Value: []byte(l.id), Ttl: legacyKeysLeaseTTL}},
+		})
+		if err != nil {
+			return false, err
+		}
+		if resp.Succeeded {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// scan migrates the legacy keys of all nodes, and finishes the migration
+// once a complete scan finds none.
+func (l *legacyKeys) scan() {
+	namespaces := l.namespaces()
+	if len(namespaces) == 0 {
+		return
+	}
+
+	l.server.mu.RLock()
+	nodes := make([]string, 0, len(l.server.nodeClients))
+	for node := range l.server.nodeClients {
+		nodes = append(nodes, node)
+	}
+	hashFunction := l.server.placement.HashFunction
+	l.server.mu.RUnlock()
+	if len(nodes) == 0 {
+		return
+	}
+	sort.Strings(nodes)
+
+	found := 0
+	complete := true
+	for _, node := range nodes {
+		n, err := l.scanNode(node, hashFunction, namespaces)
+		found += n
+		if err != nil {
+			complete = false
+			l.errors.Add(1)
+			l.logger.Warn("Legacy key scan of %s failed: %v", node, err)
+			if errors.Is(err, errLegacyLeaseLost) {
+				return
+			}
+		}
+	}
+
+	if found > 0 {
+		l.logger.Info("Rewrote %d legacy keys (%d migrated, %d skipped, %d rolled back in total)",
+			found, l.migrated.Load(), l.skipped.Load(), l.rolledBack.Load())
+		return
+	}
+	if !complete {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), replicaTimeout)
+	defer cancel()
+	if err := l.markDone(ctx); err != nil {
+		l.errors.Add(1)
+		l.logger.Warn("Failed to record the end of the legacy key migration: %v", err)
+		return
+	}
+	l.finish()
+	l.logger.Success("No legacy keys left on %d nodes: %d migrated, %d skipped, %d rolled back",
+		len(nodes), l.migrated.Load(), l.skipped.Load(), l.rolledBack.Load())
+}
+
+// namespaces returns the configured namespaces, longest name first, so
+// that the first match of a legacy key is the longest one.
+func (l *legacyKeys) namespaces() []config.Namespace {
+	if l.server.informer == nil {
+		return nil
+	}
+	cfg := l.server.informer.GetConfig()
+	if cfg.Proxy == nil {
+		return nil
+	}
+
+	namespaces := slices.Clone(cfg.Proxy.Namespaces)
+	sort.SliceStable(namespaces, func(i, j int) bool {
+		return len(namespaces[i].Name) > len(namespaces[j].Name)
+	})
+	return namespaces
+}
+
+// scanNode migrates the legacy keys of one node and returns how many it
+// found.
+func (l *legacyKeys) scanNode(node, hashFunction string, namespaces []config.Namespace) (int, error) {
+	l.mu.Lock()
+	batchSize := l.cfg.BatchSize
+	keysPerSecond := l.cfg.MaxKeysPerSecond
+	l.mu.Unlock()
+
+	client := l.server.nodeClient(node)
+	if client == nil {
+		return 0, errors.New("no connection to node")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-l.server.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	prefixes := make([]string, len(namespaces))
+	for i, ns := range namespaces {
+		prefixes[i] = ns.Name + ":"
+	}
+
+	stream, err := client.ScanRange(ctx, &oraclev1.ScanRangeRequest{
+		Ranges:       []*oraclev1.KeyHashRange{{Start: 0, End: math.MaxUint64}},
+		HashFunction: hashFunction,
+		BatchSize:    int32(batchSize),
+		KeyPrefixes:  prefixes,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	found := 0
+	for {
+		batch, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return found, nil
+		}
+		if err != nil {
+			return found, err
+		}
+
+		// Stop if another proxy took over the scan
+		held, err := l.renewLease(ctx)
+		if err != nil {
+			return found, err
+		}
+		if !held {
+			return found, errLegacyLeaseLost
+		}
+
+		started := time.Now()
+		if err := l.rewriteBatch(ctx, client, batch.Entries, namespaces); err != nil {
+			return found, err
+		}
+		found += len(batch.Entries)
+
+		// Throttle to the configured key rate
+		budget := time.Duration(float64(len(batch.Entries)) / float64(keysPerSecond) * float64(time.Second))
+		if wait := budget - time.Since(started); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return found, ctx.Err()
+			}
+		}
+	}
+}
+
+// rewriteBatch moves one scanned batch to the current key encoding.
+func (l *legacyKeys) rewriteBatch(ctx context.Context, source oraclev1.NodeServiceClient, entries []*oraclev1.MigrationEntry, namespaces []config.Namespace) error {
+	moves := make([]keyMove, 0, len(entries))
+	for _, entry := range entries {
+		ns, clientKey, ok := legacyNamespace(entry.Key, namespaces)
+		if !ok {
+			continue
+		}
+
+		key := kv.NamespaceKey(ns.Name, clientKey)
+		replicas, _, _ := ns.Replication()
+		l.server.mu.RLock()
+		targets := l.server.ring.GetNodes(kv.RoutingKey(key), replicas)
+		l.server.mu.RUnlock()
+		moves = append(moves, keyMove{entry: entry, key: key, targets: targets})
+	}
+
+	result, err := l.server.moveKeys(ctx, source, moves)
+	l.migrated.Add(result.imported)
+	l.skipped.Add(result.skipped)
+	l.rolledBack.Add(result.rolledBack)
+	return err
+}
+
+// legacyNamespace assigns a legacy key to the namespace with the longest
+// matching name.
+//
+// Parameters:
+//   - legacyKey: Key in the "namespace:key" encoding
+//   - namespaces: Configured namespaces, longest name first
+//
+// Returns:
+//   - config.Namespace: The namespace the key belongs to
+//   - string: Client key
+//   - bool: False if no namespace matches
+func legacyNamespace(legacyKey string, namespaces []config.Namespace) (config.Namespace, string, bool) {
+	for _, ns := range namespaces {
+		if clientKey, ok := strings.CutPrefix(legacyKey, ns.Name+":"); ok {
+			return ns, clientKey, true
+		}
+	}
+	return config.Namespace{}, "", false
+}
+
+// legacyKey returns the legacy encoding of a namespaced key, unless the
+// legacy key belongs to another namespace with a longer name.
+func (l *legacyKeys) legacyKey(key string) (string, bool) {
+	namespace, clientKey, ok := kv.SplitNamespaceKey(key)
+	if !ok {
+		return "", false
+	}
+
+	legacyKey := namespace + ":" + clientKey
+	ns, _, ok := legacyNamespace(legacyKey, l.namespaces())
+	if !ok || ns.Name != namespace {
+		return "", false
+	}
+	return legacyKey, true
+}
+
+// get looks up the legacy copy of a key that was not found under its
+// current encoding.
+//
+// Returns:
+//   - *oraclev1.GetResponse: The legacy copy if it was found, nil otherwise
+//   - string: The node that served the legacy copy
+func (l *legacyKeys) get(ctx context.Context, key string) (*oraclev1.GetResponse, string) {
+	if !l.active() {
+		return nil, ""
+	}
+	legacyKey, ok := l.legacyKey(key)
+	if !ok {
+		return nil, ""
+	}
+
+	node := l.server.ownerOf(legacyKey)
+	client := l.server.nodeClient(node)
+	if client == nil {
+		return nil, ""
+	}
+
+	resp, err := client.Get(ctx, &oraclev1.GetRequest{Key: legacyKey})
+	if err != nil || !resp.Found {
+		return nil, ""
+	}
+	return resp, node
+}
+
+// delete removes the legacy copies of keys a client deletes.
+//
+// It must run before the keys are deleted from their owners: a scan that
+// copied a key in the meantime then fails its conditional delete of the
+// legacy copy and undoes the copy (see moveKeys). Failures are logged; the
+// owner delete proceeds.
+//
+// Parameters:
+//   - ctx: Context for the node calls
+//   - keys: Namespaced keys being deleted
+//
+// Returns:
+//   - map[string]bool: Keys whose legacy copy existed
+func (l *legacyKeys) delete(ctx context.Context, keys []string) map[string]bool {
+	existed := make(map[string]bool)
+	if !l.active() {
+		return existed
+	}
+
+	// The legacy copy lives on its owner, or on its previous owner until
+	// its range is migrated
+	byNode := make(map[string][]string)
+	current := make(map[string]string)
+	for _, key := range keys {
+		legacyKey, ok := l.legacyKey(key)
+		if !ok {
+			continue
+		}
+		current[legacyKey] = key
+		owner := l.server.ownerOf(legacyKey)
+		byNode[owner] = append(byNode[owner], legacyKey)
+		if fallback := l.server.rebalancer.fallbackNode(legacyKey); fallback != "" && fallback != owner {
+			byNode[fallback] = append(byNode[fallback], legacyKey)
+		}
+	}
+
+	for node, legacyKeys := range byNode {
+		client := l.server.nodeClient(node)
+		if client == nil {
+			continue
+		}
+		resp, err := client.MultiDelete(ctx, &oraclev1.MultiDeleteRequest{Keys: legacyKeys})
+		if err != nil || len(resp.Results) != len(legacyKeys) {
+			l.logger.Warn("Failed to delete %d legacy keys from %s: %v", len(legacyKeys), node, err)
+			continue
+		}
+		for i, legacyKey := range legacyKeys {
+			if resp.Results[i].Existed {
+				existed[current[legacyKey]] = true
+			}
+		}
+	}
+	return existed
+}
+
+// SetLegacyKeysConfig applies legacy key migration settings; nil selects
+// defaults.
+func (s *Server) SetLegacyKeysConfig(cfg *config.LegacyKeysConfig) {
+	s.legacyKeys.configure(cfg)
+}
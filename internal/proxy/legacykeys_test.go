@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+func TestLegacyKeysRewriteMovesKey(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	s := newTestProxy(t, addrs)
+	ctx := context.Background()
+
+	legacyKey := "shop:user:1"
+	key := kv.NamespaceKey("shop", "user:1")
+	source := s.ownerOf(legacyKey)
+	ts := time.Now().UnixNano()
+	setVersioned(t, clients[source], legacyKey, "v1", ts)
+
+	entry := &oraclev1.MigrationEntry{Key: legacyKey, Value: []byte("v1"), Timestamp: ts}
+	namespaces := []config.Namespace{{Name: "shop"}}
+	if err := s.legacyKeys.rewriteBatch(ctx, clients[source], []*oraclev1.MigrationEntry{entry}, namespaces); err != nil {
+		t.Fatalf("rewriteBatch: %v", err)
+	}
+
+	if got, found := getValue(t, clients[s.ownerOf(key)], key); !found || got != "v1" {
+		t.Errorf("owner holds %q (found %v), want the legacy value", got, found)
+	}
+	if _, found := getValue(t, clients[source], legacyKey); found {
+		t.Error("legacy copy was not deleted")
+	}
+}
+
+func TestLegacyKeysRewriteDoesNotResurrectDeletedKey(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	scanner := newTestProxy(t, addrs)
+	ctx := context.Background()
+
+	legacyKey := "shop:user:1"
+	key := kv.NamespaceKey("shop", "user:1")
+	source := scanner.ownerOf(legacyKey)
+	ts := time.Now().UnixNano()
+	setVersioned(t, clients[source], legacyKey, "v1", ts)
+
+	// The scanner read the legacy copy, then another proxy deleted the key:
+	// legacy copy first, then the current one
+	entry := &oraclev1.MigrationEntry{Key: legacyKey, Value: []byte("v1"), Timestamp: ts}
+	if _, err := clients[source].Delete(ctx, &oraclev1.DeleteRequest{Key: legacyKey}); err != nil {
+		t.Fatalf("delete legacy key: %v", err)
+	}
+
+	namespaces := []config.Namespace{{Name: "shop"}}
+	if err := scanner.legacyKeys.rewriteBatch(ctx, clients[source], []*oraclev1.MigrationEntry{entry}, namespaces); err != nil {
+		t.Fatalf("rewriteBatch: %v", err)
+	}
+
+	if _, found := getValue(t, clients[scanner.ownerOf(key)], key); found {
+		t.Error("deleted key was brought back by the legacy key scan")
+	}
+	if n := scanner.legacyKeys.rolledBack.Load(); n != 1 {
+		t.Errorf("rolled back %d moves, want 1", n)
+	}
+}
+
+func TestLegacyKeysLeaseIsExclusive(t *testing.T) {
+	addrs, _ := startNodes(t, 3)
+	a := newTestProxy(t, addrs)
+	b := newTestProxy(t, addrs)
+	ctx := context.Background()
+
+	if held, err := a.legacyKeys.renewLease(ctx); err != nil || !held {
+		t.Fatalf("first proxy did not acquire the lease: held=%v err=%v", held, err)
+	}
+	if held, err := b.legacyKeys.renewLease(ctx); err != nil || held {
+		t.Fatalf("second proxy acquired a held lease: held=%v err=%v", held, err)
+	}
+	if held, err := a.legacyKeys.renewLease(ctx); err != nil || !held {
+		t.Fatalf("lease holder could not renew: held=%v err=%v", held, err)
+	}
+}
+
+func TestLegacyKeysDoneIsShared(t *testing.T) {
+	addrs, _ := startNodes(t, 3)
+	a := newTestProxy(t, addrs)
+	b := newTestProxy(t, addrs)
+	ctx := context.Background()
+
+	if b.legacyKeys.markedDone(ctx) {
+		t.Fatal("migration marked done before any scan")
+	}
+	if err := a.legacyKeys.markDone(ctx); err != nil {
+		t.Fatalf("markDone: %v", err)
+	}
+	if !b.legacyKeys.markedDone(ctx) {
+		t.Error("other proxy does not see the completed migration")
+	}
+
+	b.legacyKeys.step()
+	if b.legacyKeys.active() {
+		t.Error("migration still active after another proxy completed it")
+	}
+}
+
+func TestLegacyNamespacePrefersLongestName(t *testing.T) {
+	namespaces := []config.Namespace{{Name: "a:b"}, {Name: "a"}}
+
+	ns, clientKey, ok := legacyNamespace("a:b:c", namespaces)
+	if !ok || ns.Name != "a:b" || clientKey != "c" {
+		t.Errorf("legacyNamespace(a:b:c) = %q, %q, %v; want a:b, c", ns.Name, clientKey, ok)
+	}
+	if _, _, ok := legacyNamespace("other:c", namespaces); ok {
+		t.Error("legacyNamespace matched a key of no configured namespace")
+	}
+}
+
+// newLegacyProxy returns a proxy over the given nodes serving namespace
+// shop, whose keys may still be stored under the legacy encoding.
+func newLegacyProxy(t *testing.T, addrs []string) *Server {
+	t.Helper()
+
+	s := NewServer(config.NewStaticInformer(config.Config{
+		Proxy: &config.ProxyConfig{Namespaces: []config.Namespace{{Name: "shop"}}},
+	}))
+	s.SetNodes(addrs)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestLegacyKeyReads(t *testing.T) {
+	// Each read returns whether it found the key, the value and the node
+	// that served it
+	reads := map[string]func(t *testing.T, s *Server, key string) (bool, string, string){
+		"batch get": func(t *testing.T, s *Server, key string) (bool, string, string) {
+			item := batchGet(context.Background(), s, []string{key})[0]
+			if item.Status == oraclev1.KeyStatus_KEY_STATUS_ERROR {
+				t.Fatalf("batch get: %s", item.Error)
+			}
+			return item.Status == oraclev1.KeyStatus_KEY_STATUS_FOUND, string(item.Value), item.Node
+		},
+		"stream": func(t *testing.T, s *Server, key string) (bool, string, string) {
+			src, err := s.openGetStream(context.Background(), key, 0)
+			if err != nil {
+				t.Fatalf("openGetStream: %v", err)
+			}
+			defer src.cancel()
+			value := ""
+			for {
+				msg, err := src.stream.Recv()
+				if err != nil {
+					break
+				}
+				value += string(msg.GetChunk())
+			}
+			return src.header.Found, value, src.node
+		},
+		"replicated": func(t *testing.T, s *Server, key string) (bool, string, string) {
+			resp, node, err := s.readReplicas(context.Background(), key, 2, 2)
+			if err != nil {
+				t.Fatalf("readReplicas: %v", err)
+			}
+			return resp.Found, string(resp.Value), node
+		},
+	}
+
+	for name, read := range reads {
+		for _, finished := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/finished=%v", name, finished), func(t *testing.T) {
+				addrs, clients := startNodes(t, 3)
+				s := newLegacyProxy(t, addrs)
+				if finished {
+					s.legacyKeys.finish()
+				}
+
+				legacyKey := "shop:user:1"
+				source := s.ownerOf(legacyKey)
+				setVersioned(t, clients[source], legacyKey, "v1", time.Now().UnixNano())
+
+				found, value, node := read(t, s, kv.NamespaceKey("shop", "user:1"))
+				if finished {
+					if found {
+						t.Errorf("read the legacy copy from %s after the migration finished", node)
+					}
+					return
+				}
+				if !found || value != "v1" || node != source {
+					t.Errorf("read %q (found %v) from %s, want the legacy copy v1 from %s", value, found, node, source)
+				}
+
+				if found, _, _ := read(t, s, kv.NamespaceKey("shop", "user:2")); found {
+					t.Error("found a key stored under neither encoding")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+)
+
+// DefaultMaxMessageSize is the default gRPC message size limit in bytes.
+//
+// It applies to the proxy's own gRPC server and to its client connections
+// towards cache nodes. Nodes must be configured with the same limit,
+// otherwise values accepted by the proxy can still be rejected by a node.
+const DefaultMaxMessageSize = 16 * 1024 * 1024
+
+// DefaultMaxStreamValueSize bounds values uploaded through SetStream when
+// the namespace does not configure maxValueBytes.
+const DefaultMaxStreamValueSize = 512 * 1024 * 1024
+
+// DefaultStreamChunkSize is the chunk size used when the proxy streams a
+// value itself and the client does not request one.
+const DefaultStreamChunkSize = 1024 * 1024
+
+// messageEnvelopeBytes is the headroom reserved for the key, namespace
+// prefix and protobuf framing when deriving the largest value that still
+// fits into a single proxy-to-node message.
+const messageEnvelopeBytes = 64 * 1024
+
+// validateKey checks the key against the namespace key size limit.
+//
+// Returns:
+//   - error: INVALID_ARGUMENT status if the key is too long, nil otherwise
+func (s *Server) validateKey(ns *config.Namespace, key string) error {
+	if ns.MaxKeyBytes > 0 && len(key) > ns.MaxKeyBytes {
+		return status.Errorf(codes.InvalidArgument,
+			"key length %d bytes exceeds maxKeyBytes %d for namespace '%s'",
+			len(key), ns.MaxKeyBytes, ns.Name)
+	}
+	return nil
+}
+
+// validateValue checks the value against the namespace value size limit
+// and the gRPC message size limit towards cache nodes.
+//
+// The namespace limit is reported first so clients see the configured
+// business rule rather than a transport detail.
+//
+// Returns:
+//   - error: INVALID_ARGUMENT status if the value is too large, nil otherwise
+func (s *Server) validateValue(ns *config.Namespace, value []byte) error {
+	if ns.MaxValueBytes > 0 && len(value) > ns.MaxValueBytes {
+		return status.Errorf(codes.InvalidArgument,
+			"value size %d bytes exceeds maxValueBytes %d for namespace '%s'",
+			len(value), ns.MaxValueBytes, ns.Name)
+	}
+
+	s.mu.RLock()
+	maxMessageSize := s.maxMessageSize
+	s.mu.RUnlock()
+
+	if limit := maxValueSize(maxMessageSize); len(value) > limit {
+		return status.Errorf(codes.InvalidArgument,
+			"value size %d bytes exceeds the %d byte limit imposed by the gRPC max message size (%d bytes)",
+			len(value), limit, maxMessageSize)
+	}
+
+	return nil
+}
+
+// maxValueSize returns the largest value that fits into a single
+// proxy-to-node message of the given size.
+func maxValueSize(maxMessageSize int) int {
+	limit := maxMessageSize - messageEnvelopeBytes
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// validateStreamSize checks the declared size of a streamed value against
+// the namespace value size limit, falling back to DefaultMaxStreamValueSize
+// when the namespace does not configure one.
+//
+// Returns:
+//   - error: INVALID_ARGUMENT status if the size is negative or too large, nil otherwise
+func (s *Server) validateStreamSize(ns *config.Namespace, totalSize int64) error {
+	if totalSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "total_size cannot be negative, got %d", totalSize)
+	}
+
+	if ns.MaxValueBytes > 0 {
+		if totalSize > int64(ns.MaxValueBytes) {
+			return status.Errorf(codes.InvalidArgument,
+				"value size %d bytes exceeds maxValueBytes %d for namespace '%s'",
+				totalSize, ns.MaxValueBytes, ns.Name)
+		}
+		return nil
+	}
+
+	if totalSize > DefaultMaxStreamValueSize {
+		return status.Errorf(codes.InvalidArgument,
+			"value size %d bytes exceeds the default streaming limit of %d bytes",
+			totalSize, DefaultMaxStreamValueSize)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+)
+
+func TestValidateKeyRejectsLongKeys(t *testing.T) {
+	s := newTestProxy(t, nil)
+	ns := &config.Namespace{Name: "shop", MaxKeyBytes: 8}
+
+	if err := s.validateKey(ns, "12345678"); err != nil {
+		t.Errorf("key at the limit rejected: %v", err)
+	}
+
+	err := s.validateKey(ns, "123456789")
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("key over the limit: err = %v, want INVALID_ARGUMENT", err)
+	}
+	if !strings.Contains(err.Error(), "maxKeyBytes 8") {
+		t.Errorf("error %q does not name the limit", err)
+	}
+
+	// Without a limit any key is accepted
+	if err := s.validateKey(&config.Namespace{Name: "shop"}, strings.Repeat("k", 4096)); err != nil {
+		t.Errorf("key rejected without a limit: %v", err)
+	}
+}
+
+func TestValidateValueChecksNamespaceAndMessageLimits(t *testing.T) {
+	s := newTestProxy(t, nil)
+	s.SetMaxMessageSize(messageEnvelopeBytes + 100)
+
+	ns := &config.Namespace{Name: "shop", MaxValueBytes: 10}
+	if err := s.validateValue(ns, make([]byte, 11)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("value over maxValueBytes: err = %v, want INVALID_ARGUMENT", err)
+	}
+
+	ns = &config.Namespace{Name: "shop"}
+	if err := s.validateValue(ns, make([]byte, 100)); err != nil {
+		t.Errorf("value that fits into a message rejected: %v", err)
+	}
+	if err := s.validateValue(ns, make([]byte, 101)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("value over the message limit: err = %v, want INVALID_ARGUMENT", err)
+	}
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+)
+
+// loadReportInterval is how often per-node request rates are fed to a
+// bounded-load placement.
+const loadReportInterval = time.Second
+
+// loadSmoothing is the weight of the newest interval in the exponentially
+// weighted request rate. Smoothing keeps keys from flapping between a node
+// and its neighbour when traffic is bursty.
+const loadSmoothing = 0.5
+
+// reportLoads periodically converts the per-node request counters into
+// request rates and passes them to the placement when it supports bounded
+// loads. It returns when the server is stopped.
+func (s *Server) reportLoads() {
+	ticker := time.NewTicker(loadReportInterval)
+	defer ticker.Stop()
+
+	rates := make(map[string]float64)
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.RLock()
+		ring := s.ring
+		bounded := s.placement.BoundedLoadEpsilon > 0
+		current := make(map[string]float64, len(s.nodeRequests))
+		for node, counter := range s.nodeRequests {
+			current[node] = float64(counter.Swap(0)) / loadReportInterval.Seconds()
+		}
+		s.mu.RUnlock()
+
+		la, ok := ring.(hash.LoadAware)
+		if !ok || !bounded {
+			clear(rates)
+			continue
+		}
+
+		for node, rate := range current {
+			if previous, seen := rates[node]; seen {
+				rate = loadSmoothing*rate + (1-loadSmoothing)*previous
+			}
+			current[node] = rate
+		}
+		rates = current
+
+		la.SetLoads(rates)
+	}
+}
@@ -0,0 +1,231 @@
+package proxy
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/discovery"
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+)
+
+// UpdateNodes applies a new set of cache node endpoints to the ring.
+//
+// The ring is updated in place: nodes missing from the set are removed and
+// their connections closed, new nodes are added and dialed, and nodes whose
+// weight changed are reweighted. Nodes present before and after keep their
+// connections, so requests in flight to them are unaffected.
+//
+// Draining endpoints (pods shutting down) are removed from the ring, but
+// their connections stay open until they disappear from the set, so that
+// the rebalancer can still move their keys to the new owners and in-flight
+// requests complete.
+//
+// An empty set is ignored while nodes are known, since discovery reports no
+// endpoints transiently (e.g. while every pod restarts) and dropping the
+// whole ring would fail all requests.
+//
+// Parameters:
+//   - endpoints: The cache nodes with their weights; weights <= 0 select
+//     discovery.DefaultWeight
+//
+// Example:
+//
+//	server.UpdateNodes([]discovery.Endpoint{
+//	    {Address: "node-0:7070", Weight: 1},
+//	    {Address: "node-1:7070", Weight: 2},
+//	})
+func (s *Server) UpdateNodes(endpoints []discovery.Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weights := make(map[string]int, len(endpoints))
+	names := make(map[string]string, len(endpoints))
+	draining := make(map[string]bool)
+	for _, ep := range endpoints {
+		names[ep.Address] = describeNode(ep)
+		if ep.Draining {
+			draining[ep.Address] = true
+			continue
+		}
+
+		weight := ep.Weight
+		if weight <= 0 {
+			weight = discovery.DefaultWeight
+		}
+		weights[ep.Address] = weight
+	}
+
+	if len(weights) == 0 && s.ring.Size() > 0 {
+		s.logger.Warn("Ignoring node list without ready nodes; keeping %d cache nodes", s.ring.Size())
+		return
+	}
+
+	// Keep the old ring for the ownership diff
+	var previous hash.Placement = s.ring
+	if ring, ok := s.ring.(*hash.Ring); ok {
+		previous = ring.Clone()
+	}
+
+	changed := false
+	for _, node := range s.ring.Nodes() {
+		if _, ok := weights[node]; ok {
+			continue
+		}
+		s.ring.RemoveNode(node)
+		delete(s.nodeRequests, node)
+		if draining[node] {
+			s.logger.Info("Draining cache node: %s", names[node])
+		} else {
+			s.logger.Info("Removed cache node: %s", node)
+		}
+		changed = true
+	}
+
+	// Close connections to nodes that are gone, including finished drains
+	for node := range s.nodeConns {
+		if _, ok := weights[node]; !ok && !draining[node] {
+			s.disconnect(node)
+		}
+	}
+
+	added := make([]string, 0, len(weights))
+	for node := range weights {
+		added = append(added, node)
+	}
+	sort.Strings(added)
+
+	for _, node := range added {
+		weight := weights[node]
+		if current := s.ring.Weight(node); current > 0 {
+			if current != weight && s.ring.UpdateWeight(node, weight) {
+				s.logger.Info("Updated weight of cache node %s: %d -> %d", names[node], current, weight)
+				changed = true
+			}
+			continue
+		}
+
+		s.ring.AddWeightedNode(node, weight)
+		s.nodeRequests[node] = new(atomic.Int64)
+		s.connect(node)
+		s.logger.Info("Added cache node: %s (weight %d)", names[node], weight)
+		changed = true
+	}
+
+	if !changed {
+		return
+	}
+
+	s.breakers.sync(s.ring.Nodes())
+	if diff, ring := s.logOwnershipChange(previous, s.ring); diff != nil {
+		s.rebalancer.plan(diff, ring, s.placement.HashFunction)
+	}
+
+	s.logger.Info("Cache node ring updated: %d nodes", s.ring.Size())
+}
+
+// describeNode formats an endpoint for logging, with its stable name if
+// discovery reports one.
+func describeNode(ep discovery.Endpoint) string {
+	if ep.Name == "" {
+		return ep.Address
+	}
+	return ep.Name + " (" + ep.Address + ")"
+}
+
+// connect creates the gRPC client for a cache node unless one exists.
+// Connection errors are logged; the node stays in the ring and requests
+// routed to it fail until the next update. The caller must hold the lock.
+func (s *Server) connect(node string) {
+	if _, exists := s.nodeClients[node]; exists {
+		return
+	}
+
+	conn, err := s.dial(node, node)
+	if err != nil {
+		s.logger.Error("Failed to connect to node %s: %v", node, err)
+		return
+	}
+	s.nodeConns[node] = conn
+	s.nodeClients[node] = oraclev1.NewNodeServiceClient(conn)
+}
+
+// dial creates a connection to a cache node with the retry policy and the
+// circuit breaker of the ring node it serves. That is the node itself,
+// except for a promoted follower serving its failed primary's position.
+// The caller must hold the lock.
+//
+// Parameters:
+//   - addr: Address to connect to (e.g. "cache-node-0-backup:8080")
+//   - node: Ring node whose breaker applies (e.g. "cache-node-0:8080")
+func (s *Server) dial(addr, node string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(s.maxMessageSize),
+			grpc.MaxCallSendMsgSize(s.maxMessageSize),
+		),
+		grpc.WithChainUnaryInterceptor(
+			s.policy.interceptor(node),
+			s.breakers.interceptor(node),
+		),
+	)
+}
+
+// disconnect closes the connection to a removed cache node. Requests still
+// using it fail with codes.Canceled. The caller must hold the lock.
+func (s *Server) disconnect(node string) {
+	delete(s.nodeClients, node)
+
+	conn, ok := s.nodeConns[node]
+	if !ok {
+		return
+	}
+	delete(s.nodeConns, node)
+	if err := conn.Close(); err != nil {
+		s.logger.Warn("Failed to close connection to node %s: %v", node, err)
+	}
+}
+
+// WatchNodes starts service discovery and keeps the ring in sync with the
+// discovered cache nodes.
+//
+// Each change reported by d is applied with UpdateNodes. Discoveries that
+// implement discovery.DetailedServiceDiscovery contribute node weights;
+// others add every node with the default weight. The caller stops d to end
+// the watch.
+//
+// Parameters:
+//   - ctx: Context for the discovery's lifetime
+//   - d: The service discovery to watch
+//
+// Returns:
+//   - error: Error if the discovery fails to start
+//
+// Example:
+//
+//	d, err := discovery.NewK8sServiceDiscovery(discovery.Config{
+//	    Namespace:   "default",
+//	    ServiceName: "yao-oracle-node",
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	if err := server.WatchNodes(ctx, d); err != nil {
+//	    return err
+//	}
+//	defer d.Stop()
+func (s *Server) WatchNodes(ctx context.Context, d discovery.ServiceDiscovery) error {
+	return d.Start(ctx, func(addresses []string) {
+		if detailed, ok := d.(discovery.DetailedServiceDiscovery); ok {
+			s.UpdateNodes(detailed.GetEndpointDetails())
+			return
+		}
+		s.SetNodes(addresses)
+	})
+}
@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/eggybyte-technology/yao-oracle/core/discovery"
+)
+
+func nodeEndpoints(ips ...string) *corev1.Endpoints {
+	subset := corev1.EndpointSubset{Ports: []corev1.EndpointPort{{Name: "grpc", Port: 7070}}}
+	for _, ip := range ips {
+		subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{IP: ip})
+	}
+	return &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "yao-oracle-node", Namespace: "default"},
+		Subsets:    []corev1.EndpointSubset{subset},
+	}
+}
+
+// waitForNodes waits until the ring holds exactly the given nodes.
+func waitForNodes(t *testing.T, s *Server, want ...string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.RLock()
+		nodes := s.ring.Nodes()
+		clients := len(s.nodeClients)
+		s.mu.RUnlock()
+
+		slices.Sort(nodes)
+		if slices.Equal(nodes, want) && clients == len(want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ring nodes = %v with %d clients, want %v", nodes, clients, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchNodesFollowsDiscovery(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewClientset(nodeEndpoints("10.0.0.1", "10.0.0.2"))
+	d := discovery.NewK8sServiceDiscoveryWithClient(clientset, discovery.Config{
+		Namespace:   "default",
+		ServiceName: "yao-oracle-node",
+	})
+	defer d.Stop()
+
+	s := NewServer(nil)
+	defer s.Stop()
+	if err := s.WatchNodes(ctx, d); err != nil {
+		t.Fatalf("WatchNodes: %v", err)
+	}
+	waitForNodes(t, s, "10.0.0.1:7070", "10.0.0.2:7070")
+
+	s.mu.RLock()
+	kept := s.nodeConns["10.0.0.1:7070"]
+	s.mu.RUnlock()
+
+	endpoints := clientset.CoreV1().Endpoints("default")
+	if _, err := endpoints.Update(ctx, nodeEndpoints("10.0.0.1", "10.0.0.3"), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update endpoints: %v", err)
+	}
+	waitForNodes(t, s, "10.0.0.1:7070", "10.0.0.3:7070")
+
+	// Nodes present before and after keep their connection
+	s.mu.RLock()
+	_, removedConn := s.nodeConns["10.0.0.2:7070"]
+	same := s.nodeConns["10.0.0.1:7070"] == kept
+	s.mu.RUnlock()
+	if removedConn {
+		t.Error("connection to the removed node is still open")
+	}
+	if !same {
+		t.Error("connection to a node that stayed was replaced")
+	}
+}
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// keyMove is one scanned entry to copy from a source node to other nodes.
+type keyMove struct {
+	// entry is the entry as read from the source node
+	entry *oraclev1.MigrationEntry
+
+	// key is the key the entry is stored under on the targets; it differs
+	// from entry.Key when the key encoding changes
+	key string
+
+	// targets are the nodes that receive a copy
+	targets []string
+
+	// keepSource leaves the source copy in place, for example because the
+	// source still replicates the key
+	keepSource bool
+}
+
+// moveResult counts the outcome of moveKeys.
+type moveResult struct {
+	imported int64
+	skipped  int64
+
+	// rolledBack is the number of moves undone because the source copy
+	// changed or was deleted while it was being copied
+	rolledBack int64
+}
+
+// moveKeys copies entries from a source node to their targets and then
+// deletes the source copies.
+//
+// Imports never overwrite existing keys, so newer client writes on a target
+// win. The source copy is only deleted if it still holds the scanned value
+// and version, using a conditional Transaction. If it does not, a client
+// changed or deleted the key in the meantime (possibly through another
+// proxy, which migrates the same ranges independently), and every copy this
+// call imported is removed again, also conditionally, so a deleted key is
+// never resurrected and a newer write is never lost. Deletes remove the
+// source copy before the owner's (see Server.Delete), which makes this check
+// sufficient without any state shared between proxies.
+//
+// Parameters:
+//   - ctx: Context for the node calls
+//   - source: Client of the node the entries were scanned from
+//   - moves: Entries to move, each with its targets
+//
+// Returns:
+//   - moveResult: Imported, skipped and rolled back counts
+//   - error: First node error; moves after it are not completed and are
+//     retried by the next scan
+func (s *Server) moveKeys(ctx context.Context, source oraclev1.NodeServiceClient, moves []keyMove) (moveResult, error) {
+	var result moveResult
+
+	byTarget := make(map[string][]int)
+	for i, move := range moves {
+		for _, target := range move.targets {
+			byTarget[target] = append(byTarget[target], i)
+		}
+	}
+
+	// importedOn lists, per move, the targets where this call stored the key
+	importedOn := make([][]string, len(moves))
+	for target, indexes := range byTarget {
+		client := s.nodeClient(target)
+		if client == nil {
+			return result, errors.New("no connection to target node " + target)
+		}
+
+		req := &oraclev1.ImportRequest{Entries: make([]*oraclev1.MigrationEntry, len(indexes))}
+		byKey := make(map[string]int, len(indexes))
+		for j, i := range indexes {
+			entry := moves[i].entry
+			req.Entries[j] = &oraclev1.MigrationEntry{
+				Key:       moves[i].key,
+				Value:     entry.Value,
+				TtlMs:     entry.TtlMs,
+				Timestamp: entry.Timestamp,
+			}
+			byKey[moves[i].key] = i
+		}
+
+		resp, err := client.Import(ctx, req)
+		if err != nil {
+			return result, err
+		}
+		result.imported += int64(resp.Imported)
+		result.skipped += int64(resp.Skipped)
+		for _, key := range resp.ImportedKeys {
+			if i, ok := byKey[key]; ok {
+				importedOn[i] = append(importedOn[i], target)
+			}
+		}
+	}
+
+	for i, move := range moves {
+		if move.keepSource {
+			continue
+		}
+
+		deleted, err := deleteIfUnchanged(ctx, source, move.entry.Key, move.entry)
+		if err != nil {
+			return result, err
+		}
+		if deleted {
+			continue
+		}
+
+		// The source copy changed or is gone: undo this call's imports
+		for _, target := range importedOn[i] {
+			client := s.nodeClient(target)
+			if client == nil {
+				return result, errors.New("no connection to target node " + target)
+			}
+			if _, err := deleteIfUnchanged(ctx, client, move.key, move.entry); err != nil {
+				return result, err
+			}
+		}
+		result.rolledBack++
+	}
+
+	return result, nil
+}
+
+// deleteIfUnchanged deletes key from a node only if it still holds the value
+// and version of entry, and reports whether it did.
+func deleteIfUnchanged(ctx context.Context, client oraclev1.NodeServiceClient, key string, entry *oraclev1.MigrationEntry) (bool, error) {
+	resp, err := client.Transaction(ctx, &oraclev1.TransactionRequest{
+		Checks: []*oraclev1.TxnCheck{
+			{Key: key, Type: oraclev1.TxnCheckType_TXN_CHECK_TYPE_VERSION_EQUALS, Version: entry.Timestamp},
+			{Key: key, Type: oraclev1.TxnCheckType_TXN_CHECK_TYPE_VALUE_EQUALS, Value: entry.Value},
+		},
+		Mutations: []*oraclev1.TxnMutation{{Key: key, Delete: true}},
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"context"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// DefaultBoundedLoadCopyTTL is how long an overflow copy lives when
+// PlacementConfig.BoundedLoadCopyTTLSeconds is not set.
+const DefaultBoundedLoadCopyTTL = time.Second
+
+// selectReadNode selects the node that serves a Get of a key in a
+// non-replicated namespace and records the request on it.
+//
+// With boundedLoadEpsilon set, the placement's GetNode skips an owner that
+// is above its load bound and returns the next node clockwise with room.
+// The key is only stored on its owner, so that node serves the read from a
+// short-lived overflow copy: on a miss the read goes to the owner, and the
+// value is then copied to the overflow node (see fillOverflowCopy). Writes
+// always go to the owner and drop the copy (see dropOverflowCopy).
+//
+// A copy may outlive a write when the load figures moved the key's reads to
+// another node in between, when the drop fails, or when another proxy wrote
+// the key, so overflow reads can be stale for at most the copy TTL, like
+// near cache reads.
+//
+// Returns:
+//   - string: The key's owner (see ownerOf)
+//   - string: The node to read from; differs from the owner only when
+//     bounded loads divert the read to an overflow copy
+func (s *Server) selectReadNode(key string) (string, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	routingKey := kv.RoutingKey(key)
+	owner := ownerOn(s.ring, routingKey)
+	node := owner
+	if s.placement.BoundedLoadEpsilon > 0 {
+		node = s.ring.GetNode(routingKey)
+	}
+
+	if counter, ok := s.nodeRequests[node]; ok {
+		counter.Add(1)
+	}
+	return owner, node
+}
+
+// overflowNode returns the node holding the overflow copy of a key owned by
+// owner, or "" if the key's reads are not diverted.
+func (s *Server) overflowNode(key, owner string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.placement.BoundedLoadEpsilon <= 0 {
+		return ""
+	}
+	if node := s.ring.GetNode(kv.RoutingKey(key)); node != owner {
+		return node
+	}
+	return ""
+}
+
+// overflowCopyTTL returns the configured lifetime of overflow copies.
+func (s *Server) overflowCopyTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.placement.BoundedLoadCopyTTLSeconds > 0 {
+		return time.Duration(s.placement.BoundedLoadCopyTTLSeconds) * time.Second
+	}
+	return DefaultBoundedLoadCopyTTL
+}
+
+// getOverflowCopy reads the overflow copy of a key from node.
+//
+// Returns:
+//   - *oraclev1.GetResponse: The copy, or nil if node holds none or cannot
+//     be reached; the read then goes to the owner
+func (s *Server) getOverflowCopy(ctx context.Context, node, key string) *oraclev1.GetResponse {
+	if s.breakers.ejected(node) {
+		return nil
+	}
+
+	client := s.nodeClient(node)
+	if client == nil {
+		return nil
+	}
+
+	resp, err := client.Get(ctx, &oraclev1.GetRequest{Key: key})
+	if err != nil || !resp.Found {
+		return nil
+	}
+	return resp
+}
+
+// fillOverflowCopy copies a value read from the owner to the overflow node
+// in the background. The copy carries the owner's write timestamp, so it
+// never replaces a newer copy, and expires after the copy TTL or with the
+// original, whichever comes first.
+func (s *Server) fillOverflowCopy(node, key string, resp *oraclev1.GetResponse) {
+	client := s.nodeClient(node)
+	if client == nil || !resp.Found {
+		return
+	}
+
+	ttl := s.overflowCopyTTL()
+	if resp.TtlMs > 0 {
+		ttl = min(ttl, time.Duration(resp.TtlMs)*time.Millisecond)
+	}
+
+	req := &oraclev1.SetRequest{
+		Key:       key,
+		Value:     resp.Value,
+		Timestamp: resp.Timestamp,
+		// Round up; the copy may outlive the original by under a second
+		Ttl: int32((ttl + time.Second - 1) / time.Second),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaTimeout)
+		defer cancel()
+
+		if _, err := client.Set(ctx, req); err != nil {
+			s.logger.Warn("Failed to store overflow copy of %q on %s: %v", key, node, err)
+		}
+	}()
+}
+
+// dropOverflowCopy removes the overflow copy of a key after it was written
+// on its owner, so reads through this proxy do not return the old value.
+// A failed drop is logged; the copy then expires with its TTL.
+func (s *Server) dropOverflowCopy(ctx context.Context, owner, key string) {
+	node := s.overflowNode(key, owner)
+	if node == "" {
+		return
+	}
+
+	client := s.nodeClient(node)
+	if client == nil {
+		return
+	}
+
+	if _, err := client.Delete(ctx, &oraclev1.DeleteRequest{Key: key}); err != nil {
+		s.logger.Warn("Failed to drop overflow copy of %q on %s: %v", key, node, err)
+	}
+}
+
+// ownerOn returns the load-independent owner of a routing key.
+func ownerOn(ring hash.Placement, routingKey string) string {
+	if la, ok := ring.(hash.LoadAware); ok {
+		return la.Owner(routingKey)
+	}
+	return ring.GetNode(routingKey)
+}
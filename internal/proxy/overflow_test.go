@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// newBoundedProxy returns a proxy with bounded loads whose owner of key is
+// far above its bound, together with the owner and the overflow node.
+func newBoundedProxy(t *testing.T, addrs []string, key string) (*Server, string, string) {
+	t.Helper()
+
+	s := newTestProxy(t, addrs)
+	if err := s.SetPlacement(&config.PlacementConfig{BoundedLoadEpsilon: 0.25}); err != nil {
+		t.Fatalf("SetPlacement: %v", err)
+	}
+
+	owner := s.ownerOf(key)
+	s.ring.(hash.LoadAware).SetLoads(map[string]float64{owner: 1000})
+
+	_, readNode := s.selectReadNode(key)
+	if readNode == owner {
+		t.Fatalf("read of %q was not diverted from its overloaded owner %s", key, owner)
+	}
+	return s, owner, readNode
+}
+
+func TestFetchServesOverloadedOwnerFromOverflowCopy(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	key := kv.NamespaceKey("sessions", "hot")
+	s, owner, readNode := newBoundedProxy(t, addrs, key)
+	ns := &config.Namespace{Name: "sessions"}
+	ctx := context.Background()
+
+	setVersioned(t, clients[owner], key, "v1", time.Now().UnixNano())
+
+	// The first read misses the overflow node and fills it from the owner
+	resp, err := s.fetch(ctx, ns, key)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if !resp.Found || string(resp.Value) != "v1" || resp.Node != owner {
+		t.Fatalf("first fetch = %q from %s (found %v), want v1 from owner %s", resp.Value, resp.Node, resp.Found, owner)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if value, found := getValue(t, clients[readNode], key); found && value == "v1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("overflow copy of %q never reached %s", key, readNode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Later reads are served by the overflow node
+	resp, err = s.fetch(ctx, ns, key)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if !resp.Found || string(resp.Value) != "v1" || resp.Node != readNode {
+		t.Errorf("second fetch = %q from %s (found %v), want v1 from overflow node %s", resp.Value, resp.Node, resp.Found, readNode)
+	}
+}
+
+func TestOverflowCopyIsShortLived(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	key := kv.NamespaceKey("sessions", "hot")
+	s, owner, readNode := newBoundedProxy(t, addrs, key)
+
+	setVersioned(t, clients[owner], key, "v1", time.Now().UnixNano())
+	resp, err := clients[owner].Get(context.Background(), &oraclev1.GetRequest{Key: key})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	s.fillOverflowCopy(readNode, key, resp)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		copyResp, err := clients[readNode].Get(context.Background(), &oraclev1.GetRequest{Key: key})
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if copyResp.Found {
+			if copyResp.TtlMs <= 0 || copyResp.TtlMs > DefaultBoundedLoadCopyTTL.Milliseconds() {
+				t.Errorf("overflow copy TTL = %dms, want at most %v", copyResp.TtlMs, DefaultBoundedLoadCopyTTL)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("overflow copy of %q never reached %s", key, readNode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWriteDropsOverflowCopy(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	key := kv.NamespaceKey("sessions", "hot")
+	s, owner, readNode := newBoundedProxy(t, addrs, key)
+
+	base := time.Now().UnixNano()
+	setVersioned(t, clients[readNode], key, "v1", base)
+	setVersioned(t, clients[owner], key, "v2", base+1)
+
+	s.dropOverflowCopy(context.Background(), owner, key)
+
+	if value, found := getValue(t, clients[readNode], key); found {
+		t.Errorf("overflow node %s still holds %q after the write", readNode, value)
+	}
+	if value, _ := getValue(t, clients[owner], key); value != "v2" {
+		t.Errorf("owner holds %q, want v2", value)
+	}
+}
+
+func TestWritesAndOwnerIgnoreLoads(t *testing.T) {
+	addrs, _ := startNodes(t, 3)
+	key := kv.NamespaceKey("sessions", "hot")
+	s, owner, _ := newBoundedProxy(t, addrs, key)
+
+	if got := s.selectNode(key); got != owner {
+		t.Errorf("selectNode with overloaded owner = %s, want owner %s", got, owner)
+	}
+	if got := s.ownerOf(key); got != owner {
+		t.Errorf("ownerOf with overloaded owner = %s, want %s", got, owner)
+	}
+}
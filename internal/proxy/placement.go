@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+)
+
+// SetPlacement configures the algorithm used to map keys to cache nodes.
+//
+// If cache nodes are already registered, the placement is rebuilt with the
+// same nodes and weights. Changing the placement remaps most keys, so it
+// should be rolled out to all proxy instances at the same time.
+//
+// Parameters:
+//   - cfg: Placement settings; nil selects the default ketama ring with CRC32
+//
+// Returns:
+//   - error: Error if the settings are invalid; the current placement is kept
+func (s *Server) SetPlacement(cfg *config.PlacementConfig) error {
+	var placement config.PlacementConfig
+	if cfg != nil {
+		placement = *cfg
+	}
+
+	if err := config.ValidatePlacementConfig(&placement); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if placement == s.placement {
+		return nil
+	}
+
+	// A new load bound or copy TTL does not change key ownership; apply it in place
+	previous := s.placement
+	s.placement = placement
+	previous.BoundedLoadEpsilon = placement.BoundedLoadEpsilon
+	previous.BoundedLoadCopyTTLSeconds = placement.BoundedLoadCopyTTLSeconds
+	if la, ok := s.ring.(hash.LoadAware); ok && previous == placement {
+		la.SetLoadBound(placement.BoundedLoadEpsilon)
+		s.logger.Info("Bounded-load epsilon set to %g", placement.BoundedLoadEpsilon)
+		return nil
+	}
+
+	ring := s.newPlacement()
+	for _, node := range s.ring.Nodes() {
+		ring.AddWeightedNode(node, s.ring.Weight(node))
+	}
+	s.ring = ring
+
+	if ring.Size() > 0 {
+		s.logger.Warn("Placement changed to %s; most keys now map to different nodes", describePlacement(placement))
+	} else {
+		s.logger.Info("Placement set to %s", describePlacement(placement))
+	}
+	return nil
+}
+
+// newPlacement creates an empty placement from the configured settings.
+// The caller must hold the lock.
+func (s *Server) newPlacement() hash.Placement {
+	p, err := hash.NewPlacement(hash.PlacementConfig{
+		Algorithm:    s.placement.Algorithm,
+		HashFunction: s.placement.HashFunction,
+		VirtualNodes: s.placement.VirtualNodes,
+		TableSize:    s.placement.TableSize,
+		LoadBound:    s.placement.BoundedLoadEpsilon,
+	})
+	if err != nil {
+		// SetPlacement validates the settings, so this is unreachable in practice
+		s.logger.Error("Invalid placement settings, using default ring: %v", err)
+		return hash.NewRing(hash.DefaultVirtualNodes)
+	}
+	return p
+}
+
+// describePlacement formats placement settings for logging.
+func describePlacement(cfg config.PlacementConfig) string {
+	algorithm := cfg.Algorithm
+	if algorithm == "" {
+		algorithm = hash.AlgorithmKetama
+	}
+	hashFunction := cfg.HashFunction
+	if hashFunction == "" {
+		hashFunction = hash.HashCRC32
+	}
+	if cfg.BoundedLoadEpsilon > 0 {
+		return fmt.Sprintf("%s/%s (bounded load, epsilon %g)", algorithm, hashFunction, cfg.BoundedLoadEpsilon)
+	}
+	return fmt.Sprintf("%s/%s", algorithm, hashFunction)
+}
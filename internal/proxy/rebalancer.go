@@ -0,0 +1,564 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	"github.com/eggybyte-technology/yao-oracle/core/utils"
+)
+
+// Default key migration settings, used when RebalanceConfig leaves them unset.
+const (
+	DefaultRebalanceKeysPerSecond = 1000
+	DefaultRebalanceBatchSize     = 100
+	DefaultRebalanceMaxAttempts   = 10
+)
+
+// rebalanceRetryInterval is how long a failed old owner waits before its
+// scan is resumed.
+const rebalanceRetryInterval = 5 * time.Second
+
+// rebalanceSource tracks the migration of all moved ranges of one old owner.
+type rebalanceSource struct {
+	node     string
+	ranges   []hash.HashRange
+	cursor   string
+	done     bool
+	migrated int64
+	attempts int
+	lastErr  string
+	retryAt  time.Time
+
+	// generation changes whenever new ranges are planned, so that a scan
+	// started before the change does not mark the source as done
+	generation int
+}
+
+// contains reports whether a key hash falls into one of the source's ranges.
+func (src *rebalanceSource) contains(h uint64) bool {
+	i := sort.Search(len(src.ranges), func(i int) bool {
+		return src.ranges[i].Start > h
+	}) - 1
+	return i >= 0 && src.ranges[i].Contains(h)
+}
+
+// rebalancer migrates keys to their new owner after ring membership changes.
+//
+// For every old owner it streams the moved hash ranges with ScanRange,
+// imports each entry into the key's current owner (never overwriting newer
+// client writes) and deletes the old copy if it is unchanged. Progress is
+// tracked as a cursor per old owner, so a paused or failed migration
+// resumes where it stopped. While a range is pending, reads that miss on
+// the new owner fall back to the old owner.
+//
+// Every proxy migrates the same ranges independently. Correctness across
+// proxies relies on the conditional deletes in Server.moveKeys, not on the
+// tombstones below, which only save work within one proxy.
+type rebalancer struct {
+	server *Server
+	logger *utils.Logger
+
+	mu            sync.Mutex
+	cfg           config.RebalanceConfig
+	sources       map[string]*rebalanceSource
+	hashFunction  string
+	keyHash       func(key string) uint64
+	movedFraction float64
+	startedAt     time.Time
+	paused        bool
+
+	// tombstones holds keys deleted by clients through this proxy during
+	// the migration, so that in-flight batches skip them early
+	tombstones map[string]struct{}
+
+	// wake signals the worker that there is new work or it was resumed
+	wake chan struct{}
+
+	scanned  atomic.Int64
+	migrated atomic.Int64
+	skipped  atomic.Int64
+	errors   atomic.Int64
+}
+
+// newRebalancer creates an idle rebalancer for the server.
+func newRebalancer(s *Server) *rebalancer {
+	return &rebalancer{
+		server:     s,
+		logger:     utils.NewLogger("rebalancer"),
+		sources:    make(map[string]*rebalanceSource),
+		tombstones: make(map[string]struct{}),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// configure applies rebalance settings; nil selects the defaults.
+func (r *rebalancer) configure(cfg *config.RebalanceConfig) {
+	var effective config.RebalanceConfig
+	if cfg != nil {
+		effective = *cfg
+	}
+	if effective.MaxKeysPerSecond <= 0 {
+		effective.MaxKeysPerSecond = DefaultRebalanceKeysPerSecond
+	}
+	if effective.BatchSize <= 0 {
+		effective.BatchSize = DefaultRebalanceBatchSize
+	}
+	if effective.MaxAttempts <= 0 {
+		effective.MaxAttempts = DefaultRebalanceMaxAttempts
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg = effective
+}
+
+// plan schedules the moved ranges of an ownership diff for migration.
+//
+// Ranges are merged into any migration still in progress. A source that
+// gains new ranges restarts its scan from the beginning; re-importing keys
+// is harmless because imports never overwrite.
+func (r *rebalancer) plan(diff *hash.OwnershipDiff, ring *hash.Ring, hashFunction string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cfg.Disabled {
+		r.logger.Info("Key migration disabled; %.2f%% of the keyspace changed owner", diff.MovedFraction*100)
+		return
+	}
+
+	if r.active() && hashFunction != r.hashFunction {
+		r.logger.Warn("Hash function changed during migration; abandoning pending ranges")
+		r.reset()
+	}
+	if !r.active() {
+		r.reset()
+		r.startedAt = time.Now()
+	}
+	r.hashFunction = hashFunction
+	r.keyHash = ring.KeyHash
+
+	for _, move := range diff.Moves {
+		if move.From == "" {
+			continue
+		}
+		src, exists := r.sources[move.From]
+		if !exists {
+			src = &rebalanceSource{node: move.From}
+			r.sources[move.From] = src
+		}
+		src.ranges = append(src.ranges, move.HashRange)
+		src.cursor = ""
+		src.done = false
+		src.attempts = 0
+		src.generation++
+	}
+	for _, src := range r.sources {
+		sort.Slice(src.ranges, func(i, j int) bool {
+			return src.ranges[i].Start < src.ranges[j].Start
+		})
+	}
+	r.movedFraction += diff.MovedFraction
+
+	r.logger.Info("Planned migration of %.2f%% of the keyspace from %d nodes",
+		diff.MovedFraction*100, len(r.sources))
+	r.signal()
+}
+
+// run is the migration worker loop. It returns when the server is stopped.
+func (r *rebalancer) run() {
+	ticker := time.NewTicker(rebalanceRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.server.stopCh:
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+
+		for {
+			src := r.next()
+			if src == nil {
+				break
+			}
+			r.migrate(src)
+		}
+	}
+}
+
+// next returns the next source that is ready to be migrated, or nil if the
+// rebalancer is paused or no source is ready. It also finishes the
+// migration once every source is done or abandoned.
+func (r *rebalancer) next() *rebalanceSource {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.paused || len(r.sources) == 0 {
+		return nil
+	}
+
+	pending := false
+	now := time.Now()
+	for _, node := range r.sourceOrder() {
+		src := r.sources[node]
+		if src.done {
+			continue
+		}
+		if src.attempts >= r.cfg.MaxAttempts {
+			continue
+		}
+		pending = true
+		if now.Before(src.retryAt) {
+			continue
+		}
+		src.attempts++
+		return src
+	}
+
+	if !pending {
+		r.logger.Success("Migration finished in %v: %d keys migrated, %d skipped, %d errors",
+			time.Since(r.startedAt).Round(time.Second), r.migrated.Load(), r.skipped.Load(), r.errors.Load())
+		for _, src := range r.sources {
+			if !src.done {
+				r.logger.Error("Abandoned remaining keys on %s after %d attempts: %s", src.node, src.attempts, src.lastErr)
+			}
+		}
+		r.reset()
+	}
+	return nil
+}
+
+// migrate streams the moved ranges of one old owner to the new owners,
+// starting after the source's cursor.
+func (r *rebalancer) migrate(src *rebalanceSource) {
+	r.mu.Lock()
+	node := src.node
+	ranges := make([]*oraclev1.KeyHashRange, len(src.ranges))
+	for i, hr := range src.ranges {
+		ranges[i] = &oraclev1.KeyHashRange{Start: hr.Start, End: hr.End}
+	}
+	cursor := src.cursor
+	generation := src.generation
+	hashFunction := r.hashFunction
+	batchSize := r.cfg.BatchSize
+	keysPerSecond := r.cfg.MaxKeysPerSecond
+	r.mu.Unlock()
+
+	client := r.server.nodeClient(node)
+	if client == nil {
+		r.fail(src, "no connection to node")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.server.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := client.ScanRange(ctx, &oraclev1.ScanRangeRequest{
+		Ranges:       ranges,
+		HashFunction: hashFunction,
+		After:        cursor,
+		BatchSize:    int32(batchSize),
+	})
+	if err != nil {
+		r.fail(src, err.Error())
+		return
+	}
+
+	for {
+		batch, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			r.fail(src, err.Error())
+			return
+		}
+
+		started := time.Now()
+		if err := r.moveBatch(ctx, client, node, batch.Entries); err != nil {
+			r.fail(src, err.Error())
+			return
+		}
+
+		r.mu.Lock()
+		if src.generation != generation {
+			// New ranges were planned; the scan restarts from the beginning
+			r.mu.Unlock()
+			return
+		}
+		if n := len(batch.Entries); n > 0 {
+			src.cursor = batch.Entries[n-1].Key
+		}
+		paused := r.paused
+		if paused {
+			// Pausing is not a failed attempt
+			src.attempts--
+		}
+		r.mu.Unlock()
+
+		if paused {
+			r.logger.Info("Migration from %s paused", node)
+			return
+		}
+
+		// Throttle to the configured key rate
+		budget := time.Duration(float64(len(batch.Entries)) / float64(keysPerSecond) * float64(time.Second))
+		if wait := budget - time.Since(started); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if src.generation != generation {
+		return
+	}
+	src.done = true
+	src.lastErr = ""
+	r.logger.Info("Migrated all moved ranges from %s (%d keys)", node, src.migrated)
+}
+
+// moveBatch imports one scanned batch into the current owners and deletes
+// the old copies that are still unchanged (see Server.moveKeys).
+func (r *rebalancer) moveBatch(ctx context.Context, source oraclev1.NodeServiceClient, node string, entries []*oraclev1.MigrationEntry) error {
+	r.scanned.Add(int64(len(entries)))
+
+	live := make([]*oraclev1.MigrationEntry, 0, len(entries))
+	r.mu.Lock()
+	for _, entry := range entries {
+		if _, deleted := r.tombstones[entry.Key]; !deleted {
+			live = append(live, entry)
+		}
+	}
+	r.mu.Unlock()
+
+	// Owners are resolved outside r.mu: SetNodes holds the server lock
+	// while planning, so the two locks must never be nested this way
+	moves := make([]keyMove, 0, len(live))
+	for _, entry := range live {
+		target := r.server.ownerOf(entry.Key)
+		if target == "" || target == node {
+			// The key is owned by the source again; nothing to move
+			continue
+		}
+		moves = append(moves, keyMove{
+			entry:   entry,
+			key:     entry.Key,
+			targets: []string{target},
+			// The source keeps its copy while it still replicates the key
+			keepSource: r.server.isReplica(entry.Key, node),
+		})
+	}
+
+	result, err := r.server.moveKeys(ctx, source, moves)
+	r.migrated.Add(result.imported)
+	r.skipped.Add(result.skipped + result.rolledBack)
+
+	r.mu.Lock()
+	if src := r.sources[node]; src != nil {
+		src.migrated += result.imported
+	}
+	r.mu.Unlock()
+
+	return err
+}
+
+// fail records a failed attempt; the source is retried from its cursor.
+func (r *rebalancer) fail(src *rebalanceSource, msg string) {
+	r.errors.Add(1)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	src.lastErr = msg
+	src.retryAt = time.Now().Add(rebalanceRetryInterval)
+	r.logger.Warn("Migration from %s failed (attempt %d/%d): %s", src.node, src.attempts, r.cfg.MaxAttempts, msg)
+}
+
+// fallbackNode returns the old owner of a key whose range has not been
+// migrated yet, or "" if the key is not pending migration.
+func (r *rebalancer) fallbackNode(key string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.active() {
+		return ""
+	}
+
+	h := r.keyHash(kv.RoutingKey(key))
+	for _, node := range r.sourceOrder() {
+		src := r.sources[node]
+		if !src.done && src.contains(h) {
+			return node
+		}
+	}
+	return ""
+}
+
+// forget records a client delete so that the key is not migrated afterwards.
+func (r *rebalancer) forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active() {
+		r.tombstones[key] = struct{}{}
+	}
+}
+
+// setPaused pauses or resumes the migration.
+func (r *rebalancer) setPaused(paused bool) {
+	r.mu.Lock()
+	r.paused = paused
+	r.mu.Unlock()
+
+	if !paused {
+		r.signal()
+	}
+}
+
+// status reports the migration progress.
+func (r *rebalancer) status() *oraclev1.ProxyRebalanceResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resp := &oraclev1.ProxyRebalanceResponse{
+		Active:        r.active(),
+		Paused:        r.paused,
+		MovedFraction: r.movedFraction,
+		KeysScanned:   r.scanned.Load(),
+		KeysMigrated:  r.migrated.Load(),
+		KeysSkipped:   r.skipped.Load(),
+		Errors:        r.errors.Load(),
+	}
+	if !r.startedAt.IsZero() {
+		resp.StartedAt = r.startedAt.Unix()
+	}
+
+	for _, node := range r.sourceOrder() {
+		src := r.sources[node]
+		resp.Sources = append(resp.Sources, &oraclev1.RebalanceSource{
+			Node:         src.node,
+			Ranges:       int32(len(src.ranges)),
+			Done:         src.done,
+			Cursor:       src.cursor,
+			KeysMigrated: src.migrated,
+			Attempts:     int32(src.attempts),
+			LastError:    src.lastErr,
+		})
+	}
+	return resp
+}
+
+// active reports whether any ranges are pending. The caller must hold the lock.
+func (r *rebalancer) active() bool {
+	return len(r.sources) > 0
+}
+
+// reset clears all migration state. The caller must hold the lock.
+// Counters are cumulative and are not reset.
+func (r *rebalancer) reset() {
+	r.sources = make(map[string]*rebalanceSource)
+	r.tombstones = make(map[string]struct{})
+	r.movedFraction = 0
+	r.startedAt = time.Time{}
+}
+
+// sourceOrder returns the source nodes in a stable order.
+// The caller must hold the lock.
+func (r *rebalancer) sourceOrder() []string {
+	nodes := make([]string, 0, len(r.sources))
+	for node := range r.sources {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// signal wakes the worker without blocking.
+func (r *rebalancer) signal() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Rebalance reports, pauses or resumes the migration of keys that changed
+// owner after a ring membership change.
+func (s *Server) Rebalance(ctx context.Context, req *oraclev1.ProxyRebalanceRequest) (*oraclev1.ProxyRebalanceResponse, error) {
+	switch req.Action {
+	case oraclev1.RebalanceAction_REBALANCE_ACTION_PAUSE:
+		s.rebalancer.setPaused(true)
+		s.logger.Info("Key migration paused")
+	case oraclev1.RebalanceAction_REBALANCE_ACTION_RESUME:
+		s.rebalancer.setPaused(false)
+		s.logger.Info("Key migration resumed")
+	}
+	return s.rebalancer.status(), nil
+}
+
+// SetRebalanceConfig applies key migration settings; nil selects defaults.
+func (s *Server) SetRebalanceConfig(cfg *config.RebalanceConfig) {
+	s.rebalancer.configure(cfg)
+}
+
+// nodeClient returns the gRPC client for a node, or nil if not connected.
+func (s *Server) nodeClient(node string) oraclev1.NodeServiceClient {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nodeClients[node]
+}
+
+// ownerOf returns the owner of a key without recording load.
+//
+// The owner only depends on the key and the ring membership (never on
+// bounded loads), so every proxy resolves the same node.
+func (s *Server) ownerOf(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return ownerOn(s.ring, kv.RoutingKey(key))
+}
+
+// getFromFallback looks a key up on its old owner while its range is
+// still being migrated.
+//
+// Returns:
+//   - *oraclev1.GetResponse: The old owner's response if the key was found there, nil otherwise
+//   - string: The old owner that served the key
+func (s *Server) getFromFallback(ctx context.Context, key, targetNode string) (*oraclev1.GetResponse, string) {
+	fallback := s.rebalancer.fallbackNode(key)
+	if fallback == "" || fallback == targetNode {
+		return nil, ""
+	}
+
+	client := s.nodeClient(fallback)
+	if client == nil {
+		return nil, ""
+	}
+
+	resp, err := client.Get(ctx, &oraclev1.GetRequest{Key: key})
+	if err != nil || !resp.Found {
+		return nil, ""
+	}
+	return resp, fallback
+}
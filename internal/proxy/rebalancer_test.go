@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// scaleOut writes keys to a proxy routing to the first two nodes, adds the
+// third node and returns the keys that changed owner with their old owner.
+// The migration is planned but not run.
+func scaleOut(t *testing.T, addrs []string, clients map[string]oraclev1.NodeServiceClient, keys []string) (*Server, map[string]string) {
+	t.Helper()
+
+	s := newTestProxy(t, addrs[:2])
+	base := time.Now().UnixNano()
+	previous := make(map[string]string, len(keys))
+	for i, key := range keys {
+		previous[key] = s.ownerOf(key)
+		setVersioned(t, clients[previous[key]], key, "v-"+key, base+int64(i))
+	}
+
+	s.SetNodes(addrs)
+
+	moved := make(map[string]string)
+	for _, key := range keys {
+		if owner := s.ownerOf(key); owner != previous[key] {
+			moved[key] = previous[key]
+		}
+	}
+	if len(moved) == 0 {
+		t.Fatal("no key changed owner when a node was added")
+	}
+	return s, moved
+}
+
+// migrateAll runs the planned migration to completion.
+func migrateAll(r *rebalancer) {
+	for src := r.next(); src != nil; src = r.next() {
+		r.migrate(src)
+	}
+}
+
+func rebalanceKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = kv.NamespaceKey("sessions", fmt.Sprintf("session-%d", i))
+	}
+	return keys
+}
+
+func TestRebalancerMovesKeys(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	keys := rebalanceKeys(300)
+	s, moved := scaleOut(t, addrs, clients, keys)
+
+	migrateAll(s.rebalancer)
+
+	for _, key := range keys {
+		owner := s.ownerOf(key)
+		if value, found := getValue(t, clients[owner], key); !found || value != "v-"+key {
+			t.Fatalf("owner %s of %q holds %q (found %v), want %q", owner, key, value, found, "v-"+key)
+		}
+		if from, ok := moved[key]; ok {
+			if _, found := getValue(t, clients[from], key); found {
+				t.Fatalf("old owner %s still holds migrated key %q", from, key)
+			}
+		}
+	}
+
+	if s.rebalancer.active() {
+		t.Error("rebalancer still active after all sources were migrated")
+	}
+	if got := s.rebalancer.migrated.Load(); got != int64(len(moved)) {
+		t.Errorf("migrated %d keys, want %d", got, len(moved))
+	}
+}
+
+func TestRebalancerFallbackReads(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	keys := rebalanceKeys(100)
+	s, moved := scaleOut(t, addrs, clients, keys)
+	ns := &config.Namespace{Name: "sessions"}
+	ctx := context.Background()
+
+	for key, from := range moved {
+		if got := s.rebalancer.fallbackNode(key); got != from {
+			t.Fatalf("fallbackNode(%q) = %q, want old owner %s", key, got, from)
+		}
+
+		// The new owner misses, so the read falls back to the old owner
+		resp, err := s.fetch(ctx, ns, key)
+		if err != nil {
+			t.Fatalf("fetch %q: %v", key, err)
+		}
+		if !resp.Found || string(resp.Value) != "v-"+key || resp.Node != from {
+			t.Fatalf("fetch %q = %q from %s (found %v), want it from old owner %s", key, resp.Value, resp.Node, resp.Found, from)
+		}
+	}
+
+	migrateAll(s.rebalancer)
+
+	for key := range moved {
+		if got := s.rebalancer.fallbackNode(key); got != "" {
+			t.Fatalf("fallbackNode(%q) = %q after the migration, want none", key, got)
+		}
+
+		resp, err := s.fetch(ctx, ns, key)
+		if err != nil {
+			t.Fatalf("fetch %q: %v", key, err)
+		}
+		if owner := s.ownerOf(key); !resp.Found || resp.Node != owner {
+			t.Fatalf("fetch %q after the migration served by %s (found %v), want owner %s", key, resp.Node, resp.Found, owner)
+		}
+	}
+}
+
+func TestRebalancerSkipsDeletedKeys(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	keys := rebalanceKeys(100)
+	s, moved := scaleOut(t, addrs, clients, keys)
+
+	var deleted, from string
+	for deleted, from = range moved {
+		break
+	}
+	s.rebalancer.forget(deleted)
+
+	migrateAll(s.rebalancer)
+
+	if _, found := getValue(t, clients[s.ownerOf(deleted)], deleted); found {
+		t.Errorf("key %q deleted during the migration was imported into its new owner", deleted)
+	}
+	// Client deletes remove the old copy themselves (see Server.Delete)
+	if _, found := getValue(t, clients[from], deleted); !found {
+		t.Errorf("migration deleted the old copy of %q it skipped", deleted)
+	}
+}
+
+func TestMoveKeysDeletesSourceOnlyIfUnchanged(t *testing.T) {
+	tests := []struct {
+		name string
+		// change runs on the source after the entry was scanned
+		change func(t *testing.T, source oraclev1.NodeServiceClient, key string, scanned int64)
+		// wantSource is the value left on the source, "" if none
+		wantSource string
+		// wantTarget is the value left on the target, "" if none
+		wantTarget   string
+		wantRollback int64
+	}{
+		{
+			name:       "unchanged",
+			change:     func(t *testing.T, source oraclev1.NodeServiceClient, key string, scanned int64) {},
+			wantTarget: "v1",
+		},
+		{
+			name: "overwritten",
+			change: func(t *testing.T, source oraclev1.NodeServiceClient, key string, scanned int64) {
+				setVersioned(t, source, key, "v2", scanned+1)
+			},
+			wantSource:   "v2",
+			wantRollback: 1,
+		},
+		{
+			name: "deleted",
+			change: func(t *testing.T, source oraclev1.NodeServiceClient, key string, scanned int64) {
+				if _, err := source.Delete(context.Background(), &oraclev1.DeleteRequest{Key: key}); err != nil {
+					t.Fatalf("delete: %v", err)
+				}
+			},
+			wantRollback: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs, clients := startNodes(t, 2)
+			s := newTestProxy(t, addrs)
+			source, target := clients[addrs[0]], clients[addrs[1]]
+
+			key := kv.NamespaceKey("sessions", "moving")
+			scanned := time.Now().UnixNano()
+			setVersioned(t, source, key, "v1", scanned)
+			tt.change(t, source, key, scanned)
+
+			entry := &oraclev1.MigrationEntry{Key: key, Value: []byte("v1"), Timestamp: scanned}
+			result, err := s.moveKeys(context.Background(), source, []keyMove{{entry: entry, key: key, targets: []string{addrs[1]}}})
+			if err != nil {
+				t.Fatalf("moveKeys: %v", err)
+			}
+			if result.rolledBack != tt.wantRollback {
+				t.Errorf("rolled back %d moves, want %d", result.rolledBack, tt.wantRollback)
+			}
+
+			if value, _ := getValue(t, source, key); value != tt.wantSource {
+				t.Errorf("source holds %q, want %q", value, tt.wantSource)
+			}
+			if value, _ := getValue(t, target, key); value != tt.wantTarget {
+				t.Errorf("target holds %q, want %q", value, tt.wantTarget)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// replicaTimeout bounds replica writes that finish after the quorum was
+// reached, as well as background read repairs.
+const replicaTimeout = 5 * time.Second
+
+// replicaResult is the outcome of a request to a single replica.
+type replicaResult struct {
+	node string
+	resp *oraclev1.GetResponse
+	err  error
+}
+
+// selectReplicas returns up to n distinct nodes for a key in preference
+// order; the first node is the primary returned by selectNode.
+func (s *Server) selectReplicas(key string, n int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	nodes := s.ring.GetNodes(kv.RoutingKey(key), n)
+	for _, node := range nodes {
+		if counter, ok := s.nodeRequests[node]; ok {
+			counter.Add(1)
+		}
+	}
+	return nodes
+}
+
+// readReplicaOrder returns up to n distinct replicas of a key in the order
+// reads should try them. With bounded loads enabled, the first replica
+// within its load bound (see hash.Ring.GetNodeBounded) leads, so a hot key
+// spreads its reads over its replicas. Ownership is not affected: every
+// replica holds the key. Unlike selectReplicas, nothing is counted; reads
+// count the nodes they actually query.
+func (s *Server) readReplicaOrder(key string, n int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	replicas := s.ring.GetNodes(kv.RoutingKey(key), n)
+	la, ok := s.ring.(hash.LoadAware)
+	if !ok || s.placement.BoundedLoadEpsilon <= 0 {
+		return replicas
+	}
+
+	preferred := la.GetNodeBounded(kv.RoutingKey(key), n)
+	for i, node := range replicas {
+		if node == preferred {
+			copy(replicas[1:i+1], replicas[:i])
+			replicas[0] = preferred
+			break
+		}
+	}
+	return replicas
+}
+
+// countRequest adds one request to a node's load counter.
+func (s *Server) countRequest(node string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if counter, ok := s.nodeRequests[node]; ok {
+		counter.Add(1)
+	}
+}
+
+// getReplicated serves a Get for a replicated namespace from r of its n
+// replicas, returning the newest value.
+func (s *Server) getReplicated(ctx context.Context, key string, n, r int) (*oraclev1.ProxyGetResponse, error) {
+	nodeResp, node, err := s.readReplicas(ctx, key, n, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oraclev1.ProxyGetResponse{
+		Found:   nodeResp.Found,
+		Value:   nodeResp.Value,
+		Ttl:     nodeResp.Ttl,
+		Node:    node,
+		Version: nodeResp.Timestamp,
+	}, nil
+}
+
+// setReplicated writes a value to all n replicas of a key and succeeds once
+// w of them acknowledged it.
+func (s *Server) setReplicated(ctx context.Context, key string, value []byte, ttl int32, n, w int) (*oraclev1.ProxySetResponse, error) {
+	replicas := s.selectReplicas(key, n)
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("no cache node available")
+	}
+
+	// The timestamp orders concurrent writes: every replica keeps the newest
+	req := &oraclev1.SetRequest{
+		Key:       key,
+		Value:     value,
+		Ttl:       ttl,
+		Timestamp: time.Now().UnixNano(),
+	}
+
+	// A replica holding a newer value answers but drops the write, which
+	// reads then never return
+	var superseded atomic.Pointer[string]
+	acked, err := s.writeReplicas(ctx, replicas, w, func(ctx context.Context, client oraclev1.NodeServiceClient) error {
+		resp, err := client.Set(ctx, req)
+		if err == nil && !resp.Success {
+			superseded.Store(&resp.Message)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &oraclev1.ProxySetResponse{
+		Success:  true,
+		Node:     replicas[0],
+		Replicas: acked,
+	}
+	if message := superseded.Load(); message != nil {
+		resp.Success = false
+		resp.Message = *message
+	}
+	return resp, nil
+}
+
+// deleteReplicated removes a key from all n replicas and succeeds once w of
+// them acknowledged the delete.
+func (s *Server) deleteReplicated(ctx context.Context, key string, n, w int) (*oraclev1.ProxyDeleteResponse, error) {
+	replicas := s.selectReplicas(key, n)
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("no cache node available")
+	}
+
+	// The legacy copy goes first, like in Delete
+	var existed atomic.Bool
+	existed.Store(s.legacyKeys.delete(ctx, []string{key})[key])
+	acked, err := s.writeReplicas(ctx, replicas, w, func(ctx context.Context, client oraclev1.NodeServiceClient) error {
+		resp, err := client.Delete(ctx, &oraclev1.DeleteRequest{Key: key})
+		if err == nil && resp.Existed {
+			existed.Store(true)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &oraclev1.ProxyDeleteResponse{
+		Success:  true,
+		Existed:  existed.Load(),
+		Node:     replicas[0],
+		Replicas: acked,
+	}, nil
+}
+
+// writeReplicas sends a write to every replica and returns as soon as w of
+// them acknowledged it, or as soon as the quorum can no longer be reached.
+// Writes still in flight complete in the background, bounded by
+// replicaTimeout, so that slow replicas converge too.
+//
+// Returns:
+//   - []string: The replicas that acknowledged the write so far
+//   - error: Error if fewer than w replicas acknowledged the write
+func (s *Server) writeReplicas(ctx context.Context, replicas []string, w int, write func(ctx context.Context, client oraclev1.NodeServiceClient) error) ([]string, error) {
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), replicaTimeout)
+
+	results := make(chan replicaResult, len(replicas))
+	var wg sync.WaitGroup
+	for _, node := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := s.nodeClient(node)
+			if client == nil {
+				results <- replicaResult{node: node, err: fmt.Errorf("node client not found: %s", node)}
+				return
+			}
+			results <- replicaResult{node: node, err: write(writeCtx, client)}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	acked := make([]string, 0, len(replicas))
+	failed := 0
+	var lastErr error
+	for len(acked) < w && len(replicas)-failed >= w {
+		select {
+		case res := <-results:
+			if res.err != nil {
+				failed++
+				lastErr = res.err
+				s.logger.Warn("Replica write to %s failed: %v", res.node, res.err)
+				continue
+			}
+			acked = append(acked, res.node)
+		case <-ctx.Done():
+			return acked, ctx.Err()
+		}
+	}
+
+	if len(acked) < w {
+		if lastErr == nil {
+			return acked, fmt.Errorf("write quorum not reached: only %d replicas available (need %d)", len(replicas), w)
+		}
+		return acked, fmt.Errorf("write quorum not reached: %d of %d replicas acknowledged (need %d): %w",
+			len(acked), len(replicas), w, lastErr)
+	}
+	return acked, nil
+}
+
+// readReplicas reads a key from r of its n replicas and returns the newest
+// value together with the replica that holds it. Replicas are tried in
+// readReplicaOrder, so with bounded loads the least loaded replica leads. A
+// replica that fails is replaced by the next one in that order, and with
+// hedging enabled, a slow read is hedged by asking the next replica too;
+// the first r answers win. Replicas that returned an older value, or none,
+// are repaired in the background. A key no replica holds is looked up
+// under its legacy encoding.
+func (s *Server) readReplicas(ctx context.Context, key string, n, r int) (*oraclev1.GetResponse, string, error) {
+	replicas := s.readReplicaOrder(key, n)
+	if len(replicas) < r {
+		return nil, "", fmt.Errorf("read quorum not reached: only %d replicas available (need %d)", len(replicas), r)
+	}
+
+	results := make(chan replicaResult, len(replicas))
+	hedged := make(map[string]bool)
+	query := func(node string) {
+		s.countRequest(node)
+		go func() {
+			client := s.nodeClient(node)
+			if client == nil {
+				results <- replicaResult{node: node, err: fmt.Errorf("node client not found: %s", node)}
+				return
+			}
+			started := time.Now()
+			resp, err := client.Get(ctx, &oraclev1.GetRequest{Key: key})
+			if err == nil {
+				s.policy.recordReadLatency(time.Since(started))
+			}
+			results <- replicaResult{node: node, resp: resp, err: err}
+		}()
+	}
+
+	next := 0
+	for ; next < r; next++ {
+		query(replicas[next])
+	}
+
+	responses := make([]replicaResult, 0, r)
+	var lastErr error
+	hedge := s.policy.hedgeTimer()
+	for pending := r; pending > 0 && len(responses) < r; {
+		select {
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				lastErr = res.err
+				s.logger.Warn("Replica read from %s failed: %v", res.node, res.err)
+				if next < len(replicas) {
+					query(replicas[next])
+					next++
+					pending++
+				}
+				continue
+			}
+			if hedged[res.node] {
+				s.metrics.IncHedgeWins()
+			}
+			responses = append(responses, res)
+		case <-hedge:
+			hedge = nil
+			if next < len(replicas) && s.policy.allowHedge() {
+				hedged[replicas[next]] = true
+				query(replicas[next])
+				next++
+				pending++
+			}
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+	}
+
+	if len(responses) < r {
+		return nil, "", fmt.Errorf("read quorum not reached: %d of %d replicas responded (need %d): %w",
+			len(responses), len(replicas), r, lastErr)
+	}
+
+	newest := responses[0]
+	for _, res := range responses[1:] {
+		if res.resp.Found && (!newest.resp.Found || res.resp.Timestamp > newest.resp.Timestamp) {
+			newest = res
+		}
+	}
+
+	s.repairReplicas(key, newest, responses)
+
+	// The key may still be stored under its legacy encoding
+	if !newest.resp.Found {
+		if legacyResp, legacyNode := s.legacyKeys.get(ctx, key); legacyResp != nil {
+			return legacyResp, legacyNode, nil
+		}
+	}
+	return newest.resp, newest.node, nil
+}
+
+// repairReplicas writes the newest value back to replicas that returned an
+// older value or none at all. Repairs run in the background and never
+// overwrite a value written after the read, since they carry the original
+// write timestamp.
+func (s *Server) repairReplicas(key string, newest replicaResult, responses []replicaResult) {
+	// Unversioned entries cannot be ordered safely against concurrent writes
+	if !newest.resp.Found || newest.resp.Timestamp == 0 {
+		return
+	}
+
+	var stale []string
+	for _, res := range responses {
+		if !res.resp.Found || res.resp.Timestamp < newest.resp.Timestamp {
+			stale = append(stale, res.node)
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	req := &oraclev1.SetRequest{
+		Key:       key,
+		Value:     newest.resp.Value,
+		Timestamp: newest.resp.Timestamp,
+	}
+	if newest.resp.TtlMs > 0 {
+		// Round up; the repaired copy may outlive the original by under a second
+		req.Ttl = int32((newest.resp.TtlMs + 999) / 1000)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaTimeout)
+		defer cancel()
+
+		for _, node := range stale {
+			client := s.nodeClient(node)
+			if client == nil {
+				continue
+			}
+			if _, err := client.Set(ctx, req); err != nil {
+				s.logger.Warn("Read repair of %q on %s failed: %v", key, node, err)
+				continue
+			}
+			s.metrics.IncReadRepairs()
+			s.logger.Debug("Repaired stale replica of %q on %s", key, node)
+		}
+	}()
+}
+
+// isReplica reports whether node is still one of the replicas of a key for
+// the largest replication factor configured in any namespace. Keys whose
+// ownership moved must not be deleted from nodes that still replicate them.
+func (s *Server) isReplica(key, node string) bool {
+	n := s.maxReplicationFactor()
+	if n <= 1 {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Contains(s.ring.GetNodes(kv.RoutingKey(key), n), node)
+}
+
+// maxReplicationFactor returns the largest replication factor of all
+// configured namespaces.
+func (s *Server) maxReplicationFactor() int {
+	if s.informer == nil {
+		return 1
+	}
+
+	cfg := s.informer.GetConfig()
+	if cfg.Proxy == nil {
+		return 1
+	}
+
+	n := 1
+	for i := range cfg.Proxy.Namespaces {
+		replicas, _, _ := cfg.Proxy.Namespaces[i].Replication()
+		n = max(n, replicas)
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// newReplicatedProxy returns a proxy over two live nodes and one that is
+// down, so every key has all three nodes as replicas with n = 3.
+func newReplicatedProxy(t *testing.T) (*Server, []string, map[string]oraclev1.NodeServiceClient) {
+	t.Helper()
+
+	addrs, clients := startNodes(t, 2)
+	return newTestProxy(t, append(slices.Clone(addrs), unreachableAddr(t))), addrs, clients
+}
+
+func TestSetReplicatedQuorum(t *testing.T) {
+	tests := []struct {
+		name    string
+		w       int
+		wantErr bool
+	}{
+		{name: "quorum of live replicas", w: 2},
+		{name: "quorum includes the down replica", w: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, addrs, clients := newReplicatedProxy(t)
+			key := kv.NamespaceKey("orders", "order-1")
+
+			resp, err := s.setReplicated(context.Background(), key, []byte("v1"), 0, 3, tt.w)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "write quorum not reached") {
+					t.Fatalf("setReplicated with w=%d = %v, want a write quorum error", tt.w, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setReplicated: %v", err)
+			}
+
+			slices.Sort(resp.Replicas)
+			if !resp.Success || !slices.Equal(resp.Replicas, slices.Sorted(slices.Values(addrs))) {
+				t.Errorf("acknowledged by %v, want the live replicas %v", resp.Replicas, addrs)
+			}
+			for _, addr := range addrs {
+				if value, found := getValue(t, clients[addr], key); !found || value != "v1" {
+					t.Errorf("replica %s holds %q (found %v), want v1", addr, value, found)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteReplicatedQuorum(t *testing.T) {
+	s, _, _ := newReplicatedProxy(t)
+	key := kv.NamespaceKey("orders", "order-1")
+	ctx := context.Background()
+
+	if _, err := s.setReplicated(ctx, key, []byte("v1"), 0, 3, 2); err != nil {
+		t.Fatalf("setReplicated: %v", err)
+	}
+
+	resp, err := s.deleteReplicated(ctx, key, 3, 2)
+	if err != nil || !resp.Existed {
+		t.Fatalf("deleteReplicated with w=2 = %v, %v; want an existing key deleted", resp, err)
+	}
+	if _, err := s.deleteReplicated(ctx, key, 3, 3); err == nil {
+		t.Error("deleteReplicated with w=3 succeeded with a replica down")
+	}
+}
+
+func TestGetReplicatedQuorum(t *testing.T) {
+	s, addrs, clients := newReplicatedProxy(t)
+	key := kv.NamespaceKey("orders", "order-1")
+	ctx := context.Background()
+
+	base := time.Now().UnixNano()
+	setVersioned(t, clients[addrs[0]], key, "old", base)
+	setVersioned(t, clients[addrs[1]], key, "new", base+1)
+
+	// Two live replicas answer a read quorum of 2; the newest value wins
+	resp, err := s.getReplicated(ctx, key, 3, 2)
+	if err != nil {
+		t.Fatalf("getReplicated with r=2: %v", err)
+	}
+	if !resp.Found || string(resp.Value) != "new" || resp.Node != addrs[1] {
+		t.Errorf("getReplicated = %q from %s (found %v), want new from %s", resp.Value, resp.Node, resp.Found, addrs[1])
+	}
+
+	// The stale replica is repaired in the background
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if value, _ := getValue(t, clients[addrs[0]], key); value == "new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stale replica %s was not repaired", addrs[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := s.getReplicated(ctx, key, 3, 3); err == nil || !strings.Contains(err.Error(), "read quorum not reached") {
+		t.Errorf("getReplicated with r=3 = %v, want a read quorum error", err)
+	}
+}
+
+func TestSetReplicatedReportsDroppedWrite(t *testing.T) {
+	s, addrs, clients := newReplicatedProxy(t)
+	key := kv.NamespaceKey("orders", "order-1")
+
+	// A replica holds a value stamped later, e.g. by a proxy whose clock is ahead
+	setVersioned(t, clients[addrs[0]], key, "newer", time.Now().Add(time.Hour).UnixNano())
+
+	resp, err := s.setReplicated(context.Background(), key, []byte("v1"), 0, 3, 2)
+	if err != nil {
+		t.Fatalf("setReplicated: %v", err)
+	}
+	if resp.Success || resp.Message == "" {
+		t.Errorf("setReplicated = %v, want the dropped write reported as not stored", resp)
+	}
+}
@@ -0,0 +1,304 @@
+package proxy
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/config"
+)
+
+// Default retry and hedging settings, used when RetryConfig and
+// HedgingConfig leave them unset.
+const (
+	DefaultRetryMaxAttempts         = 2
+	DefaultRetryInitialBackoff      = 10 * time.Millisecond
+	DefaultRetryMaxBackoff          = 200 * time.Millisecond
+	DefaultRetryBudgetPercent       = 10
+	DefaultRetryMinPerSecond        = 10
+	DefaultHedgingLatencyPercentile = 0.95
+	DefaultHedgingMinDelay          = time.Millisecond
+)
+
+// Hedge delay tracking: the percentile is computed over the last
+// hedgeLatencyWindow replica reads, re-evaluated every hedgeLatencyEvalEvery
+// reads. Reads are not hedged before the window is full.
+const (
+	hedgeLatencyWindow    = 1000
+	hedgeLatencyEvalEvery = 100
+)
+
+// isRetryable reports whether a node call may be sent again after it failed.
+//
+// Reads are always safe to repeat. A write is only safe if it carries a
+// timestamp: the node then keeps whichever write is newer, so an attempt
+// that lands after a later write of the same key cannot overwrite it.
+// Unversioned writes and deletes are never retried, because the first
+// attempt may have been applied and a concurrent write could be undone.
+// Health checks are not retried either: a failed check is the answer.
+func isRetryable(method string, req any) bool {
+	switch method {
+	case oraclev1.NodeService_Get_FullMethodName,
+		oraclev1.NodeService_MultiGet_FullMethodName:
+		return true
+	case oraclev1.NodeService_Set_FullMethodName:
+		set, ok := req.(*oraclev1.SetRequest)
+		return ok && set.Timestamp > 0
+	case oraclev1.NodeService_MultiSet_FullMethodName:
+		multi, ok := req.(*oraclev1.MultiSetRequest)
+		if !ok {
+			return false
+		}
+		for _, item := range multi.Items {
+			if item.Timestamp <= 0 {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// retryBudget limits retries and hedges to a share of the request rate.
+//
+// Every request deposits a fraction of a token and every retry or hedge
+// withdraws a whole one. A minimum allowance is refilled over time, so that
+// a low-traffic proxy can still retry.
+type retryBudget struct {
+	mu          sync.Mutex
+	tokens      float64
+	ratio       float64
+	minPerSec   float64
+	lastRefresh time.Time
+}
+
+// configure sets the budget ratio and minimum allowance.
+func (b *retryBudget) configure(percent, minPerSecond int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ratio = float64(percent) / 100
+	b.minPerSec = float64(minPerSecond)
+	b.tokens = min(b.tokens, b.capacity())
+	b.lastRefresh = time.Now()
+}
+
+// capacity is the largest number of tokens that can be saved up; b.mu must
+// be held.
+func (b *retryBudget) capacity() float64 {
+	return max(b.minPerSec, 1) * 10
+}
+
+// deposit accounts for one request.
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.capacity())
+}
+
+// withdraw takes a token for a retry or hedge.
+//
+// Returns:
+//   - bool: False if the budget is used up
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.tokens+now.Sub(b.lastRefresh).Seconds()*b.minPerSec, b.capacity())
+	b.lastRefresh = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// requestPolicy retries failed node requests and hedges slow replica reads.
+type requestPolicy struct {
+	server *Server
+	budget retryBudget
+
+	mu        sync.Mutex
+	retry     config.RetryConfig
+	retryable map[codes.Code]bool
+	hedging   config.HedgingConfig
+	latencies [hedgeLatencyWindow]time.Duration
+	samples   int
+
+	// hedgeDelay is the current hedge delay in nanoseconds (0 = no hedging)
+	hedgeDelay atomic.Int64
+}
+
+// newRequestPolicy creates a request policy with default settings.
+func newRequestPolicy(s *Server) *requestPolicy {
+	return &requestPolicy{server: s}
+}
+
+// configure applies retry and hedging settings; nil selects defaults.
+func (p *requestPolicy) configure(retry *config.RetryConfig, hedging *config.HedgingConfig) {
+	var r config.RetryConfig
+	if retry != nil {
+		r = *retry
+	}
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if len(r.RetryableCodes) == 0 {
+		r.RetryableCodes = []string{"UNAVAILABLE"}
+	}
+	if r.InitialBackoffMs <= 0 {
+		r.InitialBackoffMs = int(DefaultRetryInitialBackoff / time.Millisecond)
+	}
+	if r.MaxBackoffMs <= 0 {
+		r.MaxBackoffMs = max(int(DefaultRetryMaxBackoff/time.Millisecond), r.InitialBackoffMs)
+	}
+	if r.BudgetPercent <= 0 {
+		r.BudgetPercent = DefaultRetryBudgetPercent
+	}
+	if r.MinRetriesPerSecond <= 0 {
+		r.MinRetriesPerSecond = DefaultRetryMinPerSecond
+	}
+
+	retryable := make(map[codes.Code]bool, len(r.RetryableCodes))
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		for _, name := range r.RetryableCodes {
+			// "DEADLINE_EXCEEDED" matches codes.DeadlineExceeded
+			if strings.ReplaceAll(name, "_", "") == strings.ToUpper(c.String()) {
+				retryable[c] = true
+			}
+		}
+	}
+
+	var h config.HedgingConfig
+	if hedging != nil {
+		h = *hedging
+	}
+	if h.LatencyPercentile <= 0 {
+		h.LatencyPercentile = DefaultHedgingLatencyPercentile
+	}
+	if h.MinDelayMs <= 0 {
+		h.MinDelayMs = int(DefaultHedgingMinDelay / time.Millisecond)
+	}
+
+	p.budget.configure(r.BudgetPercent, r.MinRetriesPerSecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retry = r
+	p.retryable = retryable
+	p.hedging = h
+	if !h.Enabled {
+		p.hedgeDelay.Store(0)
+	}
+}
+
+// interceptor returns a unary client interceptor that retries reads and
+// versioned writes (see isRetryable) on the connection to a node. It runs
+// outside the circuit breaker, so every attempt is accounted for by the
+// breaker, and it stops retrying once the node is ejected.
+func (p *requestPolicy) interceptor(node string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !isRetryable(method, req) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		p.budget.deposit()
+
+		p.mu.Lock()
+		maxAttempts := p.retry.MaxAttempts
+		retryable := p.retryable
+		backoff := time.Duration(p.retry.InitialBackoffMs) * time.Millisecond
+		maxBackoff := time.Duration(p.retry.MaxBackoffMs) * time.Millisecond
+		p.mu.Unlock()
+
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= maxAttempts || !retryable[status.Code(err)] || ctx.Err() != nil {
+				return err
+			}
+			if p.server.breakers.ejected(node) {
+				return err
+			}
+			if !p.budget.withdraw() {
+				p.server.metrics.IncRetryBudgetExhausted()
+				return err
+			}
+			p.server.metrics.IncRetries()
+
+			// Full jitter spreads the retries of concurrent requests
+			select {
+			case <-time.After(rand.N(backoff) + 1):
+			case <-ctx.Done():
+				return err
+			}
+			backoff = min(2*backoff, maxBackoff)
+		}
+	}
+}
+
+// recordReadLatency adds a replica read latency to the hedge delay window.
+func (p *requestPolicy) recordReadLatency(latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.hedging.Enabled {
+		return
+	}
+	p.latencies[p.samples%hedgeLatencyWindow] = latency
+	p.samples++
+	if p.samples < hedgeLatencyWindow || p.samples%hedgeLatencyEvalEvery != 0 {
+		return
+	}
+
+	delay := percentile(p.latencies[:], p.hedging.LatencyPercentile)
+	delay = max(delay, time.Duration(p.hedging.MinDelayMs)*time.Millisecond)
+	p.hedgeDelay.Store(int64(delay))
+}
+
+// hedgeTimer returns a channel that fires when a read should be hedged, or
+// nil if hedging is disabled or not warmed up yet.
+func (p *requestPolicy) hedgeTimer() <-chan time.Time {
+	delay := time.Duration(p.hedgeDelay.Load())
+	if delay <= 0 {
+		return nil
+	}
+	return time.After(delay)
+}
+
+// allowHedge takes a retry budget token for a hedged read.
+func (p *requestPolicy) allowHedge() bool {
+	if !p.budget.withdraw() {
+		p.server.metrics.IncRetryBudgetExhausted()
+		return false
+	}
+	p.server.metrics.IncHedges()
+	return true
+}
+
+// stats returns the retry and hedging counters.
+func (p *requestPolicy) stats() *oraclev1.RetryStats {
+	m := p.server.metrics
+	return &oraclev1.RetryStats{
+		Retries:         m.GetRetries(),
+		Hedges:          m.GetHedges(),
+		HedgeWins:       m.GetHedgeWins(),
+		BudgetExhausted: m.GetRetryBudgetExhausted(),
+		HedgeDelayMs:    float64(p.hedgeDelay.Load()) / float64(time.Millisecond),
+	}
+}
+
+// SetRequestPolicy applies retry and hedging settings; nil selects the
+// defaults (one retry of UNAVAILABLE errors, no hedging).
+func (s *Server) SetRequestPolicy(retry *config.RetryConfig, hedging *config.HedgingConfig) {
+	s.policy.configure(retry, hedging)
+}
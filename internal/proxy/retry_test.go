@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"testing"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		req    any
+		want   bool
+	}{
+		{"get", oraclev1.NodeService_Get_FullMethodName, &oraclev1.GetRequest{Key: "k"}, true},
+		{"multi get", oraclev1.NodeService_MultiGet_FullMethodName, &oraclev1.MultiGetRequest{}, true},
+		{"versioned set", oraclev1.NodeService_Set_FullMethodName, &oraclev1.SetRequest{Key: "k", Timestamp: 1}, true},
+		{"unversioned set", oraclev1.NodeService_Set_FullMethodName, &oraclev1.SetRequest{Key: "k"}, false},
+		{"versioned multi set", oraclev1.NodeService_MultiSet_FullMethodName, &oraclev1.MultiSetRequest{
+			Items: []*oraclev1.SetRequest{{Key: "a", Timestamp: 1}, {Key: "b", Timestamp: 2}},
+		}, true},
+		{"partly versioned multi set", oraclev1.NodeService_MultiSet_FullMethodName, &oraclev1.MultiSetRequest{
+			Items: []*oraclev1.SetRequest{{Key: "a", Timestamp: 1}, {Key: "b"}},
+		}, false},
+		{"delete", oraclev1.NodeService_Delete_FullMethodName, &oraclev1.DeleteRequest{Key: "k"}, false},
+		{"multi delete", oraclev1.NodeService_MultiDelete_FullMethodName, &oraclev1.MultiDeleteRequest{}, false},
+		{"transaction", oraclev1.NodeService_Transaction_FullMethodName, &oraclev1.TransactionRequest{}, false},
+		{"health", oraclev1.NodeService_Health_FullMethodName, &oraclev1.HealthRequest{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.method, tt.req); got != tt.want {
+				t.Errorf("isRetryable(%s) = %v, want %v", tt.method, got, tt.want)
+			}
+		})
+	}
+}
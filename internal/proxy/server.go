@@ -5,17 +5,20 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	grpchealth "google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
 	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
 
 	"github.com/eggybyte-technology/yao-oracle/core/config"
+	"github.com/eggybyte-technology/yao-oracle/core/discovery"
 	"github.com/eggybyte-technology/yao-oracle/core/hash"
 	"github.com/eggybyte-technology/yao-oracle/core/health"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
 	"github.com/eggybyte-technology/yao-oracle/core/metrics"
 	"github.com/eggybyte-technology/yao-oracle/core/utils"
 )
@@ -34,12 +37,52 @@ type Server struct {
 
 	mu            sync.RWMutex
 	informer      *config.K8sInformer
-	ring          *hash.Ring
+	ring          hash.Placement
 	nodeClients   map[string]oraclev1.NodeServiceClient
+	nodeConns     map[string]*grpc.ClientConn
 	metrics       *metrics.Metrics
 	healthChecker *health.Checker
 	logger        *utils.Logger
 	stopCh        chan struct{}
+
+	// maxMessageSize is the gRPC message size limit in bytes, applied to
+	// the proxy server and to all connections towards cache nodes
+	maxMessageSize int
+
+	// placement selects the algorithm used to map keys to nodes
+	placement config.PlacementConfig
+
+	// nodeRequests counts requests routed to each node since the last
+	// load report; used to feed bounded-load placement
+	nodeRequests map[string]*atomic.Int64
+
+	// rebalancer migrates keys to their new owner after ring changes
+	rebalancer *rebalancer
+
+	// failover promotes followers of primaries that fail health checks
+	failover *failoverMonitor
+
+	// antiEntropy repairs replicas that drifted apart
+	antiEntropy *antiEntropy
+
+	// hints holds writes for unavailable nodes until they are back
+	hints *hintedHandoff
+
+	// breakers eject failing or slow nodes from routing
+	breakers *breakerSet
+
+	// policy retries failed node requests and hedges slow replica reads
+	policy *requestPolicy
+
+	// coalescer shares node reads between concurrent Gets of the same key
+	coalescer *getCoalescer
+
+	// hotKeys detects the most requested keys and serves them from the
+	// near cache
+	hotKeys *hotKeys
+
+	// legacyKeys rewrites keys stored in the legacy "namespace:key" format
+	legacyKeys *legacyKeys
 }
 
 // NewServer creates a new proxy server instance with Kubernetes Informer.
@@ -62,17 +105,56 @@ type Server struct {
 func NewServer(informer *config.K8sInformer) *Server {
 	s := &Server{
 		informer:      informer,
-		ring:          hash.NewRing(150),
+		ring:          hash.NewRing(hash.DefaultVirtualNodes),
 		nodeClients:   make(map[string]oraclev1.NodeServiceClient),
+		nodeConns:     make(map[string]*grpc.ClientConn),
+		nodeRequests:  make(map[string]*atomic.Int64),
 		metrics:       metrics.NewMetrics(),
 		healthChecker: health.NewChecker(),
 		logger:        utils.NewLogger("proxy"),
 		stopCh:        make(chan struct{}),
+
+		maxMessageSize: DefaultMaxMessageSize,
 	}
+	s.rebalancer = newRebalancer(s)
+	s.rebalancer.configure(nil)
+	s.failover = newFailoverMonitor(s)
+	s.failover.configure(nil)
+	s.antiEntropy = newAntiEntropy(s)
+	s.antiEntropy.configure(nil)
+	s.hints = newHintedHandoff(s)
+	s.hints.configure(nil)
+	s.breakers = newBreakerSet(s)
+	s.breakers.configure(nil)
+	s.policy = newRequestPolicy(s)
+	s.policy.configure(nil, nil)
+	s.coalescer = newGetCoalescer(s.metrics)
+	s.hotKeys = newHotKeys(s)
+	s.hotKeys.configure(nil)
+	s.legacyKeys = newLegacyKeys(s)
+	s.legacyKeys.configure(nil)
 
 	return s
 }
 
+// SetMaxMessageSize configures the gRPC message size limit in bytes.
+//
+// The limit applies to the proxy gRPC server and to connections towards
+// cache nodes, and must match the limit configured on the nodes. It must
+// be called before Run and SetNodes to take effect.
+//
+// Parameters:
+//   - size: Maximum message size in bytes. Values <= 0 are ignored.
+func (s *Server) SetMaxMessageSize(size int) {
+	if size <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxMessageSize = size
+}
+
 // SetNodes configures the cache nodes for routing.
 //
 // This method is typically used for:
@@ -80,39 +162,23 @@ func NewServer(informer *config.K8sInformer) *Server {
 //   - Manual node registration
 //   - Initial cluster setup
 //
-// In production, nodes are usually discovered via Kubernetes service discovery.
+// In production, nodes are usually discovered via Kubernetes service
+// discovery (see WatchNodes).
 //
 // Parameters:
 //   - nodes: List of cache node addresses (e.g., ["node-0:7070", "node-1:7070"])
 //
 // Side effects:
-//   - Clears existing hash ring
-//   - Establishes gRPC connections to all nodes
+//   - Adds missing nodes with the default weight and removes nodes not in the list
+//   - Establishes gRPC connections to new nodes and closes those of removed nodes
 //   - Logs connection errors (but continues for successful nodes)
+//   - Schedules migration of keys that changed owner (ketama placement only)
 func (s *Server) SetNodes(nodes []string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Clear existing ring
-	s.ring = hash.NewRing(150)
-
-	// Add new nodes
-	for _, node := range nodes {
-		s.ring.AddNode(node)
-		s.logger.Info("Added cache node: %s", node)
-
-		// Create gRPC client for this node
-		if _, exists := s.nodeClients[node]; !exists {
-			conn, err := grpc.Dial(node, grpc.WithTransportCredentials(insecure.NewCredentials()))
-			if err != nil {
-				s.logger.Error("Failed to connect to node %s: %v", node, err)
-				continue
-			}
-			s.nodeClients[node] = oraclev1.NewNodeServiceClient(conn)
-		}
+	endpoints := make([]discovery.Endpoint, len(nodes))
+	for i, node := range nodes {
+		endpoints[i] = discovery.Endpoint{Address: node, Weight: discovery.DefaultWeight}
 	}
-
-	s.logger.Info("Cache node ring updated: %d nodes", s.ring.Size())
+	s.UpdateNodes(endpoints)
 }
 
 // Get retrieves a value by key (with API key authentication).
@@ -120,9 +186,11 @@ func (s *Server) SetNodes(nodes []string) {
 // Request flow:
 // 1. Validate API key and determine namespace
 // 2. Add namespace prefix to key
-// 3. Use consistent hashing to select target node
-// 4. Forward request to selected node
-// 5. Return result to client
+// 3. Serve hot keys from the near cache
+// 4. Join a concurrent Get of the same key, if one is in flight
+// 5. Otherwise use consistent hashing to select target node
+// 6. Forward request to selected node
+// 7. Return result to client
 func (s *Server) Get(ctx context.Context, req *oraclev1.ProxyGetRequest) (*oraclev1.ProxyGetResponse, error) {
 	s.metrics.IncRequests()
 
@@ -133,15 +201,76 @@ func (s *Server) Get(ctx context.Context, req *oraclev1.ProxyGetRequest) (*oracl
 		return nil, fmt.Errorf("invalid API key")
 	}
 
+	// Enforce namespace size limits
+	if err := s.validateKey(ns, req.Key); err != nil {
+		s.metrics.IncRequestsError()
+		return nil, err
+	}
+
 	// Add namespace prefix to key
 	namespacedKey := s.namespaceKey(ns.Name, req.Key)
 
-	// Route to appropriate node
-	targetNode := s.selectNode(namespacedKey)
-	if targetNode == "" {
-		s.metrics.IncRequestsError()
+	// Hot keys are served from the near cache
+	s.hotKeys.record(ns.Name, namespacedKey)
+	resp, generation := s.hotKeys.lookup(namespacedKey)
+	if resp != nil {
+		s.metrics.IncNearCacheHits()
+	} else {
+		// Concurrent Gets of the same key share one node read
+		var err error
+		resp, err = s.coalescer.do(ctx, namespacedKey, func(ctx context.Context) (*oraclev1.ProxyGetResponse, error) {
+			return s.fetch(ctx, ns, namespacedKey)
+		})
+		if err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+		s.hotKeys.fill(namespacedKey, generation, resp)
+	}
+
+	if resp.Found {
+		s.metrics.IncCacheHits()
+	} else {
+		s.metrics.IncCacheMisses()
+	}
+	s.metrics.IncRequestsOK()
+
+	return resp, nil
+}
+
+// fetch reads a namespaced key from the cache nodes.
+func (s *Server) fetch(ctx context.Context, ns *config.Namespace, namespacedKey string) (*oraclev1.ProxyGetResponse, error) {
+	// Replicated namespaces read from a quorum of replicas
+	if n, _, r := ns.Replication(); n > 1 {
+		return s.getReplicated(ctx, namespacedKey, n, r)
+	}
+
+	// Route to appropriate node. With bounded loads, an overloaded owner's
+	// reads are served from an overflow copy on the next node with room.
+	owner, readNode := s.selectReadNode(namespacedKey)
+	if owner == "" {
 		return nil, fmt.Errorf("no cache node available")
 	}
+	if readNode != owner {
+		if copyResp := s.getOverflowCopy(ctx, readNode, namespacedKey); copyResp != nil {
+			return &oraclev1.ProxyGetResponse{
+				Found:   true,
+				Value:   copyResp.Value,
+				Ttl:     copyResp.Ttl,
+				Node:    readNode,
+				Version: copyResp.Timestamp,
+			}, nil
+		}
+		s.countRequest(owner)
+	}
+	targetNode := owner
+
+	// Read around an ejected node, unless the key was handed off as a hint
+	if s.breakers.ejected(targetNode) && s.hints.holderOf(targetNode, namespacedKey) == "" {
+		if next := s.breakers.readNode(namespacedKey, targetNode); next != "" {
+			targetNode = next
+		}
+	}
 
 	// Get client for target node
 	s.mu.RLock()
@@ -149,7 +278,6 @@ func (s *Server) Get(ctx context.Context, req *oraclev1.ProxyGetRequest) (*oracl
 	s.mu.RUnlock()
 
 	if !exists {
-		s.metrics.IncRequestsError()
 		return nil, fmt.Errorf("node client not found: %s", targetNode)
 	}
 
@@ -158,22 +286,43 @@ func (s *Server) Get(ctx context.Context, req *oraclev1.ProxyGetRequest) (*oracl
 		Key: namespacedKey,
 	})
 	if err != nil {
-		s.metrics.IncRequestsError()
-		return nil, fmt.Errorf("node error: %w", err)
+		// A write accepted while the node is down lives on a stand-in node
+		holder := s.hints.holderOf(targetNode, namespacedKey)
+		holderClient := s.nodeClient(holder)
+		if holderClient == nil || !isUnavailable(err) {
+			return nil, fmt.Errorf("node error: %w", err)
+		}
+		if nodeResp, err = holderClient.Get(ctx, &oraclev1.GetRequest{Key: namespacedKey}); err != nil {
+			return nil, fmt.Errorf("node error: %w", err)
+		}
+		targetNode = holder
 	}
 
-	if nodeResp.Found {
-		s.metrics.IncCacheHits()
-	} else {
-		s.metrics.IncCacheMisses()
+	// The key may not have been migrated to its new owner yet
+	if !nodeResp.Found {
+		if fallbackResp, fallbackNode := s.getFromFallback(ctx, namespacedKey, targetNode); fallbackResp != nil {
+			nodeResp, targetNode = fallbackResp, fallbackNode
+		}
+	}
+
+	// The key may still be stored under its legacy encoding
+	if !nodeResp.Found {
+		if legacyResp, legacyNode := s.legacyKeys.get(ctx, namespacedKey); legacyResp != nil {
+			nodeResp, targetNode = legacyResp, legacyNode
+		}
+	}
+
+	// Later reads of the overloaded owner's key hit the overflow copy
+	if readNode != owner {
+		s.fillOverflowCopy(readNode, namespacedKey, nodeResp)
 	}
-	s.metrics.IncRequestsOK()
 
 	return &oraclev1.ProxyGetResponse{
-		Found: nodeResp.Found,
-		Value: nodeResp.Value,
-		Ttl:   nodeResp.Ttl,
-		Node:  targetNode,
+		Found:   nodeResp.Found,
+		Value:   nodeResp.Value,
+		Ttl:     nodeResp.Ttl,
+		Node:    targetNode,
+		Version: nodeResp.Timestamp,
 	}, nil
 }
 
@@ -188,9 +337,33 @@ func (s *Server) Set(ctx context.Context, req *oraclev1.ProxySetRequest) (*oracl
 		return nil, fmt.Errorf("invalid API key")
 	}
 
+	// Enforce namespace size limits
+	if err := s.validateKey(ns, req.Key); err != nil {
+		s.metrics.IncRequestsError()
+		return nil, err
+	}
+	if err := s.validateValue(ns, req.Value); err != nil {
+		s.metrics.IncRequestsError()
+		return nil, err
+	}
+
 	// Add namespace prefix to key
 	namespacedKey := s.namespaceKey(ns.Name, req.Key)
 
+	// Gets that start after the write must not see an older value
+	defer s.keyWritten(namespacedKey)
+
+	// Replicated namespaces write to every replica
+	if n, w, _ := ns.Replication(); n > 1 {
+		resp, err := s.setReplicated(ctx, namespacedKey, req.Value, req.Ttl, n, w)
+		if err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+		s.metrics.IncRequestsOK()
+		return resp, nil
+	}
+
 	// Route to appropriate node
 	targetNode := s.selectNode(namespacedKey)
 	if targetNode == "" {
@@ -208,17 +381,40 @@ func (s *Server) Set(ctx context.Context, req *oraclev1.ProxySetRequest) (*oracl
 		return nil, fmt.Errorf("node client not found: %s", targetNode)
 	}
 
-	// Forward request to node
-	nodeResp, err := client.Set(ctx, &oraclev1.SetRequest{
-		Key:   namespacedKey,
-		Value: req.Value,
-		Ttl:   req.Ttl,
-	})
+	// Forward request to node. The timestamp orders this write against a
+	// hint for the key that may be replayed later; the node reports a write
+	// that is older than its stored value as not stored.
+	nodeReq := &oraclev1.SetRequest{
+		Key:       namespacedKey,
+		Value:     req.Value,
+		Ttl:       req.Ttl,
+		Timestamp: time.Now().UnixNano(),
+	}
+	nodeResp, err := client.Set(ctx, nodeReq)
 	if err != nil {
+		// Keep the write on another node until the owner is back
+		if isUnavailable(err) {
+			if hintResp, holder, ok := s.hints.store(ctx, targetNode, nodeReq); ok {
+				message := fmt.Sprintf("node %s unavailable, stored as hint", targetNode)
+				if !hintResp.Success {
+					message = hintResp.Message
+				}
+				s.metrics.IncRequestsOK()
+				return &oraclev1.ProxySetResponse{
+					Success: hintResp.Success,
+					Message: message,
+					Node:    holder,
+				}, nil
+			}
+		}
 		s.metrics.IncRequestsError()
 		return nil, fmt.Errorf("node error: %w", err)
 	}
 
+	// The owner now holds a newer value than any pending hint or copy
+	s.hints.forget(targetNode, namespacedKey)
+	s.dropOverflowCopy(ctx, targetNode, namespacedKey)
+
 	s.metrics.IncRequestsOK()
 
 	return &oraclev1.ProxySetResponse{
@@ -239,9 +435,29 @@ func (s *Server) Delete(ctx context.Context, req *oraclev1.ProxyDeleteRequest) (
 		return nil, fmt.Errorf("invalid API key")
 	}
 
+	// Enforce namespace size limits
+	if err := s.validateKey(ns, req.Key); err != nil {
+		s.metrics.IncRequestsError()
+		return nil, err
+	}
+
 	// Add namespace prefix to key
 	namespacedKey := s.namespaceKey(ns.Name, req.Key)
 
+	// Gets that start after the write must not see an older value
+	defer s.keyWritten(namespacedKey)
+
+	// Replicated namespaces delete from every replica
+	if n, w, _ := ns.Replication(); n > 1 {
+		resp, err := s.deleteReplicated(ctx, namespacedKey, n, w)
+		if err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+		s.metrics.IncRequestsOK()
+		return resp, nil
+	}
+
 	// Route to appropriate node
 	targetNode := s.selectNode(namespacedKey)
 	if targetNode == "" {
@@ -259,6 +475,22 @@ func (s *Server) Delete(ctx context.Context, req *oraclev1.ProxyDeleteRequest) (
 		return nil, fmt.Errorf("node client not found: %s", targetNode)
 	}
 
+	// Remove the copies that are still waiting to be migrated first: a
+	// migration that copied one to the owner in the meantime then fails its
+	// conditional source delete and undoes the copy (see moveKeys)
+	s.rebalancer.forget(namespacedKey)
+	existed := s.legacyKeys.delete(ctx, []string{namespacedKey})[namespacedKey]
+	if fallbackNode := s.rebalancer.fallbackNode(namespacedKey); fallbackNode != "" && fallbackNode != targetNode {
+		if fallbackClient := s.nodeClient(fallbackNode); fallbackClient != nil {
+			fallbackResp, err := fallbackClient.Delete(ctx, &oraclev1.DeleteRequest{Key: namespacedKey})
+			if err != nil {
+				s.logger.Warn("Failed to delete %q from previous owner %s: %v", namespacedKey, fallbackNode, err)
+			} else {
+				existed = existed || fallbackResp.Existed
+			}
+		}
+	}
+
 	// Forward request to node
 	nodeResp, err := client.Delete(ctx, &oraclev1.DeleteRequest{
 		Key: namespacedKey,
@@ -267,6 +499,11 @@ func (s *Server) Delete(ctx context.Context, req *oraclev1.ProxyDeleteRequest) (
 		s.metrics.IncRequestsError()
 		return nil, fmt.Errorf("node error: %w", err)
 	}
+	nodeResp.Existed = nodeResp.Existed || existed
+
+	// A pending hint would bring the key back when replayed
+	s.hints.forget(targetNode, namespacedKey)
+	s.dropOverflowCopy(ctx, targetNode, namespacedKey)
 
 	s.metrics.IncRequestsOK()
 
@@ -277,68 +514,6 @@ func (s *Server) Delete(ctx context.Context, req *oraclev1.ProxyDeleteRequest) (
 	}, nil
 }
 
-// BatchGet retrieves multiple keys in a single request.
-func (s *Server) BatchGet(ctx context.Context, req *oraclev1.ProxyBatchGetRequest) (*oraclev1.ProxyBatchGetResponse, error) {
-	s.metrics.IncRequests()
-
-	// Authenticate and get namespace
-	ns, ok := s.authenticateRequest(req.ApiKey)
-	if !ok {
-		s.metrics.IncRequestsError()
-		return nil, fmt.Errorf("invalid API key")
-	}
-
-	results := make(map[string][]byte)
-	nodesUsed := make(map[string]bool)
-
-	// Process each key
-	for _, key := range req.Keys {
-		// Add namespace prefix
-		namespacedKey := s.namespaceKey(ns.Name, key)
-
-		// Route to appropriate node
-		targetNode := s.selectNode(namespacedKey)
-		if targetNode == "" {
-			continue
-		}
-
-		nodesUsed[targetNode] = true
-
-		// Get client for target node
-		s.mu.RLock()
-		client, exists := s.nodeClients[targetNode]
-		s.mu.RUnlock()
-
-		if !exists {
-			continue
-		}
-
-		// Forward request to node
-		nodeResp, err := client.Get(ctx, &oraclev1.GetRequest{
-			Key: namespacedKey,
-		})
-		if err != nil || !nodeResp.Found {
-			continue
-		}
-
-		// Store result (using original key, not namespaced)
-		results[key] = nodeResp.Value
-	}
-
-	// Convert nodes used map to slice
-	nodesList := make([]string, 0, len(nodesUsed))
-	for node := range nodesUsed {
-		nodesList = append(nodesList, node)
-	}
-
-	s.metrics.IncRequestsOK()
-
-	return &oraclev1.ProxyBatchGetResponse{
-		Results:   results,
-		NodesUsed: nodesList,
-	}, nil
-}
-
 // Health checks proxy health and cluster status.
 func (s *Server) Health(ctx context.Context, req *oraclev1.ProxyHealthRequest) (*oraclev1.ProxyHealthResponse, error) {
 	cfg := s.informer.GetConfig()
@@ -369,6 +544,16 @@ func (s *Server) Health(ctx context.Context, req *oraclev1.ProxyHealthRequest) (
 		NodesHealthy:    int32(healthyNodes),
 		NodesTotal:      int32(totalNodes),
 		Message:         fmt.Sprintf("%d of %d nodes healthy", healthyNodes, totalNodes),
+		ReadRepairs:     s.metrics.GetReadRepairs(),
+
+		AntiEntropyRepaired: s.metrics.GetAntiEntropyRepaired(),
+		Hints:               s.hints.stats(),
+		Breakers:            s.breakers.status(),
+		Retries:             s.policy.stats(),
+		CoalescedGets:       s.metrics.GetCoalescedGets(),
+		CoalescingRatio:     s.metrics.GetCoalescingRatio(),
+		HotKeys:             s.hotKeys.report(),
+		NearCacheHits:       s.metrics.GetNearCacheHits(),
 	}, nil
 }
 
@@ -387,7 +572,14 @@ func (s *Server) Run(port int) error {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	s.mu.RLock()
+	maxMessageSize := s.maxMessageSize
+	s.mu.RUnlock()
+
+	grpcServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.MaxSendMsgSize(maxMessageSize),
+	)
 	oraclev1.RegisterProxyServiceServer(grpcServer, s)
 
 	// Register gRPC health check
@@ -399,6 +591,27 @@ func (s *Server) Run(port int) error {
 	s.healthChecker.SetHealthy(true)
 	s.healthChecker.SetReady(true)
 
+	// Feed per-node request rates to bounded-load placement
+	go s.reportLoads()
+
+	// Migrate keys that changed owner after ring updates
+	go s.rebalancer.run()
+
+	// Promote followers of failed primaries
+	go s.failover.run()
+
+	// Repair replicas that drifted apart
+	go s.antiEntropy.run()
+
+	// Replay writes held for unavailable nodes
+	go s.hints.run()
+
+	// Track hot keys and keep the near cache in sync with the nodes
+	go s.hotKeys.run()
+
+	// Rewrite keys stored by proxies that used the legacy key encoding
+	go s.legacyKeys.run()
+
 	s.logger.Info("Proxy server listening on port %d", port)
 
 	return grpcServer.Serve(listener)
@@ -441,15 +654,45 @@ func (s *Server) authenticateRequest(apiKey string) (*config.Namespace, bool) {
 	return s.informer.GetNamespaceByAPIKey(apiKey)
 }
 
-// namespaceKey adds the namespace prefix to a key.
+// namespaceKey adds the namespace prefix to a key (see kv.NamespaceKey).
 func (s *Server) namespaceKey(namespace, key string) string {
-	return fmt.Sprintf("%s:%s", namespace, key)
+	return kv.NamespaceKey(namespace, key)
 }
 
-// selectNode uses consistent hashing to select a target cache node.
+// clientKey returns a namespaced key as the client sent it.
+func clientKey(namespacedKey string) string {
+	if _, key, ok := kv.SplitNamespaceKey(namespacedKey); ok {
+		return key
+	}
+	return namespacedKey
+}
+
+// keyWritten makes Gets that start after a write of a key read it from
+// the nodes again.
+func (s *Server) keyWritten(key string) {
+	s.coalescer.forget(key)
+	s.hotKeys.invalidate(key)
+}
+
+// namespacePrefix returns the prefix shared by all keys of a namespace.
+func (s *Server) namespacePrefix(namespace string) string {
+	return kv.NamespacePrefix(namespace)
+}
+
+// selectNode uses consistent hashing to select the node that owns a key
+// and records the request on it. The owner never depends on bounded loads
+// (see ownerOf); Gets use selectReadNode instead.
+//
+// Keys with a hash tag are placed by their namespace and tag only (see
+// kv.RoutingKey), so "{user:42}:profile" and "{user:42}:cart" of a
+// namespace share a node.
 func (s *Server) selectNode(key string) string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.ring.GetNode(key)
+	node := ownerOn(s.ring, kv.RoutingKey(key))
+	if counter, ok := s.nodeRequests[node]; ok {
+		counter.Add(1)
+	}
+	return node
 }
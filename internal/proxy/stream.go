@@ -0,0 +1,462 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// castagnoli is the CRC32-C table used for stream checksums.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// SetStream stores a value uploaded as a stream of chunks.
+//
+// Request flow:
+//  1. Receive the header, validate API key and size limits
+//  2. Open a SetStream to the node selected by consistent hashing
+//  3. Relay chunks to the node as they arrive (no buffering in the proxy)
+//  4. Relay the trailer; the node verifies the checksum and commits
+//
+// If the client stream ends or fails before the trailer, the node stream is
+// cancelled and nothing is written to the cache.
+//
+// Streamed values are written to a single node, so SetStream is rejected in
+// replicated namespaces; clients there must use Set.
+func (s *Server) SetStream(stream oraclev1.ProxyService_SetStreamServer) error {
+	s.metrics.IncRequests()
+
+	first, err := stream.Recv()
+	if err != nil {
+		s.metrics.IncRequestsError()
+		return status.Errorf(codes.InvalidArgument, "failed to receive stream header: %v", err)
+	}
+
+	header := first.GetHeader()
+	if header == nil {
+		s.metrics.IncRequestsError()
+		return status.Error(codes.InvalidArgument, "first stream message must be a header")
+	}
+
+	// Authenticate and get namespace
+	ns, ok := s.authenticateRequest(header.ApiKey)
+	if !ok {
+		s.metrics.IncRequestsError()
+		return fmt.Errorf("invalid API key")
+	}
+
+	// Enforce namespace size limits
+	if err := s.validateKey(ns, header.Key); err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+	if err := s.validateStreamSize(ns, header.TotalSize); err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+
+	// A single-node stream cannot honour the replication factor
+	if n, _, _ := ns.Replication(); n > 1 {
+		s.metrics.IncRequestsError()
+		return status.Errorf(codes.FailedPrecondition,
+			"SetStream is not supported in replicated namespace '%s' (replicas=%d)", ns.Name, n)
+	}
+
+	// Add namespace prefix to key
+	namespacedKey := s.namespaceKey(ns.Name, header.Key)
+
+	// Gets that start after the write must not see an older value
+	defer s.keyWritten(namespacedKey)
+
+	// Route to appropriate node
+	targetNode := s.selectNode(namespacedKey)
+	if targetNode == "" {
+		s.metrics.IncRequestsError()
+		return fmt.Errorf("no cache node available")
+	}
+
+	// Get client for target node
+	s.mu.RLock()
+	client, exists := s.nodeClients[targetNode]
+	s.mu.RUnlock()
+
+	if !exists {
+		s.metrics.IncRequestsError()
+		return fmt.Errorf("node client not found: %s", targetNode)
+	}
+
+	// Cancelling the context aborts the node stream without a commit
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	nodeStream, err := client.SetStream(ctx)
+	if err != nil {
+		s.metrics.IncRequestsError()
+		return fmt.Errorf("node error: %w", err)
+	}
+
+	if err := nodeStream.Send(&oraclev1.SetStreamRequest{
+		Payload: &oraclev1.SetStreamRequest_Header{
+			Header: &oraclev1.SetStreamHeader{
+				Key:       namespacedKey,
+				Ttl:       header.Ttl,
+				TotalSize: header.TotalSize,
+				Timestamp: time.Now().UnixNano(),
+			},
+		},
+	}); err != nil {
+		s.metrics.IncRequestsError()
+		return nodeSendError(nodeStream, err)
+	}
+
+	var received int64
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			s.metrics.IncRequestsError()
+			return status.Errorf(codes.InvalidArgument, "stream for key '%s' ended without trailer", header.Key)
+		}
+		if err != nil {
+			s.metrics.IncRequestsError()
+			return err
+		}
+
+		switch payload := msg.Payload.(type) {
+		case *oraclev1.ProxySetStreamRequest_Chunk:
+			received += int64(len(payload.Chunk))
+			if received > header.TotalSize {
+				s.metrics.IncRequestsError()
+				return status.Errorf(codes.InvalidArgument,
+					"stream for key '%s' exceeds declared total_size %d", header.Key, header.TotalSize)
+			}
+
+			if err := nodeStream.Send(&oraclev1.SetStreamRequest{
+				Payload: &oraclev1.SetStreamRequest_Chunk{Chunk: payload.Chunk},
+			}); err != nil {
+				s.metrics.IncRequestsError()
+				return nodeSendError(nodeStream, err)
+			}
+
+		case *oraclev1.ProxySetStreamRequest_Trailer:
+			if err := nodeStream.Send(&oraclev1.SetStreamRequest{
+				Payload: &oraclev1.SetStreamRequest_Trailer{Trailer: payload.Trailer},
+			}); err != nil {
+				s.metrics.IncRequestsError()
+				return nodeSendError(nodeStream, err)
+			}
+
+			nodeResp, err := nodeStream.CloseAndRecv()
+			if err != nil {
+				s.metrics.IncRequestsError()
+				return fmt.Errorf("node error: %w", err)
+			}
+
+			// The owner now holds a newer value than any pending hint or copy
+			s.hints.forget(targetNode, namespacedKey)
+			s.dropOverflowCopy(ctx, targetNode, namespacedKey)
+
+			s.metrics.IncRequestsOK()
+
+			return stream.SendAndClose(&oraclev1.ProxySetResponse{
+				Success: nodeResp.Success,
+				Message: nodeResp.Message,
+				Node:    targetNode,
+			})
+
+		default:
+			s.metrics.IncRequestsError()
+			return status.Error(codes.InvalidArgument, "unexpected header after stream start")
+		}
+	}
+}
+
+// GetStream retrieves a value as a stream of chunks.
+//
+// The node's header is forwarded with the serving node added; chunks and
+// the trailer are relayed unchanged so the client can verify the checksum.
+//
+// The node is found like in Get: an ejected owner is read around, a value
+// handed off while the owner is down is read from its stand-in node, and a
+// key whose range is still being migrated is read from its previous owner.
+// Replicated namespaces are read from a quorum of replicas; their values
+// fit into a single message (SetStream is rejected there), so the proxy
+// splits them into chunks itself.
+func (s *Server) GetStream(req *oraclev1.ProxyGetStreamRequest, stream oraclev1.ProxyService_GetStreamServer) error {
+	s.metrics.IncRequests()
+
+	// Authenticate and get namespace
+	ns, ok := s.authenticateRequest(req.ApiKey)
+	if !ok {
+		s.metrics.IncRequestsError()
+		return fmt.Errorf("invalid API key")
+	}
+
+	// Enforce namespace size limits
+	if err := s.validateKey(ns, req.Key); err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+
+	// Add namespace prefix to key
+	namespacedKey := s.namespaceKey(ns.Name, req.Key)
+
+	// Replicated namespaces read from a quorum of replicas
+	if n, _, r := ns.Replication(); n > 1 {
+		resp, err := s.getReplicated(stream.Context(), namespacedKey, n, r)
+		if err != nil {
+			s.metrics.IncRequestsError()
+			return err
+		}
+		return s.sendValueStream(stream, resp, req.ChunkSize)
+	}
+
+	src, err := s.openGetStream(stream.Context(), namespacedKey, req.ChunkSize)
+	if err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+	defer src.cancel()
+
+	if src.header.Found {
+		s.metrics.IncCacheHits()
+	} else {
+		s.metrics.IncCacheMisses()
+	}
+
+	if err := stream.Send(&oraclev1.ProxyGetStreamResponse{
+		Payload: &oraclev1.ProxyGetStreamResponse_Header{
+			Header: &oraclev1.ProxyGetStreamHeader{
+				Found:     src.header.Found,
+				Ttl:       src.header.Ttl,
+				TotalSize: src.header.TotalSize,
+				Node:      src.node,
+			},
+		},
+	}); err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+
+	for {
+		msg, err := src.stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.metrics.IncRequestsError()
+			return fmt.Errorf("node error: %w", err)
+		}
+
+		resp := &oraclev1.ProxyGetStreamResponse{}
+		switch payload := msg.Payload.(type) {
+		case *oraclev1.GetStreamResponse_Chunk:
+			resp.Payload = &oraclev1.ProxyGetStreamResponse_Chunk{Chunk: payload.Chunk}
+		case *oraclev1.GetStreamResponse_Trailer:
+			resp.Payload = &oraclev1.ProxyGetStreamResponse_Trailer{Trailer: payload.Trailer}
+		default:
+			s.metrics.IncRequestsError()
+			return status.Errorf(codes.Internal, "node %s sent an unexpected stream message", src.node)
+		}
+
+		if err := stream.Send(resp); err != nil {
+			s.metrics.IncRequestsError()
+			return err
+		}
+	}
+
+	s.metrics.IncRequestsOK()
+	return nil
+}
+
+// nodeGetStream is a GetStream opened on a node, with its header received.
+type nodeGetStream struct {
+	node   string
+	stream oraclev1.NodeService_GetStreamClient
+	header *oraclev1.GetStreamHeader
+	cancel context.CancelFunc
+}
+
+// openGetStream opens a GetStream for a namespaced key on the node that
+// serves it, following the same fallbacks as Get.
+//
+// Returns:
+//   - *nodeGetStream: The opened stream; the caller must call its cancel
+//   - error: Error if no node could be asked for the key
+func (s *Server) openGetStream(ctx context.Context, key string, chunkSize int32) (*nodeGetStream, error) {
+	// Route to appropriate node
+	targetNode := s.selectNode(key)
+	if targetNode == "" {
+		return nil, fmt.Errorf("no cache node available")
+	}
+
+	// Read around an ejected node, unless the key was handed off as a hint
+	if s.breakers.ejected(targetNode) && s.hints.holderOf(targetNode, key) == "" {
+		if next := s.breakers.readNode(key, targetNode); next != "" {
+			targetNode = next
+		}
+	}
+
+	src, err := s.getStreamFrom(ctx, targetNode, key, chunkSize)
+	if err != nil {
+		// A write accepted while the node is down lives on a stand-in node
+		holder := s.hints.holderOf(targetNode, key)
+		if holder == "" || !isUnavailable(err) {
+			return nil, err
+		}
+		if src, err = s.getStreamFrom(ctx, holder, key, chunkSize); err != nil {
+			return nil, err
+		}
+	}
+
+	// The key may not have been migrated to its new owner yet
+	if fallback := s.rebalancer.fallbackNode(key); !src.header.Found && fallback != "" && fallback != src.node {
+		if fallbackSrc, err := s.getStreamFrom(ctx, fallback, key, chunkSize); err == nil {
+			if fallbackSrc.header.Found {
+				src.cancel()
+				src = fallbackSrc
+			} else {
+				fallbackSrc.cancel()
+			}
+		}
+	}
+
+	// The key may still be stored under its legacy encoding
+	if !src.header.Found && s.legacyKeys.active() {
+		if legacyKey, ok := s.legacyKeys.legacyKey(key); ok {
+			if legacySrc, err := s.getStreamFrom(ctx, s.ownerOf(legacyKey), legacyKey, chunkSize); err == nil {
+				if legacySrc.header.Found {
+					src.cancel()
+					src = legacySrc
+				} else {
+					legacySrc.cancel()
+				}
+			}
+		}
+	}
+
+	return src, nil
+}
+
+// getStreamFrom opens a GetStream on a node and receives its header.
+func (s *Server) getStreamFrom(ctx context.Context, node, key string, chunkSize int32) (*nodeGetStream, error) {
+	client := s.nodeClient(node)
+	if client == nil {
+		return nil, fmt.Errorf("node client not found: %s", node)
+	}
+
+	// Cancelling releases a stream that is not read to the end
+	ctx, cancel := context.WithCancel(ctx)
+	nodeStream, err := client.GetStream(ctx, &oraclev1.GetStreamRequest{
+		Key:       key,
+		ChunkSize: chunkSize,
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("node error: %w", err)
+	}
+
+	first, err := nodeStream.Recv()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("node error: %w", err)
+	}
+
+	header := first.GetHeader()
+	if header == nil {
+		cancel()
+		return nil, status.Errorf(codes.Internal, "node %s did not send a stream header", node)
+	}
+
+	return &nodeGetStream{node: node, stream: nodeStream, header: header, cancel: cancel}, nil
+}
+
+// sendValueStream sends a value read in one piece as a header, chunks of
+// at most chunkSize bytes and a trailer carrying its CRC32-C checksum, the
+// way nodes stream values.
+func (s *Server) sendValueStream(stream oraclev1.ProxyService_GetStreamServer, resp *oraclev1.ProxyGetResponse, chunkSize int32) error {
+	if resp.Found {
+		s.metrics.IncCacheHits()
+	} else {
+		s.metrics.IncCacheMisses()
+	}
+
+	if err := stream.Send(&oraclev1.ProxyGetStreamResponse{
+		Payload: &oraclev1.ProxyGetStreamResponse_Header{
+			Header: &oraclev1.ProxyGetStreamHeader{
+				Found:     resp.Found,
+				Ttl:       resp.Ttl,
+				TotalSize: int64(len(resp.Value)),
+				Node:      resp.Node,
+			},
+		},
+	}); err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+
+	if !resp.Found {
+		s.metrics.IncRequestsOK()
+		return nil
+	}
+
+	size := s.streamChunkSize(int(chunkSize))
+	for offset := 0; offset < len(resp.Value); offset += size {
+		end := min(offset+size, len(resp.Value))
+		if err := stream.Send(&oraclev1.ProxyGetStreamResponse{
+			Payload: &oraclev1.ProxyGetStreamResponse_Chunk{Chunk: resp.Value[offset:end]},
+		}); err != nil {
+			s.metrics.IncRequestsError()
+			return err
+		}
+	}
+
+	if err := stream.Send(&oraclev1.ProxyGetStreamResponse{
+		Payload: &oraclev1.ProxyGetStreamResponse_Trailer{
+			Trailer: &oraclev1.StreamTrailer{Checksum: crc32.Checksum(resp.Value, castagnoli)},
+		},
+	}); err != nil {
+		s.metrics.IncRequestsError()
+		return err
+	}
+
+	s.metrics.IncRequestsOK()
+	return nil
+}
+
+// streamChunkSize returns the chunk size for values the proxy streams
+// itself, clamped so that every chunk fits into a single gRPC message.
+func (s *Server) streamChunkSize(requested int) int {
+	size := requested
+	if size <= 0 {
+		size = DefaultStreamChunkSize
+	}
+
+	s.mu.RLock()
+	maxMessageSize := s.maxMessageSize
+	s.mu.RUnlock()
+
+	if limit := maxValueSize(maxMessageSize); limit > 0 && size > limit {
+		size = limit
+	}
+	return size
+}
+
+// nodeSendError resolves the real status of a failed client-stream send.
+//
+// gRPC reports io.EOF from Send when the server has already terminated the
+// stream; the actual error is only available from CloseAndRecv.
+func nodeSendError(nodeStream oraclev1.NodeService_SetStreamClient, err error) error {
+	if errors.Is(err, io.EOF) {
+		if _, recvErr := nodeStream.CloseAndRecv(); recvErr != nil {
+			err = recvErr
+		}
+	}
+	return fmt.Errorf("node error: %w", err)
+}
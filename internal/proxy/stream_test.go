@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// getStreamRecorder collects the messages a GetStream sends to the client.
+type getStreamRecorder struct {
+	grpc.ServerStream
+	msgs []*oraclev1.ProxyGetStreamResponse
+}
+
+func (r *getStreamRecorder) Send(msg *oraclev1.ProxyGetStreamResponse) error {
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func (r *getStreamRecorder) Context() context.Context {
+	return context.Background()
+}
+
+// unreachableAddr returns a loopback address nothing listens on.
+func unreachableAddr(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+// keyOwnedBy returns a namespaced key whose owner is node.
+func keyOwnedBy(t *testing.T, s *Server, node string) string {
+	t.Helper()
+
+	for i := 0; i < 10000; i++ {
+		key := kv.NamespaceKey("files", fmt.Sprintf("file-%d", i))
+		if s.ownerOf(key) == node {
+			return key
+		}
+	}
+	t.Fatalf("no key owned by %s", node)
+	return ""
+}
+
+func TestOpenGetStreamReadsFromHintHolder(t *testing.T) {
+	addrs, clients := startNodes(t, 2)
+	down := unreachableAddr(t)
+	s := newTestProxy(t, append(addrs, down))
+
+	key := keyOwnedBy(t, s, down)
+	setVersioned(t, clients[addrs[0]], key, "handed-off", time.Now().UnixNano())
+	s.hints.add(down, &hint{key: key, holder: addrs[0], createdAt: time.Now()})
+
+	src, err := s.openGetStream(context.Background(), key, 0)
+	if err != nil {
+		t.Fatalf("openGetStream: %v", err)
+	}
+	defer src.cancel()
+
+	if src.node != addrs[0] || !src.header.Found {
+		t.Errorf("stream from %s (found %v), want the hint holder %s", src.node, src.header.Found, addrs[0])
+	}
+}
+
+func TestOpenGetStreamReadsFromPreviousOwner(t *testing.T) {
+	addrs, clients := startNodes(t, 3)
+	s := newTestProxy(t, addrs[:2])
+
+	// Keys written before a node joined stay on their old owner until moved
+	keys := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		key := kv.NamespaceKey("files", fmt.Sprintf("file-%d", i))
+		keys[key] = s.ownerOf(key)
+		setVersioned(t, clients[keys[key]], key, "v1", time.Now().UnixNano())
+	}
+	s.SetNodes(addrs)
+
+	moved := 0
+	for key, previous := range keys {
+		if s.ownerOf(key) == previous {
+			continue
+		}
+		moved++
+
+		src, err := s.openGetStream(context.Background(), key, 0)
+		if err != nil {
+			t.Fatalf("openGetStream(%q): %v", key, err)
+		}
+		src.cancel()
+		if src.node != previous || !src.header.Found {
+			t.Errorf("%q streamed from %s (found %v), want previous owner %s", key, src.node, src.header.Found, previous)
+		}
+	}
+	if moved == 0 {
+		t.Fatal("no key changed owner")
+	}
+}
+
+func TestSendValueStreamChunksValue(t *testing.T) {
+	s := newTestProxy(t, nil)
+	value := []byte("0123456789abcdefghij")
+
+	stream := &getStreamRecorder{}
+	resp := &oraclev1.ProxyGetResponse{Found: true, Value: value, Ttl: 30, Node: "cache-1:8080"}
+	if err := s.sendValueStream(stream, resp, 8); err != nil {
+		t.Fatalf("sendValueStream: %v", err)
+	}
+
+	if len(stream.msgs) != 5 {
+		t.Fatalf("sent %d messages, want header, 3 chunks and trailer", len(stream.msgs))
+	}
+	header := stream.msgs[0].GetHeader()
+	if header == nil || !header.Found || header.TotalSize != int64(len(value)) || header.Node != "cache-1:8080" {
+		t.Errorf("header = %v", header)
+	}
+
+	var received []byte
+	for _, msg := range stream.msgs[1:4] {
+		if len(msg.GetChunk()) > 8 {
+			t.Errorf("chunk of %d bytes, want at most 8", len(msg.GetChunk()))
+		}
+		received = append(received, msg.GetChunk()...)
+	}
+	if string(received) != string(value) {
+		t.Errorf("chunks = %q, want %q", received, value)
+	}
+
+	trailer := stream.msgs[4].GetTrailer()
+	if trailer == nil || trailer.Checksum != crc32.Checksum(value, castagnoli) {
+		t.Errorf("trailer = %v, want the CRC32-C of the value", trailer)
+	}
+}
+
+func TestSendValueStreamMissingKey(t *testing.T) {
+	s := newTestProxy(t, nil)
+
+	stream := &getStreamRecorder{}
+	if err := s.sendValueStream(stream, &oraclev1.ProxyGetResponse{}, 0); err != nil {
+		t.Fatalf("sendValueStream: %v", err)
+	}
+	if len(stream.msgs) != 1 || stream.msgs[0].GetHeader().GetFound() {
+		t.Errorf("sent %v, want a single header without a value", stream.msgs)
+	}
+}
@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+)
+
+// PreviewTopology reports which hash ranges would change owner if the
+// requested nodes were added or removed. The live ring is not modified.
+//
+// Ownership diffs require the ketama ring; other placement algorithms
+// return FAILED_PRECONDITION.
+func (s *Server) PreviewTopology(ctx context.Context, req *oraclev1.ProxyPreviewTopologyRequest) (*oraclev1.ProxyPreviewTopologyResponse, error) {
+	s.mu.RLock()
+	current, ok := s.ring.(*hash.Ring)
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"topology preview requires the '%s' placement algorithm", hash.AlgorithmKetama)
+	}
+
+	proposed := current.Clone()
+	for _, node := range req.RemoveNodes {
+		proposed.RemoveNode(node)
+	}
+	for _, node := range req.AddNodes {
+		proposed.AddWeightedNode(node, int(req.Weights[node]))
+	}
+	for node, weight := range req.Weights {
+		proposed.UpdateWeight(node, int(weight))
+	}
+
+	diff := current.Diff(proposed)
+
+	resp := &oraclev1.ProxyPreviewTopologyResponse{
+		MovedFraction: diff.MovedFraction,
+		Transfers:     make([]*oraclev1.OwnershipTransfer, 0, len(diff.Transfers)),
+		NodesBefore:   int32(current.Size()),
+		NodesAfter:    int32(proposed.Size()),
+	}
+	for _, t := range diff.Transfers {
+		resp.Transfers = append(resp.Transfers, &oraclev1.OwnershipTransfer{
+			FromNode: t.From,
+			ToNode:   t.To,
+			Fraction: t.Fraction,
+		})
+	}
+	if req.IncludeMoves {
+		resp.Moves = make([]*oraclev1.HashRangeMove, 0, len(diff.Moves))
+		for _, m := range diff.Moves {
+			resp.Moves = append(resp.Moves, &oraclev1.HashRangeMove{
+				Start:    m.Start,
+				End:      m.End,
+				FromNode: m.From,
+				ToNode:   m.To,
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// logOwnershipChange logs how much of the keyspace moved between two
+// placements and returns the diff together with the new ring. Only ketama
+// rings support ownership diffs; for other algorithms, or when the previous
+// ring was empty, nothing is logged and a nil diff is returned.
+func (s *Server) logOwnershipChange(before, after hash.Placement) (*hash.OwnershipDiff, *hash.Ring) {
+	oldRing, ok := before.(*hash.Ring)
+	if !ok || oldRing.Size() == 0 {
+		return nil, nil
+	}
+	newRing, ok := after.(*hash.Ring)
+	if !ok {
+		return nil, nil
+	}
+
+	diff := oldRing.Diff(newRing)
+	if diff.MovedFraction == 0 {
+		return nil, nil
+	}
+
+	s.logger.Info("Ring change moved %.2f%% of the keyspace across %d ranges",
+		diff.MovedFraction*100, len(diff.Moves))
+	for _, t := range diff.Transfers {
+		s.logger.Debug("  %s -> %s: %.2f%%", t.From, t.To, t.Fraction*100)
+	}
+	return diff, newRing
+}
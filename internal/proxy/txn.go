@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	oraclev1 "github.com/eggybyte-technology/yao-oracle/pb/yao/oracle/v1"
+)
+
+// Transaction applies a list of mutations only if all checks hold.
+//
+// Request flow:
+//  1. Authenticate and validate every key and value
+//  2. Resolve the node owning all keys; keys on different nodes are
+//     rejected with FAILED_PRECONDITION
+//  3. Forward the transaction to that node, which evaluates the checks and
+//     applies the mutations under a single cache lock
+//
+// Keys are co-located with hash tags, e.g. "{user:42}:profile" and
+// "{user:42}:cart". Replicated namespaces do not support transactions, and
+// keys whose latest value is not on their owner yet (during a migration, as
+// a hint while the owner was down, or under the legacy key encoding) are
+// rejected with UNAVAILABLE, since the owner could not check them reliably.
+func (s *Server) Transaction(ctx context.Context, req *oraclev1.ProxyTransactionRequest) (*oraclev1.ProxyTransactionResponse, error) {
+	s.metrics.IncRequests()
+
+	// Authenticate and get namespace
+	ns, ok := s.authenticateRequest(req.ApiKey)
+	if !ok {
+		s.metrics.IncRequestsError()
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	if len(req.Checks) == 0 && len(req.Mutations) == 0 {
+		s.metrics.IncRequestsError()
+		return nil, status.Error(codes.InvalidArgument, "transaction has no checks and no mutations")
+	}
+
+	if n, _, _ := ns.Replication(); n > 1 {
+		s.metrics.IncRequestsError()
+		return nil, status.Error(codes.FailedPrecondition, "transactions are not supported in replicated namespaces")
+	}
+
+	// Enforce namespace size limits and add the namespace prefix
+	nodeReq := &oraclev1.TransactionRequest{
+		Checks:    make([]*oraclev1.TxnCheck, len(req.Checks)),
+		Mutations: make([]*oraclev1.TxnMutation, len(req.Mutations)),
+	}
+	var keys, written []string
+	for i, check := range req.Checks {
+		if err := s.validateKey(ns, check.Key); err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+		key := s.namespaceKey(ns.Name, check.Key)
+		nodeReq.Checks[i] = &oraclev1.TxnCheck{
+			Key:     key,
+			Type:    check.Type,
+			Version: check.Version,
+			Value:   check.Value,
+		}
+		keys = append(keys, key)
+	}
+	for i, m := range req.Mutations {
+		if err := s.validateKey(ns, m.Key); err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+		if err := s.validateValue(ns, m.Value); err != nil {
+			s.metrics.IncRequestsError()
+			return nil, err
+		}
+		key := s.namespaceKey(ns.Name, m.Key)
+		nodeReq.Mutations[i] = &oraclev1.TxnMutation{
+			Key:    key,
+			Delete: m.Delete,
+			Value:  m.Value,
+			Ttl:    m.Ttl,
+		}
+		keys = append(keys, key)
+		written = append(written, key)
+	}
+
+	node, err := s.txnNode(keys)
+	if err != nil {
+		s.metrics.IncRequestsError()
+		return nil, err
+	}
+
+	// The owner cannot check keys still stored under the legacy encoding
+	for _, key := range keys {
+		if legacyResp, _ := s.legacyKeys.get(ctx, key); legacyResp != nil {
+			s.metrics.IncRequestsError()
+			return nil, status.Errorf(codes.Unavailable, "key '%s' is being migrated from the legacy key encoding; retry later", clientKey(key))
+		}
+	}
+
+	client := s.nodeClient(node)
+	if client == nil {
+		s.metrics.IncRequestsError()
+		return nil, fmt.Errorf("node client not found: %s", node)
+	}
+
+	// Gets that start after the transaction must not see older values
+	defer s.keysWritten(written)
+
+	nodeResp, err := client.Transaction(ctx, nodeReq)
+	if err != nil {
+		s.metrics.IncRequestsError()
+		return nil, fmt.Errorf("node error: %w", err)
+	}
+
+	if nodeResp.Succeeded {
+		for _, key := range written {
+			s.dropOverflowCopy(ctx, node, key)
+		}
+	}
+
+	// Report the keys as the client sent them
+	for i, result := range nodeResp.Results {
+		if i < len(req.Mutations) {
+			result.Key = req.Mutations[i].Key
+		}
+	}
+
+	s.metrics.IncRequestsOK()
+
+	return &oraclev1.ProxyTransactionResponse{
+		Succeeded:   nodeResp.Succeeded,
+		FailedCheck: nodeResp.FailedCheck,
+		Results:     nodeResp.Results,
+		Node:        node,
+	}, nil
+}
+
+// txnNode returns the node owning all keys of a transaction.
+//
+// Keys are grouped by their owner (see ownerOf), so co-located keys are
+// always accepted, whatever the current node loads.
+func (s *Server) txnNode(keys []string) (string, error) {
+	groups, err := s.groupByOwner(keys)
+	if err != nil {
+		return "", err
+	}
+	if len(groups) > 1 {
+		return "", status.Errorf(codes.FailedPrecondition,
+			"transaction spans %d cache nodes; all keys must be owned by one node (co-locate them with a hash tag such as \"{user:42}:cart\")",
+			len(groups))
+	}
+
+	var node string
+	for owner := range groups {
+		node = owner
+	}
+
+	for _, key := range keys {
+		if s.rebalancer.fallbackNode(key) != "" {
+			return "", status.Errorf(codes.Unavailable, "key '%s' is being migrated to node %s; retry later", clientKey(key), node)
+		}
+		if s.hints.holderOf(node, key) != "" {
+			return "", status.Errorf(codes.Unavailable, "latest value of key '%s' is not on node %s yet; retry later", clientKey(key), node)
+		}
+	}
+
+	s.countRequest(node)
+	return node, nil
+}
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/eggybyte-technology/yao-oracle/core/hash"
+	"github.com/eggybyte-technology/yao-oracle/core/kv"
+)
+
+func TestTxnNodeIgnoresLoads(t *testing.T) {
+	s := newTestProxy(t, []string{"cache-0:8080", "cache-1:8080", "cache-2:8080"})
+	keys := []string{
+		kv.NamespaceKey("shop", "{user:42}:profile"),
+		kv.NamespaceKey("shop", "{user:42}:cart"),
+		kv.NamespaceKey("shop", "{user:42}:orders"),
+	}
+	owner := s.ownerOf(keys[0])
+
+	// Overload the owner so a load-aware choice would move some keys away
+	ring := s.ring.(*hash.Ring)
+	ring.SetLoadBound(0.1)
+	ring.SetLoads(map[string]float64{owner: 1e6})
+
+	for i := 0; i < 10; i++ {
+		node, err := s.txnNode(keys)
+		if err != nil {
+			t.Fatalf("txnNode: %v", err)
+		}
+		if node != owner {
+			t.Fatalf("txnNode = %s, want owner %s", node, owner)
+		}
+	}
+
+	groups, err := s.groupWrites(keys, true)
+	if err != nil {
+		t.Fatalf("groupWrites: %v", err)
+	}
+	if len(groups[owner]) != len(keys) {
+		t.Errorf("groupWrites = %v, want all keys on %s", groups, owner)
+	}
+}
+
+func TestTxnNodeRejectsKeysOfSeveralNodes(t *testing.T) {
+	s := newTestProxy(t, []string{"cache-0:8080", "cache-1:8080", "cache-2:8080"})
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = kv.NamespaceKey("shop", fmt.Sprintf("user:%d", i))
+	}
+	if _, err := s.txnNode(keys); err == nil {
+		t.Error("txnNode accepted untagged keys spread over several nodes")
+	}
+}